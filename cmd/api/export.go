@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/patrick-tondorf/lib_api/internal/config"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/export"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+const exportUsage = "usage: api export [-format csv|jsonl|marcxml] [-o FILE] [filters]"
+
+// runExport executa o subcomando "export", que grava o catálogo num arquivo
+// (ou na saída padrão) direto do armazenamento configurado
+func runExport(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, exportUsage)
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", export.FormatCSV, "file format: csv, jsonl or marcxml")
+	out := fs.String("o", "-", `output file ("-" writes to stdout)`)
+	var filters domain.BookFilters
+	fs.StringVar(&filters.Title, "title", "", "only books whose title contains this text")
+	fs.StringVar(&filters.AuthorName, "author", "", "only books with an author whose name contains this text")
+	fs.StringVar(&filters.Publisher, "publisher", "", "only books whose publisher contains this text")
+	fs.Func("subject", "only books with this subject (repeatable)", func(s string) error {
+		filters.Subjects = append(filters.Subjects, s)
+		return nil
+	})
+	fs.Func("language", "only books in this language (repeatable)", func(s string) error {
+		filters.Languages = append(filters.Languages, s)
+		return nil
+	})
+	fs.StringVar(&filters.Sort, "sort", "title", "sort field: title or created_at")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if !slices.Contains(export.Formats, *format) {
+		fatal(fmt.Errorf("unknown export format %q; use one of: %s", *format, strings.Join(export.Formats, ", ")))
+	}
+	if filters.Sort != "title" && filters.Sort != "created_at" {
+		fatal(fmt.Errorf("unknown sort field %q; use title or created_at", filters.Sort))
+	}
+	if cfg.Storage.Backend == "memory" {
+		fatal(fmt.Errorf("export needs a persistent storage backend (postgres or sqlite)"))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	stores, closeStorage, err := openStorage(ctx, cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer closeStorage()
+
+	var dst io.Writer = os.Stdout
+	var file *os.File
+	if *out != "-" {
+		if file, err = os.Create(*out); err != nil {
+			fatal(err)
+		}
+		dst = file
+	}
+	buf := bufio.NewWriter(dst)
+
+	count, err := writeExport(ctx, stores.Books, *format, filters, buf)
+	if err == nil {
+		err = buf.Flush()
+	}
+	if file != nil {
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			// Não deixa para trás um arquivo incompleto
+			os.Remove(*out)
+		}
+	}
+	if err != nil {
+		closeStorage()
+		fatal(err)
+	}
+	if file != nil {
+		fmt.Fprintf(os.Stderr, "exported %d books to %s\n", count, *out)
+	}
+}
+
+func writeExport(ctx context.Context, books storage.BookStore, format string, filters domain.BookFilters, w io.Writer) (int, error) {
+	ew, err := export.NewWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	err = export.Books(ctx, books, filters, func(b domain.Book) error {
+		count++
+		return ew.Write(b)
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, ew.Close()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/patrick-tondorf/lib_api/internal/config"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/importer"
+)
+
+const importUsage = "usage: api import [-format csv|jsonl|marc|marcxml] [-dry-run] FILE (\"-\" reads stdin)"
+
+// runImport executa o subcomando "import", que carrega um catálogo direto no
+// armazenamento configurado, sem passar pela API
+func runImport(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, importUsage)
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "", "file format: csv, jsonl, marc or marcxml (default: from the file extension)")
+	dryRun := fs.Bool("dry-run", false, "validate and report without saving")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if cfg.Storage.Backend == "memory" {
+		fatal(fmt.Errorf("import needs a persistent storage backend (postgres or sqlite)"))
+	}
+
+	path := fs.Arg(0)
+	var in io.Reader = os.Stdin
+	source := "stdin"
+	if path != "-" {
+		source = filepath.Base(path)
+		f, err := os.Open(path)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	if *format == "" {
+		*format = importer.FormatFromName(path)
+	}
+	if *format == "" {
+		fatal(fmt.Errorf("cannot tell the format of %s; use -format csv, jsonl, marc or marcxml", path))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	stores, closeStorage, err := openStorage(ctx, cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer closeStorage()
+
+	job, err := importer.New(stores.Imports).Run(ctx, in, importer.Options{
+		Format: *format,
+		Source: source,
+		DryRun: *dryRun,
+	})
+	if job != nil {
+		printImportJob(os.Stdout, job)
+	}
+	if err != nil {
+		closeStorage()
+		fatal(err)
+	}
+	if job.Failed > 0 {
+		closeStorage()
+		os.Exit(1)
+	}
+}
+
+func printImportJob(w io.Writer, job *domain.ImportJob) {
+	mode := ""
+	if job.DryRun {
+		mode = " (dry run, nothing saved)"
+	}
+	fmt.Fprintf(w, "import %s %s%s\n", job.UUID, job.Status, mode)
+	fmt.Fprintf(w, "rows: %d, created: %d, updated: %d, failed: %d, partial: %d, authors created: %d\n",
+		job.Rows, job.Created, job.Updated, job.Failed, job.Partial, job.AuthorsCreated)
+	for _, e := range job.Errors {
+		printRowError(w, "", e)
+	}
+	if job.ErrorsTruncated {
+		fmt.Fprintf(w, "... only the first %d errors are listed\n", len(job.Errors))
+	}
+	for _, e := range job.Warnings {
+		printRowError(w, "warning: ", e)
+	}
+	if job.WarningsTruncated {
+		fmt.Fprintf(w, "... only the first %d warnings are listed\n", len(job.Warnings))
+	}
+	if job.Message != "" {
+		fmt.Fprintln(w, job.Message)
+	}
+}
+
+func printRowError(w io.Writer, prefix string, e domain.ImportRowError) {
+	where := fmt.Sprintf("line %d", e.Line)
+	if e.Record != "" {
+		where += " (" + e.Record + ")"
+	}
+	if e.Field != "" {
+		fmt.Fprintf(w, "%s%s: %s %s\n", prefix, where, e.Field, e.Message)
+	} else {
+		fmt.Fprintf(w, "%s%s: %s\n", prefix, where, e.Message)
+	}
+}
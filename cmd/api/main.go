@@ -18,64 +18,134 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
+	"github.com/patrick-tondorf/lib_api/internal/circulation"
 	"github.com/patrick-tondorf/lib_api/internal/config"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
 	"github.com/patrick-tondorf/lib_api/internal/router"
+	"github.com/patrick-tondorf/lib_api/internal/server"
 )
 
 func main() {
-	// Carrega o arquivo .env
-	err := godotenv.Load() // Procura por .env na raiz
+	// Logger provisório até a configuração ser lida, para que avisos do
+	// carregamento já saiam em JSON
+	slog.SetDefault(logging.New(os.Stderr, config.LogConfig{Level: "info", Format: "json"}))
+
+	// Carrega o arquivo .env, se existir (em containers a configuração
+	// costuma vir só do ambiente)
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fatal(fmt.Errorf("failed to load .env: %w", err))
+	}
+
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Erro ao carregar .env", err)
+		fatal(err)
 	}
 
-	// Config gin
-	if os.Getenv("GIN_MODE") == "release" {
-		gin.SetMode(gin.ReleaseMode)
-	} else {
-		gin.SetMode(gin.DebugMode)
+	logger := logging.New(os.Stderr, cfg.Log)
+	slog.SetDefault(logger)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(cfg, os.Args[2:])
+			return
+		case "import":
+			runImport(cfg, os.Args[2:])
+			return
+		case "export":
+			runExport(cfg, os.Args[2:])
+			return
+		}
+	}
+
+	if err := serve(cfg, logger); err != nil {
+		fatal(err)
+	}
+}
+
+// fatal registra o erro e encerra o processo
+func fatal(err error) {
+	slog.Error(err.Error())
+	os.Exit(1)
+}
+
+// serve sobe a API e bloqueia até SIGINT/SIGTERM. Os recursos são liberados
+// pelos defers, na ordem inversa: primeiro o servidor termina o drain das
+// requisições em andamento, só depois o armazenamento é fechado.
+func serve(cfg *config.Config, logger *slog.Logger) error {
+	// Config gin. As mensagens de debug do gin também vão para o slog; as
+	// rotas são registradas por printRoutes
+	gin.SetMode(cfg.Server.Mode)
+	gin.DebugPrintFunc = func(format string, values ...any) {
+		logger.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)), "component", "gin")
 	}
+	gin.DebugPrintRouteFunc = func(string, string, string, int) {}
 
-	// Acessa as variáveis
-	dbURI := os.Getenv("DB_URI")
-	port := os.Getenv("LOCAL_PORT")
-	if port == "" {
-		port = "8080" // Valor padrão se não existir no .env
+	if cfg.Storage.Backend == "postgres" {
+		logger.Info("database configured", "uri", cfg.RedactedDatabaseURI())
 	}
-	log.Println("DB_URI:", dbURI)
 
-	//Conecta ao Supabase
-	db, err := config.NewSupabaseDB()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	//Conecta ao armazenamento (Supabase por padrão)
+	stores, closeStorage, err := openStorage(ctx, cfg)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	defer closeStorage()
+
+	// Rotinas da circulação (vencimento de reservas, multas). Têm contexto
+	// próprio: param só depois que o servidor drenou as requisições e
+	// terminam antes de o armazenamento ser fechado (defers em ordem inversa)
+	sweepCtx, stopSweep := context.WithCancel(context.Background())
+	var sweeping sync.WaitGroup
+	defer func() {
+		stopSweep()
+		sweeping.Wait()
+	}()
+	if cfg.Circulation.SweepEnabled {
+		sweeping.Add(1)
+		go func() {
+			defer sweeping.Done()
+			circulation.NewSweeper(stores.Holds, stores.Ledger, cfg.Circulation, logger).Run(sweepCtx)
+		}()
+	} else {
+		logger.Info("circulation sweep disabled", "reason", "circulation.sweep_enabled is false")
 	}
-	defer db.Close(context.Background())
 
 	//Inicia o router
-	r := router.SetupRouter(db)
+	r := router.SetupRouter(cfg, stores, logger)
 
 	if err := r.SetTrustedProxies(nil); err != nil {
-		log.Fatal("Erro ao configurar proxies confiáveis:", err)
+		return fmt.Errorf("erro ao configurar proxies confiáveis: %w", err)
 	}
 
 	// Inicia Servidor
-	log.Printf("Servidor rodando na porta %s (modo: %s)", port, gin.Mode())
-	printRoutes(r)               // Exibe todas as rotas no console
-	err_run := r.Run(":" + port) // localHost:8080
-	if err_run != nil {
-		log.Fatal("Error ao iniciar o servidor", err_run)
+	scheme := "http"
+	if cfg.Server.TLSEnabled() {
+		scheme = "https"
 	}
-
+	logger.Info("server listening", "port", cfg.Server.Port, "mode", gin.Mode(), "scheme", scheme, "h2c", cfg.Server.H2C)
+	printRoutes(logger, r) // Exibe todas as rotas no log (nível debug)
+	return server.Run(ctx, cfg.Server, r)
 }
-func printRoutes(r *gin.Engine) {
+
+func printRoutes(logger *slog.Logger, r *gin.Engine) {
 	for _, route := range r.Routes() {
-		fmt.Printf("Method: %-6s | Path: %s\n", route.Method, route.Path)
+		logger.Debug("route registered", "method", route.Method, "path", route.Path)
 	}
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/patrick-tondorf/lib_api/internal/config"
+	"github.com/patrick-tondorf/lib_api/internal/migrations"
+)
+
+const migrateUsage = "usage: api migrate up|down|status|version"
+
+// runMigrate executa o subcomando "migrate"
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	if cfg.Storage.Backend != "postgres" {
+		fatal(fmt.Errorf("migrate only applies to the postgres backend (the %s backend migrates itself)", cfg.Storage.Backend))
+	}
+	db, err := config.NewSupabaseDB(ctx, cfg.Database)
+	if err != nil {
+		fatal(err)
+	}
+	defer db.Close()
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		fatal(err)
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		for _, m := range done {
+			fmt.Printf("applied   %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if len(done) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		m, err := migrator.Down(ctx)
+		if errors.Is(err, migrations.ErrNoMigrationApplied) {
+			fmt.Println("no migration to roll back")
+			return
+		}
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Printf("reverted  %04d_%s\n", m.Version, m.Name)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			fatal(err)
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, applied)
+		}
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Printf("current: %d, latest: %d\n", version, migrator.Latest())
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
+
+// checkSchema impede o servidor de subir com migrações pendentes
+func checkSchema(ctx context.Context, db migrations.DB) error {
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is behind: %d pending migration(s), starting at %04d_%s; run \"api migrate up\"",
+			len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/patrick-tondorf/lib_api/internal/config"
+	"github.com/patrick-tondorf/lib_api/internal/repository"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+	"github.com/patrick-tondorf/lib_api/internal/storage/memory"
+	"github.com/patrick-tondorf/lib_api/internal/storage/sqlite"
+)
+
+// openStorage abre o backend escolhido em storage.backend ("postgres",
+// "sqlite" ou "memory"). A função retornada libera os recursos.
+func openStorage(ctx context.Context, cfg *config.Config) (storage.Stores, func(), error) {
+	switch cfg.Storage.Backend {
+	case "postgres":
+		db, err := config.NewSupabaseDB(ctx, cfg.Database)
+		if err != nil {
+			return storage.Stores{}, nil, err
+		}
+		// Recusa subir com o esquema desatualizado
+		if err := checkSchema(ctx, db); err != nil {
+			db.Close()
+			return storage.Stores{}, nil, err
+		}
+		return storage.Stores{
+			Books:    repository.NewBookRepository(db),
+			Authors:  repository.NewAuthorRepository(db),
+			Users:    repository.NewUserRepository(db),
+			Search:   repository.NewSearchRepository(db),
+			Imports:  repository.NewImportRepository(db),
+			Harvest:  repository.NewHarvestRepository(db),
+			Items:    repository.NewItemRepository(db),
+			Loans:    repository.NewLoanRepository(db),
+			Holds:    repository.NewHoldRepository(db),
+			Policies: repository.NewPolicyRepository(db),
+			Ledger:   repository.NewLedgerRepository(db),
+		}, db.Close, nil
+	case "sqlite":
+		store, err := sqlite.Open(ctx, cfg.Storage.SQLitePath)
+		if err != nil {
+			return storage.Stores{}, nil, err
+		}
+		slog.Info("using sqlite storage", "path", cfg.Storage.SQLitePath)
+		return store.Stores(), func() { store.Close() }, nil
+	case "memory":
+		slog.Warn("using in-memory storage: data will not be persisted")
+		return memory.New().Stores(), func() {}, nil
+	default:
+		return storage.Stores{}, nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+}
@@ -26,7 +26,7 @@ const docTemplate = `{
     "paths": {
         "/auth/login": {
             "post": {
-                "description": "Authenticate user and return JWT token",
+                "description": "Authenticate user and return JWT token. The token carries the user's role (patron, staff or admin), which is read at login: a role change applies from the next login.",
                 "consumes": [
                     "application/json"
                 ],
@@ -58,28 +58,19 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
@@ -92,7 +83,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all authors from the library",
+                "description": "Get a page of authors, sorted by name. Pages can be requested by number (page) or by following next_cursor/prev_cursor.",
                 "produces": [
                     "application/json"
                 ],
@@ -106,25 +97,43 @@ const docTemplate = `{
                         "description": "Include books in response",
                         "name": "withBooks",
                         "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset mode)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/Author"
-                            }
+                            "$ref": "#/definitions/AuthorListResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
@@ -166,26 +175,26 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/Author"
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/Author"
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
             }
         },
-        "/authors/{id}": {
+        "/authors/{uuid}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve an author by their ID",
+                "description": "Retrieve an author, with bio and books, by their UUID",
                 "consumes": [
                     "application/json"
                 ],
@@ -195,12 +204,12 @@ const docTemplate = `{
                 "tags": [
                     "authors"
                 ],
-                "summary": "Get an author by ID",
+                "summary": "Get an author by UUID",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Author ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Author UUID",
+                        "name": "uuid",
                         "in": "path",
                         "required": true
                     }
@@ -212,22 +221,205 @@ const docTemplate = `{
                             "$ref": "#/definitions/Author"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace name and bio of an author by UUID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authors"
+                ],
+                "summary": "Replace an author",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Author UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Author data",
+                        "name": "author",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/AuthorUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Author"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete an author by UUID. Refused with 409 while the author is linked to books, unless cascade=unlink is given.",
+                "tags": [
+                    "authors"
+                ],
+                "summary": "Delete an author",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Author UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "unlink"
+                        ],
+                        "type": "string",
+                        "description": "Remove links to books before deleting",
+                        "name": "cascade",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Author still linked to books",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update only the given fields (name and/or bio) of an author by UUID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authors"
+                ],
+                "summary": "Partially update an author",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Author UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "author",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/AuthorPatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Author"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
@@ -240,7 +432,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get paginated list of books with optional filters. Choose between basic version or with authors.",
+                "description": "Get paginated list of books with optional filters. Choose between basic version or with authors.\nPages can be requested by number (page) or by following next_cursor/prev_cursor; cursor mode skips the total count and has no depth limit.\nA cursor is only valid with the same filters and sort it was issued for.\nEach book reports the availability of its items (copies) by status; total leaves out withdrawn items, and copies set aside for a ready hold count as onHold, not available.\nWith facets=true the response carries counts by author, subject, language, publication decade and item status (books with at least one item in it) over the whole filtered set.\nFacet values are passed back as repeatable filters (author_uuid, subject, language, decade, availability): values of one facet are ORed, different facets are ANDed.",
                 "produces": [
                     "application/json"
                 ],
@@ -267,6 +459,118 @@ const docTemplate = `{
                         "name": "with_authors",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Filter by ISBN-10 or ISBN-13 (exact edition)",
+                        "name": "isbn",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by publisher (partial match, case insensitive)",
+                        "name": "publisher",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "hardcover",
+                                "paperback",
+                                "ebook",
+                                "audiobook"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by format (repeatable)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Published in or after this year",
+                        "name": "year_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Published in or before this year",
+                        "name": "year_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by author UUID (facet, repeatable)",
+                        "name": "author_uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by subject (facet, repeatable)",
+                        "name": "subject",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by language (facet, repeatable)",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by publication decade, e.g. 1990 (facet, repeatable)",
+                        "name": "decade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "available",
+                                "on_loan",
+                                "in_transit",
+                                "lost",
+                                "withdrawn"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by books with an item in this status (facet, repeatable)",
+                        "name": "availability",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include facet counts",
+                        "name": "facets",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Values per facet",
+                        "name": "facet_limit",
+                        "in": "query"
+                    },
                     {
                         "enum": [
                             "title",
@@ -290,13 +594,20 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
+                        "maximum": 1000,
                         "minimum": 1,
                         "type": "integer",
                         "default": 1,
-                        "description": "Page number",
+                        "description": "Page number (offset mode)",
                         "name": "page",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
                     {
                         "maximum": 100,
                         "minimum": 1,
@@ -317,13 +628,13 @@ const docTemplate = `{
                     "400": {
                         "description": "Invalid parameters",
                         "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
@@ -334,7 +645,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a new book to the library",
+                "description": "Add a new book to the library. The response is the stored book, with its UUID and authors.",
                 "consumes": [
                     "application/json"
                 ],
@@ -366,32 +677,86 @@ const docTemplate = `{
                     "400": {
                         "description": "Invalid input",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/cite": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the citations of up to 100 books, in the order of the UUIDs (repeated UUIDs are cited once), in one of the formats of GET /books/{uuid}/cite. BibTeX keys that repeat get a, b... suffixes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain",
+                    "application/x-bibtex",
+                    "application/x-research-info-systems",
+                    "application/vnd.citationstyles.csl+json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Cite several books",
+                "parameters": [
+                    {
+                        "description": "Books and format",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CitationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Citations",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "A book was not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
             }
         },
-        "/books/{id}": {
+        "/books/{uuid}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve a book by its ID",
+                "description": "Retrieve a book, with its authors and the availability of its items, by its UUID",
                 "consumes": [
                     "application/json"
                 ],
@@ -401,12 +766,12 @@ const docTemplate = `{
                 "tags": [
                     "books"
                 ],
-                "summary": "Get a book by ID",
+                "summary": "Get a book by UUID",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Book ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Book UUID",
+                        "name": "uuid",
                         "in": "path",
                         "required": true
                     }
@@ -418,22 +783,22 @@ const docTemplate = `{
                             "$ref": "#/definitions/Book"
                         }
                     },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Book not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
@@ -444,7 +809,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update book details by ID",
+                "description": "Replace title, description and authors of a book by UUID",
                 "consumes": [
                     "application/json"
                 ],
@@ -457,9 +822,9 @@ const docTemplate = `{
                 "summary": "Update a book",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Book ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Book UUID",
+                        "name": "uuid",
                         "in": "path",
                         "required": true
                     },
@@ -469,7 +834,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/Book"
+                            "$ref": "#/definitions/BookUpdateRequest"
                         }
                     }
                 ],
@@ -483,263 +848,4731 @@ const docTemplate = `{
                     "400": {
                         "description": "Invalid input",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "404": {
                         "description": "Book not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a book and its author relations by UUID. A book that still has items cannot be deleted.",
+                "tags": [
+                    "books"
+                ],
+                "summary": "Delete a book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Book still has items",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
             }
         },
-        "/users": {
-            "post": {
+        "/books/{uuid}/cite": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new user in the system",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns the citation of a book. bibtex, ris and csljson (always an array) are meant for reference managers; apa (7th ed.), mla (9th ed.) and abnt (NBR 6023:2018) are formatted references in plain text.\nAuthors are cited in authorship order (the order of authorIds). \"et al.\" follows each style: APA lists up to 20 authors, MLA abbreviates from three and ABNT from four. Names are split on the last word unless given inverted (\"García Márquez, Gabriel\").",
                 "produces": [
-                    "application/json"
+                    "text/plain",
+                    "application/x-bibtex",
+                    "application/x-research-info-systems",
+                    "application/vnd.citationstyles.csl+json"
                 ],
                 "tags": [
-                    "users"
+                    "books"
                 ],
-                "summary": "Create a new user",
+                "summary": "Cite a book",
                 "parameters": [
                     {
-                        "description": "User data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_patrick-tondorf_lib_api_internal_domain.User"
-                        }
+                        "type": "string",
+                        "description": "Book UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "bibtex",
+                            "ris",
+                            "csljson",
+                            "apa",
+                            "mla",
+                            "abnt"
+                        ],
+                        "type": "string",
+                        "default": "apa",
+                        "description": "Citation format",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "Citation",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid UUID or format",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
             }
         },
-        "/users/{email}": {
+        "/books/{uuid}/holds": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve user details by email",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get a page of the holds of a book in queue order. Without status, only open holds (waiting, suspended and ready) are listed. Requires the staff role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "holds"
                 ],
-                "summary": "Get user by email",
+                "summary": "List the hold queue of a book",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "\"user@example.com\"",
-                        "description": "User email",
-                        "name": "email",
+                        "description": "Book UUID",
+                        "name": "uuid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "waiting",
+                                "suspended",
+                                "ready",
+                                "fulfilled",
+                                "cancelled",
+                                "expired"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by status (repeatable)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset mode)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/HoldListResponse"
+                        }
+                    },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid parameters",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Book not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "Author": {
-            "type": "object",
-            "properties": {
-                "bio": {
-                    "description": "Biografia do autor",
-                    "type": "string",
-                    "example": "Autor de 1984 e A Revolução dos Bichos"
-                },
-                "name": {
-                    "type": "string",
-                    "example": "George Orwell"
-                }
-            }
         },
-        "AuthorRequest": {
-            "type": "object",
-            "required": [
-                "title"
-            ],
-            "properties": {
-                "authorIds": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    },
-                    "example": [
+        "/books/{uuid}/items": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of the copies of a book, sorted by barcode. Accepts the same filters and pagination as GET /items.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "List the items of a book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by branch (exact)",
+                        "name": "branch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "available",
+                                "on_loan",
+                                "in_transit",
+                                "lost",
+                                "withdrawn"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by status (repeatable)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset mode)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ItemListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Register a physical copy of a book. The barcode is unique across the whole collection; without a status the item is available. The on_loan status is set by checkouts only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Add an item to a book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Item data",
+                        "name": "item",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Item"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Barcode already in use or status on_loan",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/exports/books": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Streams every book matching the filters, with its authors, as a file download. Accepts the filters and sort of GET /books; as in the listing, the author filter keeps only the matching authors of each book.\ncsv and jsonl use the columns and field names accepted by POST /imports, so an export can be imported again; marcxml writes MARC 21 bibliographic records (MARC21/slim).",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/marcxml+xml"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Export the catalog",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "jsonl",
+                            "marcxml"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "File format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by book title (partial match, case insensitive)",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by author name",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by ISBN-10 or ISBN-13 (exact edition)",
+                        "name": "isbn",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by publisher (partial match, case insensitive)",
+                        "name": "publisher",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "hardcover",
+                                "paperback",
+                                "ebook",
+                                "audiobook"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by book format (repeatable)",
+                        "name": "book_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Published in or after this year",
+                        "name": "year_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Published in or before this year",
+                        "name": "year_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by author UUID (repeatable)",
+                        "name": "author_uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by subject (repeatable)",
+                        "name": "subject",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by language (repeatable)",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by publication decade, e.g. 1990 (repeatable)",
+                        "name": "decade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "available",
+                                "on_loan",
+                                "in_transit",
+                                "lost",
+                                "withdrawn"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by books with an item in this status (repeatable)",
+                        "name": "availability",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "title",
+                            "created_at"
+                        ],
+                        "type": "string",
+                        "default": "title",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "default": "ASC",
+                        "description": "Sort direction",
+                        "name": "sort_dir",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Catalog file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of holds in queue order (oldest first). Requires the staff role. Pages can be requested by number (page) or by following next_cursor/prev_cursor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "List holds",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by book UUID",
+                        "name": "bookUuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by patron email (exact)",
+                        "name": "patron",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "waiting",
+                                "suspended",
+                                "ready",
+                                "fulfilled",
+                                "cancelled",
+                                "expired"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by status (repeatable)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset mode)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/HoldListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Put a patron in the FIFO queue of a book (any copy will do). Without patron the hold is placed for the current user; placing a hold for another patron requires the staff role. If a copy is free it is set aside at once and the hold is ready for pickup; otherwise the response has the queue position and an estimated wait in days, based on the number of copies and the loan period.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Place a hold on a book",
+                "parameters": [
+                    {
+                        "description": "Book, pickup branch and optional expiry",
+                        "name": "hold",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/HoldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Patron given without the staff role",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Book or patron not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Patron already has an open hold or a loan of this book, or the book has no copies",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Get a hold by UUID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hold UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Hold belongs to another patron",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Hold not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{uuid}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Close an open hold. A copy set aside for a ready hold goes to the next hold in the queue.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Cancel a hold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hold UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Hold belongs to another patron",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Hold not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Hold already closed",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{uuid}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Put a suspended hold back in the queue, in its original place. If a copy is free it is set aside at once.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Resume a suspended hold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hold UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Hold belongs to another patron",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Hold not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Hold is not suspended",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{uuid}/suspend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Freeze a waiting hold. It keeps its place in the queue but is skipped when a copy comes back. With until, the hold resumes by itself on that date; otherwise it stays suspended until resumed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Suspend a hold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hold UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Optional resume date",
+                        "name": "suspend",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/SuspendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Hold belongs to another patron",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Hold not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Hold is ready or closed",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/imports": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "imports"
+                ],
+                "summary": "List recent import jobs",
+                "parameters": [
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Number of jobs",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ImportJobListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Streams the uploaded file and creates or updates books in batches. Books whose ISBN already exists are updated (empty fields keep the stored value); authors are matched by name, ignoring case and extra spaces, and created when missing.\nCSV files need a header with title and authors plus any of description, isbn, publisher, publication_year, language, pages, edition, format and subjects; authors and subjects are separated by \";\". JSON Lines files have one book per line, with the fields of POST /books and \"authors\" as a list of names.\nMARC 21 records are read from ISO 2709 (.mrc, UTF-8 or MARC-8) or MARCXML (.xml) files; fields 020, 100/700, 245, 250, 260/264, 520 and 650 are mapped. Records imported with data loss are counted as partial and listed in warnings.\nInvalid rows are skipped and listed in the job report. With dry_run=true nothing is saved, but the report shows what would happen.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "imports"
+                ],
+                "summary": "Import books from a CSV, JSON Lines or MARC 21 file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Catalog file (.csv, .jsonl, .ndjson, .mrc or .xml)",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "jsonl",
+                            "marc",
+                            "marcxml"
+                        ],
+                        "type": "string",
+                        "description": "File format (default: from the file extension)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate and report without saving",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/ImportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid upload or file header",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Import aborted (the job report has the counts so far)",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/imports/{uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "imports"
+                ],
+                "summary": "Get an import job report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Import job UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ImportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Import job not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/items": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of items of the whole collection, sorted by barcode. Pages can be requested by number (page) or by following next_cursor/prev_cursor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "List items",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by barcode (exact)",
+                        "name": "barcode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by branch (exact)",
+                        "name": "branch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "available",
+                                "on_loan",
+                                "in_transit",
+                                "lost",
+                                "withdrawn"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by status (repeatable)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset mode)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ItemListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/items/{uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Get an item by UUID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Item UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Item"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Item not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace barcode, call number, branch, location, status, acquisition date and price of an item. The item stays linked to the same book. While the item has an open loan its status must stay on_loan, and no other item can be set to on_loan. An item set aside for a hold that stops being available sends the hold back to the queue.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Replace an item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Item UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Item data",
+                        "name": "item",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Item"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Item not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Barcode already in use or on_loan status change",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove an item from the collection. Items with loans cannot be deleted; set the status to withdrawn instead. Items set aside for a hold cannot be deleted either.",
+                "tags": [
+                    "items"
+                ],
+                "summary": "Delete an item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Item UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Item not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Item has loans or is set aside for a hold",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/loans": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of loans of all patrons, most recent checkout first. Pages can be requested by number (page) or by following next_cursor/prev_cursor. Requires the staff role; patrons list their own loans at /users/me/loans.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "loans"
+                ],
+                "summary": "List loans",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by patron email (exact)",
+                        "name": "patron",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by item barcode (exact)",
+                        "name": "barcode",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "returned",
+                            "overdue"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status; overdue loans are active loans past their due date",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset mode)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/LoanListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lend the item with the given barcode to a patron (a registered user, by email). The loan period and the patron's limit of open loans come from the circulation rule for the patron category and the item's material type (see /policies/rules); the due date is counted in days in the library time zone and moves to the next day the library is open. Patrons who owe more than the configured balance limit cannot borrow. An item that is on loan, in transit, lost or withdrawn cannot be checked out, nor a copy set aside for another patron's hold. The checkout fulfills the patron's own hold on the book. Requires the staff role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "loans"
+                ],
+                "summary": "Check out an item",
+                "parameters": [
+                    {
+                        "description": "Barcode and patron",
+                        "name": "loan",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CheckoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Loan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Item or patron not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Item already on loan, not available or reserved, loan limit reached or balance above the limit",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/loans/{uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Patrons can only get their own loans; staff can get any loan.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "loans"
+                ],
+                "summary": "Get a loan by UUID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Loan UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Loan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Loan of another patron",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Loan not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/loans/{uuid}/renew": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Extend an open loan on behalf of the current user. The new due date is the loan period of the circulation rule counted from the current due date, or from today if the loan is overdue, and moves to the next day the library is open. A loan cannot be renewed more times than the rule allows, while another patron is waiting in the book's hold queue, or when the patron owes more than the balance limit. The overdue fine of a late loan is charged at renewal; the fines of all due dates of a loan together stop at the cap of the rule. Each renewal is recorded in the loan's history. Patrons can only renew their own loans; staff can renew any loan.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "loans"
+                ],
+                "summary": "Renew a loan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Loan UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Loan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Loan of another patron",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Loan not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Loan returned, renewal limit reached, book on hold for another patron or balance above the limit",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/loans/{uuid}/renewals": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the renewals of a loan, oldest first, with the previous and the new due date and who renewed it. Patrons can only see their own loans; staff can see any loan.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "loans"
+                ],
+                "summary": "Get the renewal history of a loan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Loan UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/LoanRenewalListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Loan of another patron",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Loan not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/loans/{uuid}/return": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Check the item back in. The item becomes available again and the return is recorded with the current user as staff member. If the book has holds, the copy is set aside for the next hold in the queue, which becomes ready for pickup. Requires the staff role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "loans"
+                ],
+                "summary": "Return a loan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Loan UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Loan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Loan not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Loan already returned",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/oai": {
+            "get": {
+                "description": "OAI-PMH 2.0 endpoint for union catalogs and discovery services. Supports the six verbs (Identify, ListMetadataFormats, ListSets, GetRecord, ListIdentifiers, ListRecords), oai_dc and marc21 (MARCXML) metadata, one set per subject and persistent deleted records.\nIdentifiers are oai:\u003crepository identifier\u003e:\u003cbook UUID\u003e; datestamps are the last change of the book, with second granularity. Lists are paginated with resumption tokens that do not expire.\nProtocol errors are returned inside the OAI-PMH document with HTTP 200, as the specification requires. Arguments may also be sent as an application/x-www-form-urlencoded POST body.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "oai-pmh"
+                ],
+                "summary": "OAI-PMH data provider",
+                "parameters": [
+                    {
+                        "enum": [
+                            "Identify",
+                            "ListMetadataFormats",
+                            "ListSets",
+                            "GetRecord",
+                            "ListIdentifiers",
+                            "ListRecords"
+                        ],
+                        "type": "string",
+                        "description": "OAI-PMH verb",
+                        "name": "verb",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record identifier (GetRecord, ListMetadataFormats)",
+                        "name": "identifier",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "oai_dc",
+                            "marc21"
+                        ],
+                        "type": "string",
+                        "description": "Metadata format",
+                        "name": "metadataPrefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Lower bound of the datestamp (YYYY-MM-DD or YYYY-MM-DDThh:mm:ssZ)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Upper bound of the datestamp (YYYY-MM-DD or YYYY-MM-DDThh:mm:ssZ)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set spec (subject)",
+                        "name": "set",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token of the previous incomplete list (exclusive argument)",
+                        "name": "resumptionToken",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OAI-PMH response document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "OAI-PMH 2.0 endpoint for union catalogs and discovery services. Supports the six verbs (Identify, ListMetadataFormats, ListSets, GetRecord, ListIdentifiers, ListRecords), oai_dc and marc21 (MARCXML) metadata, one set per subject and persistent deleted records.\nIdentifiers are oai:\u003crepository identifier\u003e:\u003cbook UUID\u003e; datestamps are the last change of the book, with second granularity. Lists are paginated with resumption tokens that do not expire.\nProtocol errors are returned inside the OAI-PMH document with HTTP 200, as the specification requires. Arguments may also be sent as an application/x-www-form-urlencoded POST body.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "oai-pmh"
+                ],
+                "summary": "OAI-PMH data provider",
+                "parameters": [
+                    {
+                        "enum": [
+                            "Identify",
+                            "ListMetadataFormats",
+                            "ListSets",
+                            "GetRecord",
+                            "ListIdentifiers",
+                            "ListRecords"
+                        ],
+                        "type": "string",
+                        "description": "OAI-PMH verb",
+                        "name": "verb",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record identifier (GetRecord, ListMetadataFormats)",
+                        "name": "identifier",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "oai_dc",
+                            "marc21"
+                        ],
+                        "type": "string",
+                        "description": "Metadata format",
+                        "name": "metadataPrefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Lower bound of the datestamp (YYYY-MM-DD or YYYY-MM-DDThh:mm:ssZ)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Upper bound of the datestamp (YYYY-MM-DD or YYYY-MM-DDThh:mm:ssZ)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set spec (subject)",
+                        "name": "set",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token of the previous incomplete list (exclusive argument)",
+                        "name": "resumptionToken",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OAI-PMH response document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/policies/rules": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all rules in the order they are considered, most specific first, and the default limits that apply when no rule matches. Requires the staff role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policies"
+                ],
+                "summary": "List circulation rules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PolicyRuleListResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a rule with the loan period, the maximum number of renewals and the maximum number of open loans for a patron category and/or material type. Leave a criterion empty to match any value. There is at most one rule per combination of criteria. Requires the admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policies"
+                ],
+                "summary": "Create a circulation rule",
+                "parameters": [
+                    {
+                        "description": "Rule criteria and limits",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PolicyRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/PolicyRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "A rule with the same criteria already exists",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/policies/rules/{uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a rule by its UUID. Requires the staff role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policies"
+                ],
+                "summary": "Get a circulation rule by UUID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rule UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PolicyRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the criteria and limits of a rule. Open loans keep their due dates; the new limits apply from the next checkout or renewal. Requires the admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policies"
+                ],
+                "summary": "Replace a circulation rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rule UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rule criteria and limits",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PolicyRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PolicyRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "A rule with the same criteria already exists",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a rule; open loans keep their due dates. Requires the admin role.",
+                "tags": [
+                    "policies"
+                ],
+                "summary": "Delete a circulation rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rule UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid UUID",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/policies/simulate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Show which circulation rule would apply to a checkout made today, and why. Give the patron by email or by category, and the material by item barcode or by type. Every rule is listed with the reason it matched or not; the most specific match wins, and on a tie a material type rule beats a patron category rule. Requires the staff role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policies"
+                ],
+                "summary": "Simulate the rule resolution",
+                "parameters": [
+                    {
+                        "description": "Patron and material",
+                        "name": "simulation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SimulateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PolicyDecision"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patron or item not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search titles, descriptions and author names, ranked by relevance. Case and accents are ignored.\nSyntax: words must all match; \"quoted phrases\" match in sequence; pref* matches a prefix; -term excludes books containing term.\nEach hit has a headline: an HTML fragment of the title and description, with the text escaped and the matched terms wrapped in \u003cmark\u003e.\nlang selects the stemming rules applied by PostgreSQL (the memory and sqlite backends do not stem).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Full-text search over books",
+                "parameters": [
+                    {
+                        "maxLength": 256,
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pt",
+                            "en"
+                        ],
+                        "type": "string",
+                        "default": "pt",
+                        "description": "Text search language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new user in the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_patrick-tondorf_lib_api_internal_domain.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "User already exists",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/holds": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of the holds of the current user, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "List my holds",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "waiting",
+                                "suspended",
+                                "ready",
+                                "fulfilled",
+                                "cancelled",
+                                "expired"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by status (repeatable)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset mode)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/HoldListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/ledger": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the balance owed by the current user and a page of the ledger entries, most recent first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledger"
+                ],
+                "summary": "Get my ledger",
+                "parameters": [
+                    {
+                        "enum": [
+                            "overdue",
+                            "lost",
+                            "damage",
+                            "payment",
+                            "waiver"
+                        ],
+                        "type": "string",
+                        "description": "Filter by entry type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset mode)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/LedgerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/loans": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of the loans of the current user, active and past, most recent checkout first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "loans"
+                ],
+                "summary": "List my loans",
+                "parameters": [
+                    {
+                        "enum": [
+                            "active",
+                            "returned",
+                            "overdue"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status; overdue loans are active loans past their due date",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset mode)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/LoanListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/loans/renew": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Try to renew every open loan of the current user, under the same conditions as a single renewal. Loans that cannot be renewed are listed with the reason and keep their due dates.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "loans"
+                ],
+                "summary": "Renew all my loans",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RenewAllResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{email}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve user details by email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"user@example.com\"",
+                        "description": "User email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_patrick-tondorf_lib_api_internal_domain.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{email}/category": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the category (student, staff or public) that selects the circulation rules applied to the user's loans. Users registered through the API start as public. Open loans keep their due dates. Requires the admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Set the patron category of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"user@example.com\"",
+                        "description": "User email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New category",
+                        "name": "category",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CategoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_patrick-tondorf_lib_api_internal_domain.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{email}/ledger": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the current balance owed by a patron and a page of the ledger entries, most recent first. Each entry carries the balance right after it. Pages can be requested by number (page) or by following next_cursor/prev_cursor. Requires the staff role; patrons read their own ledger at /users/me/ledger.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledger"
+                ],
+                "summary": "Get a patron's ledger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"reader@example.com\"",
+                        "description": "Patron email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "overdue",
+                            "lost",
+                            "damage",
+                            "payment",
+                            "waiver"
+                        ],
+                        "type": "string",
+                        "description": "Filter by entry type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset mode)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/LedgerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patron not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Record a charge for a lost or damaged item, a payment or a waiver on a patron's account, on behalf of the current user. Charges name the loan of the item; waivers need a note with the reason. Payments and waivers cannot exceed the balance. Overdue fines are posted daily by the circulation routine, following the fine settings of the circulation rule of each loan. Requires the staff role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledger"
+                ],
+                "summary": "Post a ledger entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"reader@example.com\"",
+                        "description": "Patron email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Entry",
+                        "name": "entry",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/LedgerEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/LedgerEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not staff",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Patron or loan not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Amount exceeds the balance or loan of another patron",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{email}/role": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the role of a user in the API: patrons only use their own account (/users/me/...), staff run the circulation desk (checkouts, returns, patron ledgers) and admins also manage the circulation rules, patron categories and roles. Users registered through the API start as patrons. The new role applies from the user's next login. Requires the admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Set the role of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"user@example.com\"",
+                        "description": "User email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/RoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_patrick-tondorf_lib_api_internal_domain.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "Author": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "description": "Biografia do autor",
+                    "type": "string",
+                    "example": "Autor de 1984 e A Revolução dos Bichos"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "George Orwell"
+                }
+            }
+        },
+        "AuthorListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Author"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 1
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "AuthorPatchRequest": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "example": "Autor de 1984 e A Revolução dos Bichos"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2,
+                    "example": "George Orwell"
+                }
+            }
+        },
+        "AuthorRequest": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "authorIds": {
+                    "description": "a ordem é a de autoria",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "A dystopian novel"
+                },
+                "edition": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "1st ed."
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "hardcover",
+                        "paperback",
+                        "ebook",
+                        "audiobook"
+                    ],
+                    "example": "paperback"
+                },
+                "isbn": {
+                    "type": "string",
+                    "example": "0-451-52493-4"
+                },
+                "language": {
+                    "type": "string",
+                    "maxLength": 35,
+                    "example": "en-GB"
+                },
+                "pages": {
+                    "type": "integer",
+                    "maximum": 100000,
+                    "minimum": 1,
+                    "example": 328
+                },
+                "publicationYear": {
+                    "type": "integer",
+                    "maximum": 9999,
+                    "minimum": 0,
+                    "example": 1949
+                },
+                "publisher": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Secker \u0026 Warburg"
+                },
+                "subjects": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Dystopia",
+                        "Politics"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2,
+                    "example": "1984"
+                }
+            }
+        },
+        "AuthorUpdateRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "bio": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "example": "Autor de 1984 e A Revolução dos Bichos"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2,
+                    "example": "George Orwell"
+                }
+            }
+        },
+        "Availability": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "inTransit": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "lost": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "onHold": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "onLoan": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "Book": {
+            "type": "object",
+            "properties": {
+                "authors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Author"
+                    }
+                },
+                "availability": {
+                    "description": "exemplares por situação",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/Availability"
+                        }
+                    ]
+                },
+                "description": {
+                    "description": "@example Livro conta a história....",
+                    "type": "string",
+                    "example": "Livro conta a história...."
+                },
+                "edition": {
+                    "type": "string",
+                    "example": "1st ed."
+                },
+                "format": {
+                    "type": "string",
+                    "example": "paperback"
+                },
+                "isbn": {
+                    "type": "string",
+                    "example": "9780451524935"
+                },
+                "language": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "pages": {
+                    "type": "integer",
+                    "example": 328
+                },
+                "publicationYear": {
+                    "type": "integer",
+                    "example": 1949
+                },
+                "publisher": {
+                    "type": "string",
+                    "example": "Secker \u0026 Warburg"
+                },
+                "subjects": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Dystopia",
+                        "Politics"
+                    ]
+                },
+                "title": {
+                    "description": "@example 1984",
+                    "type": "string",
+                    "example": "1984"
+                }
+            }
+        },
+        "BookFacets": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/FacetValue"
+                    }
+                },
+                "availability": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/FacetValue"
+                    }
+                },
+                "decade": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/FacetValue"
+                    }
+                },
+                "language": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/FacetValue"
+                    }
+                },
+                "subject": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/FacetValue"
+                    }
+                }
+            }
+        },
+        "BookListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Book"
+                    }
+                },
+                "facets": {
+                    "description": "só com facets=true",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/BookFacets"
+                        }
+                    ]
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 1
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "BookUpdateRequest": {
+            "type": "object",
+            "required": [
+                "authorIds",
+                "title"
+            ],
+            "properties": {
+                "authorIds": {
+                    "description": "a ordem é a de autoria",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
                         1,
                         2,
                         3
                     ]
                 },
-                "description": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "A dystopian novel"
+                },
+                "edition": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "1st ed."
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "hardcover",
+                        "paperback",
+                        "ebook",
+                        "audiobook"
+                    ],
+                    "example": "paperback"
+                },
+                "isbn": {
+                    "type": "string",
+                    "example": "0-451-52493-4"
+                },
+                "language": {
+                    "type": "string",
+                    "maxLength": 35,
+                    "example": "en-GB"
+                },
+                "pages": {
+                    "type": "integer",
+                    "maximum": 100000,
+                    "minimum": 1,
+                    "example": 328
+                },
+                "publicationYear": {
+                    "type": "integer",
+                    "maximum": 9999,
+                    "minimum": 0,
+                    "example": 1949
+                },
+                "publisher": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Secker \u0026 Warburg"
+                },
+                "subjects": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Dystopia",
+                        "Politics"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2,
+                    "example": "1984"
+                }
+            }
+        },
+        "CategoryRequest": {
+            "type": "object",
+            "required": [
+                "category"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "enum": [
+                        "student",
+                        "staff",
+                        "public"
+                    ],
+                    "example": "student"
+                }
+            }
+        },
+        "CheckoutRequest": {
+            "type": "object",
+            "required": [
+                "barcode",
+                "patron"
+            ],
+            "properties": {
+                "barcode": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "31234000012345"
+                },
+                "patron": {
+                    "description": "email do leitor",
+                    "type": "string",
+                    "example": "reader@example.com"
+                }
+            }
+        },
+        "CitationRequest": {
+            "type": "object",
+            "required": [
+                "format",
+                "uuids"
+            ],
+            "properties": {
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "bibtex",
+                        "ris",
+                        "csljson",
+                        "apa",
+                        "mla",
+                        "abnt"
+                    ],
+                    "example": "abnt"
+                },
+                "uuids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "550e8400-e29b-41d4-a716-446655440000"
+                    ]
+                }
+            }
+        },
+        "Credential": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "FacetValue": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "label": {
+                    "type": "string",
+                    "example": "1940s"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "1940"
+                }
+            }
+        },
+        "FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "title"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "is required"
+                }
+            }
+        },
+        "Hold": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string",
+                    "example": "31234000012345"
+                },
+                "bookUuid": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "closedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "estimatedWaitDays": {
+                    "description": "ver EstimateWait",
+                    "type": "integer",
+                    "example": 14
+                },
+                "expiresOn": {
+                    "description": "o leitor não quer o livro depois desta data",
+                    "type": "string",
+                    "example": "2024-12-31"
+                },
+                "itemUuid": {
+                    "description": "exemplar separado",
+                    "type": "string",
+                    "example": "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+                },
+                "patron": {
+                    "type": "string",
+                    "example": "reader@example.com"
+                },
+                "pickupBranch": {
+                    "type": "string",
+                    "example": "Central"
+                },
+                "pickupBy": {
+                    "description": "último dia para retirar",
+                    "type": "string",
+                    "example": "2024-06-08"
+                },
+                "position": {
+                    "description": "lugar na fila; só em waiting e suspended",
+                    "type": "integer",
+                    "example": 2
+                },
+                "readyAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "waiting"
+                },
+                "suspendedUntil": {
+                    "type": "string",
+                    "example": "2024-07-01"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "1984"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string",
+                    "example": "9b2f6c1e-3d4a-4c5b-8e7f-1a2b3c4d5e6f"
+                }
+            }
+        },
+        "HoldListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Hold"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 1
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "HoldRequest": {
+            "type": "object",
+            "required": [
+                "bookUuid",
+                "pickupBranch"
+            ],
+            "properties": {
+                "bookUuid": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "expiresOn": {
+                    "type": "string",
+                    "example": "2024-12-31"
+                },
+                "patron": {
+                    "type": "string",
+                    "example": "reader@example.com"
+                },
+                "pickupBranch": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Central"
+                }
+            }
+        },
+        "ImportJob": {
+            "type": "object",
+            "properties": {
+                "authorsCreated": {
+                    "type": "integer",
+                    "example": 300
+                },
+                "created": {
+                    "type": "integer",
+                    "example": 1150
+                },
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ImportRowError"
+                    }
+                },
+                "errorsTruncated": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "csv",
+                        "jsonl"
+                    ],
+                    "example": "csv"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "import aborted: connection reset"
+                },
+                "partial": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "rows": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "source": {
+                    "type": "string",
+                    "example": "catalog.csv"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "running",
+                        "completed",
+                        "failed"
+                    ],
+                    "example": "completed"
+                },
+                "updated": {
+                    "type": "integer",
+                    "example": 40
+                },
+                "uuid": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "warnings": {
+                    "description": "Warnings descreve as linhas gravadas só em parte (Partial), como\nregistros MARC com ISBN inválido ou texto cortado",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ImportRowError"
+                    }
+                },
+                "warningsTruncated": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "ImportJobListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ImportJob"
+                    }
+                }
+            }
+        },
+        "ImportRowError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "isbn"
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "message": {
+                    "type": "string",
+                    "example": "has an invalid check digit"
+                },
+                "record": {
+                    "type": "string",
+                    "example": "ocm12345678"
+                }
+            }
+        },
+        "Item": {
+            "type": "object",
+            "properties": {
+                "acquiredOn": {
+                    "description": "AAAA-MM-DD",
+                    "type": "string",
+                    "example": "2024-03-15"
+                },
+                "barcode": {
+                    "type": "string",
+                    "example": "31234000012345"
+                },
+                "bookUuid": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "branch": {
+                    "type": "string",
+                    "example": "Central"
+                },
+                "callNumber": {
+                    "type": "string",
+                    "example": "823.912 ORW"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string",
+                    "example": "Stacks, 2nd floor"
+                },
+                "materialType": {
+                    "type": "string",
+                    "enum": [
+                        "book",
+                        "reference",
+                        "dvd",
+                        "ebook"
+                    ],
+                    "example": "book"
+                },
+                "priceCents": {
+                    "description": "preço de aquisição, em centavos",
+                    "type": "integer",
+                    "example": 4990
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "available",
+                        "on_loan",
+                        "in_transit",
+                        "lost",
+                        "withdrawn"
+                    ],
+                    "example": "available"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string",
+                    "example": "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+                }
+            }
+        },
+        "ItemListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Item"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 1
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "ItemRequest": {
+            "type": "object",
+            "required": [
+                "barcode",
+                "branch"
+            ],
+            "properties": {
+                "acquiredOn": {
+                    "type": "string",
+                    "example": "2024-03-15"
+                },
+                "barcode": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "31234000012345"
+                },
+                "branch": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Central"
+                },
+                "callNumber": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "823.912 ORW"
+                },
+                "location": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Stacks, 2nd floor"
+                },
+                "materialType": {
+                    "type": "string",
+                    "enum": [
+                        "book",
+                        "reference",
+                        "dvd",
+                        "ebook"
+                    ],
+                    "example": "book"
+                },
+                "priceCents": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 4990
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "available",
+                        "on_loan",
+                        "in_transit",
+                        "lost",
+                        "withdrawn"
+                    ],
+                    "example": "available"
+                }
+            }
+        },
+        "LedgerEntry": {
+            "type": "object",
+            "properties": {
+                "amountCents": {
+                    "description": "sempre positivo; o tipo diz o sentido",
+                    "type": "integer",
+                    "example": 150
+                },
+                "balanceCents": {
+                    "description": "saldo devedor depois do lançamento",
+                    "type": "integer",
+                    "example": 450
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "description": "vazio nas multas automáticas",
+                    "type": "string",
+                    "example": "staff@example.com"
+                },
+                "loanUuid": {
+                    "type": "string",
+                    "example": "0f8fad5b-d9cb-469f-a165-70867728950e"
+                },
+                "note": {
+                    "type": "string",
+                    "example": "Cover torn"
+                },
+                "patron": {
+                    "type": "string",
+                    "example": "reader@example.com"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "overdue",
+                        "lost",
+                        "damage",
+                        "payment",
+                        "waiver"
+                    ],
+                    "example": "overdue"
+                },
+                "uuid": {
+                    "type": "string",
+                    "example": "9b2e4f61-3c8a-4d7e-b5f0-1a2c3d4e5f60"
+                }
+            }
+        },
+        "LedgerEntryRequest": {
+            "type": "object",
+            "required": [
+                "amountCents",
+                "type"
+            ],
+            "properties": {
+                "amountCents": {
+                    "type": "integer",
+                    "maximum": 100000000,
+                    "minimum": 1,
+                    "example": 450
+                },
+                "loanUuid": {
+                    "description": "empréstimo do exemplar cobrado",
+                    "type": "string",
+                    "example": "0f8fad5b-d9cb-469f-a165-70867728950e"
+                },
+                "note": {
                     "type": "string",
                     "maxLength": 500,
-                    "example": "A dystopian novel"
+                    "example": "Paid at the front desk"
                 },
-                "title": {
+                "type": {
                     "type": "string",
-                    "maxLength": 100,
-                    "minLength": 2,
-                    "example": "1984"
+                    "enum": [
+                        "lost",
+                        "damage",
+                        "payment",
+                        "waiver"
+                    ],
+                    "example": "payment"
                 }
             }
         },
-        "Book": {
+        "LedgerResponse": {
             "type": "object",
             "properties": {
-                "authors": {
+                "balanceCents": {
+                    "description": "saldo devedor atual",
+                    "type": "integer",
+                    "example": 450
+                },
+                "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/Author"
+                        "$ref": "#/definitions/LedgerEntry"
                     }
                 },
-                "description": {
-                    "description": "@example Livro conta a história....",
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 1
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "Loan": {
+            "type": "object",
+            "properties": {
+                "barcode": {
                     "type": "string",
-                    "example": "Livro conta a história...."
+                    "example": "31234000012345"
+                },
+                "bookUuid": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "checkedOutAt": {
+                    "type": "string"
+                },
+                "checkedOutBy": {
+                    "description": "funcionário do token (sub)",
+                    "type": "string",
+                    "example": "staff@example.com"
+                },
+                "dueDate": {
+                    "description": "AAAA-MM-DD, no fuso da biblioteca",
+                    "type": "string",
+                    "example": "2024-04-01"
+                },
+                "itemUuid": {
+                    "type": "string",
+                    "example": "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+                },
+                "patron": {
+                    "type": "string",
+                    "example": "reader@example.com"
+                },
+                "renewals": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "returnedAt": {
+                    "type": "string"
+                },
+                "returnedBy": {
+                    "type": "string",
+                    "example": "staff@example.com"
                 },
                 "title": {
-                    "description": "@example 1984",
                     "type": "string",
                     "example": "1984"
+                },
+                "uuid": {
+                    "type": "string",
+                    "example": "0f8fad5b-d9cb-469f-a165-70867728950e"
                 }
             }
         },
-        "BookListResponse": {
+        "LoanListResponse": {
             "type": "object",
             "properties": {
                 "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/Book"
+                        "$ref": "#/definitions/Loan"
                     }
                 },
                 "limit": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 10
+                },
+                "next_cursor": {
+                    "type": "string"
                 },
                 "page": {
-                    "type": "integer"
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 1
+                },
+                "prev_cursor": {
+                    "type": "string"
                 },
                 "total": {
-                    "type": "integer"
+                    "description": "só no modo offset",
+                    "type": "integer",
+                    "example": 42
                 }
             }
         },
-        "Credential": {
+        "LoanRenewal": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
+                "dueDate": {
+                    "type": "string",
+                    "example": "2024-04-15"
                 },
-                "password": {
+                "previousDueDate": {
+                    "type": "string",
+                    "example": "2024-04-01"
+                },
+                "renewedAt": {
                     "type": "string"
+                },
+                "renewedBy": {
+                    "type": "string",
+                    "example": "reader@example.com"
+                }
+            }
+        },
+        "LoanRenewalListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "da mais antiga para a mais recente",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/LoanRenewal"
+                    }
+                }
+            }
+        },
+        "PolicyCandidate": {
+            "type": "object",
+            "properties": {
+                "matched": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "matches patron category student and any material type"
+                },
+                "rule": {
+                    "$ref": "#/definitions/PolicyRule"
+                }
+            }
+        },
+        "PolicyDecision": {
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/PolicyCandidate"
+                    }
+                },
+                "default": {
+                    "description": "nenhuma regra casou; valem os limites da configuração",
+                    "type": "boolean"
+                },
+                "dueDate": {
+                    "description": "de um empréstimo feito hoje",
+                    "type": "string",
+                    "example": "2024-04-01"
+                },
+                "explanation": {
+                    "type": "string",
+                    "example": "no rule matches; the configured defaults apply"
+                },
+                "loanable": {
+                    "description": "MaxItems \u003e 0",
+                    "type": "boolean"
+                },
+                "materialType": {
+                    "type": "string",
+                    "example": "dvd"
+                },
+                "patronCategory": {
+                    "type": "string",
+                    "example": "student"
+                },
+                "rule": {
+                    "$ref": "#/definitions/PolicyRule"
                 }
             }
         },
-        "ErrorResponse": {
+        "PolicyRule": {
             "type": "object",
             "properties": {
-                "details": {
-                    "description": "Additional details (debug only)",
+                "createdAt": {
                     "type": "string"
                 },
-                "error": {
-                    "description": "Error message",
+                "fineCapCents": {
+                    "description": "teto da multa de um empréstimo; 0 é sem teto",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "fineDailyCents": {
+                    "description": "multa por dia de atraso",
+                    "type": "integer",
+                    "example": 50
+                },
+                "fineGraceDays": {
+                    "description": "dias de atraso sem multa",
+                    "type": "integer",
+                    "example": 1
+                },
+                "loanDays": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "materialType": {
+                    "type": "string",
+                    "enum": [
+                        "book",
+                        "reference",
+                        "dvd",
+                        "ebook"
+                    ],
+                    "example": "dvd"
+                },
+                "maxItems": {
+                    "description": "empréstimos em aberto do material da regra; 0 proíbe o empréstimo",
+                    "type": "integer",
+                    "example": 3
+                },
+                "maxRenewals": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "patronCategory": {
+                    "type": "string",
+                    "enum": [
+                        "student",
+                        "staff",
+                        "public"
+                    ],
+                    "example": "student"
+                },
+                "updatedAt": {
                     "type": "string"
+                },
+                "uuid": {
+                    "description": "vazio na regra padrão",
+                    "type": "string",
+                    "example": "3f6c2a9e-8b1d-4e7a-9c5f-2d4b6a8e0c1f"
+                }
+            }
+        },
+        "PolicyRuleListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/PolicyRule"
+                    }
+                },
+                "default": {
+                    "description": "limites da configuração, usados quando nenhuma regra casa",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/PolicyRule"
+                        }
+                    ]
+                }
+            }
+        },
+        "PolicyRuleRequest": {
+            "type": "object",
+            "required": [
+                "fineCapCents",
+                "fineDailyCents",
+                "fineGraceDays",
+                "loanDays",
+                "maxItems",
+                "maxRenewals"
+            ],
+            "properties": {
+                "fineCapCents": {
+                    "type": "integer",
+                    "maximum": 10000000,
+                    "minimum": 0,
+                    "example": 1000
+                },
+                "fineDailyCents": {
+                    "type": "integer",
+                    "maximum": 100000,
+                    "minimum": 0,
+                    "example": 50
+                },
+                "fineGraceDays": {
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 0,
+                    "example": 1
+                },
+                "loanDays": {
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 1,
+                    "example": 7
+                },
+                "materialType": {
+                    "type": "string",
+                    "enum": [
+                        "book",
+                        "reference",
+                        "dvd",
+                        "ebook"
+                    ],
+                    "example": "dvd"
+                },
+                "maxItems": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 0,
+                    "example": 3
+                },
+                "maxRenewals": {
+                    "type": "integer",
+                    "maximum": 99,
+                    "minimum": 0,
+                    "example": 1
+                },
+                "patronCategory": {
+                    "type": "string",
+                    "enum": [
+                        "student",
+                        "staff",
+                        "public"
+                    ],
+                    "example": "student"
+                }
+            }
+        },
+        "Problem": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "book not found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/books/550e8400-e29b-41d4-a716-446655440000"
+                },
+                "requestId": {
+                    "type": "string",
+                    "example": "4bf92f3577b34da6a3ce929d0e0e4736"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:lib-api:problem:not-found"
+                }
+            }
+        },
+        "RenewAllResponse": {
+            "type": "object",
+            "properties": {
+                "refused": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/RenewalRefusal"
+                    }
+                },
+                "renewed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Loan"
+                    }
+                }
+            }
+        },
+        "RenewalRefusal": {
+            "type": "object",
+            "properties": {
+                "loan": {
+                    "$ref": "#/definitions/Loan"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "another patron is waiting for this book"
+                }
+            }
+        },
+        "RoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "patron",
+                        "staff",
+                        "admin"
+                    ],
+                    "example": "staff"
+                }
+            }
+        },
+        "SearchHit": {
+            "type": "object",
+            "properties": {
+                "book": {
+                    "$ref": "#/definitions/Book"
+                },
+                "headline": {
+                    "description": "HTML escapado",
+                    "type": "string",
+                    "example": "\u003cmark\u003e1984\u003c/mark\u003e. A dystopian novel"
+                },
+                "rank": {
+                    "type": "number",
+                    "example": 0.6079271
+                }
+            }
+        },
+        "SearchResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SearchHit"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "query": {
+                    "type": "string",
+                    "example": "\"big brother\" orwel*"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "SimulateRequest": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "31234000012345"
+                },
+                "materialType": {
+                    "type": "string",
+                    "enum": [
+                        "book",
+                        "reference",
+                        "dvd",
+                        "ebook"
+                    ],
+                    "example": "dvd"
+                },
+                "patron": {
+                    "type": "string",
+                    "example": "reader@example.com"
+                },
+                "patronCategory": {
+                    "type": "string",
+                    "enum": [
+                        "student",
+                        "staff",
+                        "public"
+                    ],
+                    "example": "student"
+                }
+            }
+        },
+        "SuspendRequest": {
+            "type": "object",
+            "properties": {
+                "until": {
+                    "type": "string",
+                    "example": "2024-07-01"
                 }
             }
         },
         "github_com_patrick-tondorf_lib_api_internal_domain.User": {
             "type": "object",
             "properties": {
+                "category": {
+                    "description": "categoria de leitor; public no cadastro",
+                    "type": "string",
+                    "readOnly": true
+                },
                 "email": {
                     "type": "string"
                 },
                 "password": {
                     "description": "Usado apenas para receber o input",
                     "type": "string"
+                },
+                "role": {
+                    "description": "papel na API; patron no cadastro",
+                    "type": "string",
+                    "readOnly": true
                 }
             }
         },
@@ -0,0 +1,85 @@
+// Package circulation executa as rotinas periódicas da circulação, que não
+// dependem de uma requisição: vencimento e retomada de reservas, separação
+// de exemplares que ficaram livres fora de uma devolução e lançamento das
+// multas por atraso.
+package circulation
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/config"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// Sweeper passa pelas reservas e pelos empréstimos vencidos a cada
+// cfg.SweepInterval
+type Sweeper struct {
+	holds  storage.HoldStore
+	ledger storage.LedgerStore
+	cfg    config.CirculationConfig
+	logger *slog.Logger
+}
+
+func NewSweeper(holds storage.HoldStore, ledger storage.LedgerStore, cfg config.CirculationConfig, logger *slog.Logger) *Sweeper {
+	return &Sweeper{holds: holds, ledger: ledger, cfg: cfg, logger: logger.With("component", "circulation")}
+}
+
+// DefaultRule monta a regra padrão da configuração, usada quando nenhuma
+// regra da tabela de políticas casa
+func DefaultRule(cfg config.CirculationConfig) domain.PolicyRule {
+	return domain.PolicyRule{
+		LoanDays:       cfg.LoanDays,
+		MaxRenewals:    cfg.MaxRenewals,
+		MaxItems:       cfg.MaxItems,
+		FineDailyCents: cfg.FineDailyCents,
+		FineGraceDays:  cfg.FineGraceDays,
+		FineCapCents:   cfg.FineCapCents,
+	}
+}
+
+// Run faz uma passada ao subir e depois uma a cada intervalo, até ctx ser
+// cancelado. Uma passada com erro é registrada e tentada de novo no
+// próximo intervalo.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		s.Sweep(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep faz uma passada com as datas de agora no fuso da biblioteca. As
+// multas não dependem das reservas: uma falha numa não impede a outra.
+func (s *Sweeper) Sweep(ctx context.Context) {
+	ctx = logging.WithContext(ctx, s.logger)
+	loc := s.cfg.Location()
+	dates := domain.NewHoldDates(time.Now(), s.cfg.HoldPickupDays, loc)
+
+	sweep, err := s.holds.SweepHolds(ctx, dates)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("hold sweep failed", "error", err)
+		}
+	} else {
+		s.logger.Info("hold sweep finished", "expired", sweep.Expired, "resumed", sweep.Resumed, "allocated", sweep.Allocated)
+	}
+
+	fines, err := s.ledger.AccrueFines(ctx, domain.FineRun{Today: dates.Today, Loc: loc, Default: DefaultRule(s.cfg)})
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("fine accrual failed", "error", err)
+		}
+		return
+	}
+	s.logger.Info("fine accrual finished", "charged", fines.Charged, "amount_cents", fines.AmountCents, "closed", fines.Closed)
+}
@@ -0,0 +1,124 @@
+package citation
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// bibtexEscaper escapa os caracteres especiais do LaTeX. Acentos ficam em
+// UTF-8, que biber e o BibTeX atual aceitam.
+var bibtexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`, `}`, `\}`,
+	`&`, `\&`, `%`, `\%`, `$`, `\$`, `#`, `\#`, `_`, `\_`,
+	`~`, `\textasciitilde{}`, `^`, `\textasciicircum{}`,
+)
+
+// writeBibTeX escreve uma entrada @book por livro. As chaves seguem o
+// padrão sobrenome+ano ("orwell1949"), com sufixo a, b... quando se
+// repetem no mesmo arquivo.
+func writeBibTeX(w *bufio.Writer, books []domain.Book) {
+	used := map[string]int{}
+	for i, b := range books {
+		if i > 0 {
+			w.WriteString("\n")
+		}
+		key := bibtexKey(b)
+		if n := used[key]; n > 0 {
+			used[key]++
+			key += string(rune('a' + n - 1))
+		} else {
+			used[key] = 1
+		}
+
+		w.WriteString("@book{" + key + ",\n")
+		field := func(name, value string) {
+			if value != "" {
+				w.WriteString("  " + name + " = {" + value + "},\n")
+			}
+		}
+		var authors []string
+		for _, n := range names(b) {
+			authors = append(authors, bibtexName(n))
+		}
+		field("author", strings.Join(authors, " and "))
+		// chaves duplas preservam as maiúsculas do título
+		field("title", "{"+bibtexEscaper.Replace(b.Title)+"}")
+		field("edition", bibtexEscaper.Replace(numericEdition(b.Edition)))
+		field("publisher", bibtexEscaper.Replace(b.Publisher))
+		y, _ := year(b)
+		field("year", y)
+		field("isbn", b.ISBN)
+		if b.Pages != nil {
+			field("pagetotal", strconv.Itoa(*b.Pages))
+		}
+		field("language", b.Language)
+		field("keywords", bibtexEscaper.Replace(strings.Join(b.Subjects, ", ")))
+		field("abstract", bibtexEscaper.Replace(oneLine(b.Description)))
+		w.WriteString("}\n")
+	}
+}
+
+// bibtexName usa a forma "von Last, Jr, First", a única em que o BibTeX
+// reconhece o sufixo
+func bibtexName(n personName) string {
+	esc := func(s string) string {
+		// " and " no meio de um nome separaria dois autores
+		if strings.Contains(s, " and ") {
+			return "{" + bibtexEscaper.Replace(s) + "}"
+		}
+		return bibtexEscaper.Replace(s)
+	}
+	out := esc(join(" ", n.Dropping, n.NonDropping, n.Family))
+	if n.Suffix != "" {
+		out += ", " + esc(n.Suffix)
+	}
+	if n.Given != "" {
+		out += ", " + esc(n.Given)
+	}
+	return out
+}
+
+// bibtexKey é o sobrenome do primeiro autor (ou a primeira palavra do
+// título) em ASCII minúsculo, seguido do ano
+func bibtexKey(b domain.Book) string {
+	base := b.Title
+	if ns := names(b); len(ns) > 0 {
+		base = ns[0].Family
+	}
+	unaccent := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(unaccent, strings.ToLower(base))
+	if err != nil {
+		folded = strings.ToLower(base)
+	}
+
+	var key strings.Builder
+	for _, r := range folded {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			key.WriteRune(r)
+		} else if key.Len() > 0 {
+			break
+		}
+	}
+	if key.Len() == 0 {
+		key.WriteString("book")
+	}
+	if y, ok := year(b); ok {
+		key.WriteString(y)
+	}
+	return key.String()
+}
+
+// oneLine junta as linhas de um texto livre, para os formatos de uma linha
+// por campo
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,150 @@
+// Package citation gera citações bibliográficas de livros: BibTeX, RIS e
+// CSL-JSON, para gerenciadores de referências, e as referências formatadas
+// nos estilos APA (7ª ed.), MLA (9ª ed.) e ABNT (NBR 6023:2018).
+//
+// Os autores seguem a ordem de autoria de domain.Book.Authors; cada estilo
+// aplica a própria regra de "et al.". Os estilos formatados saem em texto
+// simples, sem o itálico (APA, MLA) ou negrito (ABNT) do título.
+package citation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// Formatos aceitos em ?format=
+const (
+	FormatBibTeX  = "bibtex"
+	FormatRIS     = "ris"
+	FormatCSLJSON = "csljson"
+	FormatAPA     = "apa"
+	FormatMLA     = "mla"
+	FormatABNT    = "abnt"
+)
+
+// Formats lista os formatos válidos
+var Formats = []string{FormatBibTeX, FormatRIS, FormatCSLJSON, FormatAPA, FormatMLA, FormatABNT}
+
+// styles são os formatos de texto, uma referência por livro
+var styles = map[string]func(domain.Book) string{
+	FormatAPA:  apa,
+	FormatMLA:  mla,
+	FormatABNT: abnt,
+}
+
+// ContentType devolve o tipo MIME e a extensão de arquivo do formato
+func ContentType(format string) (mime, ext string) {
+	switch format {
+	case FormatBibTeX:
+		return "application/x-bibtex; charset=utf-8", "bib"
+	case FormatRIS:
+		return "application/x-research-info-systems; charset=utf-8", "ris"
+	case FormatCSLJSON:
+		return "application/vnd.citationstyles.csl+json", "json"
+	default:
+		return "text/plain; charset=utf-8", "txt"
+	}
+}
+
+// Write escreve as citações dos livros na ordem dada. Nos estilos
+// formatados cada referência ocupa uma linha; CSL-JSON é sempre um array.
+func Write(w io.Writer, format string, books []domain.Book) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatBibTeX:
+		writeBibTeX(bw, books)
+	case FormatRIS:
+		writeRIS(bw, books)
+	case FormatCSLJSON:
+		if err := writeCSL(bw, books); err != nil {
+			return err
+		}
+	default:
+		style, ok := styles[format]
+		if !ok {
+			return fmt.Errorf("unknown citation format %q", format)
+		}
+		for _, b := range books {
+			bw.WriteString(style(b) + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// names divide os nomes dos autores, na ordem de autoria
+func names(b domain.Book) []personName {
+	out := make([]personName, 0, len(b.Authors))
+	for _, a := range b.Authors {
+		if n := parseName(a.Name); n.Family != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// editionNumber lê o número de edições como "2", "2nd ed.", "2. ed." ou
+// "2ª edição"
+func editionNumber(edition string) (int, bool) {
+	end := 0
+	for end < len(edition) && edition[end] >= '0' && edition[end] <= '9' {
+		end++
+	}
+	n, err := strconv.Atoi(edition[:end])
+	return n, err == nil && n > 0
+}
+
+// englishEdition é a edição de APA e MLA ("2nd ed."); a primeira edição
+// não é citada. Textos sem número ("Rev. ed.") são mantidos.
+func englishEdition(edition string) string {
+	n, ok := editionNumber(edition)
+	if !ok {
+		return strings.TrimSuffix(edition, ".")
+	}
+	if n == 1 {
+		return ""
+	}
+	return ordinal(n) + " ed"
+}
+
+// numericEdition é a edição como número, quando houver, para BibTeX e
+// CSL-JSON, que formatam a edição conforme o estilo
+func numericEdition(edition string) string {
+	if n, ok := editionNumber(edition); ok {
+		return strconv.Itoa(n)
+	}
+	return edition
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// sentence fecha o trecho com ponto, sem duplicar a pontuação final
+func sentence(s string) string {
+	if s == "" || strings.ContainsAny(s[len(s)-1:], ".?!") {
+		return s
+	}
+	return s + "."
+}
+
+func year(b domain.Book) (string, bool) {
+	if b.PublicationYear == nil {
+		return "", false
+	}
+	return strconv.Itoa(*b.PublicationYear), true
+}
@@ -0,0 +1,75 @@
+package citation
+
+import (
+	"bufio"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// cslItem é um item CSL-JSON, o formato de entrada do citeproc (Zotero,
+// Mendeley, Pandoc)
+type cslItem struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	Title         string    `json:"title"`
+	Author        []cslName `json:"author,omitempty"`
+	Issued        *cslDate  `json:"issued,omitempty"`
+	Edition       string    `json:"edition,omitempty"`
+	Publisher     string    `json:"publisher,omitempty"`
+	ISBN          string    `json:"ISBN,omitempty"`
+	NumberOfPages string    `json:"number-of-pages,omitempty"`
+	Medium        string    `json:"medium,omitempty"`
+	Language      string    `json:"language,omitempty"`
+	Keyword       string    `json:"keyword,omitempty"`
+	Abstract      string    `json:"abstract,omitempty"`
+}
+
+type cslName struct {
+	Family      string `json:"family"`
+	Given       string `json:"given,omitempty"`
+	Dropping    string `json:"dropping-particle,omitempty"`
+	NonDropping string `json:"non-dropping-particle,omitempty"`
+	Suffix      string `json:"suffix,omitempty"`
+}
+
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+func writeCSL(w *bufio.Writer, books []domain.Book) error {
+	items := make([]cslItem, 0, len(books))
+	for _, b := range books {
+		item := cslItem{
+			ID:        b.UUID,
+			Type:      "book",
+			Title:     b.Title,
+			Edition:   numericEdition(b.Edition),
+			Publisher: b.Publisher,
+			ISBN:      b.ISBN,
+			Medium:    b.Format,
+			Language:  b.Language,
+			Keyword:   strings.Join(b.Subjects, ", "),
+			Abstract:  b.Description,
+		}
+		for _, n := range names(b) {
+			item.Author = append(item.Author, cslName{
+				Family: n.Family, Given: n.Given, Dropping: n.Dropping,
+				NonDropping: n.NonDropping, Suffix: n.Suffix,
+			})
+		}
+		if b.PublicationYear != nil {
+			item.Issued = &cslDate{DateParts: [][]int{{*b.PublicationYear}}}
+		}
+		if b.Pages != nil {
+			item.NumberOfPages = strconv.Itoa(*b.Pages)
+		}
+		items = append(items, item)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
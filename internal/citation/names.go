@@ -0,0 +1,165 @@
+package citation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// personName é um nome de autor dividido como no CSL. A partícula que cai
+// (dropping, "Machado de Assis") vai depois do prenome na forma invertida;
+// a que não cai (non-dropping, "van Gogh") fica presa ao sobrenome.
+type personName struct {
+	Given       string
+	Dropping    string
+	NonDropping string
+	Family      string
+	Suffix      string
+}
+
+// droppingParticles são as partículas das línguas românicas, que as normas
+// (e a ABNT em especial) põem depois do prenome: "ASSIS, Machado de"
+var droppingParticles = map[string]bool{
+	"da": true, "das": true, "de": true, "di": true, "do": true, "dos": true,
+	"du": true, "del": true, "della": true, "e": true,
+}
+
+var nonDroppingParticles = map[string]bool{
+	"van": true, "von": true, "der": true, "den": true, "ter": true, "ten": true,
+	"la": true, "le": true, "zu": true,
+}
+
+// suffixes são os agnomes, que acompanham o sobrenome ("SILVA JÚNIOR")
+var suffixes = map[string]bool{
+	"jr": true, "jr.": true, "junior": true, "júnior": true, "sr": true, "sr.": true,
+	"filho": true, "neto": true, "sobrinho": true, "ii": true, "iii": true, "iv": true,
+}
+
+// parseName divide o nome de um autor. Sem vírgula, o sobrenome é a última
+// palavra ("George Orwell"); sobrenomes compostos precisam vir na forma
+// invertida ("García Márquez, Gabriel"), que é respeitada. Um nome de uma
+// palavra só ("Platão") fica inteiro em Family.
+func parseName(raw string) personName {
+	raw = strings.Join(strings.Fields(raw), " ")
+	if family, rest, ok := strings.Cut(raw, ","); ok {
+		var n personName
+		given, suffix, _ := strings.Cut(rest, ",")
+		n.Given = strings.TrimSpace(given)
+		n.Suffix = strings.TrimSpace(suffix)
+		words := strings.Fields(family)
+		// partículas minúsculas no início do sobrenome: "van Gogh, Vincent"
+		i := 0
+		for i < len(words)-1 && isParticle(words[i]) {
+			i++
+		}
+		n.NonDropping = strings.Join(words[:i], " ")
+		n.Family = strings.Join(words[i:], " ")
+		// "Assis, Machado de": a partícula veio no fim do prenome
+		givenWords := strings.Fields(n.Given)
+		j := len(givenWords)
+		for j > 1 && isParticle(givenWords[j-1]) {
+			j--
+		}
+		if j < len(givenWords) {
+			n.Given = strings.Join(givenWords[:j], " ")
+			n.Dropping = strings.Join(givenWords[j:], " ")
+		}
+		return n
+	}
+
+	words := strings.Fields(raw)
+	var n personName
+	for len(words) > 1 && suffixes[strings.ToLower(words[len(words)-1])] {
+		n.Suffix = strings.TrimSpace(words[len(words)-1] + " " + n.Suffix)
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return n
+	}
+	n.Family = words[len(words)-1]
+	words = words[:len(words)-1]
+
+	// partículas antes do sobrenome, mas nunca a primeira palavra do nome
+	i := len(words)
+	for i > 1 && isParticle(words[i-1]) {
+		i--
+	}
+	var dropping, nonDropping []string
+	for _, w := range words[i:] {
+		if nonDroppingParticles[w] {
+			nonDropping = append(nonDropping, w)
+		} else {
+			dropping = append(dropping, w)
+		}
+	}
+	n.Given = strings.Join(words[:i], " ")
+	n.Dropping = strings.Join(dropping, " ")
+	n.NonDropping = strings.Join(nonDropping, " ")
+	return n
+}
+
+func isParticle(w string) bool {
+	return droppingParticles[w] || nonDroppingParticles[w]
+}
+
+// familyName é o sobrenome com a partícula que não cai
+func (n personName) familyName() string {
+	return join(" ", n.NonDropping, n.Family)
+}
+
+// inverted é a forma "Sobrenome, Prenome partícula, Sufixo" de MLA, BibTeX
+// e RIS
+func (n personName) inverted() string {
+	out := n.familyName()
+	if given := join(" ", n.Given, n.Dropping); given != "" {
+		out += ", " + given
+	}
+	if n.Suffix != "" {
+		out += ", " + n.Suffix
+	}
+	return out
+}
+
+// direct é a forma na ordem natural, "Prenome partícula Sobrenome Sufixo"
+func (n personName) direct() string {
+	out := join(" ", n.Given, n.Dropping, n.familyName())
+	if n.Suffix != "" {
+		out += ", " + n.Suffix
+	}
+	return out
+}
+
+// initials abrevia os prenomes: "Jean-Paul Charles" → "J.-P. C."
+func initials(given string) string {
+	var parts []string
+	for _, word := range strings.Fields(given) {
+		// já abreviado: "J.R.R."
+		if strings.HasSuffix(word, ".") {
+			parts = append(parts, word)
+			continue
+		}
+		var hyphenated []string
+		for _, piece := range strings.Split(word, "-") {
+			r, _ := utf8.DecodeRuneInString(piece)
+			if r == utf8.RuneError {
+				continue
+			}
+			hyphenated = append(hyphenated, string(unicode.ToUpper(r))+".")
+		}
+		if len(hyphenated) > 0 {
+			parts = append(parts, strings.Join(hyphenated, "-"))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// join junta as partes não vazias com sep
+func join(sep string, parts ...string) string {
+	kept := parts[:0:0]
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, sep)
+}
@@ -0,0 +1,72 @@
+package citation
+
+import "testing"
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		raw      string
+		want     personName
+		inverted string
+		direct   string
+	}{
+		{"George Orwell", personName{Given: "George", Family: "Orwell"},
+			"Orwell, George", "George Orwell"},
+		{"  George   Orwell ", personName{Given: "George", Family: "Orwell"},
+			"Orwell, George", "George Orwell"},
+		{"Platão", personName{Family: "Platão"},
+			"Platão", "Platão"},
+		{"Joaquim Maria Machado de Assis", personName{Given: "Joaquim Maria Machado", Dropping: "de", Family: "Assis"},
+			"Assis, Joaquim Maria Machado de", "Joaquim Maria Machado de Assis"},
+		{"Vincent van Gogh", personName{Given: "Vincent", NonDropping: "van", Family: "Gogh"},
+			"van Gogh, Vincent", "Vincent van Gogh"},
+		{"Ludwig van der Rohe", personName{Given: "Ludwig", NonDropping: "van der", Family: "Rohe"},
+			"van der Rohe, Ludwig", "Ludwig van der Rohe"},
+		{"Martin Luther King Jr.", personName{Given: "Martin Luther", Family: "King", Suffix: "Jr."},
+			"King, Martin Luther, Jr.", "Martin Luther King, Jr."},
+		{"João da Silva Filho", personName{Given: "João", Dropping: "da", Family: "Silva", Suffix: "Filho"},
+			"Silva, João da, Filho", "João da Silva, Filho"},
+		// a primeira palavra nunca é partícula
+		{"De Gaulle", personName{Given: "De", Family: "Gaulle"},
+			"Gaulle, De", "De Gaulle"},
+		{"García Márquez, Gabriel", personName{Given: "Gabriel", Family: "García Márquez"},
+			"García Márquez, Gabriel", "Gabriel García Márquez"},
+		{"van Gogh, Vincent", personName{Given: "Vincent", NonDropping: "van", Family: "Gogh"},
+			"van Gogh, Vincent", "Vincent van Gogh"},
+		{"Assis, Machado de", personName{Given: "Machado", Dropping: "de", Family: "Assis"},
+			"Assis, Machado de", "Machado de Assis"},
+		{"King, Martin Luther, Jr.", personName{Given: "Martin Luther", Family: "King", Suffix: "Jr."},
+			"King, Martin Luther, Jr.", "Martin Luther King, Jr."},
+		{"", personName{}, "", ""},
+	}
+	for _, tt := range tests {
+		n := parseName(tt.raw)
+		if n != tt.want {
+			t.Errorf("parseName(%q) = %+v, want %+v", tt.raw, n, tt.want)
+		}
+		if got := n.inverted(); got != tt.inverted {
+			t.Errorf("parseName(%q).inverted() = %q, want %q", tt.raw, got, tt.inverted)
+		}
+		if got := n.direct(); got != tt.direct {
+			t.Errorf("parseName(%q).direct() = %q, want %q", tt.raw, got, tt.direct)
+		}
+	}
+}
+
+func TestInitials(t *testing.T) {
+	tests := []struct {
+		given, want string
+	}{
+		{"George", "G."},
+		{"Martin Luther", "M. L."},
+		{"Jean-Paul", "J.-P."},
+		{"J.R.R.", "J.R.R."},
+		{"J. Michael", "J. M."},
+		{"émile", "É."},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := initials(tt.given); got != tt.want {
+			t.Errorf("initials(%q) = %q, want %q", tt.given, got, tt.want)
+		}
+	}
+}
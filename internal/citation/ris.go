@@ -0,0 +1,43 @@
+package citation
+
+import (
+	"bufio"
+	"strconv"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// writeRIS escreve um registro TY BOOK por livro. A especificação pede
+// linhas terminadas em CRLF.
+func writeRIS(w *bufio.Writer, books []domain.Book) {
+	for i, b := range books {
+		if i > 0 {
+			w.WriteString("\r\n")
+		}
+		tag := func(name, value string) {
+			if value != "" {
+				w.WriteString(name + "  - " + value + "\r\n")
+			}
+		}
+		tag("TY", "BOOK")
+		tag("ID", b.UUID)
+		for _, n := range names(b) {
+			tag("AU", n.inverted())
+		}
+		tag("TI", oneLine(b.Title))
+		y, _ := year(b)
+		tag("PY", y)
+		tag("ET", b.Edition)
+		tag("PB", b.Publisher)
+		tag("SN", b.ISBN)
+		if b.Pages != nil {
+			tag("SP", strconv.Itoa(*b.Pages))
+		}
+		tag("LA", b.Language)
+		for _, s := range b.Subjects {
+			tag("KW", s)
+		}
+		tag("AB", oneLine(b.Description))
+		w.WriteString("ER  - \r\n")
+	}
+}
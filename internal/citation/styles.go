@@ -0,0 +1,123 @@
+package citation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// apaMaxAuthors é o limite da APA 7: até 20 autores são listados; acima
+// disso, os 19 primeiros, reticências e o último
+const apaMaxAuthors = 20
+
+// apa: Orwell, G., & Huxley, A. (1949). Title (2nd ed.). Publisher.
+func apa(b domain.Book) string {
+	var authors []string
+	for _, n := range names(b) {
+		s := n.familyName()
+		if given := join(" ", initials(n.Given), n.Dropping); given != "" {
+			s += ", " + given
+		}
+		if n.Suffix != "" {
+			s += ", " + n.Suffix
+		}
+		authors = append(authors, s)
+	}
+
+	var byline string
+	switch n := len(authors); {
+	case n == 1:
+		byline = authors[0]
+	case n > apaMaxAuthors:
+		byline = strings.Join(authors[:apaMaxAuthors-1], ", ") + ", . . . " + authors[n-1]
+	case n > 1:
+		byline = strings.Join(authors[:n-1], ", ") + ", & " + authors[n-1]
+	}
+
+	title := b.Title
+	if ed := englishEdition(b.Edition); ed != "" {
+		title += " (" + ed + ".)"
+	}
+	date := "(n.d.)."
+	if y, ok := year(b); ok {
+		date = "(" + y + ")."
+	}
+
+	// sem autor, o título ocupa a posição do autor
+	if byline == "" {
+		return join(" ", sentence(title), date, sentence(b.Publisher))
+	}
+	return join(" ", sentence(byline), date, sentence(title), sentence(b.Publisher))
+}
+
+// mla: Orwell, George, and Aldous Huxley. Title. 2nd ed., Publisher, 1949.
+// Com três ou mais autores, só o primeiro seguido de "et al.".
+func mla(b domain.Book) string {
+	ns := names(b)
+	var byline string
+	switch len(ns) {
+	case 0:
+	case 1:
+		byline = ns[0].inverted()
+	case 2:
+		byline = ns[0].inverted() + ", and " + ns[1].direct()
+	default:
+		byline = ns[0].inverted() + ", et al."
+	}
+
+	var edition string
+	if ed := englishEdition(b.Edition); ed != "" {
+		edition = ed + "."
+	}
+	y, _ := year(b)
+	return join(" ", sentence(byline), sentence(b.Title), sentence(join(", ", edition, b.Publisher, y)))
+}
+
+// abntMaxAuthors: a NBR 6023:2018 permite listar todos os autores, mas
+// recomenda, a partir de quatro, só o primeiro seguido de "et al."
+const abntMaxAuthors = 3
+
+// abnt: ORWELL, George; HUXLEY, Aldous. Título. 2. ed. [S. l.]: Editora, 1949. 328 p.
+func abnt(b domain.Book) string {
+	var authors []string
+	for _, n := range names(b) {
+		// o agnome acompanha o sobrenome: SILVA JÚNIOR, João
+		s := strings.ToUpper(join(" ", n.familyName(), n.Suffix))
+		if given := join(" ", n.Given, n.Dropping); given != "" {
+			s += ", " + given
+		}
+		authors = append(authors, s)
+	}
+	byline := strings.Join(authors, "; ")
+	if len(authors) > abntMaxAuthors {
+		byline = authors[0] + " et al"
+	}
+
+	var edition string
+	if n, ok := editionNumber(b.Edition); ok {
+		if n > 1 {
+			edition = fmt.Sprintf("%d. ed.", n)
+		}
+	} else {
+		edition = b.Edition
+	}
+
+	// O catálogo não guarda o local de publicação. Sem ano, a norma pede
+	// uma data estimada, que o catálogo também não tem como dar.
+	imprint := "[S. l.]: " + b.Publisher
+	if b.Publisher == "" {
+		imprint = "[S. l.: s. n.]"
+	}
+	if y, ok := year(b); ok {
+		imprint += ", " + y
+	} else {
+		imprint += ", [s. d.]"
+	}
+
+	var pages string
+	if b.Pages != nil {
+		pages = fmt.Sprintf("%d p.", *b.Pages)
+	}
+	return join(" ", sentence(byline), sentence(b.Title), sentence(edition), sentence(imprint), pages)
+}
@@ -0,0 +1,160 @@
+package citation
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// book monta um livro com os autores na ordem dada
+func book(title string, authors ...string) domain.Book {
+	b := domain.Book{Title: title}
+	for _, name := range authors {
+		b.Authors = append(b.Authors, &domain.Author{Name: name})
+	}
+	return b
+}
+
+// orwell é o livro completo usado como base dos casos
+func orwell() domain.Book {
+	b := book("Nineteen Eighty-Four", "George Orwell")
+	year, pages := 1949, 328
+	b.PublicationYear = &year
+	b.Pages = &pages
+	b.Publisher = "Secker & Warburg"
+	b.Edition = "2nd ed."
+	return b
+}
+
+// authors gera n autores "Given1 Family1"...
+func authors(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprintf("Given%d Family%d", i+1, i+1)
+	}
+	return out
+}
+
+func with(b domain.Book, fn func(*domain.Book)) domain.Book {
+	fn(&b)
+	return b
+}
+
+func TestAPA(t *testing.T) {
+	tests := []struct {
+		name string
+		book domain.Book
+		want string
+	}{
+		{"one author", orwell(),
+			"Orwell, G. (1949). Nineteen Eighty-Four (2nd ed.). Secker & Warburg."},
+		{"two authors", with(orwell(), func(b *domain.Book) { b.Authors = book("", "George Orwell", "Aldous Huxley").Authors }),
+			"Orwell, G., & Huxley, A. (1949). Nineteen Eighty-Four (2nd ed.). Secker & Warburg."},
+		{"three authors", book("Title", "Ann Lee", "Bo Kim", "Cy Young"),
+			"Lee, A., Kim, B., & Young, C. (n.d.). Title."},
+		{"twenty authors are all listed", book("T", authors(20)...),
+			"Family1, G., Family2, G., Family3, G., Family4, G., Family5, G., Family6, G., Family7, G., Family8, G., Family9, G., Family10, G., " +
+				"Family11, G., Family12, G., Family13, G., Family14, G., Family15, G., Family16, G., Family17, G., Family18, G., Family19, G., & Family20, G. (n.d.). T."},
+		{"twenty-one authors: nineteen, ellipsis and the last", book("T", authors(21)...),
+			"Family1, G., Family2, G., Family3, G., Family4, G., Family5, G., Family6, G., Family7, G., Family8, G., Family9, G., Family10, G., " +
+				"Family11, G., Family12, G., Family13, G., Family14, G., Family15, G., Family16, G., Family17, G., Family18, G., Family19, G., . . . Family21, G. (n.d.). T."},
+		{"particles and suffix", book("Why We Can't Wait", "Martin Luther King Jr.", "Vincent van Gogh", "Machado de Assis"),
+			"King, M. L., Jr., van Gogh, V., & Assis, M. de. (n.d.). Why We Can't Wait."},
+		{"hyphenated given name", book("Being and Nothingness", "Jean-Paul Sartre"),
+			"Sartre, J.-P. (n.d.). Being and Nothingness."},
+		{"first edition not cited", with(orwell(), func(b *domain.Book) { b.Edition = "1st" }),
+			"Orwell, G. (1949). Nineteen Eighty-Four. Secker & Warburg."},
+		{"edition without number kept", with(orwell(), func(b *domain.Book) { b.Edition = "Rev. ed." }),
+			"Orwell, G. (1949). Nineteen Eighty-Four (Rev. ed.). Secker & Warburg."},
+		{"no author: title first", with(orwell(), func(b *domain.Book) { b.Authors = nil }),
+			"Nineteen Eighty-Four (2nd ed.). (1949). Secker & Warburg."},
+		{"title ending in question mark", book("Who Moved My Cheese?", "Spencer Johnson"),
+			"Johnson, S. (n.d.). Who Moved My Cheese?"},
+	}
+	for _, tt := range tests {
+		if got := apa(tt.book); got != tt.want {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMLA(t *testing.T) {
+	tests := []struct {
+		name string
+		book domain.Book
+		want string
+	}{
+		{"one author", orwell(),
+			"Orwell, George. Nineteen Eighty-Four. 2nd ed., Secker & Warburg, 1949."},
+		{"two authors: second in direct order", with(orwell(), func(b *domain.Book) { b.Authors = book("", "George Orwell", "Aldous Huxley").Authors }),
+			"Orwell, George, and Aldous Huxley. Nineteen Eighty-Four. 2nd ed., Secker & Warburg, 1949."},
+		{"three authors: et al.", book("Title", "Ann Lee", "Bo Kim", "Cy Young"),
+			"Lee, Ann, et al. Title."},
+		{"particles and suffix", book("Letters", "Vincent van Gogh", "Martin Luther King Jr."),
+			"van Gogh, Vincent, and Martin Luther King, Jr. Letters."},
+		{"first edition not cited", with(orwell(), func(b *domain.Book) { b.Edition = "1" }),
+			"Orwell, George. Nineteen Eighty-Four. Secker & Warburg, 1949."},
+		{"third edition", with(orwell(), func(b *domain.Book) { b.Edition = "3" }),
+			"Orwell, George. Nineteen Eighty-Four. 3rd ed., Secker & Warburg, 1949."},
+		{"eleventh edition", with(orwell(), func(b *domain.Book) { b.Edition = "11th ed." }),
+			"Orwell, George. Nineteen Eighty-Four. 11th ed., Secker & Warburg, 1949."},
+		{"no author", with(orwell(), func(b *domain.Book) { b.Authors = nil; b.Edition = "" }),
+			"Nineteen Eighty-Four. Secker & Warburg, 1949."},
+	}
+	for _, tt := range tests {
+		if got := mla(tt.book); got != tt.want {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestABNT(t *testing.T) {
+	tests := []struct {
+		name string
+		book domain.Book
+		want string
+	}{
+		{"one author", orwell(),
+			"ORWELL, George. Nineteen Eighty-Four. 2. ed. [S. l.]: Secker & Warburg, 1949. 328 p."},
+		{"three authors are all listed", book("Título", "Ann Lee", "Bo Kim", "Cy Young"),
+			"LEE, Ann; KIM, Bo; YOUNG, Cy. Título. [S. l.: s. n.], [s. d.]."},
+		{"four authors: et al", book("Título", "Ann Lee", "Bo Kim", "Cy Young", "Di Cruz"),
+			"LEE, Ann et al. Título. [S. l.: s. n.], [s. d.]."},
+		{"particles and suffix", book("Memórias", "Joaquim Maria Machado de Assis", "João da Silva Filho", "Vincent van Gogh"),
+			"ASSIS, Joaquim Maria Machado de; SILVA FILHO, João da; VAN GOGH, Vincent. Memórias. [S. l.: s. n.], [s. d.]."},
+		{"inverted name kept", book("Cem anos de solidão", "García Márquez, Gabriel"),
+			"GARCÍA MÁRQUEZ, Gabriel. Cem anos de solidão. [S. l.: s. n.], [s. d.]."},
+		{"single name", book("A República", "Platão"),
+			"PLATÃO. A República. [S. l.: s. n.], [s. d.]."},
+		{"portuguese edition", with(orwell(), func(b *domain.Book) { b.Edition = "3ª edição" }),
+			"ORWELL, George. Nineteen Eighty-Four. 3. ed. [S. l.]: Secker & Warburg, 1949. 328 p."},
+		{"first edition not cited", with(orwell(), func(b *domain.Book) { b.Edition = "1. ed." }),
+			"ORWELL, George. Nineteen Eighty-Four. [S. l.]: Secker & Warburg, 1949. 328 p."},
+		{"edition without number kept", with(orwell(), func(b *domain.Book) { b.Edition = "Ed. revista" }),
+			"ORWELL, George. Nineteen Eighty-Four. Ed. revista. [S. l.]: Secker & Warburg, 1949. 328 p."},
+		{"publisher without year", with(orwell(), func(b *domain.Book) { b.PublicationYear = nil; b.Pages = nil; b.Edition = "" }),
+			"ORWELL, George. Nineteen Eighty-Four. [S. l.]: Secker & Warburg, [s. d.]."},
+	}
+	for _, tt := range tests {
+		if got := abnt(tt.book); got != tt.want {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriteStyles(t *testing.T) {
+	books := []domain.Book{orwell(), book("Brave New World", "Aldous Huxley")}
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatMLA, books); err != nil {
+		t.Fatal(err)
+	}
+	want := "Orwell, George. Nineteen Eighty-Four. 2nd ed., Secker & Warburg, 1949.\nHuxley, Aldous. Brave New World.\n"
+	if buf.String() != want {
+		t.Errorf("Write(mla) = %q, want %q", buf.String(), want)
+	}
+	if err := Write(&buf, "chicago", books); err == nil {
+		t.Error("Write(chicago): want an error")
+	}
+}
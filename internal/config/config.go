@@ -0,0 +1,552 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix é o prefixo de todas as variáveis de ambiente da API
+const EnvPrefix = "LIBAPI_"
+
+// Config reúne toda a configuração da API. É carregada uma única vez na
+// inicialização, na seguinte ordem de precedência (a última vence):
+//
+//  1. valores padrão (tag default)
+//  2. arquivo YAML ou TOML opcional, indicado por LIBAPI_CONFIG_FILE
+//  3. variáveis de ambiente LIBAPI_<SEÇÃO>_<CHAVE>, ex.: LIBAPI_SERVER_PORT
+//  4. arquivos de segredo LIBAPI_<SEÇÃO>_<CHAVE>_FILE, ex.: LIBAPI_AUTH_SECRET_KEY_FILE
+//
+// A chave de cada campo está na tag config e é a mesma no arquivo
+// (server.port vira server: {port: ...}) e no ambiente (LIBAPI_SERVER_PORT).
+type Config struct {
+	Server   ServerConfig
+	Storage  StorageConfig
+	Database DatabaseConfig
+	Auth     AuthConfig
+	Log      LogConfig
+	OAI      OAIConfig
+
+	Circulation CirculationConfig
+}
+
+type ServerConfig struct {
+	Port string `config:"server.port" default:"8080"`
+	Mode string `config:"server.mode" default:"debug"` // debug, release ou test
+
+	ReadTimeout       time.Duration `config:"server.read_timeout" default:"15s"`
+	ReadHeaderTimeout time.Duration `config:"server.read_header_timeout" default:"5s"`
+	WriteTimeout      time.Duration `config:"server.write_timeout" default:"30s"`
+	IdleTimeout       time.Duration `config:"server.idle_timeout" default:"2m"`
+	MaxHeaderBytes    int           `config:"server.max_header_bytes" default:"1048576"`
+	ShutdownTimeout   time.Duration `config:"server.shutdown_timeout" default:"30s"`
+
+	// TLS é ativado quando os dois arquivos são informados
+	TLSCertFile string `config:"server.tls_cert_file"`
+	TLSKeyFile  string `config:"server.tls_key_file"`
+	// H2C aceita HTTP/2 sem TLS, para uso atrás de um proxy que já termina o TLS
+	H2C bool `config:"server.h2c" default:"false"`
+}
+
+// TLSEnabled indica se o servidor deve servir HTTPS
+func (s ServerConfig) TLSEnabled() bool {
+	return s.TLSCertFile != "" && s.TLSKeyFile != ""
+}
+
+type StorageConfig struct {
+	Backend    string `config:"storage.backend" default:"postgres"` // postgres, sqlite ou memory
+	SQLitePath string `config:"storage.sqlite_path" default:"lib_api.db"`
+}
+
+type DatabaseConfig struct {
+	// URI tem precedência sobre Host/Port/User/Password/Name quando definida
+	URI      string `config:"database.uri"`
+	Host     string `config:"database.host"`
+	Port     int    `config:"database.port" default:"5432"`
+	User     string `config:"database.user"`
+	Password string `config:"database.password"`
+	Name     string `config:"database.name"`
+
+	MaxConns          int32         `config:"database.max_conns" default:"10"`
+	MinConns          int32         `config:"database.min_conns" default:"0"`
+	MaxConnIdleTime   time.Duration `config:"database.max_conn_idle_time" default:"5m"`
+	MaxConnLifetime   time.Duration `config:"database.max_conn_lifetime" default:"1h"`
+	HealthCheckPeriod time.Duration `config:"database.health_check_period" default:"1m"`
+	StatementTimeout  time.Duration `config:"database.statement_timeout" default:"30s"`
+	ConnectRetries    int           `config:"database.connect_retries" default:"5"`
+	ConnectBackoff    time.Duration `config:"database.connect_backoff" default:"1s"`
+	// Teto do intervalo entre tentativas, que dobra a cada falha
+	MaxConnectBackoff time.Duration `config:"database.max_connect_backoff" default:"30s"`
+}
+
+type AuthConfig struct {
+	SecretKey string        `config:"auth.secret_key"`
+	TokenTTL  time.Duration `config:"auth.token_ttl" default:"24h"`
+	// Emails, separados por vírgula, que entram como administradores
+	// qualquer que seja o papel gravado; é assim que se cria o primeiro
+	// administrador
+	AdminEmails string `config:"auth.admin_emails"`
+}
+
+// Admins lista os emails de auth.admin_emails
+func (a AuthConfig) Admins() []string {
+	var emails []string
+	for _, e := range strings.Split(a.AdminEmails, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			emails = append(emails, e)
+		}
+	}
+	return emails
+}
+
+// OAIConfig descreve o repositório no Identify do OAI-PMH. O endpoint /api/oai
+// só é publicado com admin_email definido, porque o protocolo o exige.
+type OAIConfig struct {
+	RepositoryName string `config:"oai.repository_name" default:"Library API"`
+	AdminEmail     string `config:"oai.admin_email"`
+	// BaseURL é a URL pública do endpoint; vazia, é deduzida de cada requisição
+	BaseURL string `config:"oai.base_url"`
+	// RepositoryIdentifier entra nos identificadores (oai:<id>:<uuid>) e,
+	// vazio, é o host de BaseURL ou da requisição. Trocá-lo muda o
+	// identificador de todos os registros para quem já colheu.
+	RepositoryIdentifier string `config:"oai.repository_identifier"`
+	PageSize             int    `config:"oai.page_size" default:"100"` // registros por resposta
+}
+
+// Enabled indica se o endpoint OAI-PMH deve ser publicado
+func (o OAIConfig) Enabled() bool {
+	return o.AdminEmail != ""
+}
+
+// CirculationConfig define o empréstimo e as reservas. As datas de
+// devolução e de retirada são dias no fuso da biblioteca, não instantes.
+// LoanDays, MaxRenewals, MaxItems e os campos Fine* formam a regra padrão,
+// usada quando nenhuma regra da tabela de políticas casa com o empréstimo.
+type CirculationConfig struct {
+	LoanDays    int    `config:"circulation.loan_days" default:"14"`
+	MaxRenewals int    `config:"circulation.max_renewals" default:"2"`
+	MaxItems    int    `config:"circulation.max_items" default:"10"` // empréstimos em aberto por leitor
+	TimeZone    string `config:"circulation.timezone" default:"UTC"` // nome IANA, ex.: America/Sao_Paulo
+	// Dias que um exemplar separado para uma reserva espera o leitor
+	HoldPickupDays int `config:"circulation.hold_pickup_days" default:"7"`
+	// Intervalo da rotina que expira reservas, separa exemplares livres e
+	// lança as multas por atraso
+	SweepInterval time.Duration `config:"circulation.sweep_interval" default:"1h"`
+	// Liga a rotina nesta instância. Com várias réplicas no mesmo banco,
+	// deixe ligada em uma só.
+	SweepEnabled bool `config:"circulation.sweep_enabled" default:"true"`
+	// Multa por atraso, em centavos: valor por dia, dias de carência e teto
+	// por empréstimo (0 é sem teto)
+	FineDailyCents int64 `config:"circulation.fine_daily_cents" default:"25"`
+	FineGraceDays  int   `config:"circulation.fine_grace_days" default:"0"`
+	FineCapCents   int64 `config:"circulation.fine_cap_cents" default:"1000"`
+	// Saldo devedor, em centavos, acima do qual o leitor não pode emprestar
+	MaxBalanceCents int64 `config:"circulation.max_balance_cents" default:"500"`
+	// Calendário da biblioteca, em listas separadas por vírgula: dias da
+	// semana em que não abre (ex.: "sunday,saturday") e feriados
+	// (AAAA-MM-DD). Um prazo de devolução que cai num dia fechado passa
+	// para o próximo dia aberto.
+	ClosedWeekdays string `config:"circulation.closed_weekdays"`
+	Holidays       string `config:"circulation.holidays"`
+}
+
+// Location devolve o fuso da biblioteca; Validate já garantiu que existe
+func (c CirculationConfig) Location() *time.Location {
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Calendar devolve o calendário da biblioteca; Validate já garantiu que as
+// listas são válidas
+func (c CirculationConfig) Calendar() domain.Calendar {
+	cal, err := domain.ParseCalendar(c.ClosedWeekdays, c.Holidays)
+	if err != nil {
+		return domain.Calendar{}
+	}
+	return cal
+}
+
+type LogConfig struct {
+	Level  string `config:"log.level" default:"info"`  // debug, info, warn ou error
+	Format string `config:"log.format" default:"json"` // json ou text (útil em desenvolvimento)
+}
+
+// repositoryIdentifier é a sintaxe do oai-identifier: um nome de domínio
+var repositoryIdentifier = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*(\.[a-zA-Z][a-zA-Z0-9-]*)+$`)
+
+// legacyEnv mapeia as variáveis antigas, sem prefixo, para as novas chaves.
+// USER e HOST não estão aqui porque colidem com as variáveis do shell; use
+// LIBAPI_DATABASE_USER e LIBAPI_DATABASE_HOST.
+var legacyEnv = map[string]string{
+	"LOCAL_PORT": "server.port",
+	"GIN_MODE":   "server.mode",
+	"DB_URI":     "database.uri",
+	"PASSWORD":   "database.password",
+	"DBNAME":     "database.name",
+	"SECRET_KEY": "auth.secret_key",
+}
+
+// Load monta a configuração a partir de todas as fontes e a valida
+func Load() (*Config, error) {
+	cfg := &Config{}
+	fields := fieldsOf(cfg)
+
+	for _, f := range fields {
+		if def, ok := f.field.Tag.Lookup("default"); ok {
+			if err := f.set(def); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if path := os.Getenv(EnvPrefix + "CONFIG_FILE"); path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for key := range values {
+			if _, ok := fields[key]; !ok {
+				return nil, fmt.Errorf("%s: unknown setting %q", path, key)
+			}
+		}
+		for _, f := range fields {
+			if v, ok := values[f.key]; ok {
+				if err := f.set(v); err != nil {
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+			}
+		}
+	}
+
+	applied := map[string]bool{}
+	for legacy, key := range legacyEnv {
+		v, ok := os.LookupEnv(legacy)
+		if !ok || v == "" {
+			continue
+		}
+		if _, ok := os.LookupEnv(envName(key)); ok {
+			continue
+		}
+		slog.Warn("deprecated environment variable", "variable", legacy, "replacement", envName(key))
+		if err := fields[key].set(v); err != nil {
+			return nil, err
+		}
+		applied[legacy] = true
+	}
+	// DB_URI tem precedência sobre as partes da conexão, como database.uri
+	if applied["DB_URI"] {
+		var ignored []string
+		for _, legacy := range []string{"PASSWORD", "DBNAME"} {
+			if applied[legacy] {
+				ignored = append(ignored, legacy)
+			}
+		}
+		if len(ignored) > 0 {
+			slog.Warn("conflicting deprecated database variables", "used", "DB_URI", "ignored", strings.Join(ignored, ","))
+		}
+	}
+
+	for _, f := range fields {
+		name := envName(f.key)
+		if v, ok := os.LookupEnv(name); ok {
+			if err := f.set(v); err != nil {
+				return nil, err
+			}
+		}
+		if path, ok := os.LookupEnv(name + "_FILE"); ok {
+			secret, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s_FILE: %w", name, err)
+			}
+			if err := f.set(strings.TrimRight(string(secret), "\r\n")); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate confere a configuração, reportando todos os problemas de uma vez
+func (c *Config) Validate() error {
+	var errs []error
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port must be a TCP port, got %q", c.Server.Port))
+	}
+	for key, d := range map[string]time.Duration{
+		"server.read_timeout":        c.Server.ReadTimeout,
+		"server.read_header_timeout": c.Server.ReadHeaderTimeout,
+		"server.write_timeout":       c.Server.WriteTimeout,
+		"server.idle_timeout":        c.Server.IdleTimeout,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", key))
+		}
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	if c.Server.MaxHeaderBytes < 1024 {
+		errs = append(errs, errors.New("server.max_header_bytes must be at least 1024"))
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		errs = append(errs, errors.New("server.tls_cert_file and server.tls_key_file must be set together"))
+	}
+	if c.Server.TLSEnabled() && c.Server.H2C {
+		errs = append(errs, errors.New("server.h2c cannot be combined with TLS (HTTP/2 is already negotiated over TLS)"))
+	}
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		errs = append(errs, fmt.Errorf("server.mode must be debug, release or test, got %q", c.Server.Mode))
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level must be debug, info, warn or error, got %q", c.Log.Level))
+	}
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		errs = append(errs, fmt.Errorf("log.format must be json or text, got %q", c.Log.Format))
+	}
+
+	switch c.Storage.Backend {
+	case "postgres":
+		if c.Database.URI == "" && c.Database.Host == "" {
+			errs = append(errs, errors.New("database.uri or database.host is required for the postgres backend"))
+		}
+		if c.Database.URI != "" {
+			if _, err := url.Parse(c.Database.URI); err != nil {
+				errs = append(errs, errors.New("database.uri is not a valid URL"))
+			}
+		}
+		if c.Database.MaxConns < 1 {
+			errs = append(errs, errors.New("database.max_conns must be at least 1"))
+		}
+		if c.Database.MinConns < 0 || c.Database.MinConns > c.Database.MaxConns {
+			errs = append(errs, errors.New("database.min_conns must be between 0 and database.max_conns"))
+		}
+		if c.Database.ConnectRetries < 0 {
+			errs = append(errs, errors.New("database.connect_retries must not be negative"))
+		}
+		if c.Database.ConnectBackoff <= 0 || c.Database.MaxConnectBackoff < c.Database.ConnectBackoff {
+			errs = append(errs, errors.New("database.connect_backoff must be positive and at most database.max_connect_backoff"))
+		}
+		// Sem usuário, o driver conectaria como o usuário do sistema. A
+		// variável antiga USER não é lida, porque colide com a do shell.
+		if c.Database.URI == "" && c.Database.Host != "" && c.Database.User == "" {
+			errs = append(errs, fmt.Errorf("database.user is required with database.host (set %s; the legacy USER variable is not read)", envName("database.user")))
+		}
+	case "sqlite":
+		if c.Storage.SQLitePath == "" {
+			errs = append(errs, errors.New("storage.sqlite_path is required for the sqlite backend"))
+		}
+	case "memory":
+	default:
+		errs = append(errs, fmt.Errorf("storage.backend must be postgres, sqlite or memory, got %q", c.Storage.Backend))
+	}
+
+	if c.Auth.SecretKey == "" {
+		errs = append(errs, fmt.Errorf("auth.secret_key is required (set %s or %s_FILE)", envName("auth.secret_key"), envName("auth.secret_key")))
+	}
+	if c.Auth.TokenTTL <= 0 {
+		errs = append(errs, errors.New("auth.token_ttl must be positive"))
+	}
+	for _, e := range c.Auth.Admins() {
+		if !strings.Contains(e, "@") {
+			errs = append(errs, fmt.Errorf("auth.admin_emails must list email addresses, got %q", e))
+		}
+	}
+
+	if c.OAI.AdminEmail != "" && !strings.Contains(c.OAI.AdminEmail, "@") {
+		errs = append(errs, fmt.Errorf("oai.admin_email must be an email address, got %q", c.OAI.AdminEmail))
+	}
+	if c.OAI.BaseURL != "" {
+		if u, err := url.Parse(c.OAI.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, errors.New("oai.base_url must be an absolute http or https URL"))
+		}
+	}
+	if c.OAI.RepositoryIdentifier != "" && !repositoryIdentifier.MatchString(c.OAI.RepositoryIdentifier) {
+		errs = append(errs, fmt.Errorf("oai.repository_identifier must be a domain name, e.g. library.example.org, got %q", c.OAI.RepositoryIdentifier))
+	}
+	if c.OAI.PageSize < 1 || c.OAI.PageSize > 1000 {
+		errs = append(errs, errors.New("oai.page_size must be between 1 and 1000"))
+	}
+
+	if c.Circulation.LoanDays < 1 || c.Circulation.LoanDays > 365 {
+		errs = append(errs, errors.New("circulation.loan_days must be between 1 and 365"))
+	}
+	if c.Circulation.MaxRenewals < 0 || c.Circulation.MaxRenewals > 99 {
+		errs = append(errs, errors.New("circulation.max_renewals must be between 0 and 99"))
+	}
+	if c.Circulation.MaxItems < 1 || c.Circulation.MaxItems > 1000 {
+		errs = append(errs, errors.New("circulation.max_items must be between 1 and 1000"))
+	}
+	if c.Circulation.FineDailyCents < 0 || c.Circulation.FineDailyCents > 100000 {
+		errs = append(errs, errors.New("circulation.fine_daily_cents must be between 0 and 100000"))
+	}
+	if c.Circulation.FineGraceDays < 0 || c.Circulation.FineGraceDays > 365 {
+		errs = append(errs, errors.New("circulation.fine_grace_days must be between 0 and 365"))
+	}
+	if c.Circulation.FineCapCents < 0 {
+		errs = append(errs, errors.New("circulation.fine_cap_cents must not be negative"))
+	}
+	if c.Circulation.MaxBalanceCents < 0 {
+		errs = append(errs, errors.New("circulation.max_balance_cents must not be negative"))
+	}
+	if _, err := time.LoadLocation(c.Circulation.TimeZone); err != nil {
+		errs = append(errs, fmt.Errorf("circulation.timezone must be an IANA time zone, got %q", c.Circulation.TimeZone))
+	}
+	if _, err := domain.ParseCalendar(c.Circulation.ClosedWeekdays, ""); err != nil {
+		errs = append(errs, fmt.Errorf("circulation.closed_weekdays: %w", err))
+	}
+	if _, err := domain.ParseCalendar("", c.Circulation.Holidays); err != nil {
+		errs = append(errs, fmt.Errorf("circulation.holidays: %w", err))
+	}
+	if c.Circulation.HoldPickupDays < 1 || c.Circulation.HoldPickupDays > 90 {
+		errs = append(errs, errors.New("circulation.hold_pickup_days must be between 1 and 90"))
+	}
+	if c.Circulation.SweepInterval < time.Minute {
+		errs = append(errs, errors.New("circulation.sweep_interval must be at least 1m"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// RedactedDatabaseURI retorna a URI do banco sem a senha, para logs
+func (c *Config) RedactedDatabaseURI() string {
+	if c.Database.URI == "" {
+		return fmt.Sprintf("postgres://%s@%s:%d/%s", c.Database.User, c.Database.Host, c.Database.Port, c.Database.Name)
+	}
+	u, err := url.Parse(c.Database.URI)
+	if err != nil {
+		return "<invalid uri>"
+	}
+	return u.Redacted()
+}
+
+// envName converte uma chave (database.max_conns) no nome da variável
+// de ambiente (LIBAPI_DATABASE_MAX_CONNS)
+func envName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+type configField struct {
+	key   string
+	field reflect.StructField
+	value reflect.Value
+}
+
+// set converte o texto para o tipo do campo
+func (f configField) set(raw string) error {
+	switch f.value.Interface().(type) {
+	case string:
+		f.value.SetString(raw)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", f.key, err)
+		}
+		f.value.SetInt(int64(d))
+	case int, int32, int64:
+		n, err := strconv.ParseInt(raw, 10, f.field.Type.Bits())
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", f.key, err)
+		}
+		f.value.SetInt(n)
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", f.key, err)
+		}
+		f.value.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type for %s", f.key)
+	}
+	return nil
+}
+
+// fieldsOf lista, por chave, os campos com tag config das seções de cfg
+func fieldsOf(cfg *Config) map[string]configField {
+	fields := map[string]configField{}
+	root := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Field(i)
+		for j := 0; j < section.NumField(); j++ {
+			sf := section.Type().Field(j)
+			key, ok := sf.Tag.Lookup("config")
+			if !ok {
+				continue
+			}
+			fields[key] = configField{key: key, field: sf, value: section.Field(j)}
+		}
+	}
+	return fields
+}
+
+// readFile lê um arquivo YAML ou TOML e o achata em chaves "secao.chave"
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("config file %s not found", path)
+		}
+		return nil, err
+	}
+
+	raw := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten("", raw, values)
+	return values, nil
+}
+
+func flatten(prefix string, in map[string]any, out map[string]string) {
+	for k, v := range in {
+		key := strings.ToLower(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := v.(map[string]any); ok {
+			flatten(key, nested, out)
+			continue
+		}
+		out[key] = fmt.Sprint(v)
+	}
+}
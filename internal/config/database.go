@@ -2,25 +2,66 @@ package config
 
 import (
 	"context"
-	"os"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewSupabaseDB() (*pgx.Conn, error) {
-
-	config, _ := pgx.ParseConfig("")
-	config.Host = os.Getenv("HOST")
-	config.User = os.Getenv("USER")
-	config.Password = os.Getenv("PASSWORD")
-	config.Database = os.Getenv("DBNAME")
-	conn, err := pgx.ConnectConfig(context.Background(), config)
+// NewSupabaseDB abre um pool de conexões com o banco, tentando novamente
+// com backoff exponencial, limitado a cfg.MaxConnectBackoff, enquanto o
+// Postgres ainda não aceita conexões.
+func NewSupabaseDB(ctx context.Context, cfg DatabaseConfig) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(cfg.URI)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid database.uri: %w", err)
+	}
+	if cfg.URI == "" {
+		config.ConnConfig.Host = cfg.Host
+		config.ConnConfig.Port = uint16(cfg.Port)
+		config.ConnConfig.User = cfg.User
+		config.ConnConfig.Password = cfg.Password
+		config.ConnConfig.Database = cfg.Name
+	}
+	config.MaxConns = cfg.MaxConns
+	config.MinConns = cfg.MinConns
+	config.MaxConnIdleTime = cfg.MaxConnIdleTime
+	config.MaxConnLifetime = cfg.MaxConnLifetime
+	config.HealthCheckPeriod = cfg.HealthCheckPeriod
+	if cfg.StatementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
 	}
-	return conn, nil
+
+	return connectWithRetry(ctx, config, cfg.ConnectRetries, cfg.ConnectBackoff, cfg.MaxConnectBackoff)
 }
 
-func GetSecretKey() string {
-	return os.Getenv("SECRET_KEY")
+func connectWithRetry(ctx context.Context, config *pgxpool.Config, retries int, backoff, maxBackoff time.Duration) (*pgxpool.Pool, error) {
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			slog.WarnContext(ctx, "database not ready, retrying",
+				"attempt", attempt, "retries", retries, "error", lastErr, "backoff", backoff.String())
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, maxBackoff)
+		}
+
+		pool, err := pgxpool.NewWithConfig(ctx, config)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if err := pool.Ping(ctx); err != nil {
+			pool.Close()
+			lastErr = err
+			continue
+		}
+		return pool, nil
+	}
+	return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", retries+1, lastErr)
 }
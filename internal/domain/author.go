@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Author struct {
 	ID        int        `json:"-" swaggerignore:"true" db:"id"`
@@ -11,3 +14,22 @@ type Author struct {
 	CreatedAt time.Time  `json:"createdAt" swaggerignore:"true" db:"created_at"`
 	UpdatedAt *time.Time `json:"updatedAt,omitempty"  swaggerignore:"true" db:"updated_at"`
 } // @name Author
+
+// AuthorUpdateRequest substitui todos os campos editáveis de um autor (PUT)
+type AuthorUpdateRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=100" example:"George Orwell"`
+	Bio  string `json:"bio" binding:"max=2000" example:"Autor de 1984 e A Revolução dos Bichos"`
+} // @name AuthorUpdateRequest
+
+// AuthorPatchRequest altera apenas os campos informados (PATCH)
+type AuthorPatchRequest struct {
+	Name *string `json:"name,omitempty" binding:"omitempty,min=2,max=100" example:"George Orwell"`
+	Bio  *string `json:"bio,omitempty" binding:"omitempty,max=2000" example:"Autor de 1984 e A Revolução dos Bichos"`
+} // @name AuthorPatchRequest
+
+// AuthorNameKey é a forma normalizada do nome usada para reconhecer um
+// autor já cadastrado em importações: sem espaços extras e em minúsculas.
+// Acentos são mantidos ("Jose" e "José" podem ser pessoas diferentes).
+func AuthorNameKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
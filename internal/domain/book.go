@@ -1,23 +1,69 @@
 package domain
 
 import (
+	"strings"
 	"time"
+
+	"golang.org/x/text/language"
 )
 
 type Book struct {
-	ID          int        `json:"-"`                         //swagger:ignore
-	UUID        string     `json:"uuid" swaggerignore:"true"` // Ignora no input
-	Title       string     `json:"title" example:"1984"`      // @example 1984
-	Authors     []*Author  `json:"authors"`
-	Description string     `json:"description" example:"Livro conta a história...."` //@example Livro conta a história....
-	CreatedAt   *time.Time `json:"-,omitempty"`                                      //swagger:ignore
+	ID              int           `json:"-"`                         //swagger:ignore
+	UUID            string        `json:"uuid" swaggerignore:"true"` // Ignora no input
+	Title           string        `json:"title" example:"1984"`      // @example 1984
+	Authors         []*Author     `json:"authors"`
+	Description     string        `json:"description" example:"Livro conta a história...."` //@example Livro conta a história....
+	ISBN            string        `json:"isbn,omitempty" example:"9780451524935"`
+	Publisher       string        `json:"publisher,omitempty" example:"Secker & Warburg"`
+	PublicationYear *int          `json:"publicationYear,omitempty" example:"1949"`
+	Language        string        `json:"language,omitempty" example:"en"`
+	Pages           *int          `json:"pages,omitempty" example:"328"`
+	Edition         string        `json:"edition,omitempty" example:"1st ed."`
+	Format          string        `json:"format,omitempty" example:"paperback"`
+	Subjects        []string      `json:"subjects,omitempty" example:"Dystopia,Politics"`
+	Availability    *Availability `json:"availability,omitempty"` // exemplares por situação
+	CreatedAt       *time.Time    `json:"-,omitempty"`            //swagger:ignore
+	UpdatedAt       *time.Time    `json:"updatedAt,omitempty" swaggerignore:"true"`
 } //@name Book
 type BookCreateRequest struct {
 	Title       string `json:"title" binding:"required,min=2,max=100" example:"1984"`
 	Description string `json:"description,omitempty" example:"A dystopian novel" binding:"max=500"`
-	AuthorIDs   []int  `json:"authorIds" example:"1,2,3"`
+	AuthorIDs   []int  `json:"authorIds" example:"1,2,3"` // a ordem é a de autoria
+	BookMetadata
 } //@name AuthorRequest
 
+// Formatos físicos e digitais aceitos em Book.Format
+const (
+	FormatHardcover = "hardcover"
+	FormatPaperback = "paperback"
+	FormatEbook     = "ebook"
+	FormatAudiobook = "audiobook"
+)
+
+// BookFormats lista os formatos válidos
+var BookFormats = []string{FormatHardcover, FormatPaperback, FormatEbook, FormatAudiobook}
+
+// BookMetadata são os dados de catalogação opcionais de um livro. O ISBN
+// identifica a edição e é único; é gravado sempre como ISBN-13.
+type BookMetadata struct {
+	ISBN            string   `json:"isbn,omitempty" example:"0-451-52493-4"`
+	Publisher       string   `json:"publisher,omitempty" binding:"omitempty,max=200" example:"Secker & Warburg"`
+	PublicationYear *int     `json:"publicationYear,omitempty" binding:"omitempty,gte=0,lte=9999" example:"1949"`
+	Language        string   `json:"language,omitempty" binding:"omitempty,max=35" example:"en-GB"`
+	Pages           *int     `json:"pages,omitempty" binding:"omitempty,gte=1,lte=100000" example:"328"`
+	Edition         string   `json:"edition,omitempty" binding:"omitempty,max=100" example:"1st ed."`
+	Format          string   `json:"format,omitempty" binding:"omitempty,oneof=hardcover paperback ebook audiobook" example:"paperback" enums:"hardcover,paperback,ebook,audiobook"`
+	Subjects        []string `json:"subjects,omitempty" binding:"omitempty,max=20,dive,min=1,max=100" example:"Dystopia,Politics"`
+}
+
+// BookUpdateRequest substitui título, descrição e o conjunto de autores de um livro
+type BookUpdateRequest struct {
+	Title       string `json:"title" binding:"required,min=2,max=100" example:"1984"`
+	Description string `json:"description,omitempty" example:"A dystopian novel" binding:"max=500"`
+	AuthorIDs   []int  `json:"authorIds" binding:"required,min=1" example:"1,2,3"` // a ordem é a de autoria
+	BookMetadata
+} //@name BookUpdateRequest
+
 type BookCreateResponse struct {
 	UUID        string    `json:"uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
 	Title       string    `json:"title" example:"1984"`
@@ -27,21 +73,82 @@ type BookCreateResponse struct {
 } // @name BookCreateResponse
 type BookFilters struct {
 	Title         string
-	AuthorName    string // Only used in WithAuthors version
-	Sort          string // "title", "created_at"
-	SortDirection string // "ASC", "DESC"
-	Limit         int    // 10, 25, 50...
-	Offset        int    // (page-1)*limit
+	AuthorName    string  // Only used in WithAuthors version
+	Sort          string  // "title", "created_at"
+	SortDirection string  // "ASC", "DESC"
+	Limit         int     // 10, 25, 50...
+	Offset        int     // (page-1)*limit
+	Keyset        *Keyset // paginação por cursor; quando definido, Offset é ignorado
+
+	// Filtros de faceta: valores da mesma faceta se somam (OU) e facetas
+	// diferentes se restringem (E). Valem nas duas listagens.
+	AuthorUUIDs []string
+	Subjects    []string
+	Languages   []string
+	Decades     []int
+
+	// Filtros bibliográficos
+	ISBN      string   // exato, já normalizado para ISBN-13
+	Publisher string   // parcial, sem diferenciar maiúsculas
+	Formats   []string // OU entre os formatos
+	YearFrom  *int     // ano de publicação, inclusive
+	YearTo    *int
+
+	// Livros com algum exemplar nas situações informadas (OU)
+	Availability []string
 } //@nome BookFilters
 type BookListResponse struct {
-	Data  []Book `json:"data"`
-	Total int    `json:"total"`
-	Page  int    `json:"page"`
-	Limit int    `json:"limit"`
+	Data       []Book      `json:"data"`
+	Total      *int        `json:"total,omitempty" example:"42"` // só no modo offset
+	Page       *int        `json:"page,omitempty" example:"1"`   // só no modo offset
+	Limit      int         `json:"limit" example:"10"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+	Facets     *BookFacets `json:"facets,omitempty"` // só com facets=true
 } //@name BookListResponse
 
-// ErrorResponse represents an API error
-type ErrorResponse struct {
-	Error   string `json:"error"`             // Error message
-	Details string `json:"details,omitempty"` // Additional details (debug only)
-} // @name ErrorResponse
+// Normalize valida e normaliza os campos que as tags de binding não cobrem:
+// converte o ISBN para ISBN-13, canoniza o idioma como tag BCP 47 ("pt-br"
+// vira "pt-BR"), recusa anos no futuro, remove espaços das pontas e
+// descarta assuntos vazios ou repetidos (sem diferenciar maiúsculas).
+func (m *BookMetadata) Normalize() error {
+	var fields []FieldError
+
+	if m.ISBN = strings.TrimSpace(m.ISBN); m.ISBN != "" {
+		isbn, err := NormalizeISBN(m.ISBN)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "isbn", Message: err.Error()})
+		}
+		m.ISBN = isbn
+	}
+	if m.Language = strings.TrimSpace(m.Language); m.Language != "" {
+		tag, err := language.Parse(m.Language)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "language", Message: "must be a BCP 47 language tag, e.g. pt-BR"})
+		}
+		m.Language = tag.String()
+	}
+	if m.PublicationYear != nil && *m.PublicationYear > time.Now().Year()+1 {
+		fields = append(fields, FieldError{Field: "publicationYear", Message: "must not be in the future"})
+	}
+	m.Publisher = strings.TrimSpace(m.Publisher)
+	m.Edition = strings.TrimSpace(m.Edition)
+
+	seen := make(map[string]bool, len(m.Subjects))
+	subjects := m.Subjects[:0]
+	for _, s := range m.Subjects {
+		s = strings.TrimSpace(s)
+		key := strings.ToLower(s)
+		if s == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		subjects = append(subjects, s)
+	}
+	m.Subjects = subjects
+
+	if len(fields) > 0 {
+		return ValidationError("invalid book metadata", fields...)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// weekdays aceita os nomes dos dias em inglês, por extenso ou abreviados
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// Calendar é o calendário da biblioteca: os dias da semana em que ela não
+// abre e os feriados. Um prazo de devolução que cai num dia fechado passa
+// para o próximo dia aberto. O calendário vazio tem todos os dias abertos.
+type Calendar struct {
+	Closed   []time.Weekday
+	Holidays []string // AAAA-MM-DD
+}
+
+// ParseCalendar lê as listas separadas por vírgula da configuração, ex.:
+// "sunday,saturday" e "2024-12-25,2025-01-01"
+func ParseCalendar(closedWeekdays, holidays string) (Calendar, error) {
+	var c Calendar
+	for _, name := range splitList(closedWeekdays) {
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return Calendar{}, fmt.Errorf("unknown weekday %q", name)
+		}
+		if !slices.Contains(c.Closed, day) {
+			c.Closed = append(c.Closed, day)
+		}
+	}
+	if len(c.Closed) == 7 {
+		return Calendar{}, fmt.Errorf("the library must open on at least one weekday")
+	}
+	for _, date := range splitList(holidays) {
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return Calendar{}, fmt.Errorf("invalid holiday %q, expected YYYY-MM-DD", date)
+		}
+		c.Holidays = append(c.Holidays, date)
+	}
+	return c, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// IsOpen indica se a biblioteca abre no dia t
+func (c Calendar) IsOpen(t time.Time) bool {
+	return !slices.Contains(c.Closed, t.Weekday()) && !slices.Contains(c.Holidays, t.Format(time.DateOnly))
+}
+
+// DueDate soma days dias à data from (AAAA-MM-DD) e, se o prazo cair num
+// dia fechado, o adia para o próximo dia aberto
+func (c Calendar) DueDate(from string, days int) (string, error) {
+	t, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q: %w", from, err)
+	}
+	t = t.AddDate(0, 0, days)
+	// ParseCalendar garante um dia aberto por semana; os feriados são
+	// finitos, então o laço termina
+	for !c.IsOpen(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t.Format(time.DateOnly), nil
+}
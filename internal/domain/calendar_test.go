@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseCalendar(t *testing.T) {
+	tests := []struct {
+		name     string
+		closed   string
+		holidays string
+		want     []time.Weekday
+		err      bool
+	}{
+		{"empty", "", "", nil, false},
+		{"names and abbreviations", "Sunday, sat", "", []time.Weekday{time.Sunday, time.Saturday}, false},
+		{"repeated day", "sun,sunday,SUN", "", []time.Weekday{time.Sunday}, false},
+		{"unknown day", "funday", "", nil, true},
+		{"closed all week", "sun,mon,tue,wed,thu,fri,sat", "", nil, true},
+		{"holidays", "", "2024-12-25, 2025-01-01", nil, false},
+		{"invalid holiday", "", "2024-13-01", nil, true},
+		{"holiday not in ISO format", "", "25/12/2024", nil, true},
+	}
+	for _, tt := range tests {
+		c, err := ParseCalendar(tt.closed, tt.holidays)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: error %v, want error %v", tt.name, err, tt.err)
+			continue
+		}
+		if !slices.Equal(c.Closed, tt.want) {
+			t.Errorf("%s: closed %v, want %v", tt.name, c.Closed, tt.want)
+		}
+	}
+}
+
+func TestDueDate(t *testing.T) {
+	library, err := ParseCalendar("saturday,sunday", "2024-12-25,2024-12-26,2025-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		cal  Calendar
+		from string
+		days int
+		want string
+	}{
+		{"open day", library, "2024-12-10", 14, "2024-12-24"},
+		{"weekend moves to monday", library, "2024-12-07", 14, "2024-12-23"},
+		{"holidays in a row", library, "2024-12-11", 14, "2024-12-27"},
+		{"holiday across the year", library, "2024-12-18", 14, "2025-01-02"},
+		{"zero days on a closed day", library, "2024-12-21", 0, "2024-12-23"},
+		{"no calendar", Calendar{}, "2024-12-11", 14, "2024-12-25"},
+		{"leap year", Calendar{}, "2024-02-15", 14, "2024-02-29"},
+	}
+	for _, tt := range tests {
+		got, err := tt.cal.DueDate(tt.from, tt.days)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: DueDate(%s, %d) = %q, %v; want %q", tt.name, tt.from, tt.days, got, err, tt.want)
+		}
+	}
+
+	if _, err := library.DueDate("2024/12/10", 14); err == nil {
+		t.Error("invalid date: want an error")
+	}
+}
+
+func TestIsOpen(t *testing.T) {
+	c := Calendar{Closed: []time.Weekday{time.Sunday}, Holidays: []string{"2024-12-25"}}
+	tests := map[string]bool{
+		"2024-12-22": false, // domingo
+		"2024-12-23": true,
+		"2024-12-25": false, // feriado
+		"2024-12-28": true,  // sábado
+	}
+	for date, want := range tests {
+		day, _ := time.Parse(time.DateOnly, date)
+		if got := c.IsOpen(day); got != want {
+			t.Errorf("IsOpen(%s) = %v, want %v", date, got, want)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package domain
+
+// MaxCitationBooks limita os livros de uma citação em lote
+const MaxCitationBooks = 100
+
+// CitationRequest pede as citações de vários livros, na ordem dos UUIDs
+type CitationRequest struct {
+	Format string   `json:"format" binding:"required,oneof=bibtex ris csljson apa mla abnt" example:"abnt" enums:"bibtex,ris,csljson,apa,mla,abnt"`
+	UUIDs  []string `json:"uuids" binding:"required,min=1,max=100,dive,uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+} //@name CitationRequest
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Categorias de erro. Cada *Error pertence a uma delas e errors.Is(err,
+// ErrNotFound) funciona para qualquer erro de "não encontrado", inclusive
+// os específicos abaixo (ErrBookNotFound...).
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrTooLarge     = errors.New("too large")
+)
+
+// Erros específicos retornados pelos stores
+var (
+	ErrBookNotFound   = NotFoundError("book not found")
+	ErrISBNExists     = ConflictError("a book with this ISBN already exists")
+	ErrAuthorNotFound = NotFoundError("author not found")
+	ErrAuthorHasBooks = ConflictError("author is still linked to books")
+	ErrBookHasItems   = ConflictError("book still has items")
+	ErrUserNotFound   = NotFoundError("user not found")
+	ErrUserExists     = ConflictError("user with this email already exists")
+
+	ErrImportJobNotFound = NotFoundError("import job not found")
+
+	ErrItemNotFound  = NotFoundError("item not found")
+	ErrBarcodeExists = ConflictError("an item with this barcode already exists")
+	ErrItemOnLoan    = ConflictError("item is already on loan")
+	ErrItemHasLoans  = ConflictError("item has loan history; withdraw it instead")
+	ErrOnLoanStatus  = ConflictError("the on_loan status is set by checkout and return")
+	ErrItemReserved  = ConflictError("item is set aside for another patron's hold")
+
+	ErrLoanNotFound   = NotFoundError("loan not found")
+	ErrLoanReturned   = ConflictError("loan has already been returned")
+	ErrPatronNotFound = NotFoundError("patron not found")
+	ErrLoanHeld       = ConflictError("another patron is waiting for this book")
+	ErrNotOwnLoan     = ForbiddenError("loan belongs to another patron")
+
+	ErrHoldNotFound = NotFoundError("hold not found")
+	ErrHoldExists   = ConflictError("patron already has an open hold on this book")
+	ErrHoldOnLoan   = ConflictError("patron already has a copy of this book on loan")
+	ErrNoCopies     = ConflictError("book has no copies that can be lent")
+	ErrNotOwnHold   = ForbiddenError("hold belongs to another patron")
+	ErrHoldForOther = ForbiddenError("placing a hold for another patron requires the staff role")
+
+	ErrPolicyRuleNotFound = NotFoundError("policy rule not found")
+	ErrPolicyRuleExists   = ConflictError("a rule for this patron category and material type already exists")
+	ErrLoanOfOtherPatron  = ConflictError("loan belongs to a different patron than this account")
+)
+
+// FieldError descreve um campo inválido de uma requisição
+type FieldError struct {
+	Field   string `json:"field" example:"title"`
+	Message string `json:"message" example:"is required"`
+} // @name FieldError
+
+// Error é um erro de domínio com categoria, mensagem para o cliente e,
+// opcionalmente, a causa original (que nunca é exposta na resposta).
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap permite errors.Is/As tanto pela categoria quanto pela causa
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// Wrap devolve uma cópia do erro com a causa anexada. errors.Is continua
+// reconhecendo o erro original, útil para sentinelas como ErrBookNotFound.
+func (e *Error) Wrap(cause error) *Error {
+	return &Error{Kind: e, Message: e.Message, Fields: e.Fields, Err: cause}
+}
+
+func NotFoundError(msg string) *Error {
+	return &Error{Kind: ErrNotFound, Message: msg}
+}
+
+func ConflictError(msg string) *Error {
+	return &Error{Kind: ErrConflict, Message: msg}
+}
+
+func ValidationError(msg string, fields ...FieldError) *Error {
+	return &Error{Kind: ErrValidation, Message: msg, Fields: fields}
+}
+
+func UnauthorizedError(msg string) *Error {
+	return &Error{Kind: ErrUnauthorized, Message: msg}
+}
+
+func ForbiddenError(msg string) *Error {
+	return &Error{Kind: ErrForbidden, Message: msg}
+}
+
+func TooLargeError(msg string) *Error {
+	return &Error{Kind: ErrTooLarge, Message: msg}
+}
+
+// Problem é o corpo application/problem+json (RFC 7807) de toda resposta de erro
+type Problem struct {
+	Type      string       `json:"type" example:"urn:lib-api:problem:not-found"`
+	Title     string       `json:"title" example:"Not Found"`
+	Status    int          `json:"status" example:"404"`
+	Detail    string       `json:"detail,omitempty" example:"book not found"`
+	Instance  string       `json:"instance,omitempty" example:"/api/books/550e8400-e29b-41d4-a716-446655440000"`
+	RequestID string       `json:"requestId,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	Errors    []FieldError `json:"errors,omitempty"`
+} // @name Problem
+
+// ProblemType monta a URI estável de um tipo de problema, ex.: "not-found"
+func ProblemType(slug string) string {
+	return "urn:lib-api:problem:" + strings.ToLower(slug)
+}
+
+// ItemUnavailableError recusa o empréstimo de um exemplar que não está
+// disponível (em trânsito, extraviado ou baixado)
+func ItemUnavailableError(status string) *Error {
+	return ConflictError("item is not available for loan (status " + status + ")")
+}
+
+// UnknownAuthorsError é retornado quando authorIds referencia autores inexistentes
+func UnknownAuthorsError(ids ...int) *Error {
+	fields := make([]FieldError, 0, len(ids))
+	for _, id := range ids {
+		fields = append(fields, FieldError{Field: "authorIds", Message: "author " + strconv.Itoa(id) + " does not exist"})
+	}
+	return ValidationError("one or more authors do not exist", fields...)
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+)
+
+// Nomes das facetas da listagem de livros
+const (
+	FacetAuthor   = "author"
+	FacetSubject  = "subject"
+	FacetLanguage = "language"
+	FacetDecade   = "decade"
+
+	// FacetAvailability conta os livros com algum exemplar em cada situação
+	FacetAvailability = "availability"
+)
+
+// Limites dos filtros de faceta, para não gerar consultas enormes
+const (
+	MaxFacetFilterValues = 20
+	MaxSubjectsPerBook   = 20
+)
+
+// FacetValue é um valor de faceta com o número de livros que o têm. Value é
+// o que se passa de volta como filtro; Label, quando presente, é o texto
+// para exibição (o nome do autor, "1940s"...).
+type FacetValue struct {
+	Value string `json:"value" example:"1940"`
+	Label string `json:"label,omitempty" example:"1940s"`
+	Count int    `json:"count" example:"3"`
+} //@name FacetValue
+
+// BookFacets traz as contagens por faceta sobre todo o conjunto filtrado,
+// não apenas sobre a página
+type BookFacets struct {
+	Author   []FacetValue `json:"author"`
+	Subject  []FacetValue `json:"subject"`
+	Language []FacetValue `json:"language"`
+	Decade   []FacetValue `json:"decade"`
+
+	Availability []FacetValue `json:"availability"`
+} //@name BookFacets
+
+// NewBookFacets devolve facetas vazias (listas vazias, não null, no JSON)
+func NewBookFacets() *BookFacets {
+	return &BookFacets{
+		Author:   []FacetValue{},
+		Subject:  []FacetValue{},
+		Language: []FacetValue{},
+		Decade:   []FacetValue{},
+
+		Availability: []FacetValue{},
+	}
+}
+
+// Add acrescenta um valor à faceta indicada; facetas desconhecidas são
+// ignoradas. A década ganha o rótulo "1940s" e a situação dos exemplares,
+// o nome por extenso ("On loan").
+func (f *BookFacets) Add(facet string, v FacetValue) {
+	switch facet {
+	case FacetAuthor:
+		f.Author = append(f.Author, v)
+	case FacetSubject:
+		f.Subject = append(f.Subject, v)
+	case FacetLanguage:
+		f.Language = append(f.Language, v)
+	case FacetDecade:
+		if v.Label == "" {
+			v.Label = v.Value + "s"
+		}
+		f.Decade = append(f.Decade, v)
+	case FacetAvailability:
+		if v.Label == "" {
+			v.Label = itemStatusLabels[v.Value]
+		}
+		f.Availability = append(f.Availability, v)
+	}
+}
+
+// Truncate ordena cada faceta por contagem decrescente (desempate pelo
+// valor) e mantém os limit primeiros valores
+func (f *BookFacets) Truncate(limit int) {
+	for _, values := range []*[]FacetValue{&f.Author, &f.Subject, &f.Language, &f.Decade, &f.Availability} {
+		slices.SortFunc(*values, func(a, b FacetValue) int {
+			if c := cmp.Compare(b.Count, a.Count); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.Value, b.Value)
+		})
+		if len(*values) > limit {
+			*values = (*values)[:limit]
+		}
+	}
+}
+
+// Decade devolve a década de um ano (1949 → 1940)
+func Decade(year int) int {
+	return year / 10 * 10
+}
+
+// DecadeValue é o valor da faceta de década para um ano
+func DecadeValue(year int) string {
+	return strconv.Itoa(Decade(year))
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// HarvestQuery seleciona livros e exclusões para a colheita OAI-PMH. Os
+// registros vêm na ordem do datestamp (a última alteração: updated_at ou,
+// sem ele, created_at; numa exclusão, o momento da remoção), com desempate
+// pelo id do livro, que não é reaproveitado depois de uma exclusão.
+type HarvestQuery struct {
+	From      *time.Time // datestamp mínimo, inclusive
+	Until     *time.Time // datestamp máximo, exclusivo
+	Subjects  []string   // assuntos do set (OU); vazio não filtra
+	After     *Keyset    // continua depois deste registro (Value é o datestamp em RFC 3339)
+	Limit     int
+	WithBooks bool // carrega os livros com autores (ListRecords)
+}
+
+// HarvestRecord é um livro, ou a marca de um livro excluído, na colheita
+type HarvestRecord struct {
+	ID        int
+	UUID      string
+	Datestamp time.Time
+	Deleted   bool
+	Subjects  []string
+	Book      *Book // só com WithBooks e em livros não excluídos
+}
+
+// Keyset posiciona a próxima página da colheita depois deste registro
+func (r HarvestRecord) Keyset() Keyset {
+	return Keyset{Value: r.Datestamp.UTC().Format(time.RFC3339Nano), ID: r.ID}
+}
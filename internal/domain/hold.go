@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Situações de uma reserva. waiting e suspended estão na fila; ready tem um
+// exemplar separado à espera do leitor; as demais estão encerradas.
+const (
+	HoldWaiting   = "waiting"
+	HoldSuspended = "suspended" // mantém o lugar na fila, mas não recebe exemplar
+	HoldReady     = "ready"     // pronta para retirada
+	HoldFulfilled = "fulfilled" // o leitor levou o livro
+	HoldCancelled = "cancelled"
+	HoldExpired   = "expired"
+)
+
+// HoldStatuses lista as situações aceitas no filtro status
+var HoldStatuses = []string{HoldWaiting, HoldSuspended, HoldReady, HoldFulfilled, HoldCancelled, HoldExpired}
+
+// Hold é a reserva de um título (não de um exemplar) por um leitor. A fila
+// de cada livro é FIFO pela data da reserva.
+type Hold struct {
+	ID             int        `json:"-"`
+	UUID           string     `json:"uuid" example:"9b2f6c1e-3d4a-4c5b-8e7f-1a2b3c4d5e6f"`
+	BookID         int        `json:"-"`
+	BookUUID       string     `json:"bookUuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Title          string     `json:"title" example:"1984"`
+	PatronID       int        `json:"-"`
+	Patron         string     `json:"patron" example:"reader@example.com"`
+	PickupBranch   string     `json:"pickupBranch" example:"Central"`
+	Status         string     `json:"status" example:"waiting"`
+	Position       *int       `json:"position,omitempty" example:"2"`           // lugar na fila; só em waiting e suspended
+	EstimatedWait  *int       `json:"estimatedWaitDays,omitempty" example:"14"` // ver EstimateWait
+	ExpiresOn      string     `json:"expiresOn,omitempty" example:"2024-12-31"` // o leitor não quer o livro depois desta data
+	SuspendedUntil string     `json:"suspendedUntil,omitempty" example:"2024-07-01"`
+	ItemID         int        `json:"-"`
+	ItemUUID       string     `json:"itemUuid,omitempty" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"` // exemplar separado
+	Barcode        string     `json:"barcode,omitempty" example:"31234000012345"`
+	ReadyAt        *time.Time `json:"readyAt,omitempty"`
+	PickupBy       string     `json:"pickupBy,omitempty" example:"2024-06-08"` // último dia para retirar
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+	ClosedAt       *time.Time `json:"closedAt,omitempty"`
+} //@name Hold
+
+// Open indica se a reserva ainda não foi encerrada
+func (h *Hold) Open() bool {
+	return h.Status == HoldWaiting || h.Status == HoldSuspended || h.Status == HoldReady
+}
+
+// HoldRequest coloca um leitor na fila de um livro. Sem patron, a reserva é
+// do usuário autenticado.
+type HoldRequest struct {
+	BookUUID     string `json:"bookUuid" binding:"required,uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	PickupBranch string `json:"pickupBranch" binding:"required,max=100" example:"Central"`
+	ExpiresOn    string `json:"expiresOn" binding:"omitempty,datetime=2006-01-02" example:"2024-12-31"`
+	Patron       string `json:"patron" binding:"omitempty,email" example:"reader@example.com"`
+} //@name HoldRequest
+
+// Normalize apara os campos e confere as regras que o binding não cobre
+func (r *HoldRequest) Normalize(today string) error {
+	r.BookUUID = strings.ToLower(strings.TrimSpace(r.BookUUID))
+	r.PickupBranch = strings.TrimSpace(r.PickupBranch)
+	r.Patron = strings.TrimSpace(r.Patron)
+
+	var fields []FieldError
+	if r.PickupBranch == "" {
+		fields = append(fields, FieldError{Field: "pickupBranch", Message: "is required"})
+	}
+	if r.ExpiresOn != "" && r.ExpiresOn < today {
+		fields = append(fields, FieldError{Field: "expiresOn", Message: "must not be in the past"})
+	}
+	if len(fields) > 0 {
+		return ValidationError("invalid hold", fields...)
+	}
+	return nil
+}
+
+// SuspendRequest congela a reserva; com until, ela volta à fila nesse dia
+type SuspendRequest struct {
+	Until string `json:"until" binding:"omitempty,datetime=2006-01-02" example:"2024-07-01"`
+} //@name SuspendRequest
+
+// HoldDates são as datas do dia usadas pelos stores quando separam um
+// exemplar para uma reserva, no fuso da biblioteca
+type HoldDates struct {
+	Today    string // AAAA-MM-DD
+	PickupBy string // último dia para retirar um exemplar separado hoje
+}
+
+// NewHoldDates calcula as datas de at no fuso loc
+func NewHoldDates(at time.Time, pickupDays int, loc *time.Location) HoldDates {
+	local := at.In(loc)
+	return HoldDates{
+		Today:    local.Format(time.DateOnly),
+		PickupBy: local.AddDate(0, 0, pickupDays).Format(time.DateOnly),
+	}
+}
+
+// HoldPlacement é a reserva a gravar: o pedido validado e o leitor (pelo
+// email, ou pelo id do usuário autenticado quando o email está vazio)
+type HoldPlacement struct {
+	BookUUID     string
+	PatronEmail  string
+	PatronID     int
+	PickupBranch string
+	ExpiresOn    string
+	Dates        HoldDates
+}
+
+// HoldSweep resume uma passada da rotina de reservas
+type HoldSweep struct {
+	Expired   int // reservas vencidas, na fila ou sem retirada
+	Resumed   int // suspensões que chegaram ao fim
+	Allocated int // exemplares livres separados para a fila
+}
+
+// HoldFilters filtra a listagem de reservas, na ordem da fila
+// (created_at, id)
+type HoldFilters struct {
+	BookUUID    string
+	PatronID    int
+	PatronEmail string
+	Statuses    []string
+	Limit       int
+	Offset      int
+	Keyset      *Keyset // Value é created_at em RFC 3339; quando definido, Offset é ignorado
+}
+
+type HoldListResponse struct {
+	Data       []Hold `json:"data"`
+	Total      *int   `json:"total,omitempty" example:"42"` // só no modo offset
+	Page       *int   `json:"page,omitempty" example:"1"`   // só no modo offset
+	Limit      int    `json:"limit" example:"10"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+} //@name HoldListResponse
+
+// EstimateWait estima, em dias, a espera de quem está na posição position
+// de uma fila atendida por copies exemplares, supondo que cada exemplar
+// volta ao fim de um empréstimo de loanDays dias. Sem exemplares não há
+// estimativa.
+func EstimateWait(position, copies, loanDays int) *int {
+	if copies <= 0 || position <= 0 {
+		return nil
+	}
+	rounds := (position + copies - 1) / copies
+	days := rounds * loanDays
+	return &days
+}
+
+// HoldStatusError recusa uma ação que a situação da reserva não permite
+func HoldStatusError(action, status string) *Error {
+	return ConflictError("cannot " + action + " a hold that is " + status)
+}
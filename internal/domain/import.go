@@ -0,0 +1,113 @@
+package domain
+
+import "time"
+
+// Formatos de arquivo aceitos na importação de catálogo. "marc" é o MARC 21
+// binário (ISO 2709).
+const (
+	ImportFormatCSV     = "csv"
+	ImportFormatJSONL   = "jsonl"
+	ImportFormatMARC    = "marc"
+	ImportFormatMARCXML = "marcxml"
+)
+
+// ImportFormats lista os formatos aceitos
+var ImportFormats = []string{ImportFormatCSV, ImportFormatJSONL, ImportFormatMARC, ImportFormatMARCXML}
+
+// Situações de um job de importação
+const (
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// MaxImportErrors limita os erros (e, à parte, os avisos) guardados no
+// relatório de um job; os demais só entram nas contagens
+const MaxImportErrors = 1000
+
+// ImportRow é um livro lido do arquivo, já validado. Line é a linha do
+// arquivo onde o registro começa; Authors são nomes, resolvidos (ou
+// criados) pelo store via AuthorNameKey.
+type ImportRow struct {
+	Line        int
+	Title       string
+	Description string
+	Authors     []string
+	BookMetadata
+}
+
+// ImportBatchResult é o resultado de gravar um lote. AuthorsCreated traz as
+// chaves (AuthorNameKey) dos autores criados.
+type ImportBatchResult struct {
+	Created        int
+	Updated        int
+	AuthorsCreated []string
+}
+
+// ImportRowError descreve uma linha recusada ou, em Warnings, aproveitada
+// em parte. Em arquivos MARC, Line é a posição do registro no arquivo (1 é
+// o primeiro), Record o número de controle (001) e Field a etiqueta do
+// campo ("020").
+type ImportRowError struct {
+	Line    int    `json:"line" example:"42"`
+	Record  string `json:"record,omitempty" example:"ocm12345678"`
+	Field   string `json:"field,omitempty" example:"isbn"`
+	Message string `json:"message" example:"has an invalid check digit"`
+} //@name ImportRowError
+
+// ImportJob é o relatório de uma importação. Em dry-run as linhas são
+// validadas e gravadas dentro de transações desfeitas no fim, então as
+// contagens mostram o que aconteceria sem alterar o catálogo.
+type ImportJob struct {
+	ID              int              `json:"-"`
+	UUID            string           `json:"uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Status          string           `json:"status" example:"completed" enums:"running,completed,failed"`
+	Format          string           `json:"format" example:"csv" enums:"csv,jsonl"`
+	Source          string           `json:"source,omitempty" example:"catalog.csv"`
+	DryRun          bool             `json:"dryRun"`
+	Rows            int              `json:"rows" example:"1200"`
+	Created         int              `json:"created" example:"1150"`
+	Updated         int              `json:"updated" example:"40"`
+	Failed          int              `json:"failed" example:"10"`
+	Partial         int              `json:"partial" example:"5"`
+	AuthorsCreated  int              `json:"authorsCreated" example:"300"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errorsTruncated,omitempty"`
+	// Warnings descreve as linhas gravadas só em parte (Partial), como
+	// registros MARC com ISBN inválido ou texto cortado
+	Warnings          []ImportRowError `json:"warnings,omitempty"`
+	WarningsTruncated bool             `json:"warningsTruncated,omitempty"`
+	Message           string           `json:"message,omitempty" example:"import aborted: connection reset"`
+	StartedAt         time.Time        `json:"startedAt"`
+	FinishedAt        *time.Time       `json:"finishedAt,omitempty"`
+} //@name ImportJob
+
+// AddError registra uma linha recusada, respeitando MaxImportErrors
+func (j *ImportJob) AddError(e ImportRowError) {
+	j.Failed++
+	if len(j.Errors) >= MaxImportErrors {
+		j.ErrorsTruncated = true
+		return
+	}
+	j.Errors = append(j.Errors, e)
+}
+
+// AddWarnings registra os avisos de uma linha gravada em parte
+func (j *ImportJob) AddWarnings(ws []ImportRowError) {
+	if len(ws) == 0 {
+		return
+	}
+	j.Partial++
+	for _, w := range ws {
+		if len(j.Warnings) >= MaxImportErrors {
+			j.WarningsTruncated = true
+			return
+		}
+		j.Warnings = append(j.Warnings, w)
+	}
+}
+
+// ImportJobListResponse lista os jobs mais recentes
+type ImportJobListResponse struct {
+	Data []ImportJob `json:"data"`
+} //@name ImportJobListResponse
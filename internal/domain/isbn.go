@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errISBNLength   = errors.New("must have 10 or 13 digits")
+	errISBNChars    = errors.New("must contain only digits, hyphens and spaces (and a final X in ISBN-10)")
+	errISBNChecksum = errors.New("has an invalid check digit")
+	errISBNPrefix   = errors.New("ISBN-13 must start with 978 or 979")
+)
+
+// NormalizeISBN valida um ISBN-10 ou ISBN-13 (hífens e espaços são
+// ignorados) e o devolve como ISBN-13 só com dígitos. ISBN-10 ganha o
+// prefixo 978 e um novo dígito verificador.
+func NormalizeISBN(raw string) (string, error) {
+	digits := make([]byte, 0, 13)
+	for i := 0; i < len(raw); i++ {
+		switch ch := raw[i]; {
+		case ch >= '0' && ch <= '9':
+			digits = append(digits, ch)
+		case ch == 'X' || ch == 'x':
+			digits = append(digits, 'X')
+		case ch == '-' || ch == ' ':
+		default:
+			return "", errISBNChars
+		}
+	}
+
+	switch len(digits) {
+	case 10:
+		if strings.IndexByte(string(digits[:9]), 'X') >= 0 {
+			return "", errISBNChars
+		}
+		if isbn10Check(digits[:9]) != digits[9] {
+			return "", errISBNChecksum
+		}
+		isbn := append([]byte("978"), digits[:9]...)
+		return string(append(isbn, isbn13Check(isbn))), nil
+	case 13:
+		if strings.IndexByte(string(digits), 'X') >= 0 {
+			return "", errISBNChars
+		}
+		if p := string(digits[:3]); p != "978" && p != "979" {
+			return "", errISBNPrefix
+		}
+		if isbn13Check(digits[:12]) != digits[12] {
+			return "", errISBNChecksum
+		}
+		return string(digits), nil
+	default:
+		return "", errISBNLength
+	}
+}
+
+// isbn10Check calcula o dígito verificador (módulo 11) dos 9 primeiros dígitos
+func isbn10Check(d []byte) byte {
+	sum := 0
+	for i, c := range d {
+		sum += (10 - i) * int(c-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+// isbn13Check calcula o dígito verificador (pesos 1 e 3) dos 12 primeiros dígitos
+func isbn13Check(d []byte) byte {
+	sum := 0
+	for i, c := range d {
+		w := 1
+		if i%2 == 1 {
+			w = 3
+		}
+		sum += w * int(c-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "testing"
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		err  error
+	}{
+		{"978-0-06-085052-4", "9780060850524", nil},
+		{"9780060850524", "9780060850524", nil},
+		{"979 10 90636 07 1", "9791090636071", nil},
+		{"0-451-52493-4", "9780451524935", nil},
+		{"0804429 57X", "9780804429573", nil},
+		{"080442957x", "9780804429573", nil},
+		{"", "", errISBNLength},
+		{"978006085052", "", errISBNLength},
+		{"97800608505241", "", errISBNLength},
+		{"978-0-06-085052-5", "", errISBNChecksum},
+		{"0-451-52493-5", "", errISBNChecksum},
+		{"9770000000003", "", errISBNPrefix},
+		{"978.0.06.085052.4", "", errISBNChars},
+		{"ISBN 9780060850524", "", errISBNChars},
+		{"08044X9573", "", errISBNChars},
+		{"978006085052X", "", errISBNChars},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeISBN(tt.raw)
+		if got != tt.want || err != tt.err {
+			t.Errorf("NormalizeISBN(%q) = %q, %v; want %q, %v", tt.raw, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestISBNCheckDigits(t *testing.T) {
+	tests := []struct {
+		digits string
+		check  byte
+		fn     func([]byte) byte
+	}{
+		{"045152493", '4', isbn10Check},
+		{"080442957", 'X', isbn10Check},
+		{"000000000", '0', isbn10Check},
+		{"978006085052", '4', isbn13Check},
+		{"978045152493", '5', isbn13Check},
+		{"979109063607", '1', isbn13Check},
+	}
+	for _, tt := range tests {
+		if got := tt.fn([]byte(tt.digits)); got != tt.check {
+			t.Errorf("check digit of %s = %c, want %c", tt.digits, got, tt.check)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Situações de um exemplar
+const (
+	ItemAvailable = "available"
+	ItemOnLoan    = "on_loan"
+	ItemInTransit = "in_transit"
+	ItemLost      = "lost"
+	ItemWithdrawn = "withdrawn"
+)
+
+// ItemOnHold não é gravado no exemplar: é como a disponibilidade conta um
+// exemplar available já separado para uma reserva pronta
+const ItemOnHold = "on_hold"
+
+// ItemStatuses lista as situações válidas
+var ItemStatuses = []string{ItemAvailable, ItemOnLoan, ItemInTransit, ItemLost, ItemWithdrawn}
+
+// itemStatusLabels são os rótulos da faceta de disponibilidade
+var itemStatusLabels = map[string]string{
+	ItemAvailable: "Available",
+	ItemOnLoan:    "On loan",
+	ItemInTransit: "In transit",
+	ItemLost:      "Lost",
+	ItemWithdrawn: "Withdrawn",
+}
+
+// Item é um exemplar físico de um livro. O código de barras é único em todo
+// o acervo.
+type Item struct {
+	ID         int        `json:"-"`
+	UUID       string     `json:"uuid" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	BookID     int        `json:"-"`
+	BookUUID   string     `json:"bookUuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Barcode    string     `json:"barcode" example:"31234000012345"`
+	CallNumber string     `json:"callNumber,omitempty" example:"823.912 ORW"`
+	Branch     string     `json:"branch" example:"Central"`
+	Location   string     `json:"location,omitempty" example:"Stacks, 2nd floor"`
+	Status     string     `json:"status" example:"available" enums:"available,on_loan,in_transit,lost,withdrawn"`
+	Material   string     `json:"materialType" example:"book" enums:"book,reference,dvd,ebook"`
+	AcquiredOn string     `json:"acquiredOn,omitempty" example:"2024-03-15"` // AAAA-MM-DD
+	PriceCents *int64     `json:"priceCents,omitempty" example:"4990"`       // preço de aquisição, em centavos
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+} //@name Item
+
+// ItemRequest cria um exemplar ou substitui todos os seus campos (PUT). Sem
+// status, o exemplar fica disponível; sem materialType, é um livro.
+type ItemRequest struct {
+	Barcode    string `json:"barcode" binding:"required,max=50" example:"31234000012345"`
+	CallNumber string `json:"callNumber" binding:"max=100" example:"823.912 ORW"`
+	Branch     string `json:"branch" binding:"required,max=100" example:"Central"`
+	Location   string `json:"location" binding:"max=100" example:"Stacks, 2nd floor"`
+	Status     string `json:"status" binding:"omitempty,oneof=available on_loan in_transit lost withdrawn" example:"available" enums:"available,on_loan,in_transit,lost,withdrawn"`
+	Material   string `json:"materialType" binding:"omitempty,oneof=book reference dvd ebook" example:"book" enums:"book,reference,dvd,ebook"`
+	AcquiredOn string `json:"acquiredOn" binding:"omitempty,datetime=2006-01-02" example:"2024-03-15"`
+	PriceCents *int64 `json:"priceCents" binding:"omitempty,gte=0" example:"4990"`
+} //@name ItemRequest
+
+// Normalize remove espaços das pontas, aplica o status e o material
+// padrão e recusa datas de aquisição no futuro
+func (r *ItemRequest) Normalize() error {
+	r.Barcode = strings.TrimSpace(r.Barcode)
+	r.CallNumber = strings.TrimSpace(r.CallNumber)
+	r.Branch = strings.TrimSpace(r.Branch)
+	r.Location = strings.TrimSpace(r.Location)
+	if r.Status == "" {
+		r.Status = ItemAvailable
+	}
+	if r.Material == "" {
+		r.Material = MaterialBook
+	}
+
+	var fields []FieldError
+	if r.Barcode == "" || strings.ContainsFunc(r.Barcode, func(c rune) bool { return c == ' ' || c == '\t' }) {
+		fields = append(fields, FieldError{Field: "barcode", Message: "must not be blank or contain spaces"})
+	}
+	if r.Branch == "" {
+		fields = append(fields, FieldError{Field: "branch", Message: "must not be blank"})
+	}
+	if r.AcquiredOn != "" && r.AcquiredOn > time.Now().Format(time.DateOnly) {
+		fields = append(fields, FieldError{Field: "acquiredOn", Message: "must not be in the future"})
+	}
+	if len(fields) > 0 {
+		return ValidationError("invalid item", fields...)
+	}
+	return nil
+}
+
+// ItemFilters filtra a listagem de exemplares, ordenada por código de barras
+type ItemFilters struct {
+	BookUUID string   // exemplares de um livro; ErrBookNotFound se ele não existir
+	Barcode  string   // exato
+	Branch   string   // exato
+	Statuses []string // OU entre as situações
+	Limit    int
+	Offset   int
+	Keyset   *Keyset // quando definido, Offset é ignorado
+}
+
+type ItemListResponse struct {
+	Data       []Item `json:"data"`
+	Total      *int   `json:"total,omitempty" example:"42"` // só no modo offset
+	Page       *int   `json:"page,omitempty" example:"1"`   // só no modo offset
+	Limit      int    `json:"limit" example:"10"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+} //@name ItemListResponse
+
+// Availability resume os exemplares de um livro por situação. Total conta
+// os exemplares do acervo, sem os baixados (withdrawn); Available não conta
+// os separados para reservas prontas, que ficam em OnHold.
+type Availability struct {
+	Total     int `json:"total" example:"3"`
+	Available int `json:"available" example:"1"`
+	OnHold    int `json:"onHold" example:"0"`
+	OnLoan    int `json:"onLoan" example:"1"`
+	InTransit int `json:"inTransit" example:"0"`
+	Lost      int `json:"lost" example:"1"`
+} //@name Availability
+
+// Count soma n exemplares na situação informada
+func (a *Availability) Count(status string, n int) {
+	switch status {
+	case ItemAvailable:
+		a.Available += n
+	case ItemOnHold:
+		a.OnHold += n
+	case ItemOnLoan:
+		a.OnLoan += n
+	case ItemInTransit:
+		a.InTransit += n
+	case ItemLost:
+		a.Lost += n
+	}
+	if status != ItemWithdrawn {
+		a.Total += n
+	}
+}
@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tipos de lançamento na conta do leitor. Cobranças somam ao saldo;
+// pagamentos e abonos subtraem.
+const (
+	EntryOverdue = "overdue" // multa por atraso, lançada pela rotina diária
+	EntryLost    = "lost"    // exemplar extraviado
+	EntryDamage  = "damage"  // exemplar danificado
+	EntryPayment = "payment"
+	EntryWaiver  = "waiver" // abono concedido por um funcionário
+)
+
+// LedgerEntryTypes lista os tipos válidos
+var LedgerEntryTypes = []string{EntryOverdue, EntryLost, EntryDamage, EntryPayment, EntryWaiver}
+
+// IsCharge indica se o tipo de lançamento é uma cobrança
+func IsCharge(entryType string) bool {
+	return entryType == EntryOverdue || entryType == EntryLost || entryType == EntryDamage
+}
+
+// LedgerEntry é um lançamento na conta do leitor. A conta só recebe
+// lançamentos novos: uma cobrança indevida é anulada por um abono.
+type LedgerEntry struct {
+	ID           int       `json:"-"`
+	UUID         string    `json:"uuid" example:"9b2e4f61-3c8a-4d7e-b5f0-1a2c3d4e5f60"`
+	PatronID     int       `json:"-"`
+	Patron       string    `json:"patron" example:"reader@example.com"`
+	Type         string    `json:"type" example:"overdue" enums:"overdue,lost,damage,payment,waiver"`
+	AmountCents  int64     `json:"amountCents" example:"150"`  // sempre positivo; o tipo diz o sentido
+	BalanceCents int64     `json:"balanceCents" example:"450"` // saldo devedor depois do lançamento
+	LoanUUID     string    `json:"loanUuid,omitempty" example:"0f8fad5b-d9cb-469f-a165-70867728950e"`
+	Note         string    `json:"note,omitempty" example:"Cover torn"`
+	CreatedBy    string    `json:"createdBy,omitempty" example:"staff@example.com"` // vazio nas multas automáticas
+	CreatedAt    time.Time `json:"createdAt"`
+} //@name LedgerEntry
+
+// LedgerEntryRequest lança uma cobrança manual, um pagamento ou um abono.
+// As multas por atraso são lançadas pela rotina diária.
+type LedgerEntryRequest struct {
+	Type        string `json:"type" binding:"required,oneof=lost damage payment waiver" example:"payment" enums:"lost,damage,payment,waiver"`
+	AmountCents int64  `json:"amountCents" binding:"required,min=1,max=100000000" example:"450"`
+	LoanUUID    string `json:"loanUuid" binding:"omitempty,uuid" example:"0f8fad5b-d9cb-469f-a165-70867728950e"` // empréstimo do exemplar cobrado
+	Note        string `json:"note" binding:"max=500" example:"Paid at the front desk"`
+} //@name LedgerEntryRequest
+
+// Validate exige o empréstimo nas cobranças por exemplar, que não cabe nos
+// pagamentos e abonos, e o motivo dos abonos
+func (r *LedgerEntryRequest) Validate() error {
+	var fields []FieldError
+	if IsCharge(r.Type) && r.LoanUUID == "" {
+		fields = append(fields, FieldError{Field: "loanUuid", Message: "is required for " + r.Type + " charges"})
+	}
+	if !IsCharge(r.Type) && r.LoanUUID != "" {
+		fields = append(fields, FieldError{Field: "loanUuid", Message: "is only allowed on charges"})
+	}
+	if r.Type == EntryWaiver && r.Note == "" {
+		fields = append(fields, FieldError{Field: "note", Message: "must give the reason for the waiver"})
+	}
+	if len(fields) > 0 {
+		return ValidationError("invalid ledger entry", fields...)
+	}
+	return nil
+}
+
+// LedgerPosting é o lançamento a gravar: o pedido já validado, o leitor
+// (pelo email) e o funcionário que o registra
+type LedgerPosting struct {
+	PatronEmail string
+	Type        string
+	AmountCents int64
+	LoanUUID    string
+	Note        string
+	StaffID     int
+}
+
+// LedgerFilters filtra a listagem da conta, do lançamento mais recente para
+// o mais antigo (created_at, id)
+type LedgerFilters struct {
+	PatronID    int    // pelo id do usuário autenticado
+	PatronEmail string // exato; ErrPatronNotFound se não existir
+	Type        string // vazio lista todos
+	Limit       int
+	Offset      int
+	Keyset      *Keyset // Value é created_at em RFC 3339; quando definido, Offset é ignorado
+}
+
+type LedgerResponse struct {
+	Data         []LedgerEntry `json:"data"`
+	BalanceCents int64         `json:"balanceCents" example:"450"`   // saldo devedor atual
+	Total        *int          `json:"total,omitempty" example:"42"` // só no modo offset
+	Page         *int          `json:"page,omitempty" example:"1"`   // só no modo offset
+	Limit        int           `json:"limit" example:"10"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+	PrevCursor   string        `json:"prev_cursor,omitempty"`
+} //@name LedgerResponse
+
+// FineRun são os parâmetros de uma passada das multas por atraso
+type FineRun struct {
+	Today   string         // AAAA-MM-DD no fuso da biblioteca
+	Loc     *time.Location // fuso em que a data da devolução é contada
+	Default PolicyRule     // regra padrão, quando nenhuma regra da tabela casa
+}
+
+// FineSweep resume uma passada das multas por atraso
+type FineSweep struct {
+	Charged     int   // empréstimos que receberam um lançamento
+	AmountCents int64 // total lançado
+	Closed      int   // empréstimos devolvidos cuja multa foi encerrada
+}
+
+// OverdueFine calcula a multa de um empréstimo com devolução em dueDate e
+// atraso contado até end (a data da devolução ou hoje). Os dias de
+// carência não são cobrados, e a multa para no teto da regra (0 é sem
+// teto). settled é a multa já acertada em prazos anteriores do empréstimo
+// (renovações em atraso): o teto vale para a soma, não para cada prazo.
+func OverdueFine(rule PolicyRule, dueDate, end string, settled int64) (int64, error) {
+	due, err := time.Parse(time.DateOnly, dueDate)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date %q: %w", dueDate, err)
+	}
+	until, err := time.Parse(time.DateOnly, end)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date %q: %w", end, err)
+	}
+
+	days := int(until.Sub(due).Hours()/24) - rule.FineGraceDays
+	if days <= 0 {
+		return 0, nil
+	}
+	fine := int64(days) * rule.FineDailyCents
+	if rule.FineCapCents > 0 {
+		fine = min(fine, max(rule.FineCapCents-settled, 0))
+	}
+	return fine, nil
+}
+
+// FormatCents formata um valor em centavos, ex.: 1250 vira "12.50"
+func FormatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// BalanceBlockedError recusa o empréstimo a um leitor com saldo devedor
+// acima do limite
+func BalanceBlockedError(balance, limit int64) *Error {
+	return ConflictError("patron owes " + FormatCents(balance) + ", above the limit of " +
+		FormatCents(limit) + " for borrowing")
+}
+
+// ExceedsBalanceError recusa um pagamento ou abono maior que o saldo devedor
+func ExceedsBalanceError(balance int64) *Error {
+	return ConflictError("amount exceeds the patron's balance of " + FormatCents(balance))
+}
@@ -0,0 +1,53 @@
+package domain
+
+import "testing"
+
+func TestOverdueFine(t *testing.T) {
+	rule := PolicyRule{FineDailyCents: 25, FineGraceDays: 2, FineCapCents: 500}
+	noCap := PolicyRule{FineDailyCents: 25}
+	tests := []struct {
+		name    string
+		rule    PolicyRule
+		due     string
+		end     string
+		settled int64
+		want    int64
+	}{
+		{"returned early", rule, "2024-03-15", "2024-03-10", 0, 0},
+		{"returned on the due date", rule, "2024-03-15", "2024-03-15", 0, 0},
+		{"within the grace days", rule, "2024-03-15", "2024-03-17", 0, 0},
+		{"first day after the grace", rule, "2024-03-15", "2024-03-18", 0, 25},
+		{"ten days late", rule, "2024-03-15", "2024-03-25", 0, 200},
+		{"exactly at the cap", rule, "2024-03-15", "2024-04-06", 0, 500},
+		{"stops at the cap", rule, "2024-03-15", "2024-12-31", 0, 500},
+		{"across a month and a year", noCap, "2024-12-30", "2025-01-02", 0, 75},
+		{"leap day", noCap, "2024-02-28", "2024-03-01", 0, 50},
+		{"no cap", noCap, "2024-01-01", "2024-12-31", 0, 365 * 25},
+		{"no daily fine", PolicyRule{FineCapCents: 500}, "2024-03-15", "2024-04-15", 0, 0},
+		{"cap shared with earlier due dates", rule, "2024-03-15", "2024-12-31", 350, 150},
+		{"earlier due dates below the cap", rule, "2024-03-15", "2024-03-25", 100, 200},
+		{"cap already reached", rule, "2024-03-15", "2024-12-31", 500, 0},
+		{"settled ignored without a cap", noCap, "2024-03-15", "2024-03-25", 10000, 250},
+	}
+	for _, tt := range tests {
+		got, err := OverdueFine(tt.rule, tt.due, tt.end, tt.settled)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %d, %v; want %d", tt.name, got, err, tt.want)
+		}
+	}
+
+	for _, dates := range [][2]string{{"15/03/2024", "2024-03-20"}, {"2024-03-15", ""}} {
+		if _, err := OverdueFine(rule, dates[0], dates[1], 0); err == nil {
+			t.Errorf("OverdueFine(%q, %q): want an error", dates[0], dates[1])
+		}
+	}
+}
+
+func TestFormatCents(t *testing.T) {
+	tests := map[int64]string{0: "0.00", 5: "0.05", 250: "2.50", 123456: "1234.56", -75: "-0.75"}
+	for cents, want := range tests {
+		if got := FormatCents(cents); got != want {
+			t.Errorf("FormatCents(%d) = %q, want %q", cents, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+// Situações de um empréstimo aceitas no filtro status das listagens
+const (
+	LoanActive   = "active"
+	LoanReturned = "returned"
+	LoanOverdue  = "overdue" // em aberto e com a devolução vencida
+)
+
+// LoanStatuses lista os valores válidos do filtro status
+var LoanStatuses = []string{LoanActive, LoanReturned, LoanOverdue}
+
+// Loan é o empréstimo de um exemplar a um leitor. Leitores e funcionários
+// são usuários, identificados pelo email.
+type Loan struct {
+	ID           int        `json:"-"`
+	UUID         string     `json:"uuid" example:"0f8fad5b-d9cb-469f-a165-70867728950e"`
+	ItemID       int        `json:"-"`
+	ItemUUID     string     `json:"itemUuid" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Barcode      string     `json:"barcode" example:"31234000012345"`
+	BookUUID     string     `json:"bookUuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Title        string     `json:"title" example:"1984"`
+	PatronID     int        `json:"-"`
+	Patron       string     `json:"patron" example:"reader@example.com"`
+	CheckedOutBy string     `json:"checkedOutBy" example:"staff@example.com"` // funcionário do token (sub)
+	CheckedOutAt time.Time  `json:"checkedOutAt"`
+	DueDate      string     `json:"dueDate" example:"2024-04-01"` // AAAA-MM-DD, no fuso da biblioteca
+	Renewals     int        `json:"renewals" example:"1"`
+	ReturnedAt   *time.Time `json:"returnedAt,omitempty"`
+	ReturnedBy   string     `json:"returnedBy,omitempty" example:"staff@example.com"`
+} //@name Loan
+
+// CheckoutRequest empresta o exemplar com o código de barras ao leitor
+type CheckoutRequest struct {
+	Barcode string `json:"barcode" binding:"required,max=50" example:"31234000012345"`
+	Patron  string `json:"patron" binding:"required,email" example:"reader@example.com"` // email do leitor
+} //@name CheckoutRequest
+
+// Checkout é o empréstimo a gravar: o pedido já validado e o funcionário
+// que o registra. O store resolve a regra de circulação (ResolvePolicy)
+// e conta a devolução a partir de Dates.Today.
+type Checkout struct {
+	Barcode     string
+	PatronEmail string
+	StaffID     int
+	Default     PolicyRule // regra padrão, quando nenhuma regra da tabela casa
+	MaxBalance  int64      // saldo devedor máximo, em centavos, para emprestar
+	Dates       HoldDates  // hoje, e para separar um exemplar liberado por uma reserva atendida
+	Calendar    Calendar
+}
+
+// LoanFilters filtra a listagem de empréstimos, do mais recente para o mais
+// antigo (checked_out_at, id)
+type LoanFilters struct {
+	PatronID    int    // 0 lista os de todos os leitores
+	PatronEmail string // exato
+	Barcode     string // exato
+	Status      string // LoanActive, LoanReturned ou LoanOverdue; vazio lista todos
+	Today       string // data de hoje no fuso da biblioteca, para LoanOverdue
+	Limit       int
+	Offset      int
+	Keyset      *Keyset // Value é checked_out_at em RFC 3339; quando definido, Offset é ignorado
+}
+
+type LoanListResponse struct {
+	Data       []Loan `json:"data"`
+	Total      *int   `json:"total,omitempty" example:"42"` // só no modo offset
+	Page       *int   `json:"page,omitempty" example:"1"`   // só no modo offset
+	Limit      int    `json:"limit" example:"10"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+} //@name LoanListResponse
+
+// Renewal é a renovação a gravar. O store resolve a regra de circulação
+// do empréstimo, como no Checkout, e conta o novo prazo a partir do maior
+// entre Today e o prazo atual: renovar antes do vencimento não encurta o
+// empréstimo.
+type Renewal struct {
+	LoanUUID   string
+	StaffID    int        // usuário do token, que fica no histórico
+	Default    PolicyRule // regra padrão, quando nenhuma regra da tabela casa
+	MaxBalance int64      // saldo devedor máximo, em centavos, para renovar
+	Today      string     // AAAA-MM-DD no fuso da biblioteca
+	Calendar   Calendar
+}
+
+// LoanRenewal é uma renovação no histórico do empréstimo
+type LoanRenewal struct {
+	ID              int       `json:"-"`
+	RenewedBy       string    `json:"renewedBy" example:"reader@example.com"`
+	RenewedAt       time.Time `json:"renewedAt"`
+	PreviousDueDate string    `json:"previousDueDate" example:"2024-04-01"`
+	DueDate         string    `json:"dueDate" example:"2024-04-15"`
+} //@name LoanRenewal
+
+type LoanRenewalListResponse struct {
+	Data []LoanRenewal `json:"data"` // da mais antiga para a mais recente
+} //@name LoanRenewalListResponse
+
+// RenewalRefusal é um empréstimo que a renovação em lote não renovou
+type RenewalRefusal struct {
+	Loan   Loan   `json:"loan"`
+	Reason string `json:"reason" example:"another patron is waiting for this book"`
+} //@name RenewalRefusal
+
+// RenewAllResponse é o resultado da renovação de todos os empréstimos em
+// aberto do leitor
+type RenewAllResponse struct {
+	Renewed []Loan           `json:"renewed"`
+	Refused []RenewalRefusal `json:"refused"`
+} //@name RenewAllResponse
+
+// RenewalLimitError recusa a renovação de um empréstimo que já chegou ao
+// limite da regra
+func RenewalLimitError(rule PolicyRule) *Error {
+	if rule.MaxRenewals == 0 {
+		return ConflictError("policy does not allow renewing this loan (" + rule.Scope() + ")")
+	}
+	return ConflictError("loan has reached the limit of " + strconv.Itoa(rule.MaxRenewals) +
+		" renewals (" + rule.Scope() + ")")
+}
@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// Keyset posiciona uma página de uma listagem paginada por cursor: a busca
+// continua a partir de um item de referência, sem OFFSET nem COUNT, e o custo
+// não cresce com a profundidade.
+type Keyset struct {
+	Value    string // chave de ordenação do item (título, nome ou created_at em RFC 3339)
+	ID       int    // desempate pela chave primária
+	Backward bool   // busca os itens anteriores ao de referência (prev_cursor)
+}
+
+// AuthorFilters pagina a listagem de autores, ordenada por nome
+type AuthorFilters struct {
+	Limit  int
+	Offset int
+	Keyset *Keyset // quando definido, Offset é ignorado
+}
+
+type AuthorListResponse struct {
+	Data       []Author `json:"data"`
+	Total      *int     `json:"total,omitempty" example:"42"` // só no modo offset
+	Page       *int     `json:"page,omitempty" example:"1"`   // só no modo offset
+	Limit      int      `json:"limit" example:"10"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	PrevCursor string   `json:"prev_cursor,omitempty"`
+} //@name AuthorListResponse
+
+// TimeValue interpreta Value como created_at, para ordenações por data
+func (k *Keyset) TimeValue() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, k.Value)
+	if err != nil {
+		return time.Time{}, ValidationError("invalid cursor",
+			FieldError{Field: "cursor", Message: "does not match the requested sort"})
+	}
+	return t, nil
+}
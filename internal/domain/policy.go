@@ -0,0 +1,231 @@
+package domain
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Categorias de leitor
+const (
+	PatronStudent = "student"
+	PatronStaff   = "staff"
+	PatronPublic  = "public" // categoria de quem se cadastra pela API
+)
+
+// PatronCategories lista as categorias válidas
+var PatronCategories = []string{PatronStudent, PatronStaff, PatronPublic}
+
+// Tipos de material de um exemplar
+const (
+	MaterialBook      = "book"
+	MaterialReference = "reference"
+	MaterialDVD       = "dvd"
+	MaterialEbook     = "ebook"
+)
+
+// MaterialTypes lista os tipos válidos
+var MaterialTypes = []string{MaterialBook, MaterialReference, MaterialDVD, MaterialEbook}
+
+// PolicyRule é uma linha da tabela de regras de circulação. Critério vazio
+// vale para qualquer valor; entre as regras que casam com um empréstimo,
+// vale a mais específica (ver ResolvePolicy).
+type PolicyRule struct {
+	ID             int        `json:"-"`
+	UUID           string     `json:"uuid,omitempty" example:"3f6c2a9e-8b1d-4e7a-9c5f-2d4b6a8e0c1f"` // vazio na regra padrão
+	PatronCategory string     `json:"patronCategory,omitempty" example:"student" enums:"student,staff,public"`
+	MaterialType   string     `json:"materialType,omitempty" example:"dvd" enums:"book,reference,dvd,ebook"`
+	LoanDays       int        `json:"loanDays" example:"7"`
+	MaxRenewals    int        `json:"maxRenewals" example:"1"`
+	MaxItems       int        `json:"maxItems" example:"3"`        // empréstimos em aberto do material da regra; 0 proíbe o empréstimo
+	FineDailyCents int64      `json:"fineDailyCents" example:"50"` // multa por dia de atraso
+	FineGraceDays  int        `json:"fineGraceDays" example:"1"`   // dias de atraso sem multa
+	FineCapCents   int64      `json:"fineCapCents" example:"1000"` // teto da multa de um empréstimo; 0 é sem teto
+	CreatedAt      *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+} //@name PolicyRule
+
+// Specificity conta os critérios definidos na regra
+func (r *PolicyRule) Specificity() int {
+	n := 0
+	if r.PatronCategory != "" {
+		n++
+	}
+	if r.MaterialType != "" {
+		n++
+	}
+	return n
+}
+
+// Scope descreve os critérios da regra, para as explicações
+func (r *PolicyRule) Scope() string {
+	category, material := r.PatronCategory, r.MaterialType
+	if category == "" {
+		category = "any"
+	}
+	if material == "" {
+		material = "any"
+	}
+	return "patron category " + category + ", material type " + material
+}
+
+// PolicyRuleRequest cria uma regra ou substitui todos os seus campos (PUT).
+// Os limites são obrigatórios; zero é um valor válido.
+type PolicyRuleRequest struct {
+	PatronCategory string `json:"patronCategory" binding:"omitempty,oneof=student staff public" example:"student" enums:"student,staff,public"`
+	MaterialType   string `json:"materialType" binding:"omitempty,oneof=book reference dvd ebook" example:"dvd" enums:"book,reference,dvd,ebook"`
+	LoanDays       *int   `json:"loanDays" binding:"required,min=1,max=365" example:"7"`
+	MaxRenewals    *int   `json:"maxRenewals" binding:"required,min=0,max=99" example:"1"`
+	MaxItems       *int   `json:"maxItems" binding:"required,min=0,max=1000" example:"3"`
+	FineDailyCents *int64 `json:"fineDailyCents" binding:"required,min=0,max=100000" example:"50"`
+	FineGraceDays  *int   `json:"fineGraceDays" binding:"required,min=0,max=365" example:"1"`
+	FineCapCents   *int64 `json:"fineCapCents" binding:"required,min=0,max=10000000" example:"1000"`
+} //@name PolicyRuleRequest
+
+// Rule devolve a regra descrita pelo pedido
+func (r *PolicyRuleRequest) Rule() PolicyRule {
+	return PolicyRule{
+		PatronCategory: r.PatronCategory,
+		MaterialType:   r.MaterialType,
+		LoanDays:       *r.LoanDays,
+		MaxRenewals:    *r.MaxRenewals,
+		MaxItems:       *r.MaxItems,
+		FineDailyCents: *r.FineDailyCents,
+		FineGraceDays:  *r.FineGraceDays,
+		FineCapCents:   *r.FineCapCents,
+	}
+}
+
+// PolicyRuleListResponse lista todas as regras, da mais específica para a
+// mais geral
+type PolicyRuleListResponse struct {
+	Data    []PolicyRule `json:"data"`
+	Default PolicyRule   `json:"default"` // limites da configuração, usados quando nenhuma regra casa
+} //@name PolicyRuleListResponse
+
+// SimulateRequest pergunta qual regra vale para um leitor e um material,
+// informados diretamente ou pelo email do leitor e o código de barras do
+// exemplar
+type SimulateRequest struct {
+	Patron         string `json:"patron" binding:"omitempty,email" example:"reader@example.com"`
+	PatronCategory string `json:"patronCategory" binding:"omitempty,oneof=student staff public" example:"student" enums:"student,staff,public"`
+	Barcode        string `json:"barcode" binding:"omitempty,max=50" example:"31234000012345"`
+	MaterialType   string `json:"materialType" binding:"omitempty,oneof=book reference dvd ebook" example:"dvd" enums:"book,reference,dvd,ebook"`
+} //@name SimulateRequest
+
+// Validate exige exatamente uma forma de informar cada critério
+func (r *SimulateRequest) Validate() error {
+	var fields []FieldError
+	if (r.Patron == "") == (r.PatronCategory == "") {
+		fields = append(fields, FieldError{Field: "patron", Message: "give either patron or patronCategory"})
+	}
+	if (r.Barcode == "") == (r.MaterialType == "") {
+		fields = append(fields, FieldError{Field: "barcode", Message: "give either barcode or materialType"})
+	}
+	if len(fields) > 0 {
+		return ValidationError("invalid simulation", fields...)
+	}
+	return nil
+}
+
+// PolicyCandidate é uma regra avaliada na resolução e o motivo de ter
+// casado ou não
+type PolicyCandidate struct {
+	Rule    PolicyRule `json:"rule"`
+	Matched bool       `json:"matched"`
+	Reason  string     `json:"reason" example:"matches patron category student and any material type"`
+} //@name PolicyCandidate
+
+// PolicyDecision é o resultado da resolução: a regra aplicada e, na
+// simulação, a explicação
+type PolicyDecision struct {
+	PatronCategory string            `json:"patronCategory" example:"student"`
+	MaterialType   string            `json:"materialType" example:"dvd"`
+	Rule           PolicyRule        `json:"rule"`
+	Default        bool              `json:"default"`                                // nenhuma regra casou; valem os limites da configuração
+	Loanable       bool              `json:"loanable"`                               // MaxItems > 0
+	DueDate        string            `json:"dueDate,omitempty" example:"2024-04-01"` // de um empréstimo feito hoje
+	Explanation    string            `json:"explanation" example:"no rule matches; the configured defaults apply"`
+	Candidates     []PolicyCandidate `json:"candidates,omitempty"`
+} //@name PolicyDecision
+
+// ResolvePolicy escolhe, entre as regras que casam com a categoria do
+// leitor e o tipo do material, a mais específica. No empate entre uma
+// regra só de categoria e uma só de material, vale a de material: é ela
+// que diz, por exemplo, que obras de referência não saem da biblioteca.
+// Sem nenhuma regra, vale fallback (os limites da configuração).
+func ResolvePolicy(rules []PolicyRule, category, material string, fallback PolicyRule) PolicyDecision {
+	d := PolicyDecision{PatronCategory: category, MaterialType: material}
+
+	var best *PolicyRule
+	for i := range rules {
+		r := &rules[i]
+		c := PolicyCandidate{Rule: *r}
+		switch {
+		case r.PatronCategory != "" && r.PatronCategory != category:
+			c.Reason = "patron category is " + category + ", rule is for " + r.PatronCategory
+		case r.MaterialType != "" && r.MaterialType != material:
+			c.Reason = "material type is " + material + ", rule is for " + r.MaterialType
+		default:
+			c.Matched = true
+			c.Reason = "matches " + r.Scope() + " (specificity " + strconv.Itoa(r.Specificity()) + ")"
+			if best == nil || outranks(r, best) {
+				best = r
+			}
+		}
+		d.Candidates = append(d.Candidates, c)
+	}
+
+	if best == nil {
+		d.Rule = fallback
+		d.Default = true
+		d.Explanation = "no rule matches; the configured defaults apply"
+	} else {
+		d.Rule = *best
+		d.Explanation = fmt.Sprintf("rule %s is the most specific match (%s)", best.UUID, best.Scope())
+		for _, c := range d.Candidates {
+			if c.Matched && c.Rule.UUID != best.UUID && c.Rule.Specificity() == best.Specificity() {
+				d.Explanation += "; it wins the tie with rule " + c.Rule.UUID +
+					" because rules for a material type take precedence over rules for a patron category"
+			}
+		}
+	}
+	d.Loanable = d.Rule.MaxItems > 0
+	return d
+}
+
+// outranks indica se a regra a vence b na resolução
+func outranks(a, b *PolicyRule) bool {
+	if sa, sb := a.Specificity(), b.Specificity(); sa != sb {
+		return sa > sb
+	}
+	return a.MaterialType != "" && b.MaterialType == ""
+}
+
+// SortPolicyRules ordena as regras na ordem em que são consideradas: das
+// mais específicas para a mais geral, depois por categoria e material
+func SortPolicyRules(rules []PolicyRule) {
+	slices.SortStableFunc(rules, func(a, b PolicyRule) int {
+		switch {
+		case outranks(&a, &b):
+			return -1
+		case outranks(&b, &a):
+			return 1
+		case a.PatronCategory != b.PatronCategory:
+			return cmp.Compare(a.PatronCategory, b.PatronCategory)
+		default:
+			return cmp.Compare(a.MaterialType, b.MaterialType)
+		}
+	})
+}
+
+// LoanLimitError recusa um empréstimo acima do limite da regra
+func LoanLimitError(rule PolicyRule) *Error {
+	if rule.MaxItems == 0 {
+		return ConflictError("policy does not allow lending this material to this patron (" + rule.Scope() + ")")
+	}
+	return ConflictError("patron has reached the limit of " + strconv.Itoa(rule.MaxItems) +
+		" loans (" + rule.Scope() + ")")
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+var policyRules = []PolicyRule{
+	{UUID: "all", LoanDays: 21, MaxItems: 5},
+	{UUID: "student", PatronCategory: PatronStudent, LoanDays: 14, MaxItems: 3},
+	{UUID: "reference", MaterialType: MaterialReference, LoanDays: 1, MaxItems: 0},
+	{UUID: "student-dvd", PatronCategory: PatronStudent, MaterialType: MaterialDVD, LoanDays: 3, MaxItems: 1},
+	{UUID: "staff", PatronCategory: PatronStaff, LoanDays: 60, MaxItems: 20},
+}
+
+func TestResolvePolicy(t *testing.T) {
+	fallback := PolicyRule{LoanDays: 14, MaxItems: 10}
+	tests := []struct {
+		name     string
+		rules    []PolicyRule
+		category string
+		material string
+		want     string // UUID da regra; vazio é a regra padrão
+		loanable bool
+		tie      bool
+	}{
+		{"category rule", policyRules, PatronStudent, MaterialBook, "student", true, false},
+		{"category and material", policyRules, PatronStudent, MaterialDVD, "student-dvd", true, false},
+		{"material beats category on a tie", policyRules, PatronStudent, MaterialReference, "reference", false, true},
+		{"material only", policyRules, PatronPublic, MaterialReference, "reference", false, false},
+		{"catch-all rule", policyRules, PatronPublic, MaterialBook, "all", true, false},
+		{"no matching rule", policyRules[1:], PatronPublic, MaterialEbook, "", true, false},
+		{"no rules", nil, PatronStudent, MaterialBook, "", true, false},
+	}
+	for _, tt := range tests {
+		// A ordem da tabela não pode mudar a regra escolhida
+		for _, rules := range [][]PolicyRule{tt.rules, reversed(tt.rules)} {
+			d := ResolvePolicy(rules, tt.category, tt.material, fallback)
+			if d.Rule.UUID != tt.want || d.Default != (tt.want == "") {
+				t.Errorf("%s: rule %q (default %v), want %q", tt.name, d.Rule.UUID, d.Default, tt.want)
+			}
+			if tt.want == "" && d.Rule.LoanDays != fallback.LoanDays {
+				t.Errorf("%s: default rule %+v, want %+v", tt.name, d.Rule, fallback)
+			}
+			if d.Loanable != tt.loanable {
+				t.Errorf("%s: loanable %v, want %v", tt.name, d.Loanable, tt.loanable)
+			}
+			if got := strings.Contains(d.Explanation, "wins the tie"); got != tt.tie {
+				t.Errorf("%s: explanation %q, tie mentioned %v, want %v", tt.name, d.Explanation, got, tt.tie)
+			}
+			if len(d.Candidates) != len(rules) {
+				t.Errorf("%s: %d candidates, want %d", tt.name, len(d.Candidates), len(rules))
+			}
+		}
+	}
+}
+
+func TestResolvePolicyCandidates(t *testing.T) {
+	d := ResolvePolicy(policyRules, PatronStudent, MaterialReference, PolicyRule{})
+	matched := map[string]bool{}
+	for _, c := range d.Candidates {
+		matched[c.Rule.UUID] = c.Matched
+		if c.Reason == "" {
+			t.Errorf("candidate %s has no reason", c.Rule.UUID)
+		}
+	}
+	want := map[string]bool{"all": true, "student": true, "reference": true, "student-dvd": false, "staff": false}
+	for uuid, m := range want {
+		if matched[uuid] != m {
+			t.Errorf("candidate %s: matched %v, want %v", uuid, matched[uuid], m)
+		}
+	}
+}
+
+func TestSortPolicyRules(t *testing.T) {
+	rules := slices.Clone(policyRules)
+	SortPolicyRules(rules)
+	var got []string
+	for _, r := range rules {
+		got = append(got, r.UUID)
+	}
+	want := []string{"student-dvd", "reference", "staff", "student", "all"}
+	if !slices.Equal(got, want) {
+		t.Errorf("order %v, want %v", got, want)
+	}
+}
+
+func reversed(rules []PolicyRule) []PolicyRule {
+	out := slices.Clone(rules)
+	slices.Reverse(out)
+	return out
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Limites da busca, para que uma consulta não gere um tsquery gigante
+const (
+	MaxSearchQueryLength = 256
+	MaxSearchTerms       = 16
+)
+
+// SearchTermKind diferencia palavras, frases ("...") e prefixos (pal*)
+type SearchTermKind int
+
+const (
+	SearchWord SearchTermKind = iota
+	SearchPhrase
+	SearchPrefix
+)
+
+// SearchTerm é um termo da consulta; Negated exclui os livros que o contêm
+type SearchTerm struct {
+	Kind    SearchTermKind
+	Text    string
+	Negated bool
+}
+
+// SearchQuery é a consulta já interpretada. Language escolhe a configuração
+// de texto (stemming) usada pelo Postgres: "pt" ou "en".
+type SearchQuery struct {
+	Raw      string
+	Terms    []SearchTerm
+	Language string
+	Limit    int
+	Offset   int
+}
+
+// SearchHit é um livro encontrado, com a relevância (ts_rank) e um trecho do
+// título/descrição com os termos destacados por <mark>...</mark>. Headline é
+// HTML: o texto do livro vem escapado e as únicas tags são as <mark>.
+type SearchHit struct {
+	Book     Book    `json:"book"`
+	Rank     float64 `json:"rank" example:"0.6079271"`
+	Headline string  `json:"headline" example:"<mark>1984</mark>. A dystopian novel"` // HTML escapado
+} //@name SearchHit
+
+type SearchResponse struct {
+	Query string      `json:"query" example:"\"big brother\" orwel*"`
+	Data  []SearchHit `json:"data"`
+	Total int         `json:"total" example:"3"`
+	Page  int         `json:"page" example:"1"`
+	Limit int         `json:"limit" example:"10"`
+} //@name SearchResponse
+
+// ParseSearchQuery interpreta a sintaxe aceita em /search:
+//
+//	palavra       o livro deve conter a palavra
+//	"uma frase"   as palavras devem aparecer em sequência
+//	pal*          alguma palavra começa com "pal"
+//	-termo        exclui livros com o termo (vale para frases e prefixos)
+//
+// Pontuação fora de frases é ignorada; os termos são combinados com E.
+func ParseSearchQuery(raw string) ([]SearchTerm, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, ValidationError("search query is required",
+			FieldError{Field: "q", Message: "is required"})
+	}
+	if len(raw) > MaxSearchQueryLength {
+		return nil, ValidationError("search query is too long",
+			FieldError{Field: "q", Message: "must be at most 256 characters long"})
+	}
+
+	var terms []SearchTerm
+	rest := raw
+	for rest != "" {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+
+		negated := false
+		if rest[0] == '-' {
+			negated = true
+			rest = rest[1:]
+		}
+
+		var term SearchTerm
+		if strings.HasPrefix(rest, `"`) {
+			phrase, after, _ := strings.Cut(rest[1:], `"`)
+			rest = after
+			term = SearchTerm{Kind: SearchPhrase, Text: strings.Join(searchWords(phrase), " ")}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			token := rest[:end]
+			rest = rest[end:]
+
+			term = SearchTerm{Kind: SearchWord}
+			if strings.HasSuffix(token, "*") {
+				term.Kind = SearchPrefix
+			}
+			// Um token como "sci-fi" vira a frase "sci fi"
+			words := searchWords(token)
+			switch {
+			case len(words) > 1 && term.Kind == SearchWord:
+				term.Kind = SearchPhrase
+				term.Text = strings.Join(words, " ")
+			case len(words) > 0:
+				term.Text = words[len(words)-1]
+				if len(words) > 1 {
+					terms = append(terms, SearchTerm{Kind: SearchPhrase, Text: strings.Join(words[:len(words)-1], " "), Negated: negated})
+				}
+			}
+		}
+		if term.Text == "" {
+			continue
+		}
+		term.Negated = negated
+		terms = append(terms, term)
+	}
+
+	if len(terms) > MaxSearchTerms {
+		return nil, ValidationError("search query has too many terms",
+			FieldError{Field: "q", Message: "must have at most 16 terms"})
+	}
+	positive := false
+	for _, t := range terms {
+		positive = positive || !t.Negated
+	}
+	if !positive {
+		return nil, ValidationError("search query needs at least one term to match",
+			FieldError{Field: "q", Message: "must contain at least one word that is not excluded"})
+	}
+	return terms, nil
+}
+
+// searchWords separa letras e dígitos, descartando a pontuação
+func searchWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	word := func(text string) SearchTerm { return SearchTerm{Kind: SearchWord, Text: text} }
+	phrase := func(text string) SearchTerm { return SearchTerm{Kind: SearchPhrase, Text: text} }
+	prefix := func(text string) SearchTerm { return SearchTerm{Kind: SearchPrefix, Text: text} }
+	not := func(term SearchTerm) SearchTerm { term.Negated = true; return term }
+
+	tests := []struct {
+		raw  string
+		want []SearchTerm
+	}{
+		{"orwell", []SearchTerm{word("orwell")}},
+		{"  big   brother ", []SearchTerm{word("big"), word("brother")}},
+		{`"big brother" orwel*`, []SearchTerm{phrase("big brother"), prefix("orwel")}},
+		{`"big,  brother!"`, []SearchTerm{phrase("big brother")}},
+		{`orwell "big brother`, []SearchTerm{word("orwell"), phrase("big brother")}},
+		{`"big brother" "`, []SearchTerm{phrase("big brother")}},
+		{"dune -spice", []SearchTerm{word("dune"), not(word("spice"))}},
+		{`dune -"sand worm"`, []SearchTerm{word("dune"), not(phrase("sand worm"))}},
+		{"dune -spi*", []SearchTerm{word("dune"), not(prefix("spi"))}},
+		{"dune -", []SearchTerm{word("dune")}},
+		{"dune - spice", []SearchTerm{word("dune"), word("spice")}},
+		{"sci-fi", []SearchTerm{phrase("sci fi")}},
+		{"sci-fi*", []SearchTerm{phrase("sci"), prefix("fi")}},
+		{"-sci-fi dune", []SearchTerm{not(phrase("sci fi")), word("dune")}},
+		{"dune!!", []SearchTerm{word("dune")}},
+		{"ação", []SearchTerm{word("ação")}},
+		// Stopwords ficam a cargo do Postgres, que as descarta por idioma
+		{"the of", []SearchTerm{word("the"), word("of")}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSearchQuery(tt.raw)
+		if err != nil {
+			t.Errorf("ParseSearchQuery(%q): %v", tt.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSearchQuery(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	tests := []struct {
+		raw    string
+		detail string
+	}{
+		{"", "search query is required"},
+		{"   ", "search query is required"},
+		{"-", "search query needs at least one term to match"},
+		{"- -", "search query needs at least one term to match"},
+		{"-dune -spice", "search query needs at least one term to match"},
+		{`"`, "search query needs at least one term to match"},
+		{`"" !!`, "search query needs at least one term to match"},
+		{strings.Repeat("a", MaxSearchQueryLength+1), "search query is too long"},
+		{strings.Repeat("a ", MaxSearchTerms+1), "search query has too many terms"},
+	}
+	for _, tt := range tests {
+		_, err := ParseSearchQuery(tt.raw)
+		if err == nil || err.Error() != tt.detail || !errors.Is(err, ErrValidation) {
+			t.Errorf("ParseSearchQuery(%q) error = %v, want %q", tt.raw, err, tt.detail)
+		}
+	}
+}
@@ -1,15 +1,38 @@
 package domain
 
-import "time"
+import (
+	"slices"
+	"time"
+)
+
+// Papéis de um usuário na API. Cada papel inclui as permissões dos
+// anteriores: o funcionário também é leitor, e o administrador também é
+// funcionário.
+const (
+	RolePatron = "patron" // só a própria conta; papel de quem se cadastra pela API
+	RoleStaff  = "staff"  // balcão: empréstimos, devoluções e contas dos leitores
+	RoleAdmin  = "admin"  // regras de circulação, categorias e papéis
+)
+
+// Roles lista os papéis, do menor para o maior
+var Roles = []string{RolePatron, RoleStaff, RoleAdmin}
+
+// HasRole indica se role inclui as permissões de required. Papel
+// desconhecido ou vazio (tokens antigos) vale como leitor.
+func HasRole(role, required string) bool {
+	return max(slices.Index(Roles, role), 0) >= slices.Index(Roles, required)
+}
 
 type User struct {
 	ID           string     `json:"-" db:"id"`
 	UUID         string     `json:"-" db:"uuid"`
 	Email        string     `json:"email" db:"email"`
-	Password     string     `json:"password" db:"-"`                                  // Usado apenas para receber o input
-	PasswordHash string     `json:"-" db:"password_hash" swaggerignore:"true"`        //swagger:ignore
-	CreatedAt    time.Time  `json:"-" db:"created_at"  swaggerignore:"true`           //swagger:ignore
-	UpdatedAt    *time.Time `json:"-,omitempty" db:"updated_at"  swaggerignore:"true` //swagger:ignore
+	Password     string     `json:"password" db:"-"`                                   // Usado apenas para receber o input
+	Category     string     `json:"category,omitempty" db:"category" readonly:"true"`  // categoria de leitor; public no cadastro
+	Role         string     `json:"role,omitempty" db:"role" readonly:"true"`          // papel na API; patron no cadastro
+	PasswordHash string     `json:"-" db:"password_hash" swaggerignore:"true"`         //swagger:ignore
+	CreatedAt    time.Time  `json:"-" db:"created_at"  swaggerignore:"true"`           //swagger:ignore
+	UpdatedAt    *time.Time `json:"-,omitempty" db:"updated_at"  swaggerignore:"true"` //swagger:ignore
 }
 
 type UserListResponse struct {
@@ -20,6 +43,17 @@ type UserListResponse struct {
 	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt    *time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// CategoryRequest muda a categoria de leitor de um usuário
+type CategoryRequest struct {
+	Category string `json:"category" binding:"required,oneof=student staff public" example:"student" enums:"student,staff,public"`
+} //@name CategoryRequest
+
+// RoleRequest muda o papel de um usuário na API
+type RoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=patron staff admin" example:"staff" enums:"patron,staff,admin"`
+} //@name RoleRequest
+
 type Credentials struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
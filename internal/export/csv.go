@@ -0,0 +1,88 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// csvHeader usa os nomes de coluna aceitos pela importação, para que o
+// arquivo exportado possa ser importado de volta; uuid, created_at e
+// updated_at são só informativos e a importação os ignora
+var csvHeader = []string{
+	"title", "authors", "isbn", "publisher", "publication_year", "language", "pages",
+	"edition", "format", "subjects", "description", "uuid", "created_at", "updated_at",
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) header() error {
+	if c.wroteHeader {
+		return nil
+	}
+	c.wroteHeader = true
+	return c.w.Write(csvHeader)
+}
+
+func (c *csvWriter) Write(b domain.Book) error {
+	if err := c.header(); err != nil {
+		return err
+	}
+	return c.w.Write([]string{
+		b.Title,
+		strings.Join(authorNames(b), ";"),
+		b.ISBN,
+		b.Publisher,
+		optionalInt(b.PublicationYear),
+		b.Language,
+		optionalInt(b.Pages),
+		b.Edition,
+		b.Format,
+		strings.Join(b.Subjects, ";"),
+		b.Description,
+		b.UUID,
+		optionalTime(b.CreatedAt),
+		optionalTime(b.UpdatedAt),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.header(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func authorNames(b domain.Book) []string {
+	names := make([]string, 0, len(b.Authors))
+	for _, a := range b.Authors {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+func optionalInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+func optionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
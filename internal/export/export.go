@@ -0,0 +1,94 @@
+// Package export escreve o catálogo em CSV, JSON Lines ou MARCXML. Os
+// livros são lidos do BookStore em páginas por keyset, então a exportação
+// não carrega o catálogo inteiro em memória nem prende uma conexão do banco
+// enquanto o cliente baixa o arquivo.
+package export
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// Formatos de exportação
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatMARCXML = "marcxml"
+)
+
+// Formats lista os formatos aceitos
+var Formats = []string{FormatCSV, FormatJSONL, FormatMARCXML}
+
+// batchSize é o número de livros lidos por consulta
+const batchSize = 500
+
+// Writer escreve um livro por vez; Close completa o arquivo (o rodapé do
+// MARCXML, o flush do CSV)
+type Writer interface {
+	Write(b domain.Book) error
+	Close() error
+}
+
+// NewWriter cria o Writer do formato
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatJSONL:
+		return newJSONLWriter(w), nil
+	case FormatMARCXML:
+		return newMARCXMLWriter(w), nil
+	}
+	return nil, domain.ValidationError("unsupported export format",
+		domain.FieldError{Field: "format", Message: "must be one of: csv, jsonl, marcxml"})
+}
+
+// ContentType devolve o tipo MIME e a extensão de arquivo do formato
+func ContentType(format string) (mime, ext string) {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8", "csv"
+	case FormatMARCXML:
+		return "application/marcxml+xml", "xml"
+	default:
+		return "application/x-ndjson", "jsonl"
+	}
+}
+
+// Books percorre os livros filtrados, com autores, na ordem de filters.Sort
+// (title ou created_at, desempate por id), chamando fn para cada um. Limit,
+// Offset e Keyset de filters são ignorados.
+func Books(ctx context.Context, store storage.BookStore, filters domain.BookFilters, fn func(domain.Book) error) error {
+	if filters.Sort == "" {
+		filters.Sort = "title"
+	}
+	filters.Limit = batchSize
+	filters.Offset = 0
+	filters.Keyset = nil
+
+	for {
+		books, _, err := store.GetBooksWithAuthors(ctx, filters)
+		if err != nil {
+			return err
+		}
+		for _, b := range books {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		if len(books) < batchSize {
+			return nil
+		}
+
+		last := books[len(books)-1]
+		ks := &domain.Keyset{Value: last.Title, ID: last.ID}
+		if filters.Sort == "created_at" && last.CreatedAt != nil {
+			ks.Value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+		}
+		filters.Keyset = ks
+	}
+}
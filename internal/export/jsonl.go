@@ -0,0 +1,59 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// jsonlBook segue o formato da importação JSON Lines (autores por nome), com
+// uuid e datas a mais
+type jsonlBook struct {
+	UUID            string     `json:"uuid"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description,omitempty"`
+	Authors         []string   `json:"authors"`
+	ISBN            string     `json:"isbn,omitempty"`
+	Publisher       string     `json:"publisher,omitempty"`
+	PublicationYear *int       `json:"publicationYear,omitempty"`
+	Language        string     `json:"language,omitempty"`
+	Pages           *int       `json:"pages,omitempty"`
+	Edition         string     `json:"edition,omitempty"`
+	Format          string     `json:"format,omitempty"`
+	Subjects        []string   `json:"subjects,omitempty"`
+	CreatedAt       *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt       *time.Time `json:"updatedAt,omitempty"`
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &jsonlWriter{enc: enc}
+}
+
+func (j *jsonlWriter) Write(b domain.Book) error {
+	return j.enc.Encode(jsonlBook{
+		UUID:            b.UUID,
+		Title:           b.Title,
+		Description:     b.Description,
+		Authors:         authorNames(b),
+		ISBN:            b.ISBN,
+		Publisher:       b.Publisher,
+		PublicationYear: b.PublicationYear,
+		Language:        b.Language,
+		Pages:           b.Pages,
+		Edition:         b.Edition,
+		Format:          b.Format,
+		Subjects:        b.Subjects,
+		CreatedAt:       b.CreatedAt,
+		UpdatedAt:       b.UpdatedAt,
+	})
+}
+
+func (j *jsonlWriter) Close() error { return nil }
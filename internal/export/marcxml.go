@@ -0,0 +1,24 @@
+package export
+
+import (
+	"io"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/marc"
+)
+
+type marcXMLWriter struct {
+	x *marc.XMLWriter
+}
+
+func newMARCXMLWriter(w io.Writer) *marcXMLWriter {
+	return &marcXMLWriter{x: marc.NewXMLWriter(w)}
+}
+
+func (m *marcXMLWriter) Write(b domain.Book) error {
+	return m.x.Write(marc.FromBook(b))
+}
+
+func (m *marcXMLWriter) Close() error {
+	return m.x.Close()
+}
@@ -1,20 +1,21 @@
 package handler
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/patrick-tondorf/lib_api/internal/domain"
-	"github.com/patrick-tondorf/lib_api/internal/repository"
+	"github.com/patrick-tondorf/lib_api/internal/pagination"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
 )
 
 type AuthorHandler struct {
-	Repo *repository.AuthorRepository
+	Repo    storage.AuthorStore
+	cursors *pagination.Codec
 }
 
-func NewAuthorHandler(repo *repository.AuthorRepository) *AuthorHandler {
-	return &AuthorHandler{Repo: repo}
+func NewAuthorHandler(repo storage.AuthorStore, cursors *pagination.Codec) *AuthorHandler {
+	return &AuthorHandler{Repo: repo, cursors: cursors}
 }
 
 // CreateAuthor godoc
@@ -26,22 +27,21 @@ func NewAuthorHandler(repo *repository.AuthorRepository) *AuthorHandler {
 // @Produce json
 // @Param author body domain.Author false "Author data"
 // @Success 201 {object} domain.Author
-// @Failure 400 {object} domain.Author
-// @Failure 500 {object} domain.Author
+// @Failure 400 {object} domain.Problem
+// @Failure 500 {object} domain.Problem
 // @Router /authors [post]
 func (h *AuthorHandler) CreateAuthor(c *gin.Context) {
 	var input domain.Author
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		abort(c, bindError(err))
 		return
 	}
 
-	author := domain.Author{Name: input.Name}
+	author := domain.Author{Name: input.Name, Bio: input.Bio}
 
 	if err := h.Repo.CreateAuthor(c.Request.Context(), &author); err != nil {
-		log.Printf("Error creating author: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create author"})
+		abort(c, err)
 		return
 	}
 
@@ -50,61 +50,186 @@ func (h *AuthorHandler) CreateAuthor(c *gin.Context) {
 
 // ListAll godoc
 // @Summary List all authors
-// @Description Get all authors from the library
+// @Description Get a page of authors, sorted by name. Pages can be requested by number (page) or by following next_cursor/prev_cursor.
 // @Tags authors
 // @Security BearerAuth
 // @Produce json
 // @Param withBooks query boolean false "Include books in response"
-// @Success 200 {array} domain.Author
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Param page      query int     false "Page number (offset mode)" default(1) minimum(1) maximum(1000)
+// @Param cursor    query string  false "Opaque cursor from next_cursor or prev_cursor (cursor mode)"
+// @Param limit     query int     false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} domain.AuthorListResponse
+// @Failure 500 {object} domain.Problem "Internal server error"
 // @Router /authors [get]
 func (h *AuthorHandler) GetAuthors(c *gin.Context) {
 	withBooks := c.Query("withBooks") == "true"
 
-	var authors []domain.Author
-	var err error
+	req, page, err := pageRequest(c, h.cursors, "authors")
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	filters := domain.AuthorFilters{Limit: req.FetchLimit(), Offset: req.Offset, Keyset: req.Keyset}
 
+	var (
+		authors []domain.Author
+		total   int
+	)
 	if withBooks {
-		authors, err = h.Repo.GetAuthorsWithBooks(c.Request.Context())
+		authors, total, err = h.Repo.GetAuthorsWithBooks(c.Request.Context(), filters)
 	} else {
-		authors, err = h.Repo.GetAuthors(c.Request.Context())
+		authors, total, err = h.Repo.GetAuthors(c.Request.Context(), filters)
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch authors",
-			"details": err.Error(),
-		})
+		abort(c, err)
 		return
 	}
 
-	if len(authors) == 0 {
-		c.JSON(http.StatusOK, []domain.Author{})
+	authors, next, prev := pagination.Window(authors, req, total, func(a domain.Author) domain.Keyset {
+		return domain.Keyset{Value: a.Name, ID: a.ID}
+	})
+	if authors == nil {
+		authors = []domain.Author{}
+	}
+	resp := domain.AuthorListResponse{
+		Data:       authors,
+		Limit:      req.Limit,
+		NextCursor: encodeCursor(h.cursors, "authors", next),
+		PrevCursor: encodeCursor(h.cursors, "authors", prev),
+	}
+	if req.Keyset == nil {
+		resp.Total = &total
+		resp.Page = &page
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetAuthorByID godoc
+// @Summary Get an author by UUID
+// @Description Retrieve an author, with bio and books, by their UUID
+// @Tags authors
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Author UUID"
+// @Success 200 {object} domain.Author
+// @Failure 400 {object} domain.Problem
+// @Failure 404 {object} domain.Problem
+// @Failure 500 {object} domain.Problem
+// @Router /authors/{uuid} [get]
+func (h *AuthorHandler) GetAuthorByID(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
 		return
 	}
 
-	c.JSON(http.StatusOK, authors)
+	author, err := h.Repo.GetAuthorByUUID(c.Request.Context(), uuid)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, author)
 }
 
-// GetAuthorById godoc
-// @Summary Get an author by ID
-// @Description Retrieve an author by their ID
+// UpdateAuthor godoc
+// @Summary Replace an author
+// @Description Replace name and bio of an author by UUID
 // @Tags authors
 // @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Param id path int true "Author ID"
+// @Param uuid   path string                     true "Author UUID"
+// @Param author body domain.AuthorUpdateRequest true "Author data"
 // @Success 200 {object} domain.Author
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /authors/{id} [get]
-func (h *AuthorHandler) GetAuthorByID(c *gin.Context) {
-	/*id := c.Param("id")
-	author, err := h.repo.GetAuthorByID(c.Request.Context(), id)
+// @Failure 400 {object} domain.Problem
+// @Failure 404 {object} domain.Problem
+// @Failure 500 {object} domain.Problem
+// @Router /authors/{uuid} [put]
+func (h *AuthorHandler) UpdateAuthor(c *gin.Context) {
+	var input domain.AuthorUpdateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+
+	h.applyAuthorPatch(c, domain.AuthorPatchRequest{Name: &input.Name, Bio: &input.Bio})
+}
+
+// PatchAuthor godoc
+// @Summary Partially update an author
+// @Description Update only the given fields (name and/or bio) of an author by UUID
+// @Tags authors
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param uuid   path string                    true "Author UUID"
+// @Param author body domain.AuthorPatchRequest true "Fields to change"
+// @Success 200 {object} domain.Author
+// @Failure 400 {object} domain.Problem
+// @Failure 404 {object} domain.Problem
+// @Failure 500 {object} domain.Problem
+// @Router /authors/{uuid} [patch]
+func (h *AuthorHandler) PatchAuthor(c *gin.Context) {
+	var input domain.AuthorPatchRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+
+	h.applyAuthorPatch(c, input)
+}
+
+func (h *AuthorHandler) applyAuthorPatch(c *gin.Context, patch domain.AuthorPatchRequest) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	author, err := h.Repo.UpdateAuthor(c.Request.Context(), uuid, patch)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, author)
+}
+
+// DeleteAuthor godoc
+// @Summary Delete an author
+// @Description Delete an author by UUID. Refused with 409 while the author is linked to books, unless cascade=unlink is given.
+// @Tags authors
+// @Security BearerAuth
+// @Param uuid    path  string true  "Author UUID"
+// @Param cascade query string false "Remove links to books before deleting" Enums(unlink)
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.Problem
+// @Failure 404 {object} domain.Problem
+// @Failure 409 {object} domain.Problem "Author still linked to books"
+// @Failure 500 {object} domain.Problem
+// @Router /authors/{uuid} [delete]
+func (h *AuthorHandler) DeleteAuthor(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	cascade := c.Query("cascade")
+	if cascade != "" && cascade != "unlink" {
+		abort(c, domain.ValidationError("invalid cascade mode",
+			domain.FieldError{Field: "cascade", Message: "must be 'unlink'"}))
+		return
+	}
+
+	err := h.Repo.DeleteAuthor(c.Request.Context(), uuid, cascade == "unlink")
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
+		abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, author)*/
+	c.Status(http.StatusNoContent)
 }
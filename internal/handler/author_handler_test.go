@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+func TestAuthorCRUD(t *testing.T) {
+	r, _ := newTestRouter()
+
+	w := serve(r, http.MethodPost, "/authors", `{"name":"George Orwell","bio":"English novelist"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body.String())
+	}
+	var created domain.Author
+	decode(t, w, &created)
+	if !isValidUUID(created.UUID) || created.Name != "George Orwell" || created.Bio != "English novelist" {
+		t.Errorf("create: got %+v", created)
+	}
+
+	w = serve(r, http.MethodPatch, "/authors/"+created.UUID, `{"bio":"Author of 1984"}`)
+	var patched domain.Author
+	decode(t, w, &patched)
+	if w.Code != http.StatusOK || patched.Name != "George Orwell" || patched.Bio != "Author of 1984" {
+		t.Errorf("patch: status %d, author %+v", w.Code, patched)
+	}
+
+	w = serve(r, http.MethodPut, "/authors/"+created.UUID, `{"name":"Eric Blair"}`)
+	var replaced domain.Author
+	decode(t, w, &replaced)
+	if w.Code != http.StatusOK || replaced.Name != "Eric Blair" || replaced.Bio != "" {
+		t.Errorf("put: status %d, author %+v", w.Code, replaced)
+	}
+
+	w = serve(r, http.MethodGet, "/authors/"+created.UUID, "")
+	var got domain.Author
+	decode(t, w, &got)
+	if w.Code != http.StatusOK || got.Name != "Eric Blair" {
+		t.Errorf("get: status %d, author %+v", w.Code, got)
+	}
+
+	w = serve(r, http.MethodGet, "/authors", "")
+	var list domain.AuthorListResponse
+	decode(t, w, &list)
+	if w.Code != http.StatusOK || len(list.Data) != 1 {
+		t.Errorf("list: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	if w := serve(r, http.MethodDelete, "/authors/"+created.UUID, ""); w.Code != http.StatusNoContent {
+		t.Errorf("delete: status %d, body %s", w.Code, w.Body.String())
+	}
+	w = serve(r, http.MethodGet, "/authors/"+created.UUID, "")
+	wantProblem(t, w, http.StatusNotFound, "not-found", "author not found")
+}
+
+func TestAuthorErrors(t *testing.T) {
+	r, stores := newTestRouter()
+	author := mustAuthor(t, stores, "George Orwell")
+	if w := serve(r, http.MethodPost, "/books", fmt.Sprintf(`{"title":"1984","authorIds":[%d]}`, author.ID)); w.Code != http.StatusCreated {
+		t.Fatalf("create book: status %d, body %s", w.Code, w.Body.String())
+	}
+	missing := "00000000-0000-4000-8000-000000000000"
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		status       int
+		slug, detail string
+		fields       []domain.FieldError
+	}{
+		{"get with bad UUID", http.MethodGet, "/authors/abc", "",
+			http.StatusBadRequest, "validation", "invalid UUID",
+			[]domain.FieldError{{Field: "uuid", Message: "must be a UUID"}}},
+		{"get missing", http.MethodGet, "/authors/" + missing, "",
+			http.StatusNotFound, "not-found", "author not found", nil},
+		{"put without name", http.MethodPut, "/authors/" + author.UUID, `{"bio":"x"}`,
+			http.StatusBadRequest, "validation", "request body failed validation",
+			[]domain.FieldError{{Field: "name", Message: "is required"}}},
+		{"patch with short name", http.MethodPatch, "/authors/" + author.UUID, `{"name":"G"}`,
+			http.StatusBadRequest, "validation", "request body failed validation",
+			[]domain.FieldError{{Field: "name", Message: "must be at least 2 characters long"}}},
+		{"patch missing", http.MethodPatch, "/authors/" + missing, `{"bio":"x"}`,
+			http.StatusNotFound, "not-found", "author not found", nil},
+		{"delete linked", http.MethodDelete, "/authors/" + author.UUID, "",
+			http.StatusConflict, "conflict", "author is still linked to books", nil},
+		{"delete with bad cascade", http.MethodDelete, "/authors/" + author.UUID + "?cascade=all", "",
+			http.StatusBadRequest, "validation", "invalid cascade mode",
+			[]domain.FieldError{{Field: "cascade", Message: "must be 'unlink'"}}},
+		{"delete missing", http.MethodDelete, "/authors/" + missing, "",
+			http.StatusNotFound, "not-found", "author not found", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, tt.method, tt.path, tt.body)
+			wantProblem(t, w, tt.status, tt.slug, tt.detail, tt.fields...)
+		})
+	}
+
+	// Com cascade=unlink o autor sai dos livros
+	if w := serve(r, http.MethodDelete, "/authors/"+author.UUID+"?cascade=unlink", ""); w.Code != http.StatusNoContent {
+		t.Errorf("delete with unlink: status %d, body %s", w.Code, w.Body.String())
+	}
+}
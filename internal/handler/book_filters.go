@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+const (
+	defaultFacetLimit = 10
+	maxFacetLimit     = 100
+)
+
+// parseBookFilters lê os filtros e a ordenação da listagem de livros, que
+// valem também para a exportação. formatParam é o nome do filtro por
+// formato do livro ("format" na listagem; na exportação "format" é o
+// formato do arquivo).
+func parseBookFilters(c *gin.Context, formatParam string) (domain.BookFilters, error) {
+	filters := domain.BookFilters{
+		Title:         c.Query("title"),
+		AuthorName:    c.Query("author"),
+		Sort:          c.DefaultQuery("sort", "title"),
+		SortDirection: strings.ToUpper(c.DefaultQuery("sort_dir", "ASC")),
+	}
+
+	// Validate sort
+	if !slices.Contains([]string{"title", "created_at"}, filters.Sort) {
+		return filters, domain.ValidationError("invalid sort field",
+			domain.FieldError{Field: "sort", Message: "must be one of: title, created_at"})
+	}
+	if !slices.Contains([]string{"ASC", "DESC"}, filters.SortDirection) {
+		return filters, domain.ValidationError("invalid sort direction",
+			domain.FieldError{Field: "sort_dir", Message: "must be one of: ASC, DESC"})
+	}
+
+	if err := parseCatalogFilters(c, &filters, formatParam); err != nil {
+		return filters, err
+	}
+	if err := parseFacetFilters(c, &filters); err != nil {
+		return filters, err
+	}
+	return filters, nil
+}
+
+// parseFacetFilters lê os filtros de faceta repetíveis (author_uuid,
+// subject, language, decade e availability) da query string
+func parseFacetFilters(c *gin.Context, filters *domain.BookFilters) error {
+	var fields []domain.FieldError
+	values := func(name string) []string {
+		var out []string
+		for _, v := range c.QueryArray(name) {
+			if v = strings.TrimSpace(v); v != "" && !slices.Contains(out, v) {
+				out = append(out, v)
+			}
+		}
+		if len(out) > domain.MaxFacetFilterValues {
+			fields = append(fields, domain.FieldError{Field: name,
+				Message: fmt.Sprintf("must be given at most %d times", domain.MaxFacetFilterValues)})
+			return nil
+		}
+		return out
+	}
+
+	filters.AuthorUUIDs = values("author_uuid")
+	for _, u := range filters.AuthorUUIDs {
+		if !isValidUUID(u) {
+			fields = append(fields, domain.FieldError{Field: "author_uuid", Message: "must be a valid UUID"})
+			break
+		}
+	}
+	filters.Subjects = values("subject")
+	filters.Languages = values("language")
+	for _, d := range values("decade") {
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 0 || n%10 != 0 {
+			fields = append(fields, domain.FieldError{Field: "decade", Message: "must be a year ending in 0, e.g. 1990"})
+			break
+		}
+		filters.Decades = append(filters.Decades, n)
+	}
+	filters.Availability = values("availability")
+	for _, status := range filters.Availability {
+		if !slices.Contains(domain.ItemStatuses, status) {
+			fields = append(fields, domain.FieldError{Field: "availability",
+				Message: "must be one of: " + strings.Join(domain.ItemStatuses, ", ")})
+			break
+		}
+	}
+
+	if len(fields) > 0 {
+		return domain.ValidationError("invalid facet filter", fields...)
+	}
+	return nil
+}
+
+// facetScope descreve os filtros de faceta para o escopo do cursor
+func facetScope(filters domain.BookFilters) string {
+	decades := make([]string, len(filters.Decades))
+	for i, d := range filters.Decades {
+		decades[i] = strconv.Itoa(d)
+	}
+	parts := [][]string{filters.AuthorUUIDs, filters.Subjects, filters.Languages, decades, filters.Availability}
+	out := make([]string, len(parts))
+	for i, p := range parts {
+		p = slices.Clone(p)
+		slices.Sort(p)
+		out[i] = strings.Join(p, "\x01")
+	}
+	return strings.Join(out, "\x00")
+}
+
+// parseCatalogFilters lê os filtros bibliográficos: isbn, publisher,
+// formatParam (repetível), year_from e year_to
+func parseCatalogFilters(c *gin.Context, filters *domain.BookFilters, formatParam string) error {
+	var fields []domain.FieldError
+
+	if raw := strings.TrimSpace(c.Query("isbn")); raw != "" {
+		isbn, err := domain.NormalizeISBN(raw)
+		if err != nil {
+			fields = append(fields, domain.FieldError{Field: "isbn", Message: err.Error()})
+		}
+		filters.ISBN = isbn
+	}
+	filters.Publisher = strings.TrimSpace(c.Query("publisher"))
+	for _, f := range c.QueryArray(formatParam) {
+		if !slices.Contains(domain.BookFormats, f) {
+			fields = append(fields, domain.FieldError{Field: formatParam,
+				Message: "must be one of: " + strings.Join(domain.BookFormats, ", ")})
+			break
+		}
+		if !slices.Contains(filters.Formats, f) {
+			filters.Formats = append(filters.Formats, f)
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  **int
+	}{{"year_from", &filters.YearFrom}, {"year_to", &filters.YearTo}} {
+		if c.Query(p.name) == "" {
+			continue
+		}
+		year, err := intQuery(c, p.name, 0)
+		if err != nil {
+			fields = append(fields, domain.FieldError{Field: p.name, Message: "must be an integer"})
+			continue
+		}
+		*p.dst = &year
+	}
+
+	if len(fields) > 0 {
+		return domain.ValidationError("invalid filter", fields...)
+	}
+	return nil
+}
+
+// catalogScope descreve os filtros bibliográficos para o escopo do cursor
+func catalogScope(filters domain.BookFilters) string {
+	year := func(y *int) string {
+		if y == nil {
+			return ""
+		}
+		return strconv.Itoa(*y)
+	}
+	formats := slices.Sorted(slices.Values(filters.Formats))
+	return strings.Join([]string{filters.ISBN, filters.Publisher, strings.Join(formats, "\x01"),
+		year(filters.YearFrom), year(filters.YearTo)}, "\x00")
+}
@@ -2,26 +2,31 @@ package handler
 
 import (
 	"net/http"
-	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/patrick-tondorf/lib_api/internal/domain"
-	"github.com/patrick-tondorf/lib_api/internal/repository"
+	"github.com/patrick-tondorf/lib_api/internal/pagination"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
 )
 
 // BookHandler defines the book handler methods
 type BookHandler struct {
-	Repo *repository.BookRepository
+	Repo    storage.BookStore
+	items   storage.ItemStore
+	cursors *pagination.Codec
 }
 
 // NewBookHandler creates a new BookHandler.
-func NewBookHandler(repo *repository.BookRepository) *BookHandler {
-	return &BookHandler{Repo: repo}
+func NewBookHandler(repo storage.BookStore, items storage.ItemStore, cursors *pagination.Codec) *BookHandler {
+	return &BookHandler{Repo: repo, items: items, cursors: cursors}
 }
 
 // CreateBook godoc
 // @Summary Create a new book
-// @Description Add a new book to the library
+// @Description Add a new book to the library. The response is the stored book, with its UUID and authors.
 // @Tags books
 // @Security BearerAuth
 // @Accept  json
@@ -29,16 +34,17 @@ func NewBookHandler(repo *repository.BookRepository) *BookHandler {
 // @Param   book  body  domain.BookCreateRequest  true  "Book data"
 // @Example request({"title":"1984","description":"A dystopian novel","authors":[{"id":1},{"id":2}]})
 // @Success 201 {object} domain.Book
-// @Failure 400 {object} map[string]string "Invalid input"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 500 {object} domain.Problem "Internal server error"
 // @Router /books [post]
 func (h *BookHandler) CreateBook(c *gin.Context) {
 	var book domain.BookCreateRequest
 	if err := c.ShouldBindJSON(&book); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Validation failed",
-			"details": err.Error(), // Mostra detalhes do erro de validação
-		})
+		abort(c, bindError(err))
+		return
+	}
+	if err := book.Normalize(); err != nil {
+		abort(c, err)
 		return
 	}
 	/*	var book domain.Book
@@ -47,113 +53,255 @@ func (h *BookHandler) CreateBook(c *gin.Context) {
 			return
 		}*/
 
-	if err := h.Repo.CreateBook(c.Request.Context(), book); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create book"})
+	created, err := h.Repo.CreateBook(c.Request.Context(), book)
+	if err != nil {
+		abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, book)
+	c.JSON(http.StatusCreated, created)
 }
 
 // GetBooks godoc
 // @Summary List books with pagination and filters
 // @Description Get paginated list of books with optional filters. Choose between basic version or with authors.
+// @Description Pages can be requested by number (page) or by following next_cursor/prev_cursor; cursor mode skips the total count and has no depth limit.
+// @Description A cursor is only valid with the same filters and sort it was issued for.
+// @Description Each book reports the availability of its items (copies) by status; total leaves out withdrawn items, and copies set aside for a ready hold count as onHold, not available.
+// @Description With facets=true the response carries counts by author, subject, language, publication decade and item status (books with at least one item in it) over the whole filtered set.
+// @Description Facet values are passed back as repeatable filters (author_uuid, subject, language, decade, availability): values of one facet are ORed, different facets are ANDed.
 // @Tags books
 // @Security BearerAuth
 // @Produce json
 // @Param title        query string  false "Filter by book title (partial match, case insensitive)"
 // @Param author       query string  false "Filter by author name (only works when with_authors=true)"
 // @Param with_authors query boolean false "Include full author information in response"
+// @Param isbn         query string  false "Filter by ISBN-10 or ISBN-13 (exact edition)"
+// @Param publisher    query string  false "Filter by publisher (partial match, case insensitive)"
+// @Param format       query []string false "Filter by format (repeatable)" Enums(hardcover, paperback, ebook, audiobook) collectionFormat(multi)
+// @Param year_from    query int     false "Published in or after this year"
+// @Param year_to      query int     false "Published in or before this year"
+// @Param author_uuid  query []string false "Filter by author UUID (facet, repeatable)" collectionFormat(multi)
+// @Param subject      query []string false "Filter by subject (facet, repeatable)" collectionFormat(multi)
+// @Param language     query []string false "Filter by language (facet, repeatable)" collectionFormat(multi)
+// @Param decade       query []int    false "Filter by publication decade, e.g. 1990 (facet, repeatable)" collectionFormat(multi)
+// @Param availability query []string false "Filter by books with an item in this status (facet, repeatable)" Enums(available, on_loan, in_transit, lost, withdrawn) collectionFormat(multi)
+// @Param facets       query boolean false "Include facet counts"
+// @Param facet_limit  query int     false "Values per facet" default(10) minimum(1) maximum(100)
 // @Param sort         query string  false "Sort field" Enums(title, created_at) default(title)
 // @Param sort_dir     query string  false "Sort direction" Enums(ASC, DESC) default(ASC)
-// @Param page         query int     false "Page number" default(1) minimum(1)
+// @Param page         query int     false "Page number (offset mode)" default(1) minimum(1) maximum(1000)
+// @Param cursor       query string  false "Opaque cursor from next_cursor or prev_cursor (cursor mode)"
 // @Param limit        query int     false "Items per page" default(10) minimum(1) maximum(100)
-// @Success 200 {object} BookListResponse
-// @Failure 400 {object} ErrorResponse "Invalid parameters"
-// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Success 200 {object} domain.BookListResponse
+// @Failure 400 {object} domain.Problem "Invalid parameters"
+// @Failure 500 {object} domain.Problem "Internal server error"
 // @Router /books [get]
 func (h *BookHandler) GetBooks(c *gin.Context) {
-	// Parse common filters
-	filters := domain.BookFilters{
-		Title:         c.Query("title"),
-		AuthorName:    c.Query("author"),
-		Sort:          c.DefaultQuery("sort", "title"),
-		SortDirection: c.DefaultQuery("sort_dir", "ASC"),
-		Limit:         clamp(c.GetInt("limit"), 1, 100),
-		Offset:        (clamp(c.GetInt("page"), 1, 1000) - 1) * clamp(c.GetInt("limit"), 1, 100),
+	filters, err := parseBookFilters(c, "format")
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	withAuthors := c.Query("with_authors") == "true"
+	withFacets := c.Query("facets") == "true"
+	facetLimit, err := intQuery(c, "facet_limit", defaultFacetLimit)
+	if err != nil {
+		abort(c, err)
+		return
 	}
 
-	// Validate sort
-	if !slices.Contains([]string{"title", "created_at"}, filters.Sort) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort field"})
+	// O cursor fica preso aos filtros e à ordenação em que foi emitido
+	scope := strings.Join([]string{"books", filters.Sort, filters.SortDirection,
+		filters.Title, filters.AuthorName, strconv.FormatBool(withAuthors), facetScope(filters),
+		catalogScope(filters)}, "\x00")
+	req, page, err := pageRequest(c, h.cursors, scope)
+	if err != nil {
+		abort(c, err)
 		return
 	}
+	filters.Limit = req.FetchLimit()
+	filters.Offset = req.Offset
+	filters.Keyset = req.Keyset
 
 	// Choose repository method based on query param
 	var (
 		books []domain.Book
 		total int
-		err   error
 	)
 
-	if c.Query("with_authors") == "true" {
+	if withAuthors {
 		books, total, err = h.Repo.GetBooksWithAuthors(c.Request.Context(), filters)
 	} else {
 		books, total, err = h.Repo.GetBooksBasic(c.Request.Context(), filters)
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abort(c, err)
+		return
+	}
+
+	books, next, prev := pagination.Window(books, req, total, bookKeyset(filters.Sort))
+	if books == nil {
+		books = []domain.Book{}
+	}
+	if err := h.attachAvailability(c, books); err != nil {
+		abort(c, err)
 		return
 	}
+	resp := domain.BookListResponse{
+		Data:       books,
+		Limit:      req.Limit,
+		NextCursor: encodeCursor(h.cursors, scope, next),
+		PrevCursor: encodeCursor(h.cursors, scope, prev),
+	}
+	if req.Keyset == nil {
+		resp.Total = &total
+		resp.Page = &page
+	}
 
-	c.JSON(http.StatusOK, domain.BookListResponse{
-		Data:  books,
-		Total: total,
-		Page:  filters.Offset/filters.Limit + 1,
-		Limit: filters.Limit,
-	})
+	if withFacets {
+		// Sem with_authors o filtro por nome de autor não vale para a
+		// listagem, então também não vale para as facetas
+		if !withAuthors {
+			filters.AuthorName = ""
+		}
+		resp.Facets, err = h.Repo.GetBookFacets(c.Request.Context(), filters, clamp(facetLimit, 1, maxFacetLimit))
+		if err != nil {
+			abort(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// bookKeyset extrai do livro a chave da ordenação usada na listagem
+func bookKeyset(sort string) func(domain.Book) domain.Keyset {
+	return func(b domain.Book) domain.Keyset {
+		k := domain.Keyset{Value: b.Title, ID: b.ID}
+		if sort == "created_at" && b.CreatedAt != nil {
+			k.Value = b.CreatedAt.UTC().Format(time.RFC3339Nano)
+		}
+		return k
+	}
+}
+
+// attachAvailability preenche a disponibilidade dos exemplares de cada livro
+func (h *BookHandler) attachAvailability(c *gin.Context, books []domain.Book) error {
+	ids := make([]int, len(books))
+	for i, b := range books {
+		ids[i] = b.ID
+	}
+	counts, err := h.items.GetAvailability(c.Request.Context(), ids)
+	if err != nil {
+		return err
+	}
+	for i := range books {
+		a := counts[books[i].ID]
+		books[i].Availability = &a
+	}
+	return nil
+}
+
+// GetBook godoc
+// @Summary Get a book by UUID
+// @Description Retrieve a book, with its authors and the availability of its items, by its UUID
+// @Tags books
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Book UUID"
+// @Success 200 {object} domain.Book
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 404 {object} domain.Problem "Book not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /books/{uuid} [get]
+func (h *BookHandler) GetBook(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	book, err := h.Repo.GetBookByUUID(c.Request.Context(), uuid)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	books := []domain.Book{*book}
+	if err := h.attachAvailability(c, books); err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, books[0])
 }
 
 // UpdateBook godoc
 // @Summary Update a book
-// @Description Update book details by ID
+// @Description Replace title, description and authors of a book by UUID
 // @Tags books
 // @Security BearerAuth
 // @Accept  json
 // @Produce  json
-// @Param   id    path  int         true  "Book ID"
-// @Param   book  body  domain.Book true  "Updated book data"
+// @Param   uuid  path  string                    true  "Book UUID"
+// @Param   book  body  domain.BookUpdateRequest  true  "Updated book data"
 // @Success 200 {object} domain.Book
-// @Failure 400 {object} map[string]string "Invalid input"
-// @Failure 404 {object} map[string]string "Book not found"
-// @Router /books/{id} [put]
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 404 {object} domain.Problem "Book not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /books/{uuid} [put]
 func (h *BookHandler) UpdateBook(c *gin.Context) {
-	// Implementação...
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	var req domain.BookUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+	if err := req.Normalize(); err != nil {
+		abort(c, err)
+		return
+	}
+
+	book, err := h.Repo.UpdateBook(c.Request.Context(), uuid, req)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, book)
 }
 
-// GetBook godoc
-// @Summary Get a book by ID
-// @Description Retrieve a book by its ID
+// DeleteBook godoc
+// @Summary Delete a book
+// @Description Remove a book and its author relations by UUID. A book that still has items cannot be deleted.
 // @Tags books
 // @Security BearerAuth
-// @Accept json
-// @Produce json
-// @Param id path int true "Book ID"
-// @Success 200 {object} domain.Book
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /books/{id} [get]
-func (h *BookHandler) GetBook(c *gin.Context) {
-	/*	id := c.Param("id")
+// @Param uuid path string true "Book UUID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 404 {object} domain.Problem "Book not found"
+// @Failure 409 {object} domain.Problem "Book still has items"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /books/{uuid} [delete]
+func (h *BookHandler) DeleteBook(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
 
-		book, err := h.Repo.GetBookByID(c.Request.Context(), id)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
-			return
-		}
+	if err := h.Repo.DeleteBook(c.Request.Context(), uuid); err != nil {
+		abort(c, err)
+		return
+	}
 
-		c.JSON(http.StatusOK, book)*/
+	c.Status(http.StatusNoContent)
 }
 
 // Helper function to clamp values
@@ -166,3 +314,23 @@ func clamp(value, min, max int) int {
 	}
 	return value
 }
+
+// isValidUUID checks the canonical 8-4-4-4-12 hexadecimal UUID form
+func isValidUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return false
+			}
+		}
+	}
+	return true
+}
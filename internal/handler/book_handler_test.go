@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// mustAuthor cadastra um autor direto no store; o id não sai na API
+func mustAuthor(t *testing.T, stores storage.Stores, name string) *domain.Author {
+	t.Helper()
+	author := &domain.Author{Name: name}
+	if err := stores.Authors.CreateAuthor(context.Background(), author); err != nil {
+		t.Fatalf("create author %q: %v", name, err)
+	}
+	return author
+}
+
+func TestBookCRUD(t *testing.T) {
+	r, stores := newTestRouter()
+	orwell := mustAuthor(t, stores, "George Orwell")
+	huxley := mustAuthor(t, stores, "Aldous Huxley")
+
+	w := serve(r, http.MethodPost, "/books",
+		fmt.Sprintf(`{"title":"1984","description":"A dystopian novel","isbn":"978-0-451-52493-5","authorIds":[%d,%d]}`, orwell.ID, orwell.ID))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body.String())
+	}
+	var created domain.Book
+	decode(t, w, &created)
+	if !isValidUUID(created.UUID) || created.Title != "1984" || created.ISBN != "9780451524935" {
+		t.Errorf("create: got %+v", created)
+	}
+	if len(created.Authors) != 1 || created.Authors[0].Name != "George Orwell" {
+		t.Errorf("create: authors = %+v, want George Orwell once", created.Authors)
+	}
+
+	w = serve(r, http.MethodGet, "/books/"+created.UUID, "")
+	var got domain.Book
+	decode(t, w, &got)
+	if w.Code != http.StatusOK || got.UUID != created.UUID || got.Availability == nil {
+		t.Errorf("get: status %d, book %+v", w.Code, got)
+	}
+
+	w = serve(r, http.MethodPut, "/books/"+created.UUID,
+		fmt.Sprintf(`{"title":"Nineteen Eighty-Four","authorIds":[%d,%d]}`, huxley.ID, orwell.ID))
+	var updated domain.Book
+	decode(t, w, &updated)
+	if w.Code != http.StatusOK || updated.Title != "Nineteen Eighty-Four" || len(updated.Authors) != 2 || updated.Authors[0].Name != "Aldous Huxley" {
+		t.Errorf("update: status %d, book %+v", w.Code, updated)
+	}
+
+	w = serve(r, http.MethodGet, "/books?title=eighty", "")
+	var list domain.BookListResponse
+	decode(t, w, &list)
+	if w.Code != http.StatusOK || list.Total == nil || *list.Total != 1 || len(list.Data) != 1 {
+		t.Errorf("list: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	if w := serve(r, http.MethodDelete, "/books/"+created.UUID, ""); w.Code != http.StatusNoContent {
+		t.Errorf("delete: status %d, body %s", w.Code, w.Body.String())
+	}
+	w = serve(r, http.MethodGet, "/books/"+created.UUID, "")
+	wantProblem(t, w, http.StatusNotFound, "not-found", "book not found")
+}
+
+func TestBookErrors(t *testing.T) {
+	r, stores := newTestRouter()
+	author := mustAuthor(t, stores, "George Orwell")
+	book := fmt.Sprintf(`{"title":"1984","isbn":"9780451524935","authorIds":[%d]}`, author.ID)
+	w := serve(r, http.MethodPost, "/books", book)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body.String())
+	}
+	var created domain.Book
+	decode(t, w, &created)
+	missing := "00000000-0000-4000-8000-000000000000"
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		status       int
+		slug, detail string
+		fields       []domain.FieldError
+	}{
+		{"create without title", http.MethodPost, "/books", fmt.Sprintf(`{"authorIds":[%d]}`, author.ID),
+			http.StatusBadRequest, "validation", "request body failed validation",
+			[]domain.FieldError{{Field: "title", Message: "is required"}}},
+		{"create without authors", http.MethodPost, "/books", `{"title":"Dune","authorIds":[]}`,
+			http.StatusBadRequest, "validation", "at least one author ID is required",
+			[]domain.FieldError{{Field: "authorIds", Message: "must contain at least one author"}}},
+		{"create with unknown author", http.MethodPost, "/books", `{"title":"Dune","authorIds":[99]}`,
+			http.StatusBadRequest, "validation", "one or more authors do not exist",
+			[]domain.FieldError{{Field: "authorIds", Message: "author 99 does not exist"}}},
+		{"create with bad ISBN", http.MethodPost, "/books", fmt.Sprintf(`{"title":"Dune","isbn":"123","authorIds":[%d]}`, author.ID),
+			http.StatusBadRequest, "validation", "invalid book metadata",
+			[]domain.FieldError{{Field: "isbn", Message: "must have 10 or 13 digits"}}},
+		{"create with wrong type", http.MethodPost, "/books", `{"title":"Dune","authorIds":"1"}`,
+			http.StatusBadRequest, "validation", "request body failed validation",
+			[]domain.FieldError{{Field: "authorIds", Message: "must be of type []int"}}},
+		{"create with malformed JSON", http.MethodPost, "/books", `{"title":`,
+			http.StatusBadRequest, "validation", "malformed JSON request body", nil},
+		{"create with taken ISBN", http.MethodPost, "/books", book,
+			http.StatusConflict, "conflict", "a book with this ISBN already exists", nil},
+		{"get with bad UUID", http.MethodGet, "/books/42", "",
+			http.StatusBadRequest, "validation", "invalid UUID",
+			[]domain.FieldError{{Field: "uuid", Message: "must be a UUID"}}},
+		{"get missing", http.MethodGet, "/books/" + missing, "",
+			http.StatusNotFound, "not-found", "book not found", nil},
+		{"update missing", http.MethodPut, "/books/" + missing, fmt.Sprintf(`{"title":"Dune","authorIds":[%d]}`, author.ID),
+			http.StatusNotFound, "not-found", "book not found", nil},
+		{"update with short title", http.MethodPut, "/books/" + created.UUID, fmt.Sprintf(`{"title":"D","authorIds":[%d]}`, author.ID),
+			http.StatusBadRequest, "validation", "request body failed validation",
+			[]domain.FieldError{{Field: "title", Message: "must be at least 2 characters long"}}},
+		{"delete missing", http.MethodDelete, "/books/" + missing, "",
+			http.StatusNotFound, "not-found", "book not found", nil},
+		{"list with bad sort", http.MethodGet, "/books?sort=pages", "",
+			http.StatusBadRequest, "validation", "invalid sort field",
+			[]domain.FieldError{{Field: "sort", Message: "must be one of: title, created_at"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, tt.method, tt.path, tt.body)
+			wantProblem(t, w, tt.status, tt.slug, tt.detail, tt.fields...)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/citation"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// CitationHandler gera as citações bibliográficas dos livros
+type CitationHandler struct {
+	books storage.BookStore
+}
+
+// NewCitationHandler creates a new CitationHandler.
+func NewCitationHandler(books storage.BookStore) *CitationHandler {
+	return &CitationHandler{books: books}
+}
+
+// CiteBook godoc
+// @Summary Cite a book
+// @Description Returns the citation of a book. bibtex, ris and csljson (always an array) are meant for reference managers; apa (7th ed.), mla (9th ed.) and abnt (NBR 6023:2018) are formatted references in plain text.
+// @Description Authors are cited in authorship order (the order of authorIds). "et al." follows each style: APA lists up to 20 authors, MLA abbreviates from three and ABNT from four. Names are split on the last word unless given inverted ("García Márquez, Gabriel").
+// @Tags books
+// @Security BearerAuth
+// @Produce text/plain
+// @Produce application/x-bibtex
+// @Produce application/x-research-info-systems
+// @Produce application/vnd.citationstyles.csl+json
+// @Param uuid   path  string true  "Book UUID"
+// @Param format query string false "Citation format" Enums(bibtex, ris, csljson, apa, mla, abnt) default(apa)
+// @Success 200 {string} string "Citation"
+// @Failure 400 {object} domain.Problem "Invalid UUID or format"
+// @Failure 404 {object} domain.Problem "Book not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /books/{uuid}/cite [get]
+func (h *CitationHandler) CiteBook(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+	format := c.DefaultQuery("format", citation.FormatAPA)
+	if !slices.Contains(citation.Formats, format) {
+		abort(c, invalidCitationFormat())
+		return
+	}
+
+	book, err := h.books.GetBookByUUID(c.Request.Context(), uuid)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.write(c, format, uuid, []domain.Book{*book})
+}
+
+// CiteBooks godoc
+// @Summary Cite several books
+// @Description Returns the citations of up to 100 books, in the order of the UUIDs (repeated UUIDs are cited once), in one of the formats of GET /books/{uuid}/cite. BibTeX keys that repeat get a, b... suffixes.
+// @Tags books
+// @Security BearerAuth
+// @Accept json
+// @Produce text/plain
+// @Produce application/x-bibtex
+// @Produce application/x-research-info-systems
+// @Produce application/vnd.citationstyles.csl+json
+// @Param request body domain.CitationRequest true "Books and format"
+// @Success 200 {string} string "Citations"
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 404 {object} domain.Problem "A book was not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /books/cite [post]
+func (h *CitationHandler) CiteBooks(c *gin.Context) {
+	var req domain.CitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	books := make([]domain.Book, 0, len(req.UUIDs))
+	seen := make(map[string]bool, len(req.UUIDs))
+	for _, uuid := range req.UUIDs {
+		uuid = strings.ToLower(uuid)
+		if seen[uuid] {
+			continue
+		}
+		seen[uuid] = true
+
+		book, err := h.books.GetBookByUUID(ctx, uuid)
+		if err != nil {
+			if errors.Is(err, domain.ErrBookNotFound) {
+				err = domain.NotFoundError("book " + uuid + " not found").Wrap(err)
+			}
+			abort(c, err)
+			return
+		}
+		books = append(books, *book)
+	}
+	h.write(c, req.Format, "citations", books)
+}
+
+// write gera as citações em memória, para que uma falha ainda possa virar
+// uma resposta de erro
+func (h *CitationHandler) write(c *gin.Context, format, name string, books []domain.Book) {
+	var buf bytes.Buffer
+	if err := citation.Write(&buf, format, books); err != nil {
+		abort(c, err)
+		return
+	}
+	mime, ext := citation.ContentType(format)
+	c.Header("Content-Disposition", `inline; filename="`+name+"."+ext+`"`)
+	c.Data(http.StatusOK, mime, buf.Bytes())
+}
+
+func invalidCitationFormat() error {
+	return domain.ValidationError("invalid citation format",
+		domain.FieldError{Field: "format", Message: "must be one of: " + strings.Join(citation.Formats, ", ")})
+}
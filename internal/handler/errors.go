@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/validation"
+)
+
+// abort registra o erro para o middleware de problemas e interrompe a cadeia
+func abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// bindError converte erros de ShouldBindJSON em um erro de validação com a
+// lista de campos inválidos
+func bindError(err error) error {
+	if fields, ok := validation.FieldErrors(err); ok {
+		return domain.ValidationError("request body failed validation", fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return domain.ValidationError("request body failed validation",
+			domain.FieldError{Field: typeErr.Field, Message: "must be of type " + typeErr.Type.String()})
+	}
+	return domain.ValidationError("malformed JSON request body").Wrap(err)
+}
+
+// invalidUUID é o erro padrão para parâmetros de rota que não são UUID
+func invalidUUID(param string) error {
+	return domain.ValidationError("invalid UUID",
+		domain.FieldError{Field: param, Message: "must be a UUID"})
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/middleware"
+)
+
+func TestProblemRendering(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		title  string
+		slug   string
+		detail string
+		fields []domain.FieldError
+	}{
+		{"validation", domain.ValidationError("invalid filter", domain.FieldError{Field: "status", Message: "must be one of: a, b"}),
+			http.StatusBadRequest, "Bad Request", "validation", "invalid filter",
+			[]domain.FieldError{{Field: "status", Message: "must be one of: a, b"}}},
+		{"invalid UUID", invalidUUID("bookUuid"),
+			http.StatusBadRequest, "Bad Request", "validation", "invalid UUID",
+			[]domain.FieldError{{Field: "bookUuid", Message: "must be a UUID"}}},
+		{"not found", domain.ErrBookNotFound,
+			http.StatusNotFound, "Not Found", "not-found", "book not found", nil},
+		{"wrapped not found keeps the cause out", domain.ErrLoanNotFound.Wrap(errors.New("no rows in result set")),
+			http.StatusNotFound, "Not Found", "not-found", "loan not found", nil},
+		{"conflict", domain.ErrISBNExists,
+			http.StatusConflict, "Conflict", "conflict", "a book with this ISBN already exists", nil},
+		{"forbidden", domain.ErrNotOwnLoan,
+			http.StatusForbidden, "Forbidden", "forbidden", "loan belongs to another patron", nil},
+		{"too large", domain.TooLargeError("file is larger than 10 MB"),
+			http.StatusRequestEntityTooLarge, "Request Entity Too Large", "too-large", "file is larger than 10 MB", nil},
+		{"unknown error", errors.New("pq: password authentication failed for user \"lib\""),
+			http.StatusInternalServerError, "Internal Server Error", "internal", "an unexpected error occurred", nil},
+		{"bare category without a domain error", fmt.Errorf("insert: %w", domain.ErrConflict),
+			http.StatusInternalServerError, "Internal Server Error", "internal", "an unexpected error occurred", nil},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.RequestID(logger), middleware.ErrorHandler())
+			r.GET("/fail", func(c *gin.Context) { abort(c, tt.err) })
+
+			w := serve(r, http.MethodGet, "/fail", "")
+			wantProblem(t, w, tt.status, tt.slug, tt.detail, tt.fields...)
+			var p domain.Problem
+			decode(t, w, &p)
+			if p.Title != tt.title {
+				t.Errorf("title = %q, want %q", p.Title, tt.title)
+			}
+			if p.Instance != "/fail" || p.RequestID == "" || p.RequestID != w.Header().Get(middleware.RequestIDHeader) {
+				t.Errorf("instance %q, requestId %q (header %q)", p.Instance, p.RequestID, w.Header().Get(middleware.RequestIDHeader))
+			}
+			if strings.Contains(w.Body.String(), "pq:") || strings.Contains(w.Body.String(), "no rows") {
+				t.Errorf("cause leaked to the client: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUnauthorizedProblem(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/fail", func(c *gin.Context) { abort(c, domain.UnauthorizedError("token has expired")) })
+
+	w := serve(r, http.MethodGet, "/fail", "")
+	wantProblem(t, w, http.StatusUnauthorized, "unauthorized", "token has expired")
+	if got := w.Header().Get("WWW-Authenticate"); got != `Bearer realm="lib-api"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+}
+
+func TestBindError(t *testing.T) {
+	type request struct {
+		Title   string `json:"title" binding:"required,min=2"`
+		Pages   int    `json:"pages" binding:"gte=1"`
+		Authors []int  `json:"authorIds" binding:"required,min=1"`
+	}
+	tests := []struct {
+		name   string
+		body   string
+		detail string
+		fields []domain.FieldError
+	}{
+		{"missing and invalid fields", `{"title":"x","pages":0}`, "request body failed validation", []domain.FieldError{
+			{Field: "title", Message: "must be at least 2 characters long"},
+			{Field: "pages", Message: "must be greater than or equal to 1"},
+			{Field: "authorIds", Message: "is required"},
+		}},
+		{"wrong type", `{"title":"Dune","pages":"many","authorIds":[1]}`, "request body failed validation",
+			[]domain.FieldError{{Field: "pages", Message: "must be of type int"}}},
+		{"malformed JSON", `{"title":"Dune",`, "malformed JSON request body", nil},
+		{"empty body", ``, "malformed JSON request body", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.ErrorHandler())
+			r.POST("/bind", func(c *gin.Context) {
+				var req request
+				if err := c.ShouldBindJSON(&req); err != nil {
+					abort(c, bindError(err))
+					return
+				}
+				c.Status(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/bind", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			wantProblem(t, w, http.StatusBadRequest, "validation", tt.detail, tt.fields...)
+		})
+	}
+}
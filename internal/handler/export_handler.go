@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/export"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// exportTimeout substitui o timeout de escrita do servidor durante uma
+// exportação, que pode levar minutos em catálogos grandes
+const exportTimeout = 30 * time.Minute
+
+// ExportHandler atende a exportação do catálogo
+type ExportHandler struct {
+	books storage.BookStore
+}
+
+// NewExportHandler creates a new ExportHandler.
+func NewExportHandler(books storage.BookStore) *ExportHandler {
+	return &ExportHandler{books: books}
+}
+
+// ExportBooks godoc
+// @Summary Export the catalog
+// @Description Streams every book matching the filters, with its authors, as a file download. Accepts the filters and sort of GET /books; as in the listing, the author filter keeps only the matching authors of each book.
+// @Description csv and jsonl use the columns and field names accepted by POST /imports, so an export can be imported again; marcxml writes MARC 21 bibliographic records (MARC21/slim).
+// @Tags exports
+// @Security BearerAuth
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/marcxml+xml
+// @Param format      query string   false "File format" Enums(csv, jsonl, marcxml) default(csv)
+// @Param title       query string   false "Filter by book title (partial match, case insensitive)"
+// @Param author      query string   false "Filter by author name"
+// @Param isbn        query string   false "Filter by ISBN-10 or ISBN-13 (exact edition)"
+// @Param publisher   query string   false "Filter by publisher (partial match, case insensitive)"
+// @Param book_format query []string false "Filter by book format (repeatable)" Enums(hardcover, paperback, ebook, audiobook) collectionFormat(multi)
+// @Param year_from   query int      false "Published in or after this year"
+// @Param year_to     query int      false "Published in or before this year"
+// @Param author_uuid query []string false "Filter by author UUID (repeatable)" collectionFormat(multi)
+// @Param subject     query []string false "Filter by subject (repeatable)" collectionFormat(multi)
+// @Param language    query []string false "Filter by language (repeatable)" collectionFormat(multi)
+// @Param decade      query []int    false "Filter by publication decade, e.g. 1990 (repeatable)" collectionFormat(multi)
+// @Param availability query []string false "Filter by books with an item in this status (repeatable)" Enums(available, on_loan, in_transit, lost, withdrawn) collectionFormat(multi)
+// @Param sort        query string   false "Sort field" Enums(title, created_at) default(title)
+// @Param sort_dir    query string   false "Sort direction" Enums(ASC, DESC) default(ASC)
+// @Success 200 {file} file "Catalog file"
+// @Failure 400 {object} domain.Problem "Invalid parameters"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /exports/books [get]
+func (h *ExportHandler) ExportBooks(c *gin.Context) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if !slices.Contains(export.Formats, format) {
+		abort(c, domain.ValidationError("invalid export format",
+			domain.FieldError{Field: "format", Message: "must be one of: csv, jsonl, marcxml"}))
+		return
+	}
+
+	filters, err := parseBookFilters(c, "book_format")
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Now().Add(exportTimeout)); err != nil {
+		logging.FromContext(ctx).Warn("could not extend write deadline", "error", err)
+	}
+
+	mime, ext := export.ContentType(format)
+	w, _ := export.NewWriter(format, c.Writer)
+	started := false
+	count := 0
+	err = export.Books(ctx, h.books, filters, func(b domain.Book) error {
+		if !started {
+			// Os cabeçalhos só saem com o primeiro livro, para que um erro na
+			// primeira consulta ainda vire um problem+json
+			started = true
+			h.startDownload(c, mime, ext)
+		}
+		count++
+		return w.Write(b)
+	})
+	if err == nil {
+		if !started {
+			h.startDownload(c, mime, ext)
+		}
+		err = w.Close()
+	}
+	if err != nil && !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		abort(c, err)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("export aborted", "format", format, "books_written", count, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+	logging.FromContext(ctx).Info("catalog exported", "format", format, "books", count)
+}
+
+func (h *ExportHandler) startDownload(c *gin.Context, mime, ext string) {
+	name := "books-" + time.Now().UTC().Format("20060102") + "." + ext
+	c.Header("Content-Type", mime)
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/middleware"
+	"github.com/patrick-tondorf/lib_api/internal/pagination"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+	"github.com/patrick-tondorf/lib_api/internal/storage/memory"
+)
+
+// Os testes dos handlers rodam sobre o store em memória, com o mesmo
+// middleware de problemas do router, sem Postgres nem autenticação
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter monta as rotas de livros e autores sobre um store vazio
+func newTestRouter() (*gin.Engine, storage.Stores) {
+	stores := memory.New().Stores()
+	cursors := pagination.NewCodec("test-secret")
+	books := NewBookHandler(stores.Books, stores.Items, cursors)
+	authors := NewAuthorHandler(stores.Authors, cursors)
+
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.POST("/books", books.CreateBook)
+	r.GET("/books", books.GetBooks)
+	r.GET("/books/:uuid", books.GetBook)
+	r.PUT("/books/:uuid", books.UpdateBook)
+	r.DELETE("/books/:uuid", books.DeleteBook)
+	r.POST("/authors", authors.CreateAuthor)
+	r.GET("/authors", authors.GetAuthors)
+	r.GET("/authors/:uuid", authors.GetAuthorByID)
+	r.PUT("/authors/:uuid", authors.UpdateAuthor)
+	r.PATCH("/authors/:uuid", authors.PatchAuthor)
+	r.DELETE("/authors/:uuid", authors.DeleteAuthor)
+	return r, stores
+}
+
+// serve faz a requisição; body vazio vai sem corpo
+func serve(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// decode lê o corpo JSON da resposta em v
+func decode(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+}
+
+// wantProblem confere o status e o corpo problem+json da resposta
+func wantProblem(t *testing.T, w *httptest.ResponseRecorder, status int, slug, detail string, fields ...domain.FieldError) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d (body %s)", w.Code, status, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != middleware.ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, middleware.ProblemContentType)
+	}
+	var p domain.Problem
+	decode(t, w, &p)
+	if p.Status != status || p.Type != domain.ProblemType(slug) || p.Detail != detail {
+		t.Errorf("problem = %d %q %q, want %d %q %q", p.Status, p.Type, p.Detail, status, domain.ProblemType(slug), detail)
+	}
+	if len(p.Errors) != len(fields) {
+		t.Fatalf("errors = %+v, want %+v", p.Errors, fields)
+	}
+	for i := range fields {
+		if p.Errors[i] != fields[i] {
+			t.Errorf("errors[%d] = %+v, want %+v", i, p.Errors[i], fields[i])
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/pagination"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// HoldHandler administra as filas de reserva dos livros. Sem patron, as
+// reservas são do usuário do token (claim sub); leitores só mexem nas
+// próprias reservas.
+type HoldHandler struct {
+	Repo       storage.HoldStore
+	items      storage.ItemStore // exemplares, para a estimativa de espera
+	cursors    *pagination.Codec
+	loanDays   int
+	pickupDays int
+	loc        *time.Location
+}
+
+// NewHoldHandler creates a new HoldHandler.
+func NewHoldHandler(repo storage.HoldStore, items storage.ItemStore, cursors *pagination.Codec, loanDays, pickupDays int, loc *time.Location) *HoldHandler {
+	return &HoldHandler{Repo: repo, items: items, cursors: cursors, loanDays: loanDays, pickupDays: pickupDays, loc: loc}
+}
+
+// dates devolve as datas de hoje no fuso da biblioteca
+func (h *HoldHandler) dates() domain.HoldDates {
+	return domain.NewHoldDates(time.Now(), h.pickupDays, h.loc)
+}
+
+// CreateHold godoc
+// @Summary Place a hold on a book
+// @Description Put a patron in the FIFO queue of a book (any copy will do). Without patron the hold is placed for the current user; placing a hold for another patron requires the staff role. If a copy is free it is set aside at once and the hold is ready for pickup; otherwise the response has the queue position and an estimated wait in days, based on the number of copies and the loan period.
+// @Tags holds
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param hold body domain.HoldRequest true "Book, pickup branch and optional expiry"
+// @Success 201 {object} domain.Hold
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 403 {object} domain.Problem "Patron given without the staff role"
+// @Failure 404 {object} domain.Problem "Book or patron not found"
+// @Failure 409 {object} domain.Problem "Patron already has an open hold or a loan of this book, or the book has no copies"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /holds [post]
+func (h *HoldHandler) CreateHold(c *gin.Context) {
+	var req domain.HoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+	dates := h.dates()
+	if err := req.Normalize(dates.Today); err != nil {
+		abort(c, err)
+		return
+	}
+
+	placement := domain.HoldPlacement{
+		BookUUID:     req.BookUUID,
+		PatronEmail:  req.Patron,
+		PickupBranch: req.PickupBranch,
+		ExpiresOn:    req.ExpiresOn,
+		Dates:        dates,
+	}
+	if placement.PatronEmail != "" && !domain.HasRole(currentRole(c), domain.RoleStaff) {
+		abort(c, domain.ErrHoldForOther)
+		return
+	}
+	if placement.PatronEmail == "" {
+		id, err := currentUserID(c)
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		placement.PatronID = id
+	}
+
+	hold, err := h.Repo.CreateHold(c.Request.Context(), placement)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.respond(c, http.StatusCreated, hold)
+}
+
+// GetHold godoc
+// @Summary Get a hold by UUID
+// @Tags holds
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "Hold UUID"
+// @Success 200 {object} domain.Hold
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 403 {object} domain.Problem "Hold belongs to another patron"
+// @Failure 404 {object} domain.Problem "Hold not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /holds/{uuid} [get]
+func (h *HoldHandler) GetHold(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	hold, err := h.ownHold(c, uuid)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, hold)
+}
+
+// GetHolds godoc
+// @Summary List holds
+// @Description Get a page of holds in queue order (oldest first). Requires the staff role. Pages can be requested by number (page) or by following next_cursor/prev_cursor.
+// @Tags holds
+// @Security BearerAuth
+// @Produce json
+// @Param bookUuid query string   false "Filter by book UUID"
+// @Param patron   query string   false "Filter by patron email (exact)"
+// @Param status   query []string false "Filter by status (repeatable)" collectionFormat(multi) Enums(waiting, suspended, ready, fulfilled, cancelled, expired)
+// @Param page     query int      false "Page number (offset mode)" default(1) minimum(1) maximum(1000)
+// @Param cursor   query string   false "Opaque cursor from next_cursor or prev_cursor (cursor mode)"
+// @Param limit    query int      false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} domain.HoldListResponse
+// @Failure 400 {object} domain.Problem "Invalid parameters"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Book not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /holds [get]
+func (h *HoldHandler) GetHolds(c *gin.Context) {
+	bookUUID := strings.TrimSpace(c.Query("bookUuid"))
+	if bookUUID != "" && !isValidUUID(bookUUID) {
+		abort(c, invalidUUID("bookUuid"))
+		return
+	}
+	h.list(c, domain.HoldFilters{
+		BookUUID:    strings.ToLower(bookUUID),
+		PatronEmail: strings.TrimSpace(c.Query("patron")),
+	}, nil)
+}
+
+// GetBookHolds godoc
+// @Summary List the hold queue of a book
+// @Description Get a page of the holds of a book in queue order. Without status, only open holds (waiting, suspended and ready) are listed. Requires the staff role.
+// @Tags holds
+// @Security BearerAuth
+// @Produce json
+// @Param uuid   path  string   true  "Book UUID"
+// @Param status query []string false "Filter by status (repeatable)" collectionFormat(multi) Enums(waiting, suspended, ready, fulfilled, cancelled, expired)
+// @Param page   query int      false "Page number (offset mode)" default(1) minimum(1) maximum(1000)
+// @Param cursor query string   false "Opaque cursor from next_cursor or prev_cursor (cursor mode)"
+// @Param limit  query int      false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} domain.HoldListResponse
+// @Failure 400 {object} domain.Problem "Invalid parameters"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Book not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /books/{uuid}/holds [get]
+func (h *HoldHandler) GetBookHolds(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+	h.list(c, domain.HoldFilters{BookUUID: strings.ToLower(uuid)},
+		[]string{domain.HoldWaiting, domain.HoldSuspended, domain.HoldReady})
+}
+
+// GetMyHolds godoc
+// @Summary List my holds
+// @Description Get a page of the holds of the current user, oldest first.
+// @Tags holds
+// @Security BearerAuth
+// @Produce json
+// @Param status query []string false "Filter by status (repeatable)" collectionFormat(multi) Enums(waiting, suspended, ready, fulfilled, cancelled, expired)
+// @Param page   query int      false "Page number (offset mode)" default(1) minimum(1) maximum(1000)
+// @Param cursor query string   false "Opaque cursor from next_cursor or prev_cursor (cursor mode)"
+// @Param limit  query int      false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} domain.HoldListResponse
+// @Failure 400 {object} domain.Problem "Invalid parameters"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /users/me/holds [get]
+func (h *HoldHandler) GetMyHolds(c *gin.Context) {
+	patronID, err := currentUserID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.list(c, domain.HoldFilters{PatronID: patronID}, nil)
+}
+
+// CancelHold godoc
+// @Summary Cancel a hold
+// @Description Close an open hold. A copy set aside for a ready hold goes to the next hold in the queue.
+// @Tags holds
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "Hold UUID"
+// @Success 200 {object} domain.Hold
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 403 {object} domain.Problem "Hold belongs to another patron"
+// @Failure 404 {object} domain.Problem "Hold not found"
+// @Failure 409 {object} domain.Problem "Hold already closed"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /holds/{uuid}/cancel [post]
+func (h *HoldHandler) CancelHold(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+	if _, err := h.ownHold(c, uuid); err != nil {
+		abort(c, err)
+		return
+	}
+
+	hold, err := h.Repo.CancelHold(c.Request.Context(), uuid, h.dates())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, hold)
+}
+
+// SuspendHold godoc
+// @Summary Suspend a hold
+// @Description Freeze a waiting hold. It keeps its place in the queue but is skipped when a copy comes back. With until, the hold resumes by itself on that date; otherwise it stays suspended until resumed.
+// @Tags holds
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param uuid    path string                 true  "Hold UUID"
+// @Param suspend body domain.SuspendRequest false "Optional resume date"
+// @Success 200 {object} domain.Hold
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 403 {object} domain.Problem "Hold belongs to another patron"
+// @Failure 404 {object} domain.Problem "Hold not found"
+// @Failure 409 {object} domain.Problem "Hold is ready or closed"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /holds/{uuid}/suspend [post]
+func (h *HoldHandler) SuspendHold(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+	if _, err := h.ownHold(c, uuid); err != nil {
+		abort(c, err)
+		return
+	}
+
+	// O corpo é opcional
+	var req domain.SuspendRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abort(c, bindError(err))
+			return
+		}
+	}
+	if req.Until != "" && req.Until <= h.dates().Today {
+		abort(c, domain.ValidationError("invalid suspension",
+			domain.FieldError{Field: "until", Message: "must be after today"}))
+		return
+	}
+
+	hold, err := h.Repo.SuspendHold(c.Request.Context(), uuid, req.Until)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, hold)
+}
+
+// ResumeHold godoc
+// @Summary Resume a suspended hold
+// @Description Put a suspended hold back in the queue, in its original place. If a copy is free it is set aside at once.
+// @Tags holds
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "Hold UUID"
+// @Success 200 {object} domain.Hold
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 403 {object} domain.Problem "Hold belongs to another patron"
+// @Failure 404 {object} domain.Problem "Hold not found"
+// @Failure 409 {object} domain.Problem "Hold is not suspended"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /holds/{uuid}/resume [post]
+func (h *HoldHandler) ResumeHold(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+	if _, err := h.ownHold(c, uuid); err != nil {
+		abort(c, err)
+		return
+	}
+
+	hold, err := h.Repo.ResumeHold(c.Request.Context(), uuid, h.dates())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, hold)
+}
+
+// ownHold lê a reserva, que precisa ser do usuário do token, a menos
+// que ele seja da equipe
+func (h *HoldHandler) ownHold(c *gin.Context, uuid string) (*domain.Hold, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	hold, err := h.Repo.GetHoldByUUID(c.Request.Context(), uuid)
+	if err != nil {
+		return nil, err
+	}
+	if hold.PatronID != userID && !domain.HasRole(currentRole(c), domain.RoleStaff) {
+		return nil, domain.ErrNotOwnHold
+	}
+	return hold, nil
+}
+
+// list atende as listagens a partir dos filtros já definidos; open são as
+// situações usadas quando o pedido não filtra por status
+func (h *HoldHandler) list(c *gin.Context, filters domain.HoldFilters, open []string) {
+	filters.Statuses = c.QueryArray("status")
+	for _, s := range filters.Statuses {
+		if !slices.Contains(domain.HoldStatuses, s) {
+			abort(c, domain.ValidationError("invalid filter", domain.FieldError{Field: "status",
+				Message: "must be one of: " + strings.Join(domain.HoldStatuses, ", ")}))
+			return
+		}
+	}
+	if len(filters.Statuses) == 0 {
+		filters.Statuses = open
+	}
+
+	// O cursor fica preso aos filtros em que foi emitido
+	scope := strings.Join([]string{"holds", filters.BookUUID, strconv.Itoa(filters.PatronID),
+		filters.PatronEmail, strings.Join(filters.Statuses, ",")}, "\x00")
+	req, page, err := pageRequest(c, h.cursors, scope)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	filters.Limit = req.FetchLimit()
+	filters.Offset = req.Offset
+	filters.Keyset = req.Keyset
+
+	holds, total, err := h.Repo.GetHolds(c.Request.Context(), filters)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	holds, next, prev := pagination.Window(holds, req, total, func(hd domain.Hold) domain.Keyset {
+		return domain.Keyset{Value: hd.CreatedAt.UTC().Format(time.RFC3339Nano), ID: hd.ID}
+	})
+	if holds == nil {
+		holds = []domain.Hold{}
+	}
+	if err := h.attachWait(c, holds); err != nil {
+		abort(c, err)
+		return
+	}
+	resp := domain.HoldListResponse{
+		Data:       holds,
+		Limit:      req.Limit,
+		NextCursor: encodeCursor(h.cursors, scope, next),
+		PrevCursor: encodeCursor(h.cursors, scope, prev),
+	}
+	if req.Keyset == nil {
+		resp.Total = &total
+		resp.Page = &page
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *HoldHandler) respond(c *gin.Context, status int, hold *domain.Hold) {
+	holds := []domain.Hold{*hold}
+	if err := h.attachWait(c, holds); err != nil {
+		abort(c, err)
+		return
+	}
+	c.JSON(status, holds[0])
+}
+
+// attachWait preenche a espera estimada das reservas na fila. Os
+// exemplares perdidos não contam; os baixados já ficam fora do total.
+func (h *HoldHandler) attachWait(c *gin.Context, holds []domain.Hold) error {
+	var ids []int
+	for _, hd := range holds {
+		if hd.Position != nil && !slices.Contains(ids, hd.BookID) {
+			ids = append(ids, hd.BookID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	counts, err := h.items.GetAvailability(c.Request.Context(), ids)
+	if err != nil {
+		return err
+	}
+	for i := range holds {
+		if holds[i].Position == nil {
+			continue
+		}
+		a := counts[holds[i].BookID]
+		holds[i].EstimatedWait = domain.EstimateWait(*holds[i].Position, a.Total-a.Lost, h.loanDays)
+	}
+	return nil
+}
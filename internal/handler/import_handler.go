@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/importer"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+const (
+	// importTimeout substitui os timeouts de leitura e escrita do servidor
+	// durante um upload de importação, que pode levar minutos
+	importTimeout = 10 * time.Minute
+	// maxImportSize limita o tamanho do upload
+	maxImportSize = 512 << 20
+
+	defaultImportJobLimit = 20
+)
+
+// ImportHandler atende a importação de catálogo em lote
+type ImportHandler struct {
+	store    storage.ImportStore
+	importer *importer.Importer
+}
+
+// NewImportHandler creates a new ImportHandler.
+func NewImportHandler(store storage.ImportStore) *ImportHandler {
+	return &ImportHandler{store: store, importer: importer.New(store)}
+}
+
+// CreateImport godoc
+// @Summary Import books from a CSV, JSON Lines or MARC 21 file
+// @Description Streams the uploaded file and creates or updates books in batches. Books whose ISBN already exists are updated (empty fields keep the stored value); authors are matched by name, ignoring case and extra spaces, and created when missing.
+// @Description CSV files need a header with title and authors plus any of description, isbn, publisher, publication_year, language, pages, edition, format and subjects; authors and subjects are separated by ";". JSON Lines files have one book per line, with the fields of POST /books and "authors" as a list of names.
+// @Description MARC 21 records are read from ISO 2709 (.mrc, UTF-8 or MARC-8) or MARCXML (.xml) files; fields 020, 100/700, 245, 250, 260/264, 520 and 650 are mapped. Records imported with data loss are counted as partial and listed in warnings.
+// @Description Invalid rows are skipped and listed in the job report. With dry_run=true nothing is saved, but the report shows what would happen.
+// @Tags imports
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file    formData file   true  "Catalog file (.csv, .jsonl, .ndjson, .mrc or .xml)"
+// @Param format  query    string false "File format (default: from the file extension)" Enums(csv, jsonl, marc, marcxml)
+// @Param dry_run query    bool   false "Validate and report without saving"
+// @Success 201 {object} domain.ImportJob
+// @Failure 400 {object} domain.Problem "Invalid upload or file header"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 413 {object} domain.Problem "File too large"
+// @Failure 500 {object} domain.Problem "Import aborted (the job report has the counts so far)"
+// @Router /imports [post]
+func (h *ImportHandler) CreateImport(c *gin.Context) {
+	rc := http.NewResponseController(c.Writer)
+	deadline := time.Now().Add(importTimeout)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		logging.FromContext(c.Request.Context()).Warn("could not extend read deadline", "error", err)
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil {
+		logging.FromContext(c.Request.Context()).Warn("could not extend write deadline", "error", err)
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	dryRun := c.Query("dry_run") == "true"
+
+	part, err := uploadedFile(c.Request, "file")
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	defer part.Close()
+
+	format := c.Query("format")
+	if format == "" {
+		format = importer.FormatFromName(part.FileName())
+	}
+	if !slices.Contains(domain.ImportFormats, format) {
+		abort(c, domain.ValidationError("unknown import format",
+			domain.FieldError{Field: "format", Message: "must be one of: csv, jsonl, marc, marcxml (or use a .csv, .jsonl, .ndjson, .mrc or .xml file)"}))
+		return
+	}
+
+	job, err := h.importer.Run(c.Request.Context(), part, importer.Options{
+		Format: format,
+		Source: part.FileName(),
+		DryRun: dryRun,
+	})
+	if job != nil {
+		// Mesmo se a importação for interrompida, o relatório fica gravado
+		c.Header("Location", "/api/imports/"+job.UUID)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		abort(c, domain.TooLargeError("the uploaded file is larger than 512 MiB"))
+		return
+	}
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, job)
+}
+
+// uploadedFile devolve a parte name do corpo multipart sem carregá-la em
+// memória nem em disco, como o c.FormFile faria
+func uploadedFile(r *http.Request, name string) (*multipart.Part, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, domain.ValidationError("the request must be multipart/form-data",
+			domain.FieldError{Field: name, Message: "is required"})
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, domain.ValidationError("no file uploaded",
+				domain.FieldError{Field: name, Message: "is required"})
+		}
+		if err != nil {
+			return nil, domain.ValidationError("invalid multipart body").Wrap(err)
+		}
+		if part.FormName() == name {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// GetImports godoc
+// @Summary List recent import jobs
+// @Tags imports
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Number of jobs" default(20) minimum(1) maximum(100)
+// @Success 200 {object} domain.ImportJobListResponse
+// @Failure 400 {object} domain.Problem "Invalid limit"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /imports [get]
+func (h *ImportHandler) GetImports(c *gin.Context) {
+	limit, err := intQuery(c, "limit", defaultImportJobLimit)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	jobs, err := h.store.ListImportJobs(c.Request.Context(), clamp(limit, 1, maxPageLimit))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, domain.ImportJobListResponse{Data: jobs})
+}
+
+// GetImport godoc
+// @Summary Get an import job report
+// @Tags imports
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "Import job UUID"
+// @Success 200 {object} domain.ImportJob
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Import job not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /imports/{uuid} [get]
+func (h *ImportHandler) GetImport(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+	job, err := h.store.GetImportJob(c.Request.Context(), uuid)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/pagination"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// ItemHandler gerencia os exemplares físicos dos livros
+type ItemHandler struct {
+	Repo    storage.ItemStore
+	cursors *pagination.Codec
+}
+
+// NewItemHandler creates a new ItemHandler.
+func NewItemHandler(repo storage.ItemStore, cursors *pagination.Codec) *ItemHandler {
+	return &ItemHandler{Repo: repo, cursors: cursors}
+}
+
+// CreateItem godoc
+// @Summary Add an item to a book
+// @Description Register a physical copy of a book. The barcode is unique across the whole collection; without a status the item is available. The on_loan status is set by checkouts only.
+// @Tags items
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param uuid path string             true "Book UUID"
+// @Param item body domain.ItemRequest true "Item data"
+// @Success 201 {object} domain.Item
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Book not found"
+// @Failure 409 {object} domain.Problem "Barcode already in use or status on_loan"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /books/{uuid}/items [post]
+func (h *ItemHandler) CreateItem(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	var req domain.ItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+	if err := req.Normalize(); err != nil {
+		abort(c, err)
+		return
+	}
+	if req.Status == domain.ItemOnLoan {
+		abort(c, domain.ErrOnLoanStatus)
+		return
+	}
+
+	item, err := h.Repo.CreateItem(c.Request.Context(), uuid, req)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// GetBookItems godoc
+// @Summary List the items of a book
+// @Description Get a page of the copies of a book, sorted by barcode. Accepts the same filters and pagination as GET /items.
+// @Tags items
+// @Security BearerAuth
+// @Produce json
+// @Param uuid   path  string   true  "Book UUID"
+// @Param branch query string   false "Filter by branch (exact)"
+// @Param status query []string false "Filter by status (repeatable)" Enums(available, on_loan, in_transit, lost, withdrawn) collectionFormat(multi)
+// @Param page   query int      false "Page number (offset mode)" default(1) minimum(1) maximum(1000)
+// @Param cursor query string   false "Opaque cursor from next_cursor or prev_cursor (cursor mode)"
+// @Param limit  query int      false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} domain.ItemListResponse
+// @Failure 400 {object} domain.Problem "Invalid parameters"
+// @Failure 404 {object} domain.Problem "Book not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /books/{uuid}/items [get]
+func (h *ItemHandler) GetBookItems(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+	h.list(c, strings.ToLower(uuid))
+}
+
+// GetItems godoc
+// @Summary List items
+// @Description Get a page of items of the whole collection, sorted by barcode. Pages can be requested by number (page) or by following next_cursor/prev_cursor.
+// @Tags items
+// @Security BearerAuth
+// @Produce json
+// @Param barcode query string   false "Filter by barcode (exact)"
+// @Param branch  query string   false "Filter by branch (exact)"
+// @Param status  query []string false "Filter by status (repeatable)" Enums(available, on_loan, in_transit, lost, withdrawn) collectionFormat(multi)
+// @Param page    query int      false "Page number (offset mode)" default(1) minimum(1) maximum(1000)
+// @Param cursor  query string   false "Opaque cursor from next_cursor or prev_cursor (cursor mode)"
+// @Param limit   query int      false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} domain.ItemListResponse
+// @Failure 400 {object} domain.Problem "Invalid parameters"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /items [get]
+func (h *ItemHandler) GetItems(c *gin.Context) {
+	h.list(c, "")
+}
+
+// list atende as duas listagens; bookUUID vazio lista todo o acervo
+func (h *ItemHandler) list(c *gin.Context, bookUUID string) {
+	filters := domain.ItemFilters{
+		BookUUID: bookUUID,
+		Barcode:  strings.TrimSpace(c.Query("barcode")),
+		Branch:   strings.TrimSpace(c.Query("branch")),
+	}
+	for _, status := range c.QueryArray("status") {
+		if !slices.Contains(domain.ItemStatuses, status) {
+			abort(c, domain.ValidationError("invalid filter", domain.FieldError{Field: "status",
+				Message: "must be one of: " + strings.Join(domain.ItemStatuses, ", ")}))
+			return
+		}
+		if !slices.Contains(filters.Statuses, status) {
+			filters.Statuses = append(filters.Statuses, status)
+		}
+	}
+
+	// O cursor fica preso aos filtros em que foi emitido
+	statuses := slices.Sorted(slices.Values(filters.Statuses))
+	scope := strings.Join([]string{"items", bookUUID, filters.Barcode, filters.Branch,
+		strings.Join(statuses, "\x01")}, "\x00")
+	req, page, err := pageRequest(c, h.cursors, scope)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	filters.Limit = req.FetchLimit()
+	filters.Offset = req.Offset
+	filters.Keyset = req.Keyset
+
+	items, total, err := h.Repo.GetItems(c.Request.Context(), filters)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	items, next, prev := pagination.Window(items, req, total, func(it domain.Item) domain.Keyset {
+		return domain.Keyset{Value: it.Barcode, ID: it.ID}
+	})
+	if items == nil {
+		items = []domain.Item{}
+	}
+	resp := domain.ItemListResponse{
+		Data:       items,
+		Limit:      req.Limit,
+		NextCursor: encodeCursor(h.cursors, scope, next),
+		PrevCursor: encodeCursor(h.cursors, scope, prev),
+	}
+	if req.Keyset == nil {
+		resp.Total = &total
+		resp.Page = &page
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetItem godoc
+// @Summary Get an item by UUID
+// @Tags items
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "Item UUID"
+// @Success 200 {object} domain.Item
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 404 {object} domain.Problem "Item not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /items/{uuid} [get]
+func (h *ItemHandler) GetItem(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	item, err := h.Repo.GetItemByUUID(c.Request.Context(), uuid)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// UpdateItem godoc
+// @Summary Replace an item
+// @Description Replace barcode, call number, branch, location, status, acquisition date and price of an item. The item stays linked to the same book. While the item has an open loan its status must stay on_loan, and no other item can be set to on_loan. An item set aside for a hold that stops being available sends the hold back to the queue.
+// @Tags items
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param uuid path string             true "Item UUID"
+// @Param item body domain.ItemRequest true "Item data"
+// @Success 200 {object} domain.Item
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Item not found"
+// @Failure 409 {object} domain.Problem "Barcode already in use or on_loan status change"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /items/{uuid} [put]
+func (h *ItemHandler) UpdateItem(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	var req domain.ItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+	if err := req.Normalize(); err != nil {
+		abort(c, err)
+		return
+	}
+
+	item, err := h.Repo.UpdateItem(c.Request.Context(), uuid, req)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteItem godoc
+// @Summary Delete an item
+// @Description Remove an item from the collection. Items with loans cannot be deleted; set the status to withdrawn instead. Items set aside for a hold cannot be deleted either.
+// @Tags items
+// @Security BearerAuth
+// @Param uuid path string true "Item UUID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Item not found"
+// @Failure 409 {object} domain.Problem "Item has loans or is set aside for a hold"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /items/{uuid} [delete]
+func (h *ItemHandler) DeleteItem(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	if err := h.Repo.DeleteItem(c.Request.Context(), uuid); err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/pagination"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// LedgerHandler mostra a conta dos leitores e registra cobranças,
+// pagamentos e abonos. As multas por atraso são lançadas pela rotina de
+// circulação.
+type LedgerHandler struct {
+	Repo    storage.LedgerStore
+	cursors *pagination.Codec
+}
+
+// NewLedgerHandler creates a new LedgerHandler.
+func NewLedgerHandler(repo storage.LedgerStore, cursors *pagination.Codec) *LedgerHandler {
+	return &LedgerHandler{Repo: repo, cursors: cursors}
+}
+
+// PostLedgerEntry godoc
+// @Summary Post a ledger entry
+// @Description Record a charge for a lost or damaged item, a payment or a waiver on a patron's account, on behalf of the current user. Charges name the loan of the item; waivers need a note with the reason. Payments and waivers cannot exceed the balance. Overdue fines are posted daily by the circulation routine, following the fine settings of the circulation rule of each loan. Requires the staff role.
+// @Tags ledger
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param email path string                    true "Patron email" example("reader@example.com")
+// @Param entry body domain.LedgerEntryRequest true "Entry"
+// @Success 201 {object} domain.LedgerEntry
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Patron or loan not found"
+// @Failure 409 {object} domain.Problem "Amount exceeds the balance or loan of another patron"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /users/{email}/ledger [post]
+func (h *LedgerHandler) PostLedgerEntry(c *gin.Context) {
+	staffID, err := currentUserID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	var req domain.LedgerEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if err := req.Validate(); err != nil {
+		abort(c, err)
+		return
+	}
+
+	entry, err := h.Repo.PostLedgerEntry(c.Request.Context(), domain.LedgerPosting{
+		PatronEmail: c.Param("email"),
+		Type:        req.Type,
+		AmountCents: req.AmountCents,
+		LoanUUID:    req.LoanUUID,
+		Note:        req.Note,
+		StaffID:     staffID,
+	})
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// GetLedger godoc
+// @Summary Get a patron's ledger
+// @Description Get the current balance owed by a patron and a page of the ledger entries, most recent first. Each entry carries the balance right after it. Pages can be requested by number (page) or by following next_cursor/prev_cursor. Requires the staff role; patrons read their own ledger at /users/me/ledger.
+// @Tags ledger
+// @Security BearerAuth
+// @Produce json
+// @Param email  path  string true  "Patron email" example("reader@example.com")
+// @Param type   query string false "Filter by entry type" Enums(overdue, lost, damage, payment, waiver)
+// @Param page   query int    false "Page number (offset mode)" default(1) minimum(1) maximum(1000)
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor (cursor mode)"
+// @Param limit  query int    false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} domain.LedgerResponse
+// @Failure 400 {object} domain.Problem "Invalid parameters"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Patron not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /users/{email}/ledger [get]
+func (h *LedgerHandler) GetLedger(c *gin.Context) {
+	h.list(c, domain.LedgerFilters{PatronEmail: c.Param("email")})
+}
+
+// GetMyLedger godoc
+// @Summary Get my ledger
+// @Description Get the balance owed by the current user and a page of the ledger entries, most recent first.
+// @Tags ledger
+// @Security BearerAuth
+// @Produce json
+// @Param type   query string false "Filter by entry type" Enums(overdue, lost, damage, payment, waiver)
+// @Param page   query int    false "Page number (offset mode)" default(1) minimum(1) maximum(1000)
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor (cursor mode)"
+// @Param limit  query int    false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} domain.LedgerResponse
+// @Failure 400 {object} domain.Problem "Invalid parameters"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /users/me/ledger [get]
+func (h *LedgerHandler) GetMyLedger(c *gin.Context) {
+	patronID, err := currentUserID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.list(c, domain.LedgerFilters{PatronID: patronID})
+}
+
+// list atende as duas listagens a partir do leitor já definido
+func (h *LedgerHandler) list(c *gin.Context, filters domain.LedgerFilters) {
+	filters.Type = c.Query("type")
+	if filters.Type != "" && !slices.Contains(domain.LedgerEntryTypes, filters.Type) {
+		abort(c, domain.ValidationError("invalid filter", domain.FieldError{Field: "type",
+			Message: "must be one of: " + strings.Join(domain.LedgerEntryTypes, ", ")}))
+		return
+	}
+
+	// O cursor fica preso aos filtros em que foi emitido
+	scope := strings.Join([]string{"ledger", strconv.Itoa(filters.PatronID), filters.PatronEmail, filters.Type}, "\x00")
+	req, page, err := pageRequest(c, h.cursors, scope)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	filters.Limit = req.FetchLimit()
+	filters.Offset = req.Offset
+	filters.Keyset = req.Keyset
+
+	entries, total, balance, err := h.Repo.GetLedger(c.Request.Context(), filters)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	entries, next, prev := pagination.Window(entries, req, total, func(e domain.LedgerEntry) domain.Keyset {
+		return domain.Keyset{Value: e.CreatedAt.UTC().Format(time.RFC3339Nano), ID: e.ID}
+	})
+	if entries == nil {
+		entries = []domain.LedgerEntry{}
+	}
+	resp := domain.LedgerResponse{
+		Data:         entries,
+		BalanceCents: balance,
+		Limit:        req.Limit,
+		NextCursor:   encodeCursor(h.cursors, scope, next),
+		PrevCursor:   encodeCursor(h.cursors, scope, prev),
+	}
+	if req.Keyset == nil {
+		resp.Total = &total
+		resp.Page = &page
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,412 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/pagination"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// LoanHandler registra empréstimos, renovações e devoluções. O funcionário
+// que opera o balcão é o usuário do token (claim sub).
+type LoanHandler struct {
+	Repo       storage.LoanStore
+	cursors    *pagination.Codec
+	policy     domain.PolicyRule // regra padrão, quando nenhuma regra da tabela casa
+	maxBalance int64             // saldo devedor máximo para emprestar, em centavos
+	pickupDays int               // prazo de retirada da reserva que recebe o exemplar devolvido
+	loc        *time.Location    // fuso em que a data de devolução é contada
+	calendar   domain.Calendar   // dias em que a biblioteca abre, para os prazos
+}
+
+// NewLoanHandler creates a new LoanHandler.
+func NewLoanHandler(repo storage.LoanStore, cursors *pagination.Codec, policy domain.PolicyRule, maxBalance int64, pickupDays int, loc *time.Location, calendar domain.Calendar) *LoanHandler {
+	return &LoanHandler{Repo: repo, cursors: cursors, policy: policy, maxBalance: maxBalance, pickupDays: pickupDays, loc: loc, calendar: calendar}
+}
+
+// CreateLoan godoc
+// @Summary Check out an item
+// @Description Lend the item with the given barcode to a patron (a registered user, by email). The loan period and the patron's limit of open loans come from the circulation rule for the patron category and the item's material type (see /policies/rules); the due date is counted in days in the library time zone and moves to the next day the library is open. Patrons who owe more than the configured balance limit cannot borrow. An item that is on loan, in transit, lost or withdrawn cannot be checked out, nor a copy set aside for another patron's hold. The checkout fulfills the patron's own hold on the book. Requires the staff role.
+// @Tags loans
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param loan body domain.CheckoutRequest true "Barcode and patron"
+// @Success 201 {object} domain.Loan
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Item or patron not found"
+// @Failure 409 {object} domain.Problem "Item already on loan, not available or reserved, loan limit reached or balance above the limit"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /loans [post]
+func (h *LoanHandler) CreateLoan(c *gin.Context) {
+	staffID, err := currentUserID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	var req domain.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+
+	loan, err := h.Repo.CreateLoan(c.Request.Context(), domain.Checkout{
+		Barcode:     strings.TrimSpace(req.Barcode),
+		PatronEmail: strings.TrimSpace(req.Patron),
+		StaffID:     staffID,
+		Default:     h.policy,
+		MaxBalance:  h.maxBalance,
+		Dates:       domain.NewHoldDates(time.Now(), h.pickupDays, h.loc),
+		Calendar:    h.calendar,
+	})
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, loan)
+}
+
+// ReturnLoan godoc
+// @Summary Return a loan
+// @Description Check the item back in. The item becomes available again and the return is recorded with the current user as staff member. If the book has holds, the copy is set aside for the next hold in the queue, which becomes ready for pickup. Requires the staff role.
+// @Tags loans
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "Loan UUID"
+// @Success 200 {object} domain.Loan
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Loan not found"
+// @Failure 409 {object} domain.Problem "Loan already returned"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /loans/{uuid}/return [post]
+func (h *LoanHandler) ReturnLoan(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+	staffID, err := currentUserID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	loan, err := h.Repo.ReturnLoan(c.Request.Context(), uuid, staffID, domain.NewHoldDates(time.Now(), h.pickupDays, h.loc))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+// RenewLoan godoc
+// @Summary Renew a loan
+// @Description Extend an open loan on behalf of the current user. The new due date is the loan period of the circulation rule counted from the current due date, or from today if the loan is overdue, and moves to the next day the library is open. A loan cannot be renewed more times than the rule allows, while another patron is waiting in the book's hold queue, or when the patron owes more than the balance limit. The overdue fine of a late loan is charged at renewal; the fines of all due dates of a loan together stop at the cap of the rule. Each renewal is recorded in the loan's history. Patrons can only renew their own loans; staff can renew any loan.
+// @Tags loans
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "Loan UUID"
+// @Success 200 {object} domain.Loan
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 403 {object} domain.Problem "Loan of another patron"
+// @Failure 404 {object} domain.Problem "Loan not found"
+// @Failure 409 {object} domain.Problem "Loan returned, renewal limit reached, book on hold for another patron or balance above the limit"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /loans/{uuid}/renew [post]
+func (h *LoanHandler) RenewLoan(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+	staffID, err := currentUserID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	if _, err := h.ownLoan(c, uuid); err != nil {
+		abort(c, err)
+		return
+	}
+
+	loan, err := h.Repo.RenewLoan(c.Request.Context(), h.renewal(uuid, staffID))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+// RenewMyLoans godoc
+// @Summary Renew all my loans
+// @Description Try to renew every open loan of the current user, under the same conditions as a single renewal. Loans that cannot be renewed are listed with the reason and keep their due dates.
+// @Tags loans
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.RenewAllResponse
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /users/me/loans/renew [post]
+func (h *LoanHandler) RenewMyLoans(c *gin.Context) {
+	patronID, err := currentUserID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	// Os empréstimos em aberto, página a página; a renovação não muda a
+	// ordem da listagem (checked_out_at)
+	var loans []domain.Loan
+	for {
+		page, total, err := h.Repo.GetLoans(c.Request.Context(), domain.LoanFilters{
+			PatronID: patronID,
+			Status:   domain.LoanActive,
+			Limit:    100,
+			Offset:   len(loans),
+		})
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		loans = append(loans, page...)
+		if len(page) == 0 || len(loans) >= total {
+			break
+		}
+	}
+
+	resp := domain.RenewAllResponse{Renewed: []domain.Loan{}, Refused: []domain.RenewalRefusal{}}
+	for _, l := range loans {
+		renewed, err := h.Repo.RenewLoan(c.Request.Context(), h.renewal(l.UUID, patronID))
+		var derr *domain.Error
+		switch {
+		case err == nil:
+			resp.Renewed = append(resp.Renewed, *renewed)
+		case errors.Is(err, domain.ErrConflict) && errors.As(err, &derr):
+			resp.Refused = append(resp.Refused, domain.RenewalRefusal{Loan: l, Reason: derr.Message})
+		default:
+			abort(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// renewal monta a renovação com as datas de agora no fuso da biblioteca
+func (h *LoanHandler) renewal(uuid string, staffID int) domain.Renewal {
+	return domain.Renewal{
+		LoanUUID:   uuid,
+		StaffID:    staffID,
+		Default:    h.policy,
+		MaxBalance: h.maxBalance,
+		Today:      time.Now().In(h.loc).Format(time.DateOnly),
+		Calendar:   h.calendar,
+	}
+}
+
+// GetLoanRenewals godoc
+// @Summary Get the renewal history of a loan
+// @Description List the renewals of a loan, oldest first, with the previous and the new due date and who renewed it. Patrons can only see their own loans; staff can see any loan.
+// @Tags loans
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "Loan UUID"
+// @Success 200 {object} domain.LoanRenewalListResponse
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 403 {object} domain.Problem "Loan of another patron"
+// @Failure 404 {object} domain.Problem "Loan not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /loans/{uuid}/renewals [get]
+func (h *LoanHandler) GetLoanRenewals(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+	if _, err := h.ownLoan(c, uuid); err != nil {
+		abort(c, err)
+		return
+	}
+
+	renewals, err := h.Repo.GetLoanRenewals(c.Request.Context(), uuid)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.LoanRenewalListResponse{Data: renewals})
+}
+
+// GetLoan godoc
+// @Summary Get a loan by UUID
+// @Description Patrons can only get their own loans; staff can get any loan.
+// @Tags loans
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "Loan UUID"
+// @Success 200 {object} domain.Loan
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 403 {object} domain.Problem "Loan of another patron"
+// @Failure 404 {object} domain.Problem "Loan not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /loans/{uuid} [get]
+func (h *LoanHandler) GetLoan(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	loan, err := h.ownLoan(c, uuid)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+// ownLoan lê o empréstimo, que precisa ser do usuário do token, a menos
+// que ele seja funcionário
+func (h *LoanHandler) ownLoan(c *gin.Context, uuid string) (*domain.Loan, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	loan, err := h.Repo.GetLoanByUUID(c.Request.Context(), uuid)
+	if err != nil {
+		return nil, err
+	}
+	if loan.PatronID != userID && !domain.HasRole(currentRole(c), domain.RoleStaff) {
+		return nil, domain.ErrNotOwnLoan
+	}
+	return loan, nil
+}
+
+// GetLoans godoc
+// @Summary List loans
+// @Description Get a page of loans of all patrons, most recent checkout first. Pages can be requested by number (page) or by following next_cursor/prev_cursor. Requires the staff role; patrons list their own loans at /users/me/loans.
+// @Tags loans
+// @Security BearerAuth
+// @Produce json
+// @Param patron  query string false "Filter by patron email (exact)"
+// @Param barcode query string false "Filter by item barcode (exact)"
+// @Param status  query string false "Filter by status; overdue loans are active loans past their due date" Enums(active, returned, overdue)
+// @Param page    query int    false "Page number (offset mode)" default(1) minimum(1) maximum(1000)
+// @Param cursor  query string false "Opaque cursor from next_cursor or prev_cursor (cursor mode)"
+// @Param limit   query int    false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} domain.LoanListResponse
+// @Failure 400 {object} domain.Problem "Invalid parameters"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /loans [get]
+func (h *LoanHandler) GetLoans(c *gin.Context) {
+	h.list(c, domain.LoanFilters{
+		PatronEmail: strings.TrimSpace(c.Query("patron")),
+		Barcode:     strings.TrimSpace(c.Query("barcode")),
+	})
+}
+
+// GetMyLoans godoc
+// @Summary List my loans
+// @Description Get a page of the loans of the current user, active and past, most recent checkout first.
+// @Tags loans
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Filter by status; overdue loans are active loans past their due date" Enums(active, returned, overdue)
+// @Param page   query int    false "Page number (offset mode)" default(1) minimum(1) maximum(1000)
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor (cursor mode)"
+// @Param limit  query int    false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} domain.LoanListResponse
+// @Failure 400 {object} domain.Problem "Invalid parameters"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /users/me/loans [get]
+func (h *LoanHandler) GetMyLoans(c *gin.Context) {
+	patronID, err := currentUserID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.list(c, domain.LoanFilters{PatronID: patronID})
+}
+
+// list atende as duas listagens a partir dos filtros de leitor já definidos
+func (h *LoanHandler) list(c *gin.Context, filters domain.LoanFilters) {
+	filters.Status = c.Query("status")
+	if filters.Status != "" && !slices.Contains(domain.LoanStatuses, filters.Status) {
+		abort(c, domain.ValidationError("invalid filter", domain.FieldError{Field: "status",
+			Message: "must be one of: " + strings.Join(domain.LoanStatuses, ", ")}))
+		return
+	}
+	filters.Today = time.Now().In(h.loc).Format(time.DateOnly)
+
+	// O cursor fica preso aos filtros em que foi emitido
+	scope := strings.Join([]string{"loans", strconv.Itoa(filters.PatronID), filters.PatronEmail,
+		filters.Barcode, filters.Status}, "\x00")
+	req, page, err := pageRequest(c, h.cursors, scope)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	filters.Limit = req.FetchLimit()
+	filters.Offset = req.Offset
+	filters.Keyset = req.Keyset
+
+	loans, total, err := h.Repo.GetLoans(c.Request.Context(), filters)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	loans, next, prev := pagination.Window(loans, req, total, func(l domain.Loan) domain.Keyset {
+		return domain.Keyset{Value: l.CheckedOutAt.UTC().Format(time.RFC3339Nano), ID: l.ID}
+	})
+	if loans == nil {
+		loans = []domain.Loan{}
+	}
+	resp := domain.LoanListResponse{
+		Data:       loans,
+		Limit:      req.Limit,
+		NextCursor: encodeCursor(h.cursors, scope, next),
+		PrevCursor: encodeCursor(h.cursors, scope, prev),
+	}
+	if req.Keyset == nil {
+		resp.Total = &total
+		resp.Page = &page
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// currentRole lê o papel do usuário autenticado (claim role); vazio nos
+// tokens emitidos antes dos papéis, que valem como leitor
+func currentRole(c *gin.Context) string {
+	claims, _ := c.Get("jwtClaims")
+	mapClaims, _ := claims.(jwt.MapClaims)
+	role, _ := mapClaims["role"].(string)
+	return role
+}
+
+// currentUserID lê o id interno do usuário autenticado (claim sub)
+func currentUserID(c *gin.Context) (int, error) {
+	claims, _ := c.Get("jwtClaims")
+	mapClaims, _ := claims.(jwt.MapClaims)
+	sub, _ := mapClaims["sub"].(string)
+	id, err := strconv.Atoi(sub)
+	if err != nil || id <= 0 {
+		return 0, domain.UnauthorizedError("token has no valid subject")
+	}
+	return id, nil
+}
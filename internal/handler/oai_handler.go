@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+	"github.com/patrick-tondorf/lib_api/internal/oai"
+)
+
+// OAIHandler publica o catálogo por OAI-PMH
+type OAIHandler struct {
+	provider *oai.Provider
+	baseURL  string
+}
+
+// NewOAIHandler creates a new OAIHandler. Without baseURL, the base URL
+// reported to harvesters is derived from each request.
+func NewOAIHandler(provider *oai.Provider, baseURL string) *OAIHandler {
+	return &OAIHandler{provider: provider, baseURL: baseURL}
+}
+
+// Harvest godoc
+// @Summary OAI-PMH data provider
+// @Description OAI-PMH 2.0 endpoint for union catalogs and discovery services. Supports the six verbs (Identify, ListMetadataFormats, ListSets, GetRecord, ListIdentifiers, ListRecords), oai_dc and marc21 (MARCXML) metadata, one set per subject and persistent deleted records.
+// @Description Identifiers are oai:<repository identifier>:<book UUID>; datestamps are the last change of the book, with second granularity. Lists are paginated with resumption tokens that do not expire.
+// @Description Protocol errors are returned inside the OAI-PMH document with HTTP 200, as the specification requires. Arguments may also be sent as an application/x-www-form-urlencoded POST body.
+// @Tags oai-pmh
+// @Produce text/xml
+// @Param verb            query string true  "OAI-PMH verb" Enums(Identify, ListMetadataFormats, ListSets, GetRecord, ListIdentifiers, ListRecords)
+// @Param identifier      query string false "Record identifier (GetRecord, ListMetadataFormats)"
+// @Param metadataPrefix  query string false "Metadata format" Enums(oai_dc, marc21)
+// @Param from            query string false "Lower bound of the datestamp (YYYY-MM-DD or YYYY-MM-DDThh:mm:ssZ)"
+// @Param until           query string false "Upper bound of the datestamp (YYYY-MM-DD or YYYY-MM-DDThh:mm:ssZ)"
+// @Param set             query string false "Set spec (subject)"
+// @Param resumptionToken query string false "Token of the previous incomplete list (exclusive argument)"
+// @Success 200 {string} string "OAI-PMH response document"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /oai [get]
+// @Router /oai [post]
+func (h *OAIHandler) Harvest(c *gin.Context) {
+	args := c.Request.URL.Query()
+	if c.Request.Method == http.MethodPost {
+		if err := c.Request.ParseForm(); err != nil {
+			abort(c, domain.ValidationError("malformed form body").Wrap(err))
+			return
+		}
+		args = c.Request.Form
+	}
+
+	ctx := c.Request.Context()
+	resp, err := h.provider.Handle(ctx, h.requestBaseURL(c), args)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/xml; charset=utf-8")
+	c.Status(http.StatusOK)
+	_, err = io.WriteString(c.Writer, xml.Header)
+	if err == nil {
+		err = xml.NewEncoder(c.Writer).Encode(resp)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("oai-pmh response interrupted", "error", err)
+	}
+}
+
+func (h *OAIHandler) requestBaseURL(c *gin.Context) string {
+	if h.baseURL != "" {
+		return h.baseURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + c.Request.URL.Path
+}
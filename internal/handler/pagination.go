@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/pagination"
+)
+
+const (
+	defaultPageLimit = 10
+	maxPageLimit     = 100
+	maxPage          = 1000 // só no modo offset; além disso, use o cursor
+)
+
+// pageRequest lê limit, page e cursor da query string. O cursor só é aceito
+// se foi emitido para a mesma consulta (scope); page e cursor são
+// mutuamente exclusivos. Devolve também o número da página no modo offset.
+func pageRequest(c *gin.Context, cursors *pagination.Codec, scope string) (pagination.Request, int, error) {
+	limit, err := intQuery(c, "limit", defaultPageLimit)
+	if err != nil {
+		return pagination.Request{}, 0, err
+	}
+	req := pagination.Request{Limit: clamp(limit, 1, maxPageLimit)}
+
+	if token := c.Query("cursor"); token != "" {
+		if c.Query("page") != "" {
+			return pagination.Request{}, 0, domain.ValidationError("page and cursor are mutually exclusive",
+				domain.FieldError{Field: "page", Message: "must not be combined with cursor"})
+		}
+		if req.Keyset, err = cursors.Decode(scope, token); err != nil {
+			return pagination.Request{}, 0, err
+		}
+		return req, 0, nil
+	}
+
+	page, err := intQuery(c, "page", 1)
+	if err != nil {
+		return pagination.Request{}, 0, err
+	}
+	page = clamp(page, 1, maxPage)
+	req.Offset = (page - 1) * req.Limit
+	return req, page, nil
+}
+
+func intQuery(c *gin.Context, name string, def int) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, domain.ValidationError("invalid query parameter",
+			domain.FieldError{Field: name, Message: "must be an integer"})
+	}
+	return n, nil
+}
+
+// encodeCursor devolve "" quando não há página naquela direção
+func encodeCursor(cursors *pagination.Codec, scope string, k *domain.Keyset) string {
+	if k == nil {
+		return ""
+	}
+	return cursors.Encode(scope, *k)
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// PolicyHandler administra a tabela de regras de circulação e simula a
+// resolução de uma regra
+type PolicyHandler struct {
+	Repo     storage.PolicyStore
+	users    storage.UserStore
+	items    storage.ItemStore
+	defaults domain.PolicyRule // regra padrão, da configuração
+	loc      *time.Location
+	calendar domain.Calendar
+}
+
+// NewPolicyHandler creates a new PolicyHandler.
+func NewPolicyHandler(repo storage.PolicyStore, users storage.UserStore, items storage.ItemStore, defaults domain.PolicyRule, loc *time.Location, calendar domain.Calendar) *PolicyHandler {
+	return &PolicyHandler{Repo: repo, users: users, items: items, defaults: defaults, loc: loc, calendar: calendar}
+}
+
+// CreatePolicyRule godoc
+// @Summary Create a circulation rule
+// @Description Add a rule with the loan period, the maximum number of renewals and the maximum number of open loans for a patron category and/or material type. Leave a criterion empty to match any value. There is at most one rule per combination of criteria. Requires the admin role.
+// @Tags policies
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param rule body domain.PolicyRuleRequest true "Rule criteria and limits"
+// @Success 201 {object} domain.PolicyRule
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 403 {object} domain.Problem "Not an admin"
+// @Failure 409 {object} domain.Problem "A rule with the same criteria already exists"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /policies/rules [post]
+func (h *PolicyHandler) CreatePolicyRule(c *gin.Context) {
+	var req domain.PolicyRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+
+	rule, err := h.Repo.CreatePolicyRule(c.Request.Context(), req.Rule())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetPolicyRules godoc
+// @Summary List circulation rules
+// @Description Get all rules in the order they are considered, most specific first, and the default limits that apply when no rule matches. Requires the staff role.
+// @Tags policies
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.PolicyRuleListResponse
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /policies/rules [get]
+func (h *PolicyHandler) GetPolicyRules(c *gin.Context) {
+	rules, err := h.Repo.GetPolicyRules(c.Request.Context())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.PolicyRuleListResponse{Data: rules, Default: h.defaults})
+}
+
+// GetPolicyRule godoc
+// @Summary Get a circulation rule by UUID
+// @Description Get a rule by its UUID. Requires the staff role.
+// @Tags policies
+// @Security BearerAuth
+// @Produce json
+// @Param uuid path string true "Rule UUID"
+// @Success 200 {object} domain.PolicyRule
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Rule not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /policies/rules/{uuid} [get]
+func (h *PolicyHandler) GetPolicyRule(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	rule, err := h.Repo.GetPolicyRuleByUUID(c.Request.Context(), uuid)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdatePolicyRule godoc
+// @Summary Replace a circulation rule
+// @Description Replace the criteria and limits of a rule. Open loans keep their due dates; the new limits apply from the next checkout or renewal. Requires the admin role.
+// @Tags policies
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param uuid path string                   true "Rule UUID"
+// @Param rule body domain.PolicyRuleRequest true "Rule criteria and limits"
+// @Success 200 {object} domain.PolicyRule
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 403 {object} domain.Problem "Not an admin"
+// @Failure 404 {object} domain.Problem "Rule not found"
+// @Failure 409 {object} domain.Problem "A rule with the same criteria already exists"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /policies/rules/{uuid} [put]
+func (h *PolicyHandler) UpdatePolicyRule(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	var req domain.PolicyRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+
+	rule, err := h.Repo.UpdatePolicyRule(c.Request.Context(), uuid, req.Rule())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeletePolicyRule godoc
+// @Summary Delete a circulation rule
+// @Description Remove a rule; open loans keep their due dates. Requires the admin role.
+// @Tags policies
+// @Security BearerAuth
+// @Param uuid path string true "Rule UUID"
+// @Success 204 "No Content"
+// @Failure 400 {object} domain.Problem "Invalid UUID"
+// @Failure 403 {object} domain.Problem "Not an admin"
+// @Failure 404 {object} domain.Problem "Rule not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /policies/rules/{uuid} [delete]
+func (h *PolicyHandler) DeletePolicyRule(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !isValidUUID(uuid) {
+		abort(c, invalidUUID("uuid"))
+		return
+	}
+
+	if err := h.Repo.DeletePolicyRule(c.Request.Context(), uuid); err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SimulatePolicy godoc
+// @Summary Simulate the rule resolution
+// @Description Show which circulation rule would apply to a checkout made today, and why. Give the patron by email or by category, and the material by item barcode or by type. Every rule is listed with the reason it matched or not; the most specific match wins, and on a tie a material type rule beats a patron category rule. Requires the staff role.
+// @Tags policies
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param simulation body domain.SimulateRequest true "Patron and material"
+// @Success 200 {object} domain.PolicyDecision
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 403 {object} domain.Problem "Not staff"
+// @Failure 404 {object} domain.Problem "Patron or item not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /policies/simulate [post]
+func (h *PolicyHandler) SimulatePolicy(c *gin.Context) {
+	var req domain.SimulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+	req.Patron = strings.TrimSpace(req.Patron)
+	req.Barcode = strings.TrimSpace(req.Barcode)
+	if err := req.Validate(); err != nil {
+		abort(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	category := req.PatronCategory
+	if req.Patron != "" {
+		user, err := h.users.GetUserByEmail(ctx, req.Patron)
+		if err != nil {
+			if errors.Is(err, domain.ErrUserNotFound) {
+				err = domain.ErrPatronNotFound
+			}
+			abort(c, err)
+			return
+		}
+		category = user.Category
+	}
+	material := req.MaterialType
+	if req.Barcode != "" {
+		items, _, err := h.items.GetItems(ctx, domain.ItemFilters{Barcode: req.Barcode, Limit: 1})
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		if len(items) == 0 {
+			abort(c, domain.ErrItemNotFound)
+			return
+		}
+		material = items[0].Material
+	}
+
+	rules, err := h.Repo.GetPolicyRules(ctx)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	decision := domain.ResolvePolicy(rules, category, material, h.defaults)
+	if decision.Loanable {
+		today := time.Now().In(h.loc).Format(time.DateOnly)
+		if decision.DueDate, err = h.calendar.DueDate(today, decision.Rule.LoanDays); err != nil {
+			abort(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, decision)
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// searchLanguages são as configurações de texto disponíveis no Postgres
+var searchLanguages = []string{"pt", "en"}
+
+// SearchHandler atende a busca textual de livros
+type SearchHandler struct {
+	store storage.SearchStore
+}
+
+// NewSearchHandler creates a new SearchHandler.
+func NewSearchHandler(store storage.SearchStore) *SearchHandler {
+	return &SearchHandler{store: store}
+}
+
+// Search godoc
+// @Summary Full-text search over books
+// @Description Search titles, descriptions and author names, ranked by relevance. Case and accents are ignored.
+// @Description Syntax: words must all match; "quoted phrases" match in sequence; pref* matches a prefix; -term excludes books containing term.
+// @Description Each hit has a headline: an HTML fragment of the title and description, with the text escaped and the matched terms wrapped in <mark>.
+// @Description lang selects the stemming rules applied by PostgreSQL (the memory and sqlite backends do not stem).
+// @Tags search
+// @Security BearerAuth
+// @Produce json
+// @Param q     query string true  "Search query" maxlength(256)
+// @Param lang  query string false "Text search language" Enums(pt, en) default(pt)
+// @Param page  query int    false "Page number" default(1) minimum(1) maximum(1000)
+// @Param limit query int    false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} domain.SearchResponse
+// @Failure 400 {object} domain.Problem "Invalid query"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	raw := c.Query("q")
+	terms, err := domain.ParseSearchQuery(raw)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	lang := c.DefaultQuery("lang", "pt")
+	if !slices.Contains(searchLanguages, lang) {
+		abort(c, domain.ValidationError("invalid search language",
+			domain.FieldError{Field: "lang", Message: "must be one of: pt, en"}))
+		return
+	}
+
+	limit, err := intQuery(c, "limit", defaultPageLimit)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	page, err := intQuery(c, "page", 1)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	limit = clamp(limit, 1, maxPageLimit)
+	page = clamp(page, 1, maxPage)
+
+	q := domain.SearchQuery{
+		Raw:      raw,
+		Terms:    terms,
+		Language: lang,
+		Limit:    limit,
+		Offset:   (page - 1) * limit,
+	}
+	hits, total, err := h.store.SearchBooks(c.Request.Context(), q)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	if hits == nil {
+		hits = []domain.SearchHit{}
+	}
+
+	c.JSON(http.StatusOK, domain.SearchResponse{
+		Query: raw,
+		Data:  hits,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	})
+}
@@ -2,22 +2,25 @@ package handler
 
 import (
 	"errors"
-	"log"
+	"fmt"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/patrick-tondorf/lib_api/internal/config"
 	"github.com/patrick-tondorf/lib_api/internal/domain"
-	"github.com/patrick-tondorf/lib_api/internal/repository"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserHandler struct {
-	repo *repository.UserRepository
+	repo     storage.UserStore
+	secret   string
+	tokenTTL time.Duration
+	admins   []string // emails que entram como administradores (auth.admin_emails)
 }
 
 // LoginResponse defines the structure of a successful login response
@@ -27,8 +30,11 @@ type LoginResponse struct {
 	TokenType string `json:"token_type"`
 }
 
-func NewUserHandler(repo *repository.UserRepository) *UserHandler {
-	return &UserHandler{repo: repo}
+// errAuthenticationFailed não diferencia email inexistente de senha errada
+var errAuthenticationFailed = domain.UnauthorizedError("authentication failed")
+
+func NewUserHandler(repo storage.UserStore, secret string, tokenTTL time.Duration, admins []string) *UserHandler {
+	return &UserHandler{repo: repo, secret: secret, tokenTTL: tokenTTL, admins: admins}
 }
 
 // CreateUser godoc
@@ -40,26 +46,28 @@ func NewUserHandler(repo *repository.UserRepository) *UserHandler {
 // @Produce json
 // @Param user body domain.User true "User data"
 // @Success 201 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} domain.Problem
+// @Failure 409 {object} domain.Problem "User already exists"
+// @Failure 500 {object} domain.Problem
 // @Router /users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var user domain.User
 	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		abort(c, bindError(err))
 		return
 	}
 
 	// Validação básica de email
 	if !isValidEmail(user.Email) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email format"})
+		abort(c, domain.ValidationError("invalid email format",
+			domain.FieldError{Field: "email", Message: "must be a valid email address"}))
 		return
 	}
 
 	// Gera o hash
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to secure password"})
+		abort(c, fmt.Errorf("failed to hash password: %w", err))
 		return
 	}
 
@@ -71,7 +79,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	}
 
 	if err := h.repo.CreateUser(c.Request.Context(), dbUser); err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		abort(c, err)
 		return
 	}
 
@@ -85,97 +93,82 @@ func isValidEmail(email string) bool {
 
 // User godoc
 // @Summary Authenticate a user
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return JWT token. The token carries the user's role (patron, staff or admin), which is read at login: a role change applies from the next login.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param credentials body domain.Credentials true "User credentials"
 // @Success 200 {object} LoginResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} domain.Problem
+// @Failure 401 {object} domain.Problem
+// @Failure 500 {object} domain.Problem
 // @Router /auth/login [post]
 func (h *UserHandler) AuthenticateUser(c *gin.Context) {
-	log.Println("Iniciando autenticação do usuário")
+	logger := logging.FromContext(c.Request.Context())
 
 	var credentials domain.Credentials
 	if err := c.ShouldBindJSON(&credentials); err != nil {
-		log.Printf("Erro ao decodificar JSON: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		logger.Debug("invalid login payload", "error", err)
+		abort(c, bindError(err))
 		return
 	}
 
-	log.Printf("Tentativa de login para o email: %s\n", credentials.Email)
+	// O email é mascarado pela redação do logger
+	logger = logger.With("email", credentials.Email)
+	logger.Debug("login attempt")
 
 	user, err := h.repo.GetUserByEmail(c.Request.Context(), credentials.Email)
 	if err != nil {
-		log.Printf("Erro ao buscar usuário por email: %v\n", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		if errors.Is(err, domain.ErrNotFound) {
+			logger.Info("login failed", "reason", "unknown email")
+			abort(c, errAuthenticationFailed)
+		} else {
+			abort(c, err)
+		}
 		return
 	}
 
-	log.Println("Usuário encontrado no banco de dados")
-
 	// Compare hashed password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(credentials.Password)); err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			log.Println("Senha incorreta para o usuário")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+			logger.Info("login failed", "reason", "wrong password", "user_id", user.ID)
+			abort(c, errAuthenticationFailed)
 			return
 		}
-		log.Printf("Erro ao comparar senhas: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Internal server error",
-			"details": "password comparison failed",
-		})
+		abort(c, fmt.Errorf("password comparison failed: %w", err))
 		return
 	}
 
-	log.Println("Credenciais validadas com sucesso")
+	// O papel vai no token; os emails de auth.admin_emails são sempre
+	// administradores
+	role := user.Role
+	if slices.Contains(h.admins, user.Email) {
+		role = domain.RoleAdmin
+	}
 
 	// Generate JWT token
-	expirationTime := time.Now().Add(24 * time.Hour)
+	expirationTime := time.Now().Add(h.tokenTTL)
 	claims := jwt.MapClaims{
 		"sub":   user.ID,
 		"email": user.Email,
+		"role":  role,
 		"exp":   expirationTime.Unix(),
 		"iat":   time.Now().Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	secret := os.Getenv("SECRET_KEY")
-	if secret == "" {
-		secret = config.GetSecretKey()
-		log.Println("Usando secret key do config")
-	} else {
-		log.Println("Usando secret key da variável de ambiente")
-	}
-
-	if secret == "" {
-		log.Println("Nenhuma secret key configurada")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Internal server error",
-			"details": "JWT secret not configured",
-		})
-		return
-	}
-
-	tokenString, err := token.SignedString([]byte(secret))
+	tokenString, err := token.SignedString([]byte(h.secret))
 	if err != nil {
-		log.Printf("Erro ao gerar token JWT: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to generate token",
-			"details": "JWT signing failed",
-		})
+		abort(c, fmt.Errorf("JWT signing failed: %w", err))
 		return
 	}
 
-	log.Println("Token JWT gerado com sucesso")
+	logger.Info("login succeeded", "user_id", user.ID)
 
 	response := LoginResponse{
 		Token:     "Bearer " + tokenString,
-		ExpiresIn: int64(time.Hour.Seconds() * 24),
+		ExpiresIn: int64(h.tokenTTL.Seconds()),
 		TokenType: "Bearer",
 	}
 
@@ -189,25 +182,85 @@ func (h *UserHandler) AuthenticateUser(c *gin.Context) {
 // @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Param email path string true "User email" example("user@example.com")// @Success 200 {object} domain.User
-// @Failure 400 {object} UserListResponse
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Param email path string true "User email" example("user@example.com")
+// @Success 200 {object} domain.User
+// @Failure 400 {object} domain.Problem
+// @Failure 404 {object} domain.Problem
+// @Failure 500 {object} domain.Problem
 // @Router /users/{email} [get]
 func (h *UserHandler) GetUserByEmail(c *gin.Context) {
 	email := c.Param("email")
 	if email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email parameter is required"})
+		abort(c, domain.ValidationError("email parameter is required",
+			domain.FieldError{Field: "email", Message: "is required"}))
 		return
 	}
 
 	user, err := h.repo.GetUserByEmail(c.Request.Context(), email)
 	if err != nil {
-		if strings.Contains(err.Error(), "user not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SetUserCategory godoc
+// @Summary Set the patron category of a user
+// @Description Change the category (student, staff or public) that selects the circulation rules applied to the user's loans. Users registered through the API start as public. Open loans keep their due dates. Requires the admin role.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param email    path string                 true "User email" example("user@example.com")
+// @Param category body domain.CategoryRequest true "New category"
+// @Success 200 {object} domain.User
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 403 {object} domain.Problem "Not an admin"
+// @Failure 404 {object} domain.Problem "User not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /users/{email}/category [put]
+func (h *UserHandler) SetUserCategory(c *gin.Context) {
+	var req domain.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+
+	user, err := h.repo.SetUserCategory(c.Request.Context(), c.Param("email"), req.Category)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SetUserRole godoc
+// @Summary Set the role of a user
+// @Description Change the role of a user in the API: patrons only use their own account (/users/me/...), staff run the circulation desk (checkouts, returns, patron ledgers) and admins also manage the circulation rules, patron categories and roles. Users registered through the API start as patrons. The new role applies from the user's next login. Requires the admin role.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param email path string             true "User email" example("user@example.com")
+// @Param role  body domain.RoleRequest true "New role"
+// @Success 200 {object} domain.User
+// @Failure 400 {object} domain.Problem "Invalid input"
+// @Failure 403 {object} domain.Problem "Not an admin"
+// @Failure 404 {object} domain.Problem "User not found"
+// @Failure 500 {object} domain.Problem "Internal server error"
+// @Router /users/{email}/role [put]
+func (h *UserHandler) SetUserRole(c *gin.Context) {
+	var req domain.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, bindError(err))
+		return
+	}
+
+	user, err := h.repo.SetUserRole(c.Request.Context(), c.Param("email"), req.Role)
+	if err != nil {
+		abort(c, err)
 		return
 	}
 
@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// listSeparator separa os valores das colunas authors e subjects
+const listSeparator = ";"
+
+// utf8BOM é ignorado no início do arquivo
+const utf8BOM = "\ufeff"
+
+// csvColumns são as colunas aceitas no cabeçalho. Os nomes são comparados
+// sem maiúsculas, espaços e "_", então "publication_year" e
+// "Publication Year" valem como publicationYear.
+var csvColumns = []string{
+	"title", "description", "authors", "isbn", "publisher", "publicationYear",
+	"language", "pages", "edition", "format", "subjects",
+}
+
+// ignoredCSVColumns são aceitas no cabeçalho e descartadas; vêm dos arquivos
+// gerados pela exportação
+var ignoredCSVColumns = []string{"uuid", "createdAt", "updatedAt"}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns []string // coluna de cada posição do cabeçalho
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	br := bufio.NewReader(r)
+	// Planilhas costumam gravar o BOM do UTF-8 (EF BB BF) no início do arquivo
+	if bom, _ := br.Peek(3); string(bom) == utf8BOM {
+		br.Discard(3)
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, domain.ValidationError("the CSV file is empty")
+	}
+	if err != nil {
+		return nil, domain.ValidationError("invalid CSV header: " + err.Error())
+	}
+
+	known := map[string]string{}
+	for _, c := range append(csvColumns, ignoredCSVColumns...) {
+		known[headerKey(c)] = c
+	}
+	columns := make([]string, len(header))
+	seen := map[string]bool{}
+	var fields []domain.FieldError
+	for i, h := range header {
+		col, ok := known[headerKey(h)]
+		switch {
+		case !ok:
+			fields = append(fields, domain.FieldError{Field: h, Message: "unknown column"})
+		case seen[col]:
+			fields = append(fields, domain.FieldError{Field: h, Message: "duplicate column"})
+		}
+		seen[col] = true
+		columns[i] = col
+	}
+	for _, required := range []string{"title", "authors"} {
+		if !seen[required] {
+			fields = append(fields, domain.FieldError{Field: required, Message: "column is required"})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, domain.ValidationError("invalid CSV header", fields...)
+	}
+	return &csvReader{r: cr, columns: columns}, nil
+}
+
+func headerKey(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.NewReplacer("_", "", " ", "", "-", "").Replace(s)
+}
+
+func (c *csvReader) next() (int, record, error) {
+	values, err := c.r.Read()
+	if err != nil {
+		var perr *csv.ParseError
+		if errors.As(err, &perr) && errors.Is(perr.Err, csv.ErrFieldCount) {
+			return perr.StartLine, record{}, &rowError{domain.ImportRowError{Line: perr.StartLine, Message: "wrong number of fields"}}
+		}
+		if errors.As(err, &perr) {
+			// Aspas desbalanceadas deixam o restante do arquivo ambíguo
+			return 0, record{}, domain.ValidationError(fmt.Sprintf("invalid CSV at line %d: %v", perr.StartLine, perr.Err))
+		}
+		return 0, record{}, err
+	}
+	line, _ := c.r.FieldPos(0)
+	if len(values) != len(c.columns) {
+		return line, record{}, &rowError{domain.ImportRowError{Line: line,
+			Message: fmt.Sprintf("wrong number of fields: expected %d, got %d", len(c.columns), len(values))}}
+	}
+
+	var rec record
+	for i, v := range values {
+		v = strings.TrimSpace(v)
+		switch c.columns[i] {
+		case "title":
+			rec.Title = v
+		case "description":
+			rec.Description = v
+		case "authors":
+			rec.Authors = splitList(v)
+		case "isbn":
+			rec.ISBN = v
+		case "publisher":
+			rec.Publisher = v
+		case "publicationYear", "pages":
+			n, err := parseInt(v)
+			if err != nil {
+				return line, record{}, &rowError{domain.ImportRowError{Line: line, Field: c.columns[i], Message: "must be a whole number"}}
+			}
+			if c.columns[i] == "pages" {
+				rec.Pages = n
+			} else {
+				rec.PublicationYear = n
+			}
+		case "language":
+			rec.Language = v
+		case "edition":
+			rec.Edition = v
+		case "format":
+			rec.Format = strings.ToLower(v)
+		case "subjects":
+			rec.Subjects = splitList(v)
+		}
+	}
+	return line, rec, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, listSeparator) {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func parseInt(s string) (*int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
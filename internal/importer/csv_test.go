@@ -0,0 +1,136 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+func TestCSVHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		columns []string
+		fields  []domain.FieldError // vazio quando o cabeçalho é aceito
+	}{
+		{name: "exact names", header: "title,authors,publicationYear",
+			columns: []string{"title", "authors", "publicationYear"}},
+		{name: "unknown column", header: " Title ,AUTHORS,Publication Year,publication_year_ignored",
+			fields: []domain.FieldError{{Field: "publication_year_ignored", Message: "unknown column"}}},
+		{name: "snake and kebab case", header: "title,authors,publication_year,Page-s",
+			columns: []string{"title", "authors", "publicationYear", "pages"}},
+		{name: "export columns are ignored", header: "uuid,title,authors,createdAt,updated_at",
+			columns: []string{"uuid", "title", "authors", "createdAt", "updatedAt"}},
+		{name: "byte order mark", header: utf8BOM + "title,authors",
+			columns: []string{"title", "authors"}},
+		{name: "duplicate column", header: "title,authors,Title",
+			fields: []domain.FieldError{{Field: "Title", Message: "duplicate column"}}},
+		{name: "required columns", header: "isbn",
+			fields: []domain.FieldError{{Field: "title", Message: "column is required"}, {Field: "authors", Message: "column is required"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr, err := newCSVReader(strings.NewReader(tt.header + "\n"))
+			if len(tt.fields) > 0 {
+				var de *domain.Error
+				if !errors.As(err, &de) || de.Kind != domain.ErrValidation || !reflect.DeepEqual(de.Fields, tt.fields) {
+					t.Fatalf("err = %v, want fields %+v", err, tt.fields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cr.columns, tt.columns) {
+				t.Errorf("columns = %v, want %v", cr.columns, tt.columns)
+			}
+		})
+	}
+}
+
+func TestCSVEmpty(t *testing.T) {
+	_, err := newCSVReader(strings.NewReader(""))
+	var de *domain.Error
+	if !errors.As(err, &de) || de.Message != "the CSV file is empty" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestCSVRows(t *testing.T) {
+	intp := func(n int) *int { return &n }
+	tests := []struct {
+		name string
+		row  string
+		line int
+		rec  record
+		err  *domain.ImportRowError
+	}{
+		{name: "all columns",
+			row: `1984,George Orwell,978-0-452-28423-4,1949,328,EPUB,Dystopia`, line: 2,
+			rec: record{Title: "1984", Authors: []string{"George Orwell"}, BookMetadata: domain.BookMetadata{
+				ISBN: "978-0-452-28423-4", PublicationYear: intp(1949), Pages: intp(328), Format: "epub", Subjects: []string{"Dystopia"}}}},
+		{name: "list splitting",
+			row: `Good Omens," Terry Pratchett ; Neil Gaiman ;; ",,,,,"Fantasy;Humor; "`, line: 2,
+			rec: record{Title: "Good Omens", Authors: []string{"Terry Pratchett", "Neil Gaiman"}, BookMetadata: domain.BookMetadata{
+				Subjects: []string{"Fantasy", "Humor"}}}},
+		{name: "empty numbers",
+			row: `Dune,Frank Herbert,,,,,`, line: 2,
+			rec: record{Title: "Dune", Authors: []string{"Frank Herbert"}}},
+		{name: "quoted newline keeps the start line",
+			row: "\"Dune\nMessiah\",Frank Herbert,,,,,", line: 2,
+			rec: record{Title: "Dune\nMessiah", Authors: []string{"Frank Herbert"}}},
+		{name: "bad number",
+			row: `Dune,Frank Herbert,,MCMLXV,,,`, line: 2,
+			err: &domain.ImportRowError{Line: 2, Field: "publicationYear", Message: "must be a whole number"}},
+		{name: "wrong number of fields",
+			row: `Dune,Frank Herbert`, line: 2,
+			err: &domain.ImportRowError{Line: 2, Message: "wrong number of fields: expected 7, got 2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr, err := newCSVReader(strings.NewReader("title,authors,isbn,publicationYear,pages,format,subjects\n" + tt.row + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			line, rec, err := cr.next()
+			if line != tt.line {
+				t.Errorf("line = %d, want %d", line, tt.line)
+			}
+			if tt.err != nil {
+				var re *rowError
+				if !errors.As(err, &re) || re.ImportRowError != *tt.err {
+					t.Errorf("err = %#v, want %+v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rec, tt.rec) {
+				t.Errorf("record = %+v, want %+v", rec, tt.rec)
+			}
+			if _, _, err := cr.next(); !errors.Is(err, io.EOF) {
+				t.Errorf("second read: %v, want EOF", err)
+			}
+		})
+	}
+}
+
+func TestCSVUnbalancedQuotes(t *testing.T) {
+	cr, err := newCSVReader(strings.NewReader("title,authors\nDune,Frank Herbert\n\"Dune Messiah,Frank Herbert\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := cr.next(); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = cr.next()
+	var de *domain.Error
+	if !errors.As(err, &de) || de.Kind != domain.ErrValidation || !strings.HasPrefix(de.Message, "invalid CSV at line 3") {
+		t.Errorf("err = %v, want a validation error at line 3", err)
+	}
+}
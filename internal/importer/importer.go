@@ -0,0 +1,272 @@
+// Package importer carrega catálogos em CSV, JSON Lines ou MARC 21 (ISO 2709
+// e MARCXML). As linhas (ou registros MARC) são
+// lidas uma a uma (o arquivo nunca é carregado inteiro), validadas com as
+// mesmas regras de POST /books e gravadas em lotes pelo storage.ImportStore.
+// Linhas inválidas entram no relatório do job sem interromper a importação.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+	"github.com/patrick-tondorf/lib_api/internal/validation"
+)
+
+// DefaultBatchSize é o número de linhas gravadas por transação
+const DefaultBatchSize = 500
+
+// Options descreve uma importação
+type Options struct {
+	Format string // um de domain.ImportFormats
+	Source string // nome do arquivo, só para o relatório
+	DryRun bool
+}
+
+// Importer executa importações sobre um store
+type Importer struct {
+	store     storage.ImportStore
+	batchSize int
+}
+
+// New cria um Importer com lotes de DefaultBatchSize linhas
+func New(store storage.ImportStore) *Importer {
+	return &Importer{store: store, batchSize: DefaultBatchSize}
+}
+
+// FormatFromName deduz o formato pela extensão do arquivo (.csv, .jsonl,
+// .ndjson, .mrc, .marc, .xml ou .marcxml); devolve "" se não reconhecer
+func FormatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return domain.ImportFormatCSV
+	case ".jsonl", ".ndjson":
+		return domain.ImportFormatJSONL
+	case ".mrc", ".marc":
+		return domain.ImportFormatMARC
+	case ".xml", ".marcxml":
+		return domain.ImportFormatMARCXML
+	}
+	return ""
+}
+
+// record é um livro como aparece no arquivo, antes da validação
+type record struct {
+	Title       string   `json:"title" binding:"required,min=2,max=100"`
+	Description string   `json:"description" binding:"max=500"`
+	Authors     []string `json:"authors" binding:"required,min=1,max=20,dive,min=2,max=100"`
+	domain.BookMetadata
+
+	ref      string                  // identificação no arquivo de origem (001 do MARC)
+	warnings []domain.ImportRowError // partes do registro que não foram aproveitadas
+}
+
+// reader devolve os registros do arquivo em ordem. Um *rowError recusa só
+// aquela linha; qualquer outro erro (ou io.EOF) encerra a leitura.
+type reader interface {
+	next() (line int, rec record, err error)
+}
+
+type rowError struct {
+	domain.ImportRowError
+}
+
+func (e *rowError) Error() string { return e.Message }
+
+func newReader(format string, r io.Reader) (reader, error) {
+	switch format {
+	case domain.ImportFormatCSV:
+		return newCSVReader(r)
+	case domain.ImportFormatJSONL:
+		return newJSONLReader(r), nil
+	case domain.ImportFormatMARC:
+		return newMARCReader(r), nil
+	case domain.ImportFormatMARCXML:
+		return newMARCXMLReader(r), nil
+	}
+	return nil, domain.ValidationError("unsupported import format",
+		domain.FieldError{Field: "format", Message: "must be one of: " + strings.Join(domain.ImportFormats, ", ")})
+}
+
+// Run lê r até o fim e devolve o job com as contagens e os erros por linha.
+// O job é gravado no início (running) e no fim (completed ou failed). Erros
+// de leitura do arquivo ou do banco interrompem a importação: o job fica
+// failed e o erro também é devolvido; os lotes já gravados permanecem.
+func (im *Importer) Run(ctx context.Context, r io.Reader, opts Options) (*domain.ImportJob, error) {
+	rd, err := newReader(opts.Format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &domain.ImportJob{
+		Status:    domain.ImportRunning,
+		Format:    opts.Format,
+		Source:    opts.Source,
+		DryRun:    opts.DryRun,
+		Errors:    []domain.ImportRowError{},
+		StartedAt: time.Now().UTC(),
+	}
+	if err := im.store.SaveImportJob(ctx, job); err != nil {
+		return nil, err
+	}
+	logger := logging.FromContext(ctx).With("import_job", job.UUID)
+	logger.Info("import started", "format", opts.Format, "source", opts.Source, "dry_run", opts.DryRun)
+
+	runErr := im.run(ctx, rd, job)
+
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	job.Status = domain.ImportCompleted
+	if runErr != nil {
+		job.Status = domain.ImportFailed
+		job.Message = "import aborted: " + publicMessage(runErr)
+	}
+	// O contexto da requisição pode já ter sido cancelado; o relatório
+	// precisa ser gravado mesmo assim
+	if err := im.store.SaveImportJob(context.WithoutCancel(ctx), job); err != nil {
+		return job, errors.Join(runErr, err)
+	}
+
+	logger.Info("import finished", "status", job.Status, "rows", job.Rows, "created", job.Created,
+		"updated", job.Updated, "failed", job.Failed, "partial", job.Partial, "authors_created", job.AuthorsCreated)
+	return job, runErr
+}
+
+func (im *Importer) run(ctx context.Context, rd reader, job *domain.ImportJob) error {
+	var (
+		batch      []domain.ImportRow
+		isbnLines  = map[string]int{} // ISBN → linha onde apareceu
+		newAuthors = map[string]bool{}
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		res, err := im.store.ImportBooks(ctx, batch, job.DryRun)
+		if err != nil {
+			return err
+		}
+		job.Created += res.Created
+		job.Updated += res.Updated
+		// Em dry-run cada lote é desfeito, então um autor novo pode
+		// aparecer como criado em mais de um lote
+		for _, key := range res.AuthorsCreated {
+			newAuthors[key] = true
+		}
+		job.AuthorsCreated = len(newAuthors)
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, rec, err := rd.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var re *rowError
+			if !errors.As(err, &re) {
+				return err
+			}
+			job.Rows++
+			job.AddError(re.ImportRowError)
+			continue
+		}
+		job.Rows++
+
+		row, rerr := toRow(line, rec)
+		if rerr == nil && row.ISBN != "" {
+			if first, dup := isbnLines[row.ISBN]; dup {
+				rerr = &domain.ImportRowError{Line: line, Record: rec.ref, Field: "isbn",
+					Message: fmt.Sprintf("duplicate ISBN, already imported at line %d", first)}
+			} else {
+				isbnLines[row.ISBN] = line
+			}
+		}
+		if rerr != nil {
+			job.AddError(*rerr)
+			continue
+		}
+		job.AddWarnings(rec.warnings)
+
+		batch = append(batch, row)
+		if len(batch) >= im.batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// toRow valida o registro com as regras de POST /books e normaliza os
+// metadados e os nomes de autores
+func toRow(line int, rec record) (domain.ImportRow, *domain.ImportRowError) {
+	rec.Title = strings.TrimSpace(rec.Title)
+	authors := make([]string, 0, len(rec.Authors))
+	seen := map[string]bool{}
+	for _, name := range rec.Authors {
+		name = strings.Join(strings.Fields(name), " ")
+		if key := domain.AuthorNameKey(name); name != "" && !seen[key] {
+			seen[key] = true
+			authors = append(authors, name)
+		}
+	}
+	rec.Authors = authors
+
+	if err := validation.Struct(rec); err != nil {
+		return domain.ImportRow{}, rowErrorFrom(line, rec, err)
+	}
+	if err := rec.Normalize(); err != nil {
+		return domain.ImportRow{}, rowErrorFrom(line, rec, err)
+	}
+	return domain.ImportRow{
+		Line:         line,
+		Title:        rec.Title,
+		Description:  rec.Description,
+		Authors:      rec.Authors,
+		BookMetadata: rec.BookMetadata,
+	}, nil
+}
+
+// rowErrorFrom usa o primeiro campo inválido como erro da linha
+func rowErrorFrom(line int, rec record, err error) *domain.ImportRowError {
+	re := &domain.ImportRowError{Line: line, Record: rec.ref, Message: err.Error()}
+	var fields []domain.FieldError
+	if fs, ok := validation.FieldErrors(err); ok {
+		fields = fs
+	}
+	var de *domain.Error
+	if errors.As(err, &de) {
+		fields = de.Fields
+	}
+	if len(fields) > 0 {
+		re.Field, re.Message = fields[0].Field, fields[0].Message
+	}
+	return re
+}
+
+// publicMessage evita expor detalhes internos (SQL, caminhos) no relatório
+func publicMessage(err error) string {
+	var de *domain.Error
+	switch {
+	case errors.As(err, &de):
+		return de.Message
+	case errors.Is(err, context.Canceled):
+		return "the request was canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "the import timed out"
+	}
+	return "internal error"
+}
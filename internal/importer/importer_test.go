@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+	"github.com/patrick-tondorf/lib_api/internal/storage/memory"
+)
+
+func runCSV(t *testing.T, stores storage.Stores, batchSize int, csv string, dryRun bool) *domain.ImportJob {
+	t.Helper()
+	im := New(stores.Imports)
+	im.batchSize = batchSize
+	job, err := im.Run(context.Background(), strings.NewReader(csv), Options{
+		Format: domain.ImportFormatCSV, Source: "catalog.csv", DryRun: dryRun,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != domain.ImportCompleted || job.FinishedAt == nil || job.UUID == "" {
+		t.Errorf("job = %+v", job)
+	}
+	return job
+}
+
+func TestRunRowErrors(t *testing.T) {
+	stores := memory.New().Stores()
+	csv := strings.Join([]string{
+		"Title,Authors,ISBN,Publication Year,Subjects",
+		"Dune,Frank Herbert,978-0-441-17271-9,1965,Science fiction",
+		"Dune (again),Frank Herbert,0441172717,1965,",             // mesmo ISBN, como ISBN-10
+		"1984,George Orwell; george  orwell;GEORGE ORWELL,,1949,", // autores repetidos
+		"X,Someone,,,",
+		"Dune Messiah,Frank Herbert,978-0-441-17271-8,1969,",
+		"Animal Farm,George Orwell,,next year,",
+		"Children of Dune,Frank Herbert",
+		"Brave New World,Aldous Huxley,,1932,Dystopia;Science fiction",
+		"Heretics of Dune,Frank Herbert,,3000,",
+		"",
+	}, "\n")
+
+	job := runCSV(t, stores, 2, csv, false)
+	want := []domain.ImportRowError{
+		{Line: 3, Field: "isbn", Message: "duplicate ISBN, already imported at line 2"},
+		{Line: 5, Field: "title", Message: "must be at least 2 characters long"},
+		{Line: 6, Field: "isbn", Message: "has an invalid check digit"},
+		{Line: 7, Field: "publicationYear", Message: "must be a whole number"},
+		{Line: 8, Message: "wrong number of fields: expected 5, got 2"},
+		{Line: 10, Field: "publicationYear", Message: "must not be in the future"},
+	}
+	if !reflect.DeepEqual(job.Errors, want) {
+		t.Errorf("errors:\n got %+v\nwant %+v", job.Errors, want)
+	}
+	if job.Rows != 9 || job.Failed != 6 || job.Created != 3 || job.Updated != 0 || job.AuthorsCreated != 3 {
+		t.Errorf("counts = rows %d failed %d created %d updated %d authors %d",
+			job.Rows, job.Failed, job.Created, job.Updated, job.AuthorsCreated)
+	}
+
+	books, _, err := stores.Books.GetBooksWithAuthors(context.Background(), domain.BookFilters{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, b := range books {
+		titles = append(titles, b.Title)
+		if b.Title == "1984" && len(b.Authors) != 1 {
+			t.Errorf("1984 has %d authors, want 1", len(b.Authors))
+		}
+	}
+	if len(titles) != 3 {
+		t.Errorf("stored books = %v", titles)
+	}
+}
+
+func TestRunUpdatesByISBN(t *testing.T) {
+	stores := memory.New().Stores()
+	header := "title,authors,isbn\n"
+	runCSV(t, stores, DefaultBatchSize, header+"Dune,Frank Herbert,9780441172719\n", false)
+
+	// Em dry-run nada é gravado, mas as contagens são as da importação real
+	job := runCSV(t, stores, DefaultBatchSize, header+"Dune (40th anniversary),Frank Herbert;Brian Herbert,0-441-17271-7\n", true)
+	if !job.DryRun || job.Created != 0 || job.Updated != 1 || job.AuthorsCreated != 1 {
+		t.Errorf("dry run = %+v", job)
+	}
+	job = runCSV(t, stores, DefaultBatchSize, header+"Dune (40th anniversary),Frank Herbert;Brian Herbert,0-441-17271-7\n", false)
+	if job.Created != 0 || job.Updated != 1 || job.AuthorsCreated != 1 {
+		t.Errorf("import = %+v", job)
+	}
+
+	books, _, err := stores.Books.GetBooksWithAuthors(context.Background(), domain.BookFilters{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 1 || books[0].Title != "Dune (40th anniversary)" || len(books[0].Authors) != 2 {
+		t.Errorf("books = %+v", books)
+	}
+}
+
+func TestRunInvalidHeader(t *testing.T) {
+	stores := memory.New().Stores()
+	_, err := New(stores.Imports).Run(context.Background(), strings.NewReader("name,authors\n"), Options{Format: domain.ImportFormatCSV})
+	if !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("err = %v, want a validation error", err)
+	}
+	jobs, err := stores.Imports.ListImportJobs(context.Background(), 10)
+	if err != nil || len(jobs) != 0 {
+		t.Errorf("jobs = %v, %v; a rejected header must not create a job", jobs, err)
+	}
+}
+
+func TestFormatFromName(t *testing.T) {
+	tests := map[string]string{
+		"catalog.csv":    domain.ImportFormatCSV,
+		"CATALOG.CSV":    domain.ImportFormatCSV,
+		"books.ndjson":   domain.ImportFormatJSONL,
+		"books.jsonl":    domain.ImportFormatJSONL,
+		"records.mrc":    domain.ImportFormatMARC,
+		"records.xml":    domain.ImportFormatMARCXML,
+		"records.tar.gz": "",
+		"catalog":        "",
+	}
+	for name, want := range tests {
+		if got := FormatFromName(name); got != want {
+			t.Errorf("FormatFromName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
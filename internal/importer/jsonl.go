@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// maxJSONLine limita o tamanho de uma linha do JSON Lines
+const maxJSONLine = 1 << 20
+
+type jsonlReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), maxJSONLine)
+	return &jsonlReader{s: s}
+}
+
+func (j *jsonlReader) next() (int, record, error) {
+	for j.s.Scan() {
+		j.line++
+		raw := bytes.TrimSpace(j.s.Bytes())
+		if j.line == 1 {
+			raw = bytes.TrimPrefix(raw, []byte(utf8BOM))
+		}
+		if len(raw) == 0 {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return j.line, record{}, &rowError{domain.ImportRowError{Line: j.line, Message: jsonMessage(err)}}
+		}
+		return j.line, rec, nil
+	}
+	if err := j.s.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return 0, record{}, domain.ValidationError("a line of the JSON Lines file is longer than 1 MiB")
+		}
+		return 0, record{}, err
+	}
+	return 0, record{}, io.EOF
+}
+
+// jsonMessage descreve o erro de decodificação sem o prefixo "json: "
+func jsonMessage(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return typeErr.Field + " must be of type " + typeErr.Type.String()
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return "invalid JSON: " + syntaxErr.Error()
+	}
+	return "invalid JSON: " + err.Error()
+}
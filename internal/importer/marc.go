@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/marc"
+)
+
+// marcReader lê registros MARC 21 (ISO 2709 ou MARCXML) e os converte com
+// marc.ToBook. O número da linha é a posição do registro no arquivo.
+type marcReader struct {
+	read func() (*marc.Record, error)
+	n    int
+}
+
+func newMARCReader(r io.Reader) *marcReader {
+	return &marcReader{read: marc.NewReader(r).Read}
+}
+
+func newMARCXMLReader(r io.Reader) *marcReader {
+	return &marcReader{read: marc.NewXMLReader(r).Read}
+}
+
+func (m *marcReader) next() (int, record, error) {
+	rec, err := m.read()
+	if errors.Is(err, io.EOF) {
+		return 0, record{}, io.EOF
+	}
+	m.n++
+	var recErr *marc.RecordError
+	if errors.As(err, &recErr) {
+		return m.n, record{}, &rowError{domain.ImportRowError{Line: m.n, Message: "record skipped: " + recErr.Msg}}
+	}
+	if errors.Is(err, marc.ErrInvalid) {
+		return 0, record{}, domain.ValidationError(fmt.Sprintf("record %d: %v", m.n, err))
+	}
+	if err != nil {
+		return 0, record{}, err
+	}
+
+	book, warnings := marc.ToBook(rec)
+	out := record{
+		Title:       book.Title,
+		Description: book.Description,
+		BookMetadata: domain.BookMetadata{
+			ISBN:            book.ISBN,
+			Publisher:       book.Publisher,
+			PublicationYear: book.PublicationYear,
+			Language:        book.Language,
+			Pages:           book.Pages,
+			Edition:         book.Edition,
+			Format:          book.Format,
+			Subjects:        book.Subjects,
+		},
+		ref: rec.Control("001"),
+	}
+	for _, a := range book.Authors {
+		out.Authors = append(out.Authors, a.Name)
+	}
+	for _, w := range warnings {
+		out.warnings = append(out.warnings, domain.ImportRowError{Line: m.n, Record: out.ref, Field: w.Field, Message: w.Message})
+	}
+	return m.n, out, nil
+}
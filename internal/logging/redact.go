@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	emailPattern  = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)
+	// password=..., "token": "...", secret_key=... dentro de mensagens livres
+	secretPairPattern = regexp.MustCompile(`(?i)((?:password|passwd|secret|token)[A-Za-z_]*["']?\s*[:=]\s*["']?)[^\s"',&]+`)
+)
+
+// Chaves cujo valor nunca é escrito
+var secretKeys = []string{"password", "passwd", "secret", "token", "authorization", "cookie"}
+
+// Redact mascara emails, tokens e senhas encontrados em um texto livre
+func Redact(s string) string {
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = bearerPattern.ReplaceAllString(s, "${1}"+redacted)
+	s = secretPairPattern.ReplaceAllString(s, "${1}"+redacted)
+	return emailPattern.ReplaceAllString(s, "${1}***@${2}")
+}
+
+// MaskEmail mantém só a primeira letra e o domínio: j***@example.com
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return redacted
+	}
+	return local[:1] + "***@" + domain
+}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, k := range secretKeys {
+		if strings.Contains(key, k) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		if strings.Contains(key, "email") {
+			return slog.String(a.Key, MaskEmail(v.String()))
+		}
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindAny:
+		// Erros costumam trazer valores do banco (ex.: Key (email)=(...))
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return a
+}
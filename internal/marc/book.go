@@ -0,0 +1,476 @@
+package marc
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// Posições do líder (24 caracteres). O tamanho do registro e o endereço
+// base ficam zerados: só importam na serialização ISO 2709.
+const (
+	leaderBook      = "00000nam a2200000   4500" // material textual, monografia, UTF-8
+	leaderAudiobook = "00000nim a2200000   4500" // gravação sonora não musical
+)
+
+// marcLanguages traz os códigos MARC (ISO 639-2/B) que diferem do código
+// ISO 639-2/T devolvido por language.Base.ISO3
+var marcLanguages = map[string]string{
+	"sqi": "alb", "hye": "arm", "eus": "baq", "mya": "bur", "zho": "chi",
+	"ces": "cze", "nld": "dut", "fra": "fre", "kat": "geo", "deu": "ger",
+	"ell": "gre", "isl": "ice", "mkd": "mac", "mri": "mao", "msa": "may",
+	"fas": "per", "ron": "rum", "slk": "slo", "bod": "tib", "cym": "wel",
+}
+
+// FromBook converte um livro em registro MARC 21 bibliográfico:
+//
+//	001 UUID            005 última alteração   008 data, ano e idioma
+//	020 $a ISBN $q formato
+//	100 primeiro autor  700 demais autores (na forma "Sobrenome, Nome")
+//	245 $a título       250 $a edição
+//	264 $b editora $c ano
+//	300 $a páginas      520 $a descrição       650 $a assuntos
+func FromBook(b domain.Book) *Record {
+	r := &Record{Leader: leaderBook}
+	if b.Format == domain.FormatAudiobook {
+		r.Leader = leaderAudiobook
+	}
+
+	r.AddControl("001", b.UUID)
+	if t := lastChange(b); !t.IsZero() {
+		r.AddControl("005", t.UTC().Format("20060102150405")+".0")
+	}
+	r.AddControl("008", field008(b))
+
+	r.AddData("020", " ", " ", Sub("a", b.ISBN), Sub("q", b.Format))
+
+	titleInd := "0" // sem entrada principal de autor
+	for i, a := range b.Authors {
+		tag := "700"
+		if i == 0 {
+			tag, titleInd = "100", "1"
+		}
+		name, ind1 := InvertName(a.Name)
+		r.AddData(tag, ind1, " ", Sub("a", name))
+	}
+	r.AddData("245", titleInd, "0", Sub("a", b.Title))
+	r.AddData("250", " ", " ", Sub("a", b.Edition))
+
+	year := ""
+	if b.PublicationYear != nil {
+		year = fmt.Sprint(*b.PublicationYear)
+	}
+	r.AddData("264", " ", "1", Sub("b", b.Publisher), Sub("c", year))
+	if b.Pages != nil {
+		r.AddData("300", " ", " ", Sub("a", fmt.Sprintf("%d pages", *b.Pages)))
+	}
+	r.AddData("520", " ", " ", Sub("a", b.Description))
+	for _, s := range b.Subjects {
+		// Segundo indicador 4: termo sem vocabulário controlado
+		r.AddData("650", " ", "4", Sub("a", s))
+	}
+	return r
+}
+
+// InvertName passa "George Orwell" para "Orwell, George" e devolve o
+// primeiro indicador do 100/700: 1 para sobrenome primeiro, 0 para nomes de
+// uma palavra. Nomes que já têm vírgula são mantidos.
+func InvertName(name string) (string, string) {
+	name = strings.Join(strings.Fields(name), " ")
+	if strings.Contains(name, ",") {
+		return name, "1"
+	}
+	i := strings.LastIndexByte(name, ' ')
+	if i < 0 {
+		return name, "0"
+	}
+	return name[i+1:] + ", " + name[:i], "1"
+}
+
+// LanguageCode converte uma etiqueta BCP 47 ("pt-BR") no código MARC de três
+// letras ("por"); devolve "" se não houver
+func LanguageCode(tag string) string {
+	if tag == "" {
+		return ""
+	}
+	t, err := language.Parse(tag)
+	if err != nil {
+		return ""
+	}
+	base, conf := t.Base()
+	if conf != language.Exact {
+		return ""
+	}
+	code := base.ISO3()
+	if b, ok := marcLanguages[code]; ok {
+		return b
+	}
+	return code
+}
+
+// field008 monta os 40 caracteres do 008: data de cadastro (00-05), tipo
+// e ano de publicação (06-10), idioma (35-37). As posições específicas de
+// livros não são codificadas (|).
+func field008(b domain.Book) string {
+	entered := "      "
+	if b.CreatedAt != nil {
+		entered = b.CreatedAt.UTC().Format("060102")
+	}
+	dates := "nuuuu"
+	if b.PublicationYear != nil && *b.PublicationYear <= 9999 {
+		dates = fmt.Sprintf("s%04d", *b.PublicationYear)
+	}
+	lang := LanguageCode(b.Language)
+	if lang == "" {
+		lang = "und"
+	}
+	return entered + dates + "    " + "xx " + strings.Repeat("|", 17) + lang + " " + "d"
+}
+
+func lastChange(b domain.Book) time.Time {
+	if b.UpdatedAt != nil {
+		return *b.UpdatedAt
+	}
+	if b.CreatedAt != nil {
+		return *b.CreatedAt
+	}
+	return time.Time{}
+}
+
+// Limites de domain.Book; campos maiores são cortados com aviso
+const (
+	maxTitle       = 100
+	maxDescription = 500
+	maxPublisher   = 200
+	maxEdition     = 100
+	maxSubject     = 100
+)
+
+var (
+	yearPattern  = regexp.MustCompile(`(?:^|\D)(\d{4})(?:\D|$)`)
+	pagesPattern = regexp.MustCompile(`(?i)(\d+)\s*(?:p\b|p\.|pp\.?|pages?|páginas?|pág\.?)`)
+)
+
+// ToBook faz o caminho inverso de FromBook:
+//
+//	020 $a ISBN (o primeiro válido) e $q formato
+//	100/700 $a autores (ind1 1: "Sobrenome, Nome" vira "Nome Sobrenome")
+//	245 $a $b $n $p título    250 $a edição
+//	264 (ou 260) $b editora e $c ano, com o 008/07-10 como alternativa
+//	008/35-37 (ou 041 $a) idioma    300 $a páginas
+//	520 $a descrição                650 $a $v $x $y $z assuntos
+//
+// Os avisos descrevem o que não pôde ser aproveitado por completo (ISBN
+// inválido, ano ilegível, texto cortado...). Campos obrigatórios ausentes
+// (título, autores) não geram aviso: a validação do livro os recusa.
+func ToBook(r *Record) (domain.Book, []domain.FieldError) {
+	var (
+		b        domain.Book
+		warnings []domain.FieldError
+	)
+	warn := func(field, format string, args ...any) {
+		warnings = append(warnings, domain.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	for _, w := range r.Warnings {
+		warn("", "%s", w)
+	}
+
+	// ISBN e formato
+	var badISBN []string
+	for _, f := range r.Fields("020") {
+		for _, raw := range f.Values("a") {
+			token, qualifier, _ := strings.Cut(strings.TrimSpace(raw), " ")
+			if b.Format == "" {
+				b.Format = formatFrom(qualifier)
+			}
+			if b.ISBN != "" {
+				continue
+			}
+			if isbn, err := domain.NormalizeISBN(token); err == nil {
+				b.ISBN = isbn
+			} else {
+				badISBN = append(badISBN, token)
+			}
+		}
+		for _, q := range f.Values("q") {
+			if b.Format == "" {
+				b.Format = formatFrom(q)
+			}
+		}
+	}
+	if b.ISBN == "" && len(badISBN) > 0 {
+		warn("020", "invalid ISBN %s ignored", strings.Join(badISBN, ", "))
+	}
+	if len(r.Leader) > 6 && r.Leader[6] == 'i' {
+		b.Format = domain.FormatAudiobook
+	}
+	if b.Format == "" {
+		for _, f := range r.Fields("338") {
+			if strings.Contains(strings.ToLower(f.Value("a")), "online resource") {
+				b.Format = domain.FormatEbook
+			}
+		}
+	}
+
+	// Autores
+	for _, tag := range []string{"100", "700"} {
+		for _, f := range r.Fields(tag) {
+			name := trimPunctuation(f.Value("a"))
+			if name == "" {
+				warn(tag, "name without subfield $a ignored")
+				continue
+			}
+			if f.Ind1 == "1" {
+				name = uninvertName(name)
+			}
+			b.Authors = append(b.Authors, &domain.Author{Name: name})
+		}
+	}
+
+	// Título
+	if f := r.Fields("245"); len(f) > 0 {
+		main := trimPunctuation(f[0].Value("a"))
+		title := main
+		for _, sf := range f[0].Subfields {
+			v := trimPunctuation(sf.Value)
+			switch {
+			case v == "":
+			case sf.Code == "b":
+				title += ": " + v
+			case sf.Code == "n" || sf.Code == "p":
+				title += ". " + v
+			}
+		}
+		switch {
+		case runeLen(title) <= maxTitle:
+		case runeLen(main) <= maxTitle:
+			title = main
+			warn("245", "subtitle dropped: the title is limited to %d characters", maxTitle)
+		default:
+			title = truncate(main, maxTitle)
+			warn("245", "title truncated to %d characters", maxTitle)
+		}
+		b.Title = title
+	}
+
+	if f := r.Fields("250"); len(f) > 0 {
+		b.Edition = limit(trimPunctuation(f[0].Value("a")), maxEdition, "250", "edition", warn)
+	}
+
+	// Publicação: o 264 com segundo indicador 1 é a publicação; o 260 é a
+	// forma antiga
+	var pub *DataField
+	for _, f := range r.Fields("264") {
+		if f.Ind2 == "1" {
+			pub = &f
+			break
+		}
+	}
+	if pub == nil {
+		if f := r.Fields("260"); len(f) > 0 {
+			pub = &f[0]
+		}
+	}
+	if pub != nil {
+		b.Publisher = limit(trimPunctuation(pub.Value("b")), maxPublisher, pub.Tag, "publisher", warn)
+		if c := pub.Value("c"); c != "" {
+			if m := yearPattern.FindStringSubmatch(c); m != nil {
+				year, _ := strconv.Atoi(m[1])
+				b.PublicationYear = &year
+			}
+		}
+	}
+	f008 := r.Control("008")
+	if b.PublicationYear == nil && len(f008) >= 11 {
+		if year, err := strconv.Atoi(f008[7:11]); err == nil {
+			b.PublicationYear = &year
+		}
+	}
+	if b.PublicationYear == nil && pub != nil && pub.Value("c") != "" {
+		warn(pub.Tag, "publication year %q not understood", pub.Value("c"))
+	}
+
+	// Idioma
+	code := ""
+	if len(f008) >= 38 {
+		code = f008[35:38]
+	}
+	if strings.TrimSpace(code) == "" || code == "|||" {
+		if f := r.Fields("041"); len(f) > 0 {
+			code = f[0].Value("a")
+		}
+	}
+	if lang, ok := languageTag(code); ok {
+		b.Language = lang
+	} else {
+		warn("008", "unknown language code %q", code)
+	}
+
+	for _, f := range r.Fields("300") {
+		if m := pagesPattern.FindStringSubmatch(f.Value("a")); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+				b.Pages = &n
+				break
+			}
+		}
+	}
+
+	var summary []string
+	for _, f := range r.Fields("520") {
+		if v := strings.TrimSpace(f.Value("a")); v != "" {
+			summary = append(summary, v)
+		}
+	}
+	b.Description = limit(strings.Join(summary, " "), maxDescription, "520", "summary", warn)
+
+	// Assuntos: o termo e as subdivisões ($v forma, $x tópico, $y período,
+	// $z lugar)
+	seen := map[string]bool{}
+	for _, f := range r.Fields("650") {
+		var parts []string
+		for _, sf := range f.Subfields {
+			if sf.Code != "" && strings.Contains("avxyz", sf.Code) {
+				if v := trimPunctuation(sf.Value); v != "" {
+					parts = append(parts, v)
+				}
+			}
+		}
+		subject := strings.Join(parts, " -- ")
+		key := strings.ToLower(subject)
+		switch {
+		case subject == "" || seen[key]:
+		case runeLen(subject) > maxSubject:
+			warn("650", "subject %q ignored: longer than %d characters", truncate(subject, 30), maxSubject)
+		case len(b.Subjects) == domain.MaxSubjectsPerBook:
+			warn("650", "only the first %d subjects were kept", domain.MaxSubjectsPerBook)
+		default:
+			seen[key] = true
+			b.Subjects = append(b.Subjects, subject)
+		}
+	}
+	return b, dedupeWarnings(warnings)
+}
+
+// formatFrom reconhece o formato no qualificador do ISBN: "(pbk.)",
+// "hardcover", "e-book"...
+func formatFrom(qualifier string) string {
+	q := strings.ToLower(qualifier)
+	switch {
+	case q == "":
+		return ""
+	case strings.Contains(q, "pbk") || strings.Contains(q, "paper"):
+		return domain.FormatPaperback
+	case strings.Contains(q, "hbk") || strings.Contains(q, "hard") || strings.Contains(q, "cloth"):
+		return domain.FormatHardcover
+	case strings.Contains(q, "ebook") || strings.Contains(q, "e-book") || strings.Contains(q, "electronic"):
+		return domain.FormatEbook
+	case strings.Contains(q, "audio"):
+		return domain.FormatAudiobook
+	}
+	return ""
+}
+
+// uninvertName desfaz "Orwell, George" em "George Orwell"
+func uninvertName(name string) string {
+	last, first, ok := strings.Cut(name, ",")
+	if !ok || strings.TrimSpace(first) == "" {
+		return name
+	}
+	return strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+}
+
+// languageTag converte o código MARC de três letras na etiqueta BCP 47
+// usada em domain.Book ("por" → "pt"). Códigos vazios e os de "sem idioma"
+// valem como ausentes.
+func languageTag(code string) (string, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	switch code {
+	case "", "|||", "und", "zxx", "mul":
+		return "", true
+	}
+	for t, b := range marcLanguages {
+		if b == code {
+			code = t
+			break
+		}
+	}
+	base, err := language.ParseBase(code)
+	if err != nil {
+		return "", false
+	}
+	return base.String(), true
+}
+
+// trimPunctuation tira a pontuação ISBD do fim de um subcampo
+// ("Orwell, George," / "1984 :" / "Secker & Warburg,"). O ponto final só
+// é removido se não encerrar uma inicial ("Tolkien, J. R. R.").
+func trimPunctuation(s string) string {
+	s = strings.TrimSpace(s)
+	for {
+		t := strings.TrimRight(s, " /:;,=")
+		if strings.HasSuffix(t, ".") && !endsWithInitial(t) {
+			t = strings.TrimSuffix(t, ".")
+		}
+		t = strings.TrimSpace(t)
+		if t == s {
+			break
+		}
+		s = t
+	}
+	// Colchetes de informação inferida pelo catalogador: "[1949]"
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return s
+}
+
+func endsWithInitial(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	i := strings.LastIndexAny(s, " .")
+	word := s[i+1:]
+	return utf8.RuneCountInString(word) == 1
+}
+
+// limit corta s em max caracteres, registrando o aviso
+func limit(s string, max int, tag, what string, warn func(string, string, ...any)) string {
+	if runeLen(s) <= max {
+		return s
+	}
+	warn(tag, "%s truncated to %d characters", what, max)
+	return truncate(s, max)
+}
+
+// truncate corta em max caracteres, de preferência num espaço, com "…"
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	cut := string(r[:max-1])
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut) + "…"
+}
+
+func runeLen(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+func dedupeWarnings(ws []domain.FieldError) []domain.FieldError {
+	var out []domain.FieldError
+	for _, w := range ws {
+		if !slices.Contains(out, w) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
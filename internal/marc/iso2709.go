@@ -0,0 +1,156 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Delimitadores do ISO 2709
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+)
+
+// maxRecordLength é o maior registro que cabe nos cinco dígitos do líder
+const maxRecordLength = 99999
+
+// ErrInvalid marca os erros de formato que impedem a leitura do restante do
+// arquivo; erros de E/S são devolvidos como vieram
+var ErrInvalid = errors.New("invalid MARC data")
+
+// RecordError é um registro ISO 2709 mal formado cujos limites ainda são
+// conhecidos: o leitor pode pular para o próximo
+type RecordError struct {
+	Msg string
+}
+
+func (e *RecordError) Error() string { return e.Msg }
+
+// Reader lê registros MARC 21 em ISO 2709 (o ".mrc" binário) um a um.
+// Registros em UTF-8 (líder/09 = "a") são lidos como estão; os demais são
+// tratados como MARC-8 (ver decodeMARC8).
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read devolve o próximo registro ou io.EOF. Um *RecordError recusa só
+// aquele registro; outros erros significam que o arquivo não pode mais ser
+// lido.
+func (rd *Reader) Read() (*Record, error) {
+	// Quebras de linha entre registros são comuns em arquivos editados à mão
+	for {
+		b, err := rd.r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != '\n' && b[0] != '\r' {
+			break
+		}
+		rd.r.Discard(1)
+	}
+
+	head, err := rd.r.Peek(5)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: truncated record at the end of the file", ErrInvalid)
+		}
+		return nil, err
+	}
+	length, err := strconv.Atoi(string(head))
+	if err != nil || length < 24+1 || length > maxRecordLength {
+		return nil, fmt.Errorf("%w: invalid record length %q, the file is not ISO 2709 or is corrupt", ErrInvalid, head)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(rd.r, data); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("%w: truncated record at the end of the file", ErrInvalid)
+		}
+		return nil, err
+	}
+	if data[length-1] != recordTerminator {
+		return nil, fmt.Errorf("%w: record does not end where its length says (%d bytes), the file is corrupt", ErrInvalid, length)
+	}
+	return parseRecord(data)
+}
+
+func parseRecord(data []byte) (*Record, error) {
+	leader := string(data[:24])
+	base, err := strconv.Atoi(leader[12:17])
+	if err != nil || base < 25 || base > len(data) {
+		return nil, &RecordError{Msg: fmt.Sprintf("invalid base address of data %q", leader[12:17])}
+	}
+	dir := data[24 : base-1]
+	if data[base-1] != fieldTerminator || len(dir)%12 != 0 {
+		return nil, &RecordError{Msg: "invalid directory"}
+	}
+
+	r := &Record{Leader: leader}
+	utf8Record := leader[9] == 'a'
+	if !utf8Record {
+		// O registro convertido passa a ser UTF-8
+		r.Leader = leader[:9] + "a" + leader[10:]
+	}
+	decode := func(b []byte) string {
+		if utf8Record {
+			if !utf8.Valid(b) {
+				r.Warnings = appendOnce(r.Warnings, "record declares UTF-8 but has invalid bytes; they were replaced")
+				return string(bytes.ToValidUTF8(b, []byte("\uFFFD")))
+			}
+			return string(b)
+		}
+		s, warning := decodeMARC8(b)
+		if warning != "" {
+			r.Warnings = appendOnce(r.Warnings, warning)
+		}
+		return s
+	}
+
+	for i := 0; i < len(dir); i += 12 {
+		entry := dir[i : i+12]
+		tag := string(entry[:3])
+		flen, err1 := strconv.Atoi(string(entry[3:7]))
+		start, err2 := strconv.Atoi(string(entry[7:12]))
+		if err1 != nil || err2 != nil || flen < 1 || base+start+flen > len(data) {
+			return nil, &RecordError{Msg: fmt.Sprintf("invalid directory entry for field %s", tag)}
+		}
+		field := data[base+start : base+start+flen]
+		field = bytes.TrimSuffix(field, []byte{fieldTerminator})
+
+		if tag < "010" {
+			r.ControlFields = append(r.ControlFields, ControlField{Tag: tag, Value: decode(field)})
+			continue
+		}
+		df := DataField{Tag: tag, Ind1: " ", Ind2: " "}
+		if len(field) >= 2 && field[0] != subfieldDelimiter && field[1] != subfieldDelimiter {
+			df.Ind1, df.Ind2 = string(field[0:1]), string(field[1:2])
+			field = field[2:]
+		}
+		for _, sf := range bytes.Split(field, []byte{subfieldDelimiter}) {
+			if len(sf) == 0 {
+				continue // texto antes do primeiro delimitador (indicadores ausentes)
+			}
+			df.Subfields = append(df.Subfields, Subfield{Code: string(sf[:1]), Value: decode(sf[1:])})
+		}
+		r.DataFields = append(r.DataFields, df)
+	}
+	return r, nil
+}
+
+func appendOnce(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
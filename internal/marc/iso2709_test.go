@@ -0,0 +1,154 @@
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// build monta um registro ISO 2709 com a codificação enc (líder/09: 'a'
+// para UTF-8, ' ' para MARC-8). fields são a etiqueta seguida do conteúdo;
+// nos campos de dados o conteúdo já traz os indicadores e os "$" como
+// delimitadores de subcampo.
+func build(enc byte, fields ...string) []byte {
+	var dir, data bytes.Buffer
+	for _, f := range fields {
+		tag, content := f[:3], strings.ReplaceAll(f[3:], "$", string(rune(subfieldDelimiter)))
+		fmt.Fprintf(&dir, "%s%04d%05d", tag, len(content)+1, data.Len())
+		data.WriteString(content)
+		data.WriteByte(fieldTerminator)
+	}
+	base := 24 + dir.Len() + 1
+	total := base + data.Len() + 1
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%05dnam %c22%05d   4500", total, enc, base)
+	out.Write(dir.Bytes())
+	out.WriteByte(fieldTerminator)
+	out.Write(data.Bytes())
+	out.WriteByte(recordTerminator)
+	return out.Bytes()
+}
+
+func TestReadRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		leader9  byte
+		title    string
+		author   string
+		warnings int
+	}{
+		{"utf-8", build('a', "001abc", "1001 $aSaramago, José,", "24510$aEnsaio sobre a cegueira /$bromance"), 'a', "Ensaio sobre a cegueira /", "Saramago, José,", 0},
+		{"marc-8", build(' ', "001abc", "1001 $aSaramago, Jos\xE2e,", "24510$aA jangada de pedra"), 'a', "A jangada de pedra", "Saramago, José,", 0},
+		{"invalid utf-8", build('a', "24510$aBad \xFF title"), 'a', "Bad � title", "", 1},
+		{"no indicators", build('a', "245$aShort field"), 'a', "Short field", "", 0},
+	}
+	for _, tt := range tests {
+		r, err := NewReader(bytes.NewReader(tt.data)).Read()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if r.Leader[9] != tt.leader9 {
+			t.Errorf("%s: leader/09 %q, want %q", tt.name, r.Leader[9], tt.leader9)
+		}
+		var title, author string
+		if f := r.Fields("245"); len(f) > 0 {
+			title = f[0].Value("a")
+		}
+		if f := r.Fields("100"); len(f) > 0 {
+			author = f[0].Value("a")
+		}
+		if title != tt.title || author != tt.author {
+			t.Errorf("%s: title %q author %q, want %q and %q", tt.name, title, author, tt.title, tt.author)
+		}
+		if len(r.Warnings) != tt.warnings {
+			t.Errorf("%s: warnings %v, want %d", tt.name, r.Warnings, tt.warnings)
+		}
+	}
+}
+
+func TestReadFields(t *testing.T) {
+	data := build('a', "001rec-1", "008240101s1949", "24510$aNineteen eighty-four :$ba novel", "650 4$aDystopia", "650 4$aPolitics$xHistory")
+	r, err := NewReader(bytes.NewReader(data)).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Control("001") != "rec-1" || r.Control("008") != "240101s1949" {
+		t.Errorf("control fields: %+v", r.ControlFields)
+	}
+	f := r.Fields("245")[0]
+	if f.Ind1 != "1" || f.Ind2 != "0" || f.Value("b") != "a novel" {
+		t.Errorf("245: %+v", f)
+	}
+	subjects := r.Fields("650")
+	if len(subjects) != 2 || subjects[1].Ind2 != "4" || subjects[1].Value("x") != "History" {
+		t.Errorf("650: %+v", subjects)
+	}
+}
+
+func TestReadSequence(t *testing.T) {
+	// Registros separados por quebras de linha, como em arquivos editados
+	// à mão
+	var data []byte
+	data = append(data, build('a', "001one")...)
+	data = append(data, "\r\n"...)
+	data = append(data, build('a', "001two")...)
+	data = append(data, '\n')
+
+	rd := NewReader(bytes.NewReader(data))
+	for _, want := range []string{"one", "two"} {
+		r, err := rd.Read()
+		if err != nil || r.Control("001") != want {
+			t.Fatalf("record %s: %+v, %v", want, r, err)
+		}
+	}
+	if _, err := rd.Read(); err != io.EOF {
+		t.Errorf("after the last record: got %v, want io.EOF", err)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	good := build('a', "001ok", "24510$aTitle")
+	badBase := bytes.Clone(good)
+	copy(badBase[12:17], "99999")
+	badEntry := bytes.Clone(good)
+	copy(badEntry[24+3:24+7], "9999") // tamanho do 001 além do fim
+
+	tests := []struct {
+		name   string
+		data   []byte
+		fatal  bool // ErrInvalid: o arquivo não pode mais ser lido
+		record bool // *RecordError: só este registro é recusado
+	}{
+		{"not iso 2709", []byte("<?xml version=\"1.0\"?><collection/>"), true, false},
+		{"length too small", []byte("00010nam"), true, false},
+		{"truncated header", []byte("0012"), true, false},
+		{"truncated record", good[:len(good)-5], true, false},
+		{"missing terminator", append(bytes.Clone(good[:len(good)-1]), 'x'), true, false},
+		{"invalid base address", badBase, false, true},
+		{"invalid directory entry", badEntry, false, true},
+	}
+	for _, tt := range tests {
+		_, err := NewReader(bytes.NewReader(tt.data)).Read()
+		var recErr *RecordError
+		if got := errors.Is(err, ErrInvalid); got != tt.fatal {
+			t.Errorf("%s: ErrInvalid %v, want %v (%v)", tt.name, got, tt.fatal, err)
+		}
+		if got := errors.As(err, &recErr); got != tt.record {
+			t.Errorf("%s: RecordError %v, want %v (%v)", tt.name, got, tt.record, err)
+		}
+	}
+
+	// Depois de um *RecordError o leitor segue para o próximo registro
+	rd := NewReader(bytes.NewReader(append(badBase, good...)))
+	if _, err := rd.Read(); err == nil {
+		t.Fatal("bad record: want an error")
+	}
+	if r, err := rd.Read(); err != nil || r.Control("001") != "ok" {
+		t.Errorf("record after a bad one: %+v, %v", r, err)
+	}
+}
@@ -0,0 +1,83 @@
+package marc
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ansel mapeia os caracteres gráficos do conjunto latino estendido do MARC-8
+// (ANSEL, 0xA1-0xC8)
+var ansel = map[byte]rune{
+	0xA1: 'Ł', 0xA2: 'Ø', 0xA3: 'Đ', 0xA4: 'Þ', 0xA5: 'Æ', 0xA6: 'Œ', 0xA7: 'ʹ',
+	0xA8: '·', 0xA9: '♭', 0xAA: '®', 0xAB: '±', 0xAC: 'Ơ', 0xAD: 'Ư', 0xAE: 'ʼ',
+	0xB0: 'ʻ', 0xB1: 'ł', 0xB2: 'ø', 0xB3: 'đ', 0xB4: 'þ', 0xB5: 'æ', 0xB6: 'œ',
+	0xB7: 'ʺ', 0xB8: 'ı', 0xB9: '£', 0xBA: 'ð', 0xBC: 'ơ', 0xBD: 'ư', 0xC0: '°',
+	0xC1: 'ℓ', 0xC2: '℗', 0xC3: '©', 0xC4: '♯', 0xC5: '¿', 0xC6: '¡', 0xC7: 'ß',
+	0xC8: '€',
+}
+
+// anselCombining mapeia os diacríticos do MARC-8 (0xE0-0xFE). No MARC-8 o
+// diacrítico vem antes da letra; em Unicode, depois.
+var anselCombining = map[byte]rune{
+	0xE0: '\u0309', 0xE1: '\u0300', 0xE2: '\u0301', 0xE3: '\u0302', 0xE4: '\u0303',
+	0xE5: '\u0304', 0xE6: '\u0306', 0xE7: '\u0307', 0xE8: '\u0308', 0xE9: '\u030C',
+	0xEA: '\u030A', 0xEB: '\uFE20', 0xEC: '\uFE21', 0xED: '\u0315', 0xEE: '\u030B',
+	0xEF: '\u0310', 0xF0: '\u0327', 0xF1: '\u0328', 0xF2: '\u0323', 0xF3: '\u0324',
+	0xF4: '\u0325', 0xF5: '\u0333', 0xF6: '\u0332', 0xF7: '\u0326', 0xF8: '\u031C',
+	0xF9: '\u032E', 0xFA: '\uFE22', 0xFB: '\uFE23', 0xFE: '\u0313',
+}
+
+// decodeMARC8 converte texto MARC-8 com os conjuntos básico e latino
+// estendido para UTF-8 (NFC). Outros alfabetos, selecionados por
+// sequências de escape, não são suportados: os caracteres viram U+FFFD e a
+// mensagem de aviso é devolvida.
+func decodeMARC8(b []byte) (string, string) {
+	var (
+		out      strings.Builder
+		pending  []rune // diacríticos à espera da letra
+		warning  string
+		fallback bool // dentro de um conjunto não suportado
+	)
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c == 0x1B:
+			// ESC s (ou ESC ( B) volta ao ASCII; qualquer outra troca de
+			// conjunto não é suportada
+			rest := b[i+1:]
+			switch {
+			case len(rest) >= 1 && rest[0] == 's':
+				fallback = false
+				i++
+			case len(rest) >= 2 && rest[0] == '(' && rest[1] == 'B':
+				fallback = false
+				i += 2
+			default:
+				fallback = true
+				warning = "record uses MARC-8 character sets other than Latin; those characters were replaced"
+				for i+1 < len(b) && b[i+1] >= 0x20 && b[i+1] <= 0x2F {
+					i++ // intermediários da sequência
+				}
+				i++ // caractere final
+			}
+		case fallback && c >= 0x21 && c != 0x7F:
+			out.WriteRune('\uFFFD')
+		case c < 0x80:
+			out.WriteByte(c)
+			out.WriteString(string(pending))
+			pending = pending[:0]
+		case anselCombining[c] != 0:
+			pending = append(pending, anselCombining[c])
+		case ansel[c] != 0:
+			out.WriteRune(ansel[c])
+			out.WriteString(string(pending))
+			pending = pending[:0]
+		default:
+			out.WriteRune('\uFFFD')
+			warning = "record has bytes that are not valid MARC-8; they were replaced"
+		}
+	}
+	out.WriteString(string(pending))
+	return norm.NFC.String(out.String()), warning
+}
@@ -0,0 +1,33 @@
+package marc
+
+import "testing"
+
+func TestDecodeMARC8(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		warning bool
+	}{
+		{"ascii", "Orwell, George", "Orwell, George", false},
+		{"acute before the letter", "Jos\xE2e", "José", false},
+		{"cedilla", "Fran\xF0cois", "François", false},
+		{"two diacritics", "\xE3\xE2e", "ế", false},
+		{"tilde", "S\xE4ao Paulo", "São Paulo", false},
+		{"extended latin", "\xA2stergaard, \xC3 1999", "Østergaard, © 1999", false},
+		{"dangling diacritic is kept", "abc\xE2", "ab\u0107", false},
+		{"escape back to ascii", "a\x1B(B b", "a b", false},
+		{"escape s", "a\x1Bsb", "ab", false},
+		{"unsupported set", "x\x1B(2ABC\x1Bs y", "x��� y", true},
+		{"invalid byte", "a\x80b", "a�b", true},
+	}
+	for _, tt := range tests {
+		got, warning := decodeMARC8([]byte(tt.in))
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		if (warning != "") != tt.warning {
+			t.Errorf("%s: warning %q, want warning %v", tt.name, warning, tt.warning)
+		}
+	}
+}
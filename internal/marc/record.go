@@ -0,0 +1,110 @@
+// Package marc representa registros bibliográficos MARC 21 e os converte de
+// e para domain.Book. Os registros são escritos em MARCXML (o esquema
+// MARC21/slim da Library of Congress).
+package marc
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Namespace é o namespace XML do MARCXML
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+// Record é um registro MARC: líder, campos de controle (001-009) e campos
+// de dados com indicadores e subcampos. Ind1, Ind2 e Code têm um caractere.
+type Record struct {
+	XMLName       xml.Name       `xml:"record"`
+	Leader        string         `xml:"leader"`
+	ControlFields []ControlField `xml:"controlfield"`
+	DataFields    []DataField    `xml:"datafield"`
+
+	// Warnings são problemas de leitura que não impediram o registro de
+	// ser lido (ex.: caracteres que não puderam ser convertidos)
+	Warnings []string `xml:"-"`
+}
+
+type ControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type DataField struct {
+	Tag       string     `xml:"tag,attr"`
+	Ind1      string     `xml:"ind1,attr"`
+	Ind2      string     `xml:"ind2,attr"`
+	Subfields []Subfield `xml:"subfield"`
+}
+
+type Subfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// AddControl acrescenta um campo de controle, se value não for vazio
+func (r *Record) AddControl(tag, value string) {
+	if value != "" {
+		r.ControlFields = append(r.ControlFields, ControlField{Tag: tag, Value: value})
+	}
+}
+
+// AddData acrescenta um campo de dados com os subcampos não vazios; o campo
+// é omitido se nenhum sobrar
+func (r *Record) AddData(tag, ind1, ind2 string, subfields ...Subfield) {
+	kept := subfields[:0:0]
+	for _, sf := range subfields {
+		if strings.TrimSpace(sf.Value) != "" {
+			kept = append(kept, sf)
+		}
+	}
+	if len(kept) > 0 {
+		r.DataFields = append(r.DataFields, DataField{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: kept})
+	}
+}
+
+// Sub é um atalho para montar um subcampo
+func Sub(code, value string) Subfield {
+	return Subfield{Code: code, Value: value}
+}
+
+// Control devolve o valor do campo de controle tag ("" se não houver)
+func (r *Record) Control(tag string) string {
+	for _, cf := range r.ControlFields {
+		if cf.Tag == tag {
+			return cf.Value
+		}
+	}
+	return ""
+}
+
+// Fields devolve os campos de dados com a etiqueta tag, na ordem do registro
+func (r *Record) Fields(tag string) []DataField {
+	var out []DataField
+	for _, df := range r.DataFields {
+		if df.Tag == tag {
+			out = append(out, df)
+		}
+	}
+	return out
+}
+
+// Value devolve o primeiro subcampo code ("" se não houver)
+func (f DataField) Value(code string) string {
+	for _, sf := range f.Subfields {
+		if sf.Code == code {
+			return sf.Value
+		}
+	}
+	return ""
+}
+
+// Values devolve todos os subcampos code
+func (f DataField) Values(code string) []string {
+	var out []string
+	for _, sf := range f.Subfields {
+		if sf.Code == code {
+			out = append(out, sf.Value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package marc
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// XMLWriter escreve uma <collection> MARCXML registro a registro, sem
+// montar o documento inteiro em memória
+type XMLWriter struct {
+	w       io.Writer
+	enc     *xml.Encoder
+	started bool
+}
+
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	return &XMLWriter{w: w, enc: xml.NewEncoder(w)}
+}
+
+func (x *XMLWriter) start() error {
+	if x.started {
+		return nil
+	}
+	x.started = true
+	_, err := io.WriteString(x.w, xml.Header+`<collection xmlns="`+Namespace+`">`+"\n")
+	return err
+}
+
+// Write acrescenta um registro à coleção
+func (x *XMLWriter) Write(r *Record) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if err := x.enc.Encode(r); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.w, "\n")
+	return err
+}
+
+// Close fecha a coleção (vazia, se nenhum registro foi escrito)
+func (x *XMLWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.w, "</collection>\n")
+	return err
+}
+
+// XMLReader lê os <record> de um documento MARCXML (uma <collection> ou um
+// registro solto), com ou sem namespace, um a um
+type XMLReader struct {
+	dec *xml.Decoder
+}
+
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{dec: xml.NewDecoder(r)}
+}
+
+// Read devolve o próximo registro ou io.EOF. Erros de XML encerram a
+// leitura, porque o restante do documento fica ambíguo.
+func (x *XMLReader) Read() (*Record, error) {
+	for {
+		tok, err := x.dec.Token()
+		if err != nil {
+			return nil, xmlError(err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var r Record
+		if err := x.dec.DecodeElement(&r, &start); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, xmlError(err)
+		}
+		r.Leader = strings.TrimRight(r.Leader, "\n")
+		return &r, nil
+	}
+}
+
+// xmlError marca com ErrInvalid os erros de sintaxe e de estrutura
+func xmlError(err error) error {
+	var (
+		syntaxErr *xml.SyntaxError
+		unmarshal xml.UnmarshalError
+	)
+	if errors.As(err, &syntaxErr) || errors.As(err, &unmarshal) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestXMLReader(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		titles []string
+	}{
+		{"collection", `<?xml version="1.0"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim">
+  <record><leader>00000nam a2200000   4500</leader>
+    <datafield tag="245" ind1="1" ind2="0"><subfield code="a">Dune</subfield></datafield>
+  </record>
+  <record><leader>00000nam a2200000   4500</leader>
+    <datafield tag="245" ind1="1" ind2="0"><subfield code="a">Emma</subfield></datafield>
+  </record>
+</collection>`, []string{"Dune", "Emma"}},
+		{"single record without namespace", `<record><leader>00000nam a2200000   4500</leader>
+<controlfield tag="001">x</controlfield>
+<datafield tag="245" ind1="0" ind2="0"><subfield code="a">Ulysses</subfield></datafield></record>`, []string{"Ulysses"}},
+		{"prefixed namespace", `<marc:collection xmlns:marc="http://www.loc.gov/MARC21/slim"><marc:record>
+<marc:datafield tag="245" ind1="0" ind2="0"><marc:subfield code="a">Beloved</marc:subfield></marc:datafield>
+</marc:record></marc:collection>`, []string{"Beloved"}},
+		{"empty collection", `<collection xmlns="http://www.loc.gov/MARC21/slim"/>`, nil},
+	}
+	for _, tt := range tests {
+		rd := NewXMLReader(strings.NewReader(tt.doc))
+		var titles []string
+		for {
+			r, err := rd.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			titles = append(titles, r.Fields("245")[0].Value("a"))
+		}
+		if strings.Join(titles, "|") != strings.Join(tt.titles, "|") {
+			t.Errorf("%s: titles %v, want %v", tt.name, titles, tt.titles)
+		}
+	}
+}
+
+func TestXMLReaderErrors(t *testing.T) {
+	tests := []string{
+		`<collection><record><leader>x</leader>`,
+		`<collection><record><datafield tag="245"></record></collection>`,
+		`<collection><record></collection>`,
+	}
+	for _, doc := range tests {
+		rd := NewXMLReader(strings.NewReader(doc))
+		var err error
+		for err == nil {
+			_, err = rd.Read()
+		}
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("%q: got %v, want ErrInvalid", doc, err)
+		}
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	var want Record
+	want.Leader = leaderBook
+	want.AddControl("001", "rec-1")
+	want.AddData("100", "1", " ", Sub("a", "Brontë, Emily"))
+	want.AddData("245", "1", "0", Sub("a", "Wuthering Heights & <more>"), Sub("c", ""))
+
+	var buf bytes.Buffer
+	w := NewXMLWriter(&buf)
+	if err := w.Write(&want); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewXMLReader(&buf).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Leader != want.Leader || got.Control("001") != "rec-1" {
+		t.Errorf("leader or 001: %+v", got)
+	}
+	title := got.Fields("245")
+	if len(title) != 1 || len(title[0].Subfields) != 1 || title[0].Value("a") != "Wuthering Heights & <more>" {
+		t.Errorf("245: %+v", title)
+	}
+	if got.Fields("100")[0].Value("a") != "Brontë, Emily" {
+		t.Errorf("100: %+v", got.Fields("100"))
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// AuthMiddleware cria um middleware para autenticação JWT
+func AuthMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			unauthorized(c, "Authorization header is required")
+			return
+		}
+
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if tokenString == authHeader {
+			unauthorized(c, "Bearer token not found in Authorization header")
+			return
+		}
+
+		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return []byte(secret), nil
+		})
+
+		if err != nil {
+			unauthorized(c, "Invalid token: "+err.Error())
+			return
+		}
+
+		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+			c.Set("jwtClaims", claims)
+			c.Next()
+		} else {
+			unauthorized(c, "Invalid token claims")
+		}
+	}
+}
+
+// RequireRole recusa com 403 o token sem o papel role (claim role), ou um
+// papel maior. Deve vir depois de AuthMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := c.Get("jwtClaims")
+		mapClaims, _ := claims.(jwt.MapClaims)
+		current, _ := mapClaims["role"].(string)
+		if !domain.HasRole(current, role) {
+			_ = c.Error(domain.ForbiddenError("this operation requires the " + role + " role"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func unauthorized(c *gin.Context, msg string) {
+	_ = c.Error(domain.UnauthorizedError(msg))
+	c.Abort()
+}
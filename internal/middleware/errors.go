@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+)
+
+// ProblemContentType é o media type das respostas de erro (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// ErrorHandler renderiza o último erro registrado com c.Error como
+// application/problem+json. Os handlers só precisam chamar c.Error(err) e
+// retornar; a categoria do erro de domínio define o status.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		WriteProblem(c, c.Errors.Last().Err)
+	}
+}
+
+// Recovery transforma panics em um problema 500, sem expor detalhes
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		// http.ErrAbortHandler interrompe de propósito uma resposta já
+		// iniciada (ex.: falha no meio de uma exportação); o net/http fecha
+		// a conexão para que o cliente não tome o arquivo truncado por inteiro
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+		logging.FromContext(c.Request.Context()).Error("panic recovered",
+			"method", c.Request.Method, "path", c.Request.URL.Path, "panic", fmt.Sprint(recovered))
+		writeProblem(c, http.StatusInternalServerError, "internal", "an unexpected error occurred", nil)
+	})
+}
+
+// NoRoute responde rotas inexistentes no mesmo formato dos demais erros
+func NoRoute(c *gin.Context) {
+	writeProblem(c, http.StatusNotFound, "not-found", "route not found", nil)
+}
+
+// NoMethod responde métodos não suportados por uma rota existente
+func NoMethod(c *gin.Context) {
+	writeProblem(c, http.StatusMethodNotAllowed, "method-not-allowed", "method not allowed for this route", nil)
+}
+
+// WriteProblem escreve err como problem+json e aborta a requisição
+func WriteProblem(c *gin.Context, err error) {
+	status, slug := classify(err)
+
+	var de *domain.Error
+	if status == http.StatusInternalServerError || !errors.As(err, &de) {
+		// Erros inesperados nunca vão para o cliente, só para o log
+		logging.FromContext(c.Request.Context()).Error("internal error",
+			"method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+		writeProblem(c, http.StatusInternalServerError, "internal", "an unexpected error occurred", nil)
+		return
+	}
+	writeProblem(c, status, slug, de.Message, de.Fields)
+}
+
+func classify(err error) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, "not-found"
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict, "conflict"
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest, "validation"
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized, "unauthorized"
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden, "forbidden"
+	case errors.Is(err, domain.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, "too-large"
+	default:
+		return http.StatusInternalServerError, "internal"
+	}
+}
+
+func writeProblem(c *gin.Context, status int, slug, detail string, fields []domain.FieldError) {
+	if status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Bearer realm="lib-api"`)
+	}
+	// gin só define o Content-Type do JSON se ele ainda não estiver presente
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(status, domain.Problem{
+		Type:      domain.ProblemType(slug),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		RequestID: GetRequestID(c),
+		Errors:    fields,
+	})
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+)
+
+// RequestIDHeader é o cabeçalho usado para propagar o ID da requisição
+const RequestIDHeader = "X-Request-ID"
+
+const requestIDKey = "requestID"
+
+// RequestID reaproveita o X-Request-ID recebido (se for razoável) ou gera um
+// novo, e o devolve no cabeçalho da resposta. O context da requisição passa
+// a carregar um logger com o request_id, usado por handlers e repositórios
+// via logging.FromContext.
+func RequestID(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+
+		ctx := logging.WithContext(c.Request.Context(), logger.With("request_id", id))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// GetRequestID devolve o ID da requisição atual, ou "" fora do middleware
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID aceita só IDs curtos e imprimíveis, para não ecoar lixo
+// vindo do cliente nos logs e respostas
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
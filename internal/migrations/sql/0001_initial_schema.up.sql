@@ -0,0 +1,49 @@
+-- Esquema inicial. Bancos criados antes das migrações já têm estas tabelas,
+-- então tudo aqui é idempotente: "api migrate up" adota o banco existente,
+-- completando só as colunas que as versões antigas não tinham.
+CREATE TABLE IF NOT EXISTS authors (
+    id         BIGSERIAL PRIMARY KEY,
+    uuid       UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    name       TEXT NOT NULL,
+    bio        TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ
+);
+
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS bio TEXT;
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS authors_name_idx ON authors (name);
+
+CREATE TABLE IF NOT EXISTS books (
+    id          BIGSERIAL PRIMARY KEY,
+    uuid        UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    title       TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ
+);
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS books_title_idx ON books (title);
+CREATE INDEX IF NOT EXISTS books_created_at_idx ON books (created_at);
+
+CREATE TABLE IF NOT EXISTS books_authors (
+    book_id   BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    author_id BIGINT NOT NULL REFERENCES authors (id) ON DELETE RESTRICT,
+    PRIMARY KEY (book_id, author_id)
+);
+
+CREATE INDEX IF NOT EXISTS books_authors_author_id_idx ON books_authors (author_id);
+
+CREATE TABLE IF NOT EXISTS users (
+    id            BIGSERIAL PRIMARY KEY,
+    uuid          UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    email         TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
@@ -0,0 +1,22 @@
+DROP INDEX IF EXISTS books_search_en_idx;
+DROP INDEX IF EXISTS books_search_pt_idx;
+
+DROP TRIGGER IF EXISTS authors_search_update ON authors;
+DROP TRIGGER IF EXISTS books_authors_search_update ON books_authors;
+DROP TRIGGER IF EXISTS books_search_update ON books;
+
+DROP FUNCTION IF EXISTS authors_search_trigger();
+DROP FUNCTION IF EXISTS books_authors_search_trigger();
+DROP FUNCTION IF EXISTS books_search_trigger();
+DROP FUNCTION IF EXISTS refresh_book_search(BIGINT);
+DROP FUNCTION IF EXISTS book_search_vector(REGCONFIG, TEXT, TEXT, TEXT);
+DROP FUNCTION IF EXISTS book_author_names(BIGINT);
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS search_en,
+    DROP COLUMN IF EXISTS search_pt;
+
+DROP TEXT SEARCH CONFIGURATION IF EXISTS en_unaccent;
+DROP TEXT SEARCH CONFIGURATION IF EXISTS pt_unaccent;
+
+-- A extensão unaccent fica instalada: pode ser usada por outros esquemas
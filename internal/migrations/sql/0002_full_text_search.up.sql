@@ -0,0 +1,92 @@
+-- Busca textual: um tsvector por idioma em books, mantido por triggers, com
+-- título (peso A), nomes dos autores (B) e descrição (C). As configurações
+-- *_unaccent removem acentos antes do stemming, então "acao" encontra "ação".
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+CREATE TEXT SEARCH CONFIGURATION pt_unaccent (COPY = portuguese);
+ALTER TEXT SEARCH CONFIGURATION pt_unaccent
+    ALTER MAPPING FOR hword, hword_part, word WITH unaccent, portuguese_stem;
+
+CREATE TEXT SEARCH CONFIGURATION en_unaccent (COPY = english);
+ALTER TEXT SEARCH CONFIGURATION en_unaccent
+    ALTER MAPPING FOR hword, hword_part, word WITH unaccent, english_stem;
+
+ALTER TABLE books
+    ADD COLUMN search_pt TSVECTOR NOT NULL DEFAULT ''::tsvector,
+    ADD COLUMN search_en TSVECTOR NOT NULL DEFAULT ''::tsvector;
+
+CREATE FUNCTION book_author_names(p_book_id BIGINT) RETURNS TEXT
+LANGUAGE sql STABLE AS $$
+    SELECT COALESCE(string_agg(a.name, ' '), '')
+    FROM books_authors ba
+    JOIN authors a ON a.id = ba.author_id
+    WHERE ba.book_id = p_book_id
+$$;
+
+CREATE FUNCTION book_search_vector(cfg REGCONFIG, title TEXT, authors TEXT, description TEXT) RETURNS TSVECTOR
+LANGUAGE sql STABLE AS $$
+    SELECT setweight(to_tsvector(cfg, COALESCE(title, '')), 'A')
+        || setweight(to_tsvector(cfg, COALESCE(authors, '')), 'B')
+        || setweight(to_tsvector(cfg, COALESCE(description, '')), 'C')
+$$;
+
+-- Recalcula os vetores de um livro; usado quando muda a lista de autores
+CREATE FUNCTION refresh_book_search(p_book_id BIGINT) RETURNS VOID
+LANGUAGE sql AS $$
+    UPDATE books SET
+        search_pt = book_search_vector('pt_unaccent', title, book_author_names(id), description),
+        search_en = book_search_vector('en_unaccent', title, book_author_names(id), description)
+    WHERE id = p_book_id
+$$;
+
+CREATE FUNCTION books_search_trigger() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+DECLARE
+    names TEXT := book_author_names(NEW.id);
+BEGIN
+    NEW.search_pt := book_search_vector('pt_unaccent', NEW.title, names, NEW.description);
+    NEW.search_en := book_search_vector('en_unaccent', NEW.title, names, NEW.description);
+    RETURN NEW;
+END
+$$;
+
+CREATE TRIGGER books_search_update
+    BEFORE INSERT OR UPDATE OF title, description ON books
+    FOR EACH ROW EXECUTE FUNCTION books_search_trigger();
+
+CREATE FUNCTION books_authors_search_trigger() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM refresh_book_search(OLD.book_id);
+    ELSE
+        PERFORM refresh_book_search(NEW.book_id);
+    END IF;
+    RETURN NULL;
+END
+$$;
+
+CREATE TRIGGER books_authors_search_update
+    AFTER INSERT OR DELETE ON books_authors
+    FOR EACH ROW EXECUTE FUNCTION books_authors_search_trigger();
+
+CREATE FUNCTION authors_search_trigger() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    PERFORM refresh_book_search(ba.book_id)
+    FROM books_authors ba
+    WHERE ba.author_id = NEW.id;
+    RETURN NULL;
+END
+$$;
+
+CREATE TRIGGER authors_search_update
+    AFTER UPDATE OF name ON authors
+    FOR EACH ROW EXECUTE FUNCTION authors_search_trigger();
+
+UPDATE books SET
+    search_pt = book_search_vector('pt_unaccent', title, book_author_names(id), description),
+    search_en = book_search_vector('en_unaccent', title, book_author_names(id), description);
+
+CREATE INDEX books_search_pt_idx ON books USING GIN (search_pt);
+CREATE INDEX books_search_en_idx ON books USING GIN (search_en);
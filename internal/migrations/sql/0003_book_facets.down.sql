@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS book_subjects;
+
+DROP INDEX IF EXISTS books_publication_year_idx;
+DROP INDEX IF EXISTS books_language_idx;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS publication_year,
+    DROP COLUMN IF EXISTS language;
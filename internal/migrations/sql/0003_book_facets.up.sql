@@ -0,0 +1,16 @@
+-- Dados de catalogação usados pelas facetas da listagem de livros: idioma,
+-- ano de publicação (a faceta agrupa por década) e assuntos.
+ALTER TABLE books
+    ADD COLUMN language         TEXT,
+    ADD COLUMN publication_year INTEGER CHECK (publication_year BETWEEN 0 AND 9999);
+
+CREATE INDEX books_language_idx ON books (language);
+CREATE INDEX books_publication_year_idx ON books (publication_year);
+
+CREATE TABLE book_subjects (
+    book_id BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    subject TEXT NOT NULL CHECK (subject <> ''),
+    PRIMARY KEY (book_id, subject)
+);
+
+CREATE INDEX book_subjects_subject_idx ON book_subjects (subject);
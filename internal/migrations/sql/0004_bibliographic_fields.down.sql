@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS books_publisher_idx;
+DROP INDEX IF EXISTS books_isbn_key;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS format,
+    DROP COLUMN IF EXISTS edition,
+    DROP COLUMN IF EXISTS pages,
+    DROP COLUMN IF EXISTS publisher,
+    DROP COLUMN IF EXISTS isbn;
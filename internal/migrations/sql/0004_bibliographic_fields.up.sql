@@ -0,0 +1,11 @@
+-- Campos bibliográficos. O ISBN (sempre ISBN-13, só dígitos) identifica a
+-- edição e é único entre os livros que o têm.
+ALTER TABLE books
+    ADD COLUMN isbn      TEXT CHECK (isbn ~ '^97[89][0-9]{10}$'),
+    ADD COLUMN publisher TEXT,
+    ADD COLUMN pages     INTEGER CHECK (pages > 0),
+    ADD COLUMN edition   TEXT,
+    ADD COLUMN format    TEXT CHECK (format IN ('hardcover', 'paperback', 'ebook', 'audiobook'));
+
+CREATE UNIQUE INDEX books_isbn_key ON books (isbn) WHERE isbn IS NOT NULL;
+CREATE INDEX books_publisher_idx ON books (publisher);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS authors_name_key_idx;
+DROP TABLE IF EXISTS import_jobs;
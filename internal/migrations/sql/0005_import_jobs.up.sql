@@ -0,0 +1,25 @@
+-- Relatórios das importações de catálogo. Os erros por linha ficam em JSON
+-- (no máximo domain.MaxImportErrors por job).
+CREATE TABLE import_jobs (
+    id               BIGSERIAL PRIMARY KEY,
+    uuid             UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    status           TEXT NOT NULL CHECK (status IN ('running', 'completed', 'failed')),
+    format           TEXT NOT NULL,
+    source           TEXT NOT NULL DEFAULT '',
+    dry_run          BOOLEAN NOT NULL DEFAULT FALSE,
+    rows_read        INTEGER NOT NULL DEFAULT 0,
+    created          INTEGER NOT NULL DEFAULT 0,
+    updated          INTEGER NOT NULL DEFAULT 0,
+    failed           INTEGER NOT NULL DEFAULT 0,
+    authors_created  INTEGER NOT NULL DEFAULT 0,
+    errors           JSONB NOT NULL DEFAULT '[]',
+    errors_truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    message          TEXT NOT NULL DEFAULT '',
+    started_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at      TIMESTAMPTZ
+);
+
+CREATE INDEX import_jobs_started_at_idx ON import_jobs (started_at);
+
+-- A importação reconhece autores pelo nome normalizado (domain.AuthorNameKey)
+CREATE INDEX authors_name_key_idx ON authors (lower(regexp_replace(btrim(name), '\s+', ' ', 'g')));
@@ -0,0 +1,4 @@
+ALTER TABLE import_jobs
+    DROP COLUMN IF EXISTS warnings_truncated,
+    DROP COLUMN IF EXISTS warnings,
+    DROP COLUMN IF EXISTS partial;
@@ -0,0 +1,5 @@
+-- Registros importados com perdas (campos MARC não aproveitados)
+ALTER TABLE import_jobs
+    ADD COLUMN partial INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN warnings JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN warnings_truncated BOOLEAN NOT NULL DEFAULT FALSE;
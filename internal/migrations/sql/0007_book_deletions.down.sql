@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS books_datestamp_idx;
+DROP TABLE IF EXISTS book_deletions;
@@ -0,0 +1,14 @@
+-- Livros excluídos, para a colheita OAI-PMH. Guardam os assuntos para que a
+-- exclusão também apareça para quem colhe por set. Os ids de books não são
+-- reaproveitados, então (deleted_at, book_id) ordena junto com os livros.
+CREATE TABLE book_deletions (
+    book_id    BIGINT PRIMARY KEY,
+    uuid       UUID NOT NULL UNIQUE,
+    subjects   TEXT[] NOT NULL DEFAULT '{}',
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX book_deletions_deleted_at_idx ON book_deletions (deleted_at, book_id);
+
+-- Datestamp (última alteração) dos livros na colheita
+CREATE INDEX books_datestamp_idx ON books ((COALESCE(updated_at, created_at)), id);
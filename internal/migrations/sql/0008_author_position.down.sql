@@ -0,0 +1 @@
+ALTER TABLE books_authors DROP COLUMN IF EXISTS position;
@@ -0,0 +1,3 @@
+-- Ordem de autoria (primeiro autor, segundo...), usada nas citações e na
+-- exportação. Vínculos antigos ficam em 0 e seguem desempatados pelo nome.
+ALTER TABLE books_authors ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
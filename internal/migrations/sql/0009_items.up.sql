@@ -0,0 +1,21 @@
+-- Exemplares físicos dos livros. Um livro com exemplares não pode ser
+-- excluído; os exemplares precisam ser removidos antes.
+CREATE TABLE items (
+    id          BIGSERIAL PRIMARY KEY,
+    uuid        UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    book_id     BIGINT NOT NULL REFERENCES books (id) ON DELETE RESTRICT,
+    barcode     TEXT NOT NULL,
+    call_number TEXT,
+    branch      TEXT NOT NULL,
+    location    TEXT,
+    status      TEXT NOT NULL DEFAULT 'available'
+                CHECK (status IN ('available', 'on_loan', 'in_transit', 'lost', 'withdrawn')),
+    acquired_on DATE,
+    price_cents BIGINT CHECK (price_cents >= 0),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ,
+    CONSTRAINT items_barcode_key UNIQUE (barcode)
+);
+
+CREATE INDEX items_book_id_idx ON items (book_id, status);
+CREATE INDEX items_branch_idx ON items (branch);
@@ -0,0 +1,21 @@
+-- Empréstimos de exemplares. Leitor e funcionários são usuários; a data de
+-- devolução é um dia no fuso da biblioteca.
+CREATE TABLE loans (
+    id             BIGSERIAL PRIMARY KEY,
+    uuid           UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    item_id        BIGINT NOT NULL REFERENCES items (id) ON DELETE RESTRICT,
+    patron_id      BIGINT NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    checked_out_by BIGINT NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    checked_out_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    due_date       DATE NOT NULL,
+    returned_at    TIMESTAMPTZ,
+    returned_by    BIGINT REFERENCES users (id) ON DELETE RESTRICT,
+    CHECK ((returned_at IS NULL) = (returned_by IS NULL))
+);
+
+-- Um exemplar tem no máximo um empréstimo em aberto: é o que impede dois
+-- empréstimos simultâneos mesmo entre transações concorrentes
+CREATE UNIQUE INDEX loans_item_active_key ON loans (item_id) WHERE returned_at IS NULL;
+CREATE INDEX loans_patron_id_idx ON loans (patron_id, checked_out_at, id);
+CREATE INDEX loans_checked_out_at_idx ON loans (checked_out_at, id);
+CREATE INDEX loans_due_date_idx ON loans (due_date) WHERE returned_at IS NULL;
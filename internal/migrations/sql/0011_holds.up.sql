@@ -0,0 +1,29 @@
+-- Reservas de títulos. A fila de cada livro é FIFO por created_at; uma
+-- reserva pronta (ready) tem um exemplar separado em item_id.
+CREATE TABLE holds (
+    id              BIGSERIAL PRIMARY KEY,
+    uuid            UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    book_id         BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    patron_id       BIGINT NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    pickup_branch   TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'waiting'
+                    CHECK (status IN ('waiting', 'suspended', 'ready', 'fulfilled', 'cancelled', 'expired')),
+    expires_on      DATE,
+    suspended_until DATE,
+    item_id         BIGINT REFERENCES items (id) ON DELETE SET NULL,
+    ready_at        TIMESTAMPTZ,
+    pickup_by       DATE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ,
+    closed_at       TIMESTAMPTZ,
+    CHECK (status <> 'ready' OR item_id IS NOT NULL)
+);
+
+-- Uma reserva aberta por leitor e livro, e um exemplar separado para no
+-- máximo uma reserva
+CREATE UNIQUE INDEX holds_patron_open_key ON holds (book_id, patron_id)
+    WHERE status IN ('waiting', 'suspended', 'ready');
+CREATE UNIQUE INDEX holds_item_ready_key ON holds (item_id) WHERE status = 'ready';
+CREATE INDEX holds_queue_idx ON holds (book_id, created_at, id) WHERE status IN ('waiting', 'suspended');
+CREATE INDEX holds_patron_id_idx ON holds (patron_id, created_at, id);
+CREATE INDEX holds_created_at_idx ON holds (created_at, id);
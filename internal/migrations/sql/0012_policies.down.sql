@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS policy_rules;
+ALTER TABLE items DROP COLUMN IF EXISTS material_type;
+ALTER TABLE users DROP COLUMN IF EXISTS category;
@@ -0,0 +1,24 @@
+-- Categoria do leitor e tipo de material do exemplar, os critérios das
+-- regras de circulação
+ALTER TABLE users ADD COLUMN category TEXT NOT NULL DEFAULT 'public'
+    CHECK (category IN ('student', 'staff', 'public'));
+ALTER TABLE items ADD COLUMN material_type TEXT NOT NULL DEFAULT 'book'
+    CHECK (material_type IN ('book', 'reference', 'dvd', 'ebook'));
+
+-- Regras de circulação. Critério vazio ('') vale para qualquer valor; no
+-- empréstimo vale a regra mais específica que casa com o leitor e o
+-- exemplar, e sem nenhuma valem os limites da configuração.
+CREATE TABLE policy_rules (
+    id              BIGSERIAL PRIMARY KEY,
+    uuid            UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    patron_category TEXT NOT NULL DEFAULT ''
+                    CHECK (patron_category IN ('', 'student', 'staff', 'public')),
+    material_type   TEXT NOT NULL DEFAULT ''
+                    CHECK (material_type IN ('', 'book', 'reference', 'dvd', 'ebook')),
+    loan_days       INTEGER NOT NULL CHECK (loan_days BETWEEN 1 AND 365),
+    max_renewals    INTEGER NOT NULL CHECK (max_renewals >= 0),
+    max_items       INTEGER NOT NULL CHECK (max_items >= 0),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ,
+    CONSTRAINT policy_rules_scope_key UNIQUE (patron_category, material_type)
+);
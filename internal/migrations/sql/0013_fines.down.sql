@@ -0,0 +1,6 @@
+ALTER TABLE loans DROP COLUMN IF EXISTS fines_closed;
+DROP TABLE IF EXISTS ledger_entries;
+ALTER TABLE policy_rules
+    DROP COLUMN IF EXISTS fine_daily_cents,
+    DROP COLUMN IF EXISTS fine_grace_days,
+    DROP COLUMN IF EXISTS fine_cap_cents;
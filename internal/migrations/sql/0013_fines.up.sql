@@ -0,0 +1,31 @@
+-- Multa por atraso de cada regra de circulação, em centavos
+ALTER TABLE policy_rules
+    ADD COLUMN fine_daily_cents BIGINT NOT NULL DEFAULT 0 CHECK (fine_daily_cents >= 0),
+    ADD COLUMN fine_grace_days  INTEGER NOT NULL DEFAULT 0 CHECK (fine_grace_days >= 0),
+    ADD COLUMN fine_cap_cents   BIGINT NOT NULL DEFAULT 0 CHECK (fine_cap_cents >= 0);
+
+-- Conta dos leitores. Os lançamentos nunca são alterados; balance_cents é
+-- o saldo devedor depois de cada um, calculado com a linha do leitor
+-- bloqueada.
+CREATE TABLE ledger_entries (
+    id            BIGSERIAL PRIMARY KEY,
+    uuid          UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    patron_id     BIGINT NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    type          TEXT NOT NULL CHECK (type IN ('overdue', 'lost', 'damage', 'payment', 'waiver')),
+    amount_cents  BIGINT NOT NULL CHECK (amount_cents > 0),
+    balance_cents BIGINT NOT NULL,
+    loan_id       BIGINT REFERENCES loans (id) ON DELETE RESTRICT,
+    note          TEXT NOT NULL DEFAULT '',
+    created_by    BIGINT REFERENCES users (id) ON DELETE RESTRICT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ledger_entries_patron_id_idx ON ledger_entries (patron_id, created_at, id);
+CREATE INDEX ledger_entries_loan_id_idx ON ledger_entries (loan_id) WHERE loan_id IS NOT NULL;
+
+-- A multa por atraso de um empréstimo é lançada dia a dia até a devolução;
+-- fines_closed marca os devolvidos que já tiveram a multa encerrada. Os
+-- devolvidos antes desta migração não são multados.
+ALTER TABLE loans ADD COLUMN fines_closed BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE loans SET fines_closed = TRUE WHERE returned_at IS NOT NULL;
+CREATE INDEX loans_fines_open_idx ON loans (due_date) WHERE NOT fines_closed;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS loan_renewals;
+ALTER TABLE loans
+    DROP COLUMN IF EXISTS renewals,
+    DROP COLUMN IF EXISTS fines_settled_cents;
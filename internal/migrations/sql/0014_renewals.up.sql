@@ -0,0 +1,19 @@
+-- Renovações de empréstimos. renewals conta as renovações para o limite da
+-- regra; fines_settled_cents é a multa por atraso já lançada para os
+-- prazos anteriores à última renovação, que não entra na multa do prazo
+-- atual.
+ALTER TABLE loans
+    ADD COLUMN renewals            INTEGER NOT NULL DEFAULT 0 CHECK (renewals >= 0),
+    ADD COLUMN fines_settled_cents BIGINT NOT NULL DEFAULT 0 CHECK (fines_settled_cents >= 0);
+
+-- Histórico das renovações, com o prazo anterior e o novo
+CREATE TABLE loan_renewals (
+    id                BIGSERIAL PRIMARY KEY,
+    loan_id           BIGINT NOT NULL REFERENCES loans (id) ON DELETE CASCADE,
+    renewed_by        BIGINT NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    renewed_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    previous_due_date DATE NOT NULL,
+    due_date          DATE NOT NULL
+);
+
+CREATE INDEX loan_renewals_loan_id_idx ON loan_renewals (loan_id, id);
@@ -0,0 +1,6 @@
+-- Papel do usuário na API, que vai no token: leitores só acessam a própria
+-- conta; funcionários operam o balcão; administradores mantêm as regras e
+-- os usuários. Não confundir com a categoria de leitor das regras de
+-- circulação.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'patron'
+    CHECK (role IN ('patron', 'staff', 'admin'));
@@ -0,0 +1,121 @@
+package oai
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/marc"
+)
+
+// Prefixos dos formatos de metadados disseminados
+const (
+	PrefixDC   = "oai_dc"
+	PrefixMARC = "marc21"
+)
+
+const (
+	dcNamespace     = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+	dcSchema        = "http://www.openarchives.org/OAI/2.0/oai_dc.xsd"
+	dcElements      = "http://purl.org/dc/elements/1.1/"
+	marcSchema      = "http://www.loc.gov/standards/marcxml/schema/MARC21slim.xsd"
+	dcSchemaLocator = dcNamespace + " " + dcSchema
+)
+
+// formats vale para todos os registros
+var formats = []metadataFormat{
+	{Prefix: PrefixDC, Schema: dcSchema, Namespace: dcNamespace},
+	{Prefix: PrefixMARC, Schema: marcSchema, Namespace: marc.Namespace},
+}
+
+func knownPrefix(prefix string) bool {
+	return slices.ContainsFunc(formats, func(f metadataFormat) bool { return f.Prefix == prefix })
+}
+
+type metadata struct {
+	DC   *dublinCore `xml:"oai_dc:dc"`
+	MARC *marcRecord `xml:"record"`
+}
+
+func metadataOf(prefix string, b domain.Book) *metadata {
+	if prefix == PrefixMARC {
+		return &metadata{MARC: &marcRecord{marc.FromBook(b)}}
+	}
+	return &metadata{DC: dublinCoreOf(b)}
+}
+
+// dublinCore é o oai_dc, o Dublin Core simples que todo repositório OAI-PMH
+// precisa oferecer
+type dublinCore struct {
+	Namespace      string   `xml:"xmlns:oai_dc,attr"`
+	DCNamespace    string   `xml:"xmlns:dc,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	Title          []string `xml:"dc:title"`
+	Creator        []string `xml:"dc:creator"`
+	Subject        []string `xml:"dc:subject"`
+	Description    []string `xml:"dc:description"`
+	Publisher      []string `xml:"dc:publisher"`
+	Date           []string `xml:"dc:date"`
+	Type           []string `xml:"dc:type"`
+	Format         []string `xml:"dc:format"`
+	Identifier     []string `xml:"dc:identifier"`
+	Language       []string `xml:"dc:language"`
+}
+
+// dublinCoreOf segue o crosswalk MARC → Dublin Core da Library of Congress:
+// extensão (páginas) e formato físico em dc:format, ISBN como URN e o tipo
+// DCMI (Text, ou Sound para audiolivros)
+func dublinCoreOf(b domain.Book) *dublinCore {
+	dc := &dublinCore{
+		Namespace:      dcNamespace,
+		DCNamespace:    dcElements,
+		SchemaLocation: dcSchemaLocator,
+		Title:          []string{b.Title},
+		Subject:        b.Subjects,
+		Type:           []string{"Text"},
+	}
+	for _, a := range b.Authors {
+		dc.Creator = append(dc.Creator, a.Name)
+	}
+	if b.Description != "" {
+		dc.Description = []string{b.Description}
+	}
+	if b.Publisher != "" {
+		dc.Publisher = []string{b.Publisher}
+	}
+	if b.PublicationYear != nil {
+		dc.Date = []string{fmt.Sprint(*b.PublicationYear)}
+	}
+	if b.Format == domain.FormatAudiobook {
+		dc.Type = []string{"Sound"}
+	}
+	if b.Pages != nil {
+		dc.Format = append(dc.Format, fmt.Sprintf("%d pages", *b.Pages))
+	}
+	if b.Format != "" {
+		dc.Format = append(dc.Format, b.Format)
+	}
+	if b.ISBN != "" {
+		dc.Identifier = []string{"urn:isbn:" + b.ISBN}
+	}
+	if b.Language != "" {
+		dc.Language = []string{b.Language}
+	}
+	return dc
+}
+
+// marcRecord escreve o registro MARCXML com o próprio namespace, já que
+// dentro do documento OAI-PMH o namespace padrão é outro
+type marcRecord struct {
+	*marc.Record
+}
+
+func (m marcRecord) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "record"}
+	start.Attr = []xml.Attr{
+		{Name: xml.Name{Local: "xmlns"}, Value: marc.Namespace},
+		{Name: xml.Name{Local: "xsi:schemaLocation"}, Value: marc.Namespace + " " + marcSchema},
+	}
+	return e.EncodeElement(m.Record, start)
+}
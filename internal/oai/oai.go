@@ -0,0 +1,469 @@
+// Package oai implementa um provedor de dados OAI-PMH 2.0 sobre o catálogo,
+// para que catálogos coletivos colham os registros dos livros. Atende os
+// seis verbos, dissemina oai_dc e MARCXML (marc21), agrupa os registros em
+// sets por assunto e informa as exclusões (deletedRecord persistent, a
+// partir da migração que passou a registrá-las).
+//
+// Os datestamps são a última alteração do livro. As listas são paginadas
+// por resumption tokens assinados, que carregam a consulta e a posição
+// (datestamp e id do último registro entregue); não expiram e não guardam
+// estado no servidor.
+package oai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/pagination"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// tokenPurpose separa os resumption tokens dos cursores das listagens
+const tokenPurpose = "oai-pmh list"
+
+// Config descreve o repositório no Identify
+type Config struct {
+	RepositoryName string
+	AdminEmail     string
+	// RepositoryIdentifier compõe os identificadores oai:<id>:<uuid>;
+	// vazio, é o host do baseURL da requisição
+	RepositoryIdentifier string
+	PageSize             int
+}
+
+// Provider responde as requisições OAI-PMH
+type Provider struct {
+	store  storage.HarvestStore
+	tokens *pagination.Codec
+	cfg    Config
+	now    func() time.Time
+}
+
+func New(store storage.HarvestStore, tokens *pagination.Codec, cfg Config) *Provider {
+	return &Provider{store: store, tokens: tokens, cfg: cfg, now: time.Now}
+}
+
+// verb descreve os argumentos aceitos por um verbo. Com resumable, o verbo
+// também aceita resumptionToken, que exclui os demais argumentos.
+type verb struct {
+	required  []string
+	optional  []string
+	resumable bool
+	handle    func(*call) error
+}
+
+var verbs = map[string]verb{
+	"Identify":            {handle: (*call).identify},
+	"ListMetadataFormats": {optional: []string{"identifier"}, handle: (*call).listMetadataFormats},
+	"ListSets":            {resumable: true, handle: (*call).listSets},
+	"GetRecord":           {required: []string{"identifier", "metadataPrefix"}, handle: (*call).getRecord},
+	"ListIdentifiers": {
+		required: []string{"metadataPrefix"}, optional: []string{"from", "until", "set"},
+		resumable: true, handle: (*call).listIdentifiers,
+	},
+	"ListRecords": {
+		required: []string{"metadataPrefix"}, optional: []string{"from", "until", "set"},
+		resumable: true, handle: (*call).listRecords,
+	},
+}
+
+// call é uma requisição em andamento
+type call struct {
+	*Provider
+	ctx     context.Context
+	resp    *Response
+	args    url.Values
+	baseURL string
+	state   listState // consulta das listas, já resolvida
+}
+
+// Handle atende uma requisição; baseURL é a URL pública do endpoint. Os
+// erros do protocolo vão dentro do documento; o erro devolvido é sempre uma
+// falha do armazenamento.
+func (p *Provider) Handle(ctx context.Context, baseURL string, args url.Values) (*Response, error) {
+	resp := newResponse(baseURL, p.now())
+
+	names := args["verb"]
+	if len(names) != 1 {
+		return resp.fail(errBadVerb, "exactly one verb argument is required"), nil
+	}
+	v, ok := verbs[names[0]]
+	if !ok {
+		return resp.fail(errBadVerb, fmt.Sprintf("illegal verb %q", names[0])), nil
+	}
+	if msg := v.check(args); msg != "" {
+		return resp.fail(errBadArgument, msg), nil
+	}
+	resp.echo(args)
+
+	c := &call{Provider: p, ctx: ctx, resp: resp, args: args, baseURL: baseURL}
+	if err := v.handle(c); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// check devolve a mensagem do badArgument, ou "" se os argumentos valem
+func (v verb) check(args url.Values) string {
+	for _, name := range slices.Sorted(maps.Keys(args)) {
+		if name == "verb" {
+			continue
+		}
+		known := slices.Contains(v.required, name) || slices.Contains(v.optional, name) ||
+			v.resumable && name == "resumptionToken"
+		if !known {
+			return fmt.Sprintf("illegal argument %q", name)
+		}
+		if len(args[name]) > 1 {
+			return fmt.Sprintf("argument %q is repeated", name)
+		}
+	}
+	if v.resumable && args.Has("resumptionToken") {
+		if len(args) > 2 {
+			return "resumptionToken is an exclusive argument"
+		}
+		return ""
+	}
+	for _, name := range v.required {
+		if args.Get(name) == "" {
+			return fmt.Sprintf("missing required argument %q", name)
+		}
+	}
+	return ""
+}
+
+func (c *call) identify() error {
+	earliest, err := c.store.EarliestDatestamp(c.ctx)
+	if err != nil {
+		return err
+	}
+	if earliest == nil {
+		now := c.now()
+		earliest = &now
+	}
+
+	id := &identify{
+		RepositoryName:    c.cfg.RepositoryName,
+		BaseURL:           c.baseURL,
+		ProtocolVersion:   "2.0",
+		AdminEmail:        c.cfg.AdminEmail,
+		EarliestDatestamp: earliest.UTC().Format(timeLayout),
+		DeletedRecord:     "persistent",
+		Granularity:       granularity,
+	}
+	// O esquema oai-identifier pede um nome de domínio; "localhost" ou um
+	// IP ainda servem de identificador, mas não são anunciados
+	if repo := c.repositoryID(); strings.Contains(repo, ".") && net.ParseIP(repo) == nil {
+		id.Description = []description{{Identifier: &oaiIdentifier{
+			Namespace:            identifierNamespace,
+			XSINamespace:         xsiNamespace,
+			SchemaLocation:       identifierSchema,
+			Scheme:               "oai",
+			RepositoryIdentifier: repo,
+			Delimiter:            ":",
+			SampleIdentifier:     c.identifier("550e8400-e29b-41d4-a716-446655440000"),
+		}}}
+	}
+	c.resp.Identify = id
+	return nil
+}
+
+func (c *call) listMetadataFormats() error {
+	if c.args.Has("identifier") {
+		uuid, ok := c.parseIdentifier(c.args.Get("identifier"))
+		if !ok {
+			c.resp.fail(errIDDoesNotExist, "unknown identifier")
+			return nil
+		}
+		if _, err := c.store.GetHarvestRecord(c.ctx, uuid); err != nil {
+			if errors.Is(err, domain.ErrBookNotFound) {
+				c.resp.fail(errIDDoesNotExist, "unknown identifier")
+				return nil
+			}
+			return err
+		}
+	}
+	c.resp.ListMetadataFormats = &listMetadataFormats{Formats: formats}
+	return nil
+}
+
+func (c *call) listSets() error {
+	if c.args.Has("resumptionToken") {
+		c.resp.fail(errBadResumptionToken, "ListSets is never paginated")
+		return nil
+	}
+	subjects, err := c.store.HarvestSubjects(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	var sets []set
+	seen := map[string]bool{}
+	for _, s := range subjects {
+		spec := setSpec(s)
+		if !seen[spec] {
+			seen[spec] = true
+			sets = append(sets, set{Spec: spec, Name: s})
+		}
+	}
+	if len(sets) == 0 {
+		c.resp.fail("noSetHierarchy", "no book has subjects yet")
+		return nil
+	}
+	slices.SortFunc(sets, func(a, b set) int { return strings.Compare(a.Spec, b.Spec) })
+	c.resp.ListSets = &listSets{Sets: sets}
+	return nil
+}
+
+func (c *call) getRecord() error {
+	prefix := c.args.Get("metadataPrefix")
+	uuid, ok := c.parseIdentifier(c.args.Get("identifier"))
+	if !ok {
+		c.resp.fail(errIDDoesNotExist, "unknown identifier")
+		return nil
+	}
+	if !knownPrefix(prefix) {
+		c.resp.fail(errCannotDisseminateFormat, fmt.Sprintf("unknown metadataPrefix %q", prefix))
+		return nil
+	}
+
+	rec, err := c.store.GetHarvestRecord(c.ctx, uuid)
+	if errors.Is(err, domain.ErrBookNotFound) {
+		c.resp.fail(errIDDoesNotExist, "unknown identifier")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.resp.GetRecord = &getRecord{Record: c.record(*rec, prefix)}
+	return nil
+}
+
+func (c *call) listIdentifiers() error {
+	page, token, ok, err := c.list(false)
+	if err != nil || !ok {
+		return err
+	}
+	out := &listIdentifiers{ResumptionToken: token}
+	for _, rec := range page {
+		out.Headers = append(out.Headers, c.header(rec))
+	}
+	c.resp.ListIdentifiers = out
+	return nil
+}
+
+func (c *call) listRecords() error {
+	page, token, ok, err := c.list(true)
+	if err != nil || !ok {
+		return err
+	}
+	out := &listRecords{ResumptionToken: token}
+	for _, rec := range page {
+		out.Records = append(out.Records, c.record(rec, c.state.Prefix))
+	}
+	c.resp.ListRecords = out
+	return nil
+}
+
+// listState é o conteúdo do resumption token: a consulta original e a
+// posição do último registro entregue
+type listState struct {
+	Prefix string     `json:"p"`
+	From   *time.Time `json:"f,omitempty"`
+	Until  *time.Time `json:"u,omitempty"` // exclusivo
+	Set    string     `json:"s,omitempty"`
+	Value  string     `json:"v,omitempty"` // datestamp do último registro (RFC 3339)
+	ID     int        `json:"i,omitempty"`
+	Cursor int        `json:"c"` // registros já entregues
+}
+
+// list lê uma página de ListIdentifiers ou ListRecords. ok é false quando
+// a resposta já tem um erro do protocolo.
+func (c *call) list(withBooks bool) (page []domain.HarvestRecord, token *resumptionToken, ok bool, err error) {
+	st, ok := c.listState()
+	if !ok {
+		return nil, nil, false, nil
+	}
+	c.state = st
+
+	q := domain.HarvestQuery{From: st.From, Until: st.Until, Limit: c.cfg.PageSize + 1, WithBooks: withBooks}
+	if st.Value != "" {
+		q.After = &domain.Keyset{Value: st.Value, ID: st.ID}
+	}
+	if st.Set != "" {
+		subjects, err := c.store.HarvestSubjects(c.ctx)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		for _, s := range subjects {
+			if setSpec(s) == st.Set {
+				q.Subjects = append(q.Subjects, s)
+			}
+		}
+		if len(q.Subjects) == 0 {
+			c.resp.fail(errNoRecordsMatch, fmt.Sprintf("set %q is empty", st.Set))
+			return nil, nil, false, nil
+		}
+	}
+
+	page, err = c.store.HarvestRecords(c.ctx, q)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if len(page) == 0 {
+		c.resp.fail(errNoRecordsMatch, "no records match the request")
+		return nil, nil, false, nil
+	}
+
+	resumed := c.args.Has("resumptionToken")
+	if len(page) > c.cfg.PageSize {
+		page = page[:c.cfg.PageSize]
+		next := st
+		last := page[len(page)-1].Keyset()
+		next.Value, next.ID = last.Value, last.ID
+		next.Cursor += len(page)
+		value, err := c.tokens.Seal(tokenPurpose, next)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		token = &resumptionToken{Cursor: st.Cursor, Value: value}
+	} else if resumed {
+		// token vazio: a lista paginada terminou
+		token = &resumptionToken{Cursor: st.Cursor}
+	}
+	return page, token, true, nil
+}
+
+// listState lê a consulta dos argumentos ou do resumption token
+func (c *call) listState() (listState, bool) {
+	if c.args.Has("resumptionToken") {
+		var st listState
+		if err := c.tokens.Open(tokenPurpose, c.args.Get("resumptionToken"), &st); err != nil {
+			c.resp.fail(errBadResumptionToken, "the resumptionToken is invalid")
+			return st, false
+		}
+		return st, true
+	}
+
+	st := listState{Prefix: c.args.Get("metadataPrefix"), Set: c.args.Get("set")}
+	if !knownPrefix(st.Prefix) {
+		c.resp.fail(errCannotDisseminateFormat, fmt.Sprintf("unknown metadataPrefix %q", st.Prefix))
+		return st, false
+	}
+
+	from, fromDay, err := parseDatestamp(c.args.Get("from"))
+	if err != nil {
+		c.resp.fail(errBadArgument, "from: "+err.Error())
+		return st, false
+	}
+	until, untilDay, err := parseDatestamp(c.args.Get("until"))
+	if err != nil {
+		c.resp.fail(errBadArgument, "until: "+err.Error())
+		return st, false
+	}
+	if from != nil && until != nil {
+		if fromDay != untilDay {
+			c.resp.fail(errBadArgument, "from and until must have the same granularity")
+			return st, false
+		}
+		if from.After(*until) {
+			c.resp.fail(errBadArgument, "from must not be later than until")
+			return st, false
+		}
+	}
+	if until != nil {
+		// until é inclusivo na granularidade pedida
+		end := until.Add(time.Second)
+		if untilDay {
+			end = until.AddDate(0, 0, 1)
+		}
+		until = &end
+	}
+	st.From, st.Until = from, until
+	return st, true
+}
+
+// parseDatestamp aceita as duas granularidades do protocolo: dia
+// (2006-01-02) e segundo (2006-01-02T15:04:05Z)
+func parseDatestamp(s string) (t *time.Time, day bool, err error) {
+	if s == "" {
+		return nil, false, nil
+	}
+	if parsed, err := time.Parse("2006-01-02", s); err == nil {
+		return &parsed, true, nil
+	}
+	parsed, err := time.Parse(timeLayout, s)
+	if err != nil {
+		return nil, false, errors.New("must be a UTC datestamp like 2006-01-02 or 2006-01-02T15:04:05Z")
+	}
+	return &parsed, false, nil
+}
+
+func (c *call) header(rec domain.HarvestRecord) header {
+	h := header{
+		Identifier: c.identifier(rec.UUID),
+		Datestamp:  rec.Datestamp.UTC().Format(timeLayout),
+		SetSpecs:   setSpecs(rec.Subjects),
+	}
+	if rec.Deleted {
+		h.Status = "deleted"
+	}
+	return h
+}
+
+// record monta o registro; exclusões só têm cabeçalho
+func (c *call) record(rec domain.HarvestRecord, prefix string) record {
+	r := record{Header: c.header(rec)}
+	if !rec.Deleted && rec.Book != nil {
+		r.Metadata = metadataOf(prefix, *rec.Book)
+	}
+	return r
+}
+
+func (c *call) repositoryID() string {
+	if c.cfg.RepositoryIdentifier != "" {
+		return c.cfg.RepositoryIdentifier
+	}
+	if u, err := url.Parse(c.baseURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return "localhost"
+}
+
+func (c *call) identifier(uuid string) string {
+	return "oai:" + c.repositoryID() + ":" + uuid
+}
+
+// parseIdentifier extrai o UUID de oai:<repositório>:<uuid>
+func (c *call) parseIdentifier(id string) (string, bool) {
+	uuid, ok := strings.CutPrefix(id, "oai:"+c.repositoryID()+":")
+	if !ok || !isUUID(uuid) {
+		return "", false
+	}
+	return strings.ToLower(uuid), true
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if r != '-' {
+				return false
+			}
+		case !strings.ContainsRune("0123456789abcdefABCDEF", r):
+			return false
+		}
+	}
+	return true
+}
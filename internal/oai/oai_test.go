@@ -0,0 +1,291 @@
+package oai
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/pagination"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+	"github.com/patrick-tondorf/lib_api/internal/storage/memory"
+)
+
+const testBaseURL = "https://lib.example.org/api/oai"
+
+// document é o que os testes leem do XML devolvido
+type document struct {
+	XMLName xml.Name `xml:"OAI-PMH"`
+	Request struct {
+		Verb            string `xml:"verb,attr"`
+		MetadataPrefix  string `xml:"metadataPrefix,attr"`
+		ResumptionToken string `xml:"resumptionToken,attr"`
+		BaseURL         string `xml:",chardata"`
+	} `xml:"request"`
+	Errors []struct {
+		Code    string `xml:"code,attr"`
+		Message string `xml:",chardata"`
+	} `xml:"error"`
+	Identify struct {
+		RepositoryName string `xml:"repositoryName"`
+		AdminEmail     string `xml:"adminEmail"`
+		DeletedRecord  string `xml:"deletedRecord"`
+		Sample         string `xml:"description>oai-identifier>sampleIdentifier"`
+	} `xml:"Identify"`
+	Sets    []string `xml:"ListSets>set>setSpec"`
+	Formats []string `xml:"ListMetadataFormats>metadataFormat>metadataPrefix"`
+	Record  struct {
+		Identifier string   `xml:"header>identifier"`
+		Title      []string `xml:"metadata>dc>title"`
+		Creator    []string `xml:"metadata>dc>creator"`
+		MARCLeader string   `xml:"metadata>record>leader"`
+	} `xml:"GetRecord>record"`
+	Headers []struct {
+		Status     string   `xml:"status,attr"`
+		Identifier string   `xml:"identifier"`
+		SetSpecs   []string `xml:"setSpec"`
+	} `xml:"ListIdentifiers>header"`
+	Records []struct {
+		Identifier string `xml:"header>identifier"`
+		Title      string `xml:"metadata>dc>title"`
+	} `xml:"ListRecords>record"`
+	Token *struct {
+		Cursor int    `xml:"cursor,attr"`
+		Value  string `xml:",chardata"`
+	} `xml:"ListIdentifiers>resumptionToken"`
+}
+
+// newTestProvider cria um catálogo em memória com os livros dados (título
+// e assuntos), em ordem de criação, e um provedor com páginas de 2
+func newTestProvider(t *testing.T, books ...domain.BookCreateRequest) (*Provider, storage.Stores, []string) {
+	t.Helper()
+	ctx := context.Background()
+	stores := memory.New().Stores()
+	author := &domain.Author{Name: "George Orwell"}
+	if err := stores.Authors.CreateAuthor(ctx, author); err != nil {
+		t.Fatal(err)
+	}
+	var uuids []string
+	for _, req := range books {
+		req.AuthorIDs = []int{author.ID}
+		b, err := stores.Books.CreateBook(ctx, req)
+		if err != nil {
+			t.Fatalf("create %q: %v", req.Title, err)
+		}
+		uuids = append(uuids, b.UUID)
+		// datestamps distintos, na ordem de criação
+		time.Sleep(2 * time.Millisecond)
+	}
+	p := New(stores.Harvest, pagination.NewCodec("test-secret"), Config{
+		RepositoryName:       "Test Library",
+		AdminEmail:           "admin@example.org",
+		RepositoryIdentifier: "lib.example.org",
+		PageSize:             2,
+	})
+	return p, stores, uuids
+}
+
+func bookReq(title string, subjects ...string) domain.BookCreateRequest {
+	req := domain.BookCreateRequest{Title: title}
+	req.Subjects = subjects
+	return req
+}
+
+// harvest atende a query e lê o XML que o handler escreveria
+func harvest(t *testing.T, p *Provider, query string) document {
+	t.Helper()
+	args, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := p.Handle(context.Background(), testBaseURL, args)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	raw, err := xml.Marshal(resp)
+	if err != nil {
+		t.Fatalf("%s: marshal: %v", query, err)
+	}
+	var doc document
+	if err := xml.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("%s: unmarshal %s: %v", query, raw, err)
+	}
+	if doc.Request.BaseURL != testBaseURL {
+		t.Errorf("%s: request base URL = %q", query, doc.Request.BaseURL)
+	}
+	return doc
+}
+
+func wantError(t *testing.T, doc document, query, code string) {
+	t.Helper()
+	if len(doc.Errors) != 1 || doc.Errors[0].Code != code {
+		t.Errorf("%s: errors = %+v, want %s", query, doc.Errors, code)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	p, _, _ := newTestProvider(t, bookReq("1984", "Dystopia"))
+	other := New(nil, pagination.NewCodec("other-secret"), Config{PageSize: 2})
+	foreign, err := other.tokens.Seal(tokenPurpose, listState{Prefix: PrefixDC, Cursor: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		code  string
+		echo  bool // o elemento request repete os argumentos
+	}{
+		{"", errBadVerb, false},
+		{"verb=Harvest", errBadVerb, false},
+		{"verb=Identify&verb=Identify", errBadVerb, false},
+		{"verb=Identify&metadataPrefix=oai_dc", errBadArgument, false},
+		{"verb=GetRecord&metadataPrefix=oai_dc", errBadArgument, false},
+		{"verb=ListRecords", errBadArgument, false},
+		{"verb=ListRecords&metadataPrefix=oai_dc&metadataPrefix=marc21", errBadArgument, false},
+		{"verb=ListRecords&metadataPrefix=oai_dc&resumptionToken=abc", errBadArgument, false},
+		{"verb=ListIdentifiers&metadataPrefix=oai_dc&from=2024-13-01", errBadArgument, true},
+		{"verb=ListIdentifiers&metadataPrefix=oai_dc&from=2024-01-01&until=2024-06-01T00:00:00Z", errBadArgument, true},
+		{"verb=ListIdentifiers&metadataPrefix=oai_dc&from=2024-06-01&until=2024-01-01", errBadArgument, true},
+		{"verb=ListRecords&metadataPrefix=mods", errCannotDisseminateFormat, true},
+		{"verb=GetRecord&metadataPrefix=mods&identifier=oai:lib.example.org:550e8400-e29b-41d4-a716-446655440000", errCannotDisseminateFormat, true},
+		{"verb=ListIdentifiers&resumptionToken=not-a-token", errBadResumptionToken, true},
+		{"verb=ListIdentifiers&resumptionToken=" + url.QueryEscape(foreign), errBadResumptionToken, true},
+		{"verb=ListSets&resumptionToken=abc", errBadResumptionToken, true},
+		{"verb=GetRecord&metadataPrefix=oai_dc&identifier=oai:other.org:550e8400-e29b-41d4-a716-446655440000", errIDDoesNotExist, true},
+		{"verb=GetRecord&metadataPrefix=oai_dc&identifier=oai:lib.example.org:550e8400-e29b-41d4-a716-446655440000", errIDDoesNotExist, true},
+		{"verb=ListMetadataFormats&identifier=oai:lib.example.org:42", errIDDoesNotExist, true},
+		{"verb=ListIdentifiers&metadataPrefix=oai_dc&set=poetry", errNoRecordsMatch, true},
+		{"verb=ListIdentifiers&metadataPrefix=oai_dc&until=2000-01-01", errNoRecordsMatch, true},
+	}
+	for _, tt := range tests {
+		doc := harvest(t, p, tt.query)
+		wantError(t, doc, tt.query, tt.code)
+		if echoed := doc.Request.Verb != ""; echoed != tt.echo {
+			t.Errorf("%s: request verb = %q, want echo %v", tt.query, doc.Request.Verb, tt.echo)
+		}
+	}
+}
+
+func TestIdentifyAndFormats(t *testing.T) {
+	p, _, uuids := newTestProvider(t, bookReq("1984"))
+
+	doc := harvest(t, p, "verb=Identify")
+	if len(doc.Errors) > 0 || doc.Identify.RepositoryName != "Test Library" || doc.Identify.AdminEmail != "admin@example.org" ||
+		doc.Identify.DeletedRecord != "persistent" || !strings.HasPrefix(doc.Identify.Sample, "oai:lib.example.org:") {
+		t.Errorf("Identify = %+v, errors %+v", doc.Identify, doc.Errors)
+	}
+
+	doc = harvest(t, p, "verb=ListMetadataFormats&identifier=oai:lib.example.org:"+uuids[0])
+	if strings.Join(doc.Formats, ",") != "oai_dc,marc21" {
+		t.Errorf("ListMetadataFormats = %v, errors %+v", doc.Formats, doc.Errors)
+	}
+}
+
+func TestGetRecord(t *testing.T) {
+	p, _, uuids := newTestProvider(t, bookReq("1984", "Dystopia"))
+	id := "oai:lib.example.org:" + uuids[0]
+
+	doc := harvest(t, p, "verb=GetRecord&metadataPrefix=oai_dc&identifier="+id)
+	if doc.Request.Verb != "GetRecord" || doc.Record.Identifier != id ||
+		strings.Join(doc.Record.Title, "|") != "1984" || strings.Join(doc.Record.Creator, "|") != "George Orwell" {
+		t.Errorf("GetRecord oai_dc = %+v, errors %+v", doc.Record, doc.Errors)
+	}
+
+	// o UUID é aceito em maiúsculas
+	doc = harvest(t, p, "verb=GetRecord&metadataPrefix=marc21&identifier=oai:lib.example.org:"+strings.ToUpper(uuids[0]))
+	if len(doc.Errors) > 0 || doc.Record.MARCLeader == "" {
+		t.Errorf("GetRecord marc21 = %+v, errors %+v", doc.Record, doc.Errors)
+	}
+}
+
+func TestResumptionTokens(t *testing.T) {
+	p, _, uuids := newTestProvider(t,
+		bookReq("One"), bookReq("Two"), bookReq("Three"), bookReq("Four"), bookReq("Five"))
+
+	var got []string
+	query := "verb=ListIdentifiers&metadataPrefix=oai_dc"
+	wantCursors := []int{0, 2, 4}
+	for page := 0; ; page++ {
+		doc := harvest(t, p, query)
+		if len(doc.Errors) > 0 {
+			t.Fatalf("page %d: errors %+v", page, doc.Errors)
+		}
+		for _, h := range doc.Headers {
+			got = append(got, strings.TrimPrefix(h.Identifier, "oai:lib.example.org:"))
+		}
+		if doc.Token == nil {
+			t.Fatalf("page %d: no resumptionToken", page)
+		}
+		if page >= len(wantCursors) || doc.Token.Cursor != wantCursors[page] {
+			t.Fatalf("page %d: cursor %d", page, doc.Token.Cursor)
+		}
+		if doc.Token.Value == "" {
+			if page != 2 || len(doc.Headers) != 1 {
+				t.Errorf("list ended on page %d with %d headers", page, len(doc.Headers))
+			}
+			break
+		}
+		if len(doc.Headers) != 2 {
+			t.Errorf("page %d: %d headers, want 2", page, len(doc.Headers))
+		}
+		query = "verb=ListIdentifiers&resumptionToken=" + url.QueryEscape(doc.Token.Value)
+	}
+	if strings.Join(got, ",") != strings.Join(uuids, ",") {
+		t.Errorf("harvested %v, want %v in datestamp order", got, uuids)
+	}
+
+	// um set sem registros não devolve lista vazia
+	doc := harvest(t, p, "verb=ListRecords&metadataPrefix=oai_dc&set=nothing")
+	wantError(t, doc, "set=nothing", errNoRecordsMatch)
+}
+
+func TestSetsAndDeletions(t *testing.T) {
+	p, stores, uuids := newTestProvider(t,
+		bookReq("1984", "Dystopia", "Politics"), bookReq("Solaris", "Ficção científica"), bookReq("Dune", "Ficção Científica"))
+
+	doc := harvest(t, p, "verb=ListSets")
+	if strings.Join(doc.Sets, ",") != "dystopia,ficcao-cientifica,politics" {
+		t.Errorf("ListSets = %v, errors %+v", doc.Sets, doc.Errors)
+	}
+
+	doc = harvest(t, p, "verb=ListIdentifiers&metadataPrefix=oai_dc&set=ficcao-cientifica")
+	if len(doc.Headers) != 2 || doc.Token != nil {
+		t.Errorf("set ficcao-cientifica: %+v, token %+v", doc.Headers, doc.Token)
+	}
+
+	if err := stores.Books.DeleteBook(context.Background(), uuids[0]); err != nil {
+		t.Fatal(err)
+	}
+	doc = harvest(t, p, "verb=ListIdentifiers&metadataPrefix=oai_dc&set=dystopia")
+	if len(doc.Headers) != 1 || doc.Headers[0].Status != "deleted" ||
+		strings.Join(doc.Headers[0].SetSpecs, ",") != "dystopia,politics" {
+		t.Errorf("deleted record: %+v", doc.Headers)
+	}
+	doc = harvest(t, p, "verb=GetRecord&metadataPrefix=oai_dc&identifier=oai:lib.example.org:"+uuids[0])
+	if len(doc.Errors) > 0 || len(doc.Record.Title) != 0 {
+		t.Errorf("GetRecord of a deleted book: %+v, errors %+v", doc.Record, doc.Errors)
+	}
+}
+
+func TestSetSpec(t *testing.T) {
+	tests := []struct {
+		subject, want string
+	}{
+		{"Dystopia", "dystopia"},
+		{"Ficção científica", "ficcao-cientifica"},
+		{"  Science -- Fiction!  ", "science-fiction"},
+		{"Século XIX", "seculo-xix"},
+	}
+	for _, tt := range tests {
+		if got := setSpec(tt.subject); got != tt.want {
+			t.Errorf("setSpec(%q) = %q, want %q", tt.subject, got, tt.want)
+		}
+	}
+	if got := setSpec("日本"); !strings.HasPrefix(got, "s-") || len(got) != 14 || got == setSpec("中国") {
+		t.Errorf("setSpec of a non-latin subject = %q", got)
+	}
+}
@@ -0,0 +1,170 @@
+package oai
+
+import (
+	"encoding/xml"
+	"net/url"
+	"time"
+)
+
+// Namespaces e esquemas do protocolo
+const (
+	namespace      = "http://www.openarchives.org/OAI/2.0/"
+	schemaLocation = "http://www.openarchives.org/OAI/2.0/ http://www.openarchives.org/OAI/2.0/OAI-PMH.xsd"
+	xsiNamespace   = "http://www.w3.org/2001/XMLSchema-instance"
+
+	identifierNamespace = "http://www.openarchives.org/OAI/2.0/oai-identifier"
+	identifierSchema    = "http://www.openarchives.org/OAI/2.0/oai-identifier http://www.openarchives.org/OAI/2.0/oai-identifier.xsd"
+)
+
+// Códigos de erro do OAI-PMH (seção 3.6 da especificação)
+const (
+	errBadArgument             = "badArgument"
+	errBadResumptionToken      = "badResumptionToken"
+	errBadVerb                 = "badVerb"
+	errCannotDisseminateFormat = "cannotDisseminateFormat"
+	errIDDoesNotExist          = "idDoesNotExist"
+	errNoRecordsMatch          = "noRecordsMatch"
+)
+
+// granularity é a precisão dos datestamps publicados
+const granularity = "YYYY-MM-DDThh:mm:ssZ"
+
+const timeLayout = "2006-01-02T15:04:05Z"
+
+// Response é o documento OAI-PMH. Só um dos elementos de verbo é
+// preenchido, ou Errors.
+type Response struct {
+	XMLName             xml.Name             `xml:"OAI-PMH"`
+	Namespace           string               `xml:"xmlns,attr"`
+	XSINamespace        string               `xml:"xmlns:xsi,attr"`
+	SchemaLocation      string               `xml:"xsi:schemaLocation,attr"`
+	ResponseDate        string               `xml:"responseDate"`
+	Request             request              `xml:"request"`
+	Errors              []Error              `xml:"error"`
+	Identify            *identify            `xml:"Identify"`
+	ListMetadataFormats *listMetadataFormats `xml:"ListMetadataFormats"`
+	ListSets            *listSets            `xml:"ListSets"`
+	GetRecord           *getRecord           `xml:"GetRecord"`
+	ListIdentifiers     *listIdentifiers     `xml:"ListIdentifiers"`
+	ListRecords         *listRecords         `xml:"ListRecords"`
+}
+
+// Error é um erro do protocolo, devolvido dentro do documento (com HTTP 200)
+type Error struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+type request struct {
+	Verb            string `xml:"verb,attr,omitempty"`
+	Identifier      string `xml:"identifier,attr,omitempty"`
+	MetadataPrefix  string `xml:"metadataPrefix,attr,omitempty"`
+	From            string `xml:"from,attr,omitempty"`
+	Until           string `xml:"until,attr,omitempty"`
+	Set             string `xml:"set,attr,omitempty"`
+	ResumptionToken string `xml:"resumptionToken,attr,omitempty"`
+	BaseURL         string `xml:",chardata"`
+}
+
+func newResponse(baseURL string, now time.Time) *Response {
+	return &Response{
+		Namespace:      namespace,
+		XSINamespace:   xsiNamespace,
+		SchemaLocation: schemaLocation,
+		ResponseDate:   now.UTC().Format(timeLayout),
+		Request:        request{BaseURL: baseURL},
+	}
+}
+
+// echo repete os argumentos da requisição no elemento request, o que a
+// especificação pede exceto nos erros badVerb e badArgument
+func (r *Response) echo(args url.Values) {
+	r.Request.Verb = args.Get("verb")
+	r.Request.Identifier = args.Get("identifier")
+	r.Request.MetadataPrefix = args.Get("metadataPrefix")
+	r.Request.From = args.Get("from")
+	r.Request.Until = args.Get("until")
+	r.Request.Set = args.Get("set")
+	r.Request.ResumptionToken = args.Get("resumptionToken")
+}
+
+func (r *Response) fail(code, message string) *Response {
+	r.Errors = append(r.Errors, Error{Code: code, Message: message})
+	return r
+}
+
+type identify struct {
+	RepositoryName    string        `xml:"repositoryName"`
+	BaseURL           string        `xml:"baseURL"`
+	ProtocolVersion   string        `xml:"protocolVersion"`
+	AdminEmail        string        `xml:"adminEmail"`
+	EarliestDatestamp string        `xml:"earliestDatestamp"`
+	DeletedRecord     string        `xml:"deletedRecord"`
+	Granularity       string        `xml:"granularity"`
+	Description       []description `xml:"description"`
+}
+
+type description struct {
+	Identifier *oaiIdentifier `xml:"oai-identifier"`
+}
+
+type oaiIdentifier struct {
+	Namespace            string `xml:"xmlns,attr"`
+	XSINamespace         string `xml:"xmlns:xsi,attr"`
+	SchemaLocation       string `xml:"xsi:schemaLocation,attr"`
+	Scheme               string `xml:"scheme"`
+	RepositoryIdentifier string `xml:"repositoryIdentifier"`
+	Delimiter            string `xml:"delimiter"`
+	SampleIdentifier     string `xml:"sampleIdentifier"`
+}
+
+type metadataFormat struct {
+	Prefix    string `xml:"metadataPrefix"`
+	Schema    string `xml:"schema"`
+	Namespace string `xml:"metadataNamespace"`
+}
+
+type listMetadataFormats struct {
+	Formats []metadataFormat `xml:"metadataFormat"`
+}
+
+type set struct {
+	Spec string `xml:"setSpec"`
+	Name string `xml:"setName"`
+}
+
+type listSets struct {
+	Sets []set `xml:"set"`
+}
+
+type header struct {
+	Status     string   `xml:"status,attr,omitempty"`
+	Identifier string   `xml:"identifier"`
+	Datestamp  string   `xml:"datestamp"`
+	SetSpecs   []string `xml:"setSpec"`
+}
+
+type record struct {
+	Header   header    `xml:"header"`
+	Metadata *metadata `xml:"metadata"`
+}
+
+type getRecord struct {
+	Record record `xml:"record"`
+}
+
+// resumptionToken vazio (Value "") encerra uma lista que foi paginada
+type resumptionToken struct {
+	Cursor int    `xml:"cursor,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type listIdentifiers struct {
+	Headers         []header         `xml:"header"`
+	ResumptionToken *resumptionToken `xml:"resumptionToken"`
+}
+
+type listRecords struct {
+	Records         []record         `xml:"record"`
+	ResumptionToken *resumptionToken `xml:"resumptionToken"`
+}
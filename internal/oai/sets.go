@@ -0,0 +1,55 @@
+package oai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// setSpec deriva o set de um assunto. O setSpec só admite caracteres não
+// reservados de URI, então o assunto vira um slug sem acentos ("Ficção
+// científica" → "ficcao-cientifica"); assuntos que diferem só em
+// maiúsculas, acentos ou pontuação caem no mesmo set. Um assunto sem letras
+// latinas nem dígitos usa um hash.
+func setSpec(subject string) string {
+	unaccent := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(unaccent, strings.ToLower(subject))
+	if err != nil {
+		folded = strings.ToLower(subject)
+	}
+
+	var b strings.Builder
+	dash := false
+	for _, r := range folded {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		sum := sha256.Sum256([]byte(subject))
+		return "s-" + hex.EncodeToString(sum[:6])
+	}
+	return b.String()
+}
+
+// setSpecs devolve os sets de um registro, sem repetições
+func setSpecs(subjects []string) []string {
+	var specs []string
+	for _, s := range subjects {
+		specs = append(specs, setSpec(s))
+	}
+	slices.Sort(specs)
+	return slices.Compact(specs)
+}
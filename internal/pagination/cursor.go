@@ -0,0 +1,116 @@
+// Package pagination implementa os cursores opacos da paginação por keyset.
+// Um cursor carrega a chave de ordenação do item de referência e é assinado
+// com HMAC, então o cliente não consegue forjar nem editar a posição.
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// ErrInvalidCursor é devolvido para cursores adulterados, truncados ou
+// emitidos para outra consulta (outro filtro ou ordenação)
+var ErrInvalidCursor = domain.ValidationError("invalid cursor",
+	domain.FieldError{Field: "cursor", Message: "is malformed, tampered with or belongs to a different query"})
+
+// Codec assina e valida cursores
+type Codec struct {
+	key []byte
+}
+
+// NewCodec deriva a chave dos cursores do segredo da API, para não reutilizar
+// diretamente a chave que assina os JWTs
+func NewCodec(secret string) *Codec {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("lib-api cursor v1"))
+	return &Codec{key: mac.Sum(nil)}
+}
+
+type payload struct {
+	Value    string `json:"v"`
+	ID       int    `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+	Scope    string `json:"s"`
+}
+
+// Encode gera o cursor de k. scope identifica a consulta (recurso, filtros e
+// ordenação): o cursor só é aceito de volta com o mesmo scope.
+func (c *Codec) Encode(scope string, k domain.Keyset) string {
+	body, _ := json.Marshal(payload{Value: k.Value, ID: k.ID, Backward: k.Backward, Scope: scopeHash(scope)})
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(body) + "." + enc.EncodeToString(c.sign(body))
+}
+
+// Decode valida a assinatura e o scope do cursor
+func (c *Codec) Decode(scope, token string) (*domain.Keyset, error) {
+	enc := base64.RawURLEncoding
+	rawBody, rawSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	body, err := enc.DecodeString(rawBody)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sig, err := enc.DecodeString(rawSig)
+	if err != nil || !hmac.Equal(sig, c.sign(body)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil || p.Scope != scopeHash(scope) {
+		return nil, ErrInvalidCursor
+	}
+	return &domain.Keyset{Value: p.Value, ID: p.ID, Backward: p.Backward}, nil
+}
+
+// Seal assina um estado arbitrário (em JSON) no formato dos cursores, para
+// tokens que carregam mais do que um Keyset. purpose entra na assinatura:
+// um token só é aceito de volta por Open com o mesmo purpose.
+func (c *Codec) Seal(purpose string, v any) (string, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(body) + "." + enc.EncodeToString(c.sign([]byte(purpose+"\x00"), body)), nil
+}
+
+// Open valida um token de Seal e decodifica o estado em v
+func (c *Codec) Open(purpose, token string, v any) error {
+	enc := base64.RawURLEncoding
+	rawBody, rawSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidCursor
+	}
+	body, err := enc.DecodeString(rawBody)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	sig, err := enc.DecodeString(rawSig)
+	if err != nil || !hmac.Equal(sig, c.sign([]byte(purpose+"\x00"), body)) {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func (c *Codec) sign(parts ...[]byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	for _, p := range parts {
+		mac.Write(p)
+	}
+	return mac.Sum(nil)
+}
+
+func scopeHash(scope string) string {
+	sum := sha256.Sum256([]byte(scope))
+	return base64.RawURLEncoding.EncodeToString(sum[:9])
+}
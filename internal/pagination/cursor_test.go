@@ -0,0 +1,91 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+const scope = "books|sort=title|dir=ASC"
+
+func TestCodecRoundTrip(t *testing.T) {
+	c := NewCodec("secret")
+	tests := []domain.Keyset{
+		{Value: "Animal Farm", ID: 7},
+		{Value: "2024-03-01T10:00:00Z", ID: 42, Backward: true},
+		{Value: "", ID: 1},
+		{Value: "ação . ç/+=", ID: 3},
+	}
+	for _, want := range tests {
+		got, err := c.Decode(scope, c.Encode(scope, want))
+		if err != nil {
+			t.Errorf("decode %+v: %v", want, err)
+			continue
+		}
+		if *got != want {
+			t.Errorf("round trip: got %+v, want %+v", *got, want)
+		}
+	}
+}
+
+func TestCodecRejectsTampering(t *testing.T) {
+	c := NewCodec("secret")
+	token := c.Encode(scope, domain.Keyset{Value: "Animal Farm", ID: 7})
+	body, sig, _ := strings.Cut(token, ".")
+
+	// Corpo trocado por outra posição, mantendo a assinatura original
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"v":"Animal Farm","i":8,"s":"x"}`))
+	// Um bit da assinatura trocado
+	raw, _ := base64.RawURLEncoding.DecodeString(sig)
+	raw[0] ^= 1
+	flipped := base64.RawURLEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name  string
+		codec *Codec
+		scope string
+		token string
+	}{
+		{"empty", c, scope, ""},
+		{"no signature", c, scope, body},
+		{"forged body", c, scope, forged + "." + sig},
+		{"flipped signature", c, scope, body + "." + flipped},
+		{"truncated signature", c, scope, body + "." + sig[:10]},
+		{"not base64", c, scope, "%%%." + sig},
+		{"other query", c, "books|sort=created_at|dir=ASC", token},
+		{"other secret", NewCodec("other"), scope, token},
+	}
+	for _, tt := range tests {
+		if _, err := tt.codec.Decode(tt.scope, tt.token); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: got %v, want ErrInvalidCursor", tt.name, err)
+		}
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	type state struct {
+		Set   string `json:"set"`
+		After int    `json:"after"`
+	}
+	c := NewCodec("secret")
+	token, err := c.Seal("oai", state{Set: "fiction", After: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got state
+	if err := c.Open("oai", token, &got); err != nil || got != (state{Set: "fiction", After: 10}) {
+		t.Errorf("open: got %+v, %v", got, err)
+	}
+	if err := c.Open("export", token, &got); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("other purpose: got %v, want ErrInvalidCursor", err)
+	}
+	// Um cursor de Encode não passa por um token de Seal
+	cursor := c.Encode(scope, domain.Keyset{Value: "x", ID: 1})
+	if err := c.Open("oai", cursor, &got); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor as sealed token: got %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,59 @@
+package pagination
+
+import "github.com/patrick-tondorf/lib_api/internal/domain"
+
+// Request é a página pedida pelo cliente: por cursor (Keyset definido) ou,
+// no modo antigo, por número de página
+type Request struct {
+	Limit  int
+	Offset int
+	Keyset *domain.Keyset
+}
+
+// FetchLimit é o limite a repassar ao store. No modo cursor pede um item a
+// mais, para saber se existe outra página sem precisar de COUNT.
+func (r Request) FetchLimit() int {
+	if r.Keyset != nil {
+		return r.Limit + 1
+	}
+	return r.Limit
+}
+
+// Window recorta o resultado do store e devolve a posição da próxima página
+// e da anterior (nil quando não existem). No modo offset total decide se há
+// próxima página; no modo cursor quem decide é o item a mais de FetchLimit.
+// key extrai a chave de ordenação de um item.
+func Window[T any](items []T, r Request, total int, key func(T) domain.Keyset) (page []T, next, prev *domain.Keyset) {
+	var hasNext, hasPrev bool
+	switch {
+	case r.Keyset == nil:
+		page = items
+		hasNext = r.Offset+len(page) < total
+		hasPrev = r.Offset > 0
+	case !r.Keyset.Backward:
+		page = items[:min(len(items), r.Limit)]
+		hasNext = len(items) > r.Limit
+		hasPrev = true // chegamos aqui a partir de uma página anterior
+	default:
+		// O store devolve a janela na ordem de exibição; o item a mais,
+		// se houver, é o primeiro
+		page = items[max(0, len(items)-r.Limit):]
+		hasNext = true
+		hasPrev = len(items) > r.Limit
+	}
+
+	if len(page) == 0 {
+		return page, nil, nil
+	}
+	if hasNext {
+		k := key(page[len(page)-1])
+		k.Backward = false
+		next = &k
+	}
+	if hasPrev {
+		k := key(page[0])
+		k.Backward = true
+		prev = &k
+	}
+	return page, next, prev
+}
@@ -0,0 +1,73 @@
+package pagination
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+func keyOf(id int) domain.Keyset {
+	return domain.Keyset{Value: strconv.Itoa(id), ID: id}
+}
+
+func ids(from, to int) []int {
+	var out []int
+	for i := from; i <= to; i++ {
+		out = append(out, i)
+	}
+	return out
+}
+
+func TestFetchLimit(t *testing.T) {
+	if got := (Request{Limit: 10}).FetchLimit(); got != 10 {
+		t.Errorf("offset mode: got %d, want 10", got)
+	}
+	if got := (Request{Limit: 10, Keyset: &domain.Keyset{}}).FetchLimit(); got != 11 {
+		t.Errorf("cursor mode: got %d, want 11", got)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		name       string
+		items      []int // o que o store devolveu, na ordem de exibição
+		req        Request
+		total      int
+		page       []int
+		next, prev int // id da referência; 0 é sem página
+	}{
+		{"offset first page", ids(1, 3), Request{Limit: 3}, 10, ids(1, 3), 3, 0},
+		{"offset middle page", ids(4, 6), Request{Limit: 3, Offset: 3}, 10, ids(4, 6), 6, 4},
+		{"offset last page", ids(10, 10), Request{Limit: 3, Offset: 9}, 10, ids(10, 10), 0, 10},
+		{"offset exact end", ids(7, 9), Request{Limit: 3, Offset: 6}, 9, ids(7, 9), 0, 7},
+		{"offset past the end", nil, Request{Limit: 3, Offset: 30}, 10, nil, 0, 0},
+		{"forward with more", ids(4, 7), Request{Limit: 3, Keyset: &domain.Keyset{ID: 3}}, -1, ids(4, 6), 6, 4},
+		{"forward at the end", ids(8, 9), Request{Limit: 3, Keyset: &domain.Keyset{ID: 7}}, -1, ids(8, 9), 0, 8},
+		{"backward with more", ids(3, 6), Request{Limit: 3, Keyset: &domain.Keyset{ID: 7, Backward: true}}, -1, ids(4, 6), 6, 4},
+		{"backward at the start", ids(1, 2), Request{Limit: 3, Keyset: &domain.Keyset{ID: 3, Backward: true}}, -1, ids(1, 2), 2, 0},
+		{"backward exactly one page", ids(1, 3), Request{Limit: 3, Keyset: &domain.Keyset{ID: 4, Backward: true}}, -1, ids(1, 3), 3, 0},
+		{"empty cursor page", nil, Request{Limit: 3, Keyset: &domain.Keyset{ID: 9}}, -1, nil, 0, 0},
+	}
+	for _, tt := range tests {
+		page, next, prev := Window(tt.items, tt.req, tt.total, keyOf)
+		if !slices.Equal(page, tt.page) {
+			t.Errorf("%s: page %v, want %v", tt.name, page, tt.page)
+		}
+		checkRef(t, tt.name+" next", next, tt.next, false)
+		checkRef(t, tt.name+" prev", prev, tt.prev, true)
+	}
+}
+
+func checkRef(t *testing.T, name string, got *domain.Keyset, want int, backward bool) {
+	t.Helper()
+	switch {
+	case want == 0 && got != nil:
+		t.Errorf("%s: got %+v, want none", name, *got)
+	case want != 0 && got == nil:
+		t.Errorf("%s: got none, want id %d", name, want)
+	case got != nil && (got.ID != want || got.Backward != backward):
+		t.Errorf("%s: got %+v, want id %d backward %v", name, *got, want, backward)
+	}
+}
@@ -2,130 +2,152 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"slices"
 
 	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
 
 	"github.com/jackc/pgx/v5"
 )
 
 type AuthorRepository struct {
-	DB *pgx.Conn
+	DB DB
 }
 
-func NewAuthorRepository(db *pgx.Conn) *AuthorRepository {
+func NewAuthorRepository(db DB) *AuthorRepository {
 	return &AuthorRepository{DB: db}
 }
 
 // Crete
 func (r *AuthorRepository) CreateAuthor(ctx context.Context, author *domain.Author) error {
 	query := `
-        INSERT INTO authors (name) 
-        VALUES ($1)
+        INSERT INTO authors (name, bio) 
+        VALUES ($1, NULLIF($2, ''))
         RETURNING id, uuid, created_at`
 
-	err := r.DB.QueryRow(ctx, query, author.Name).
+	err := r.DB.QueryRow(ctx, query, author.Name, author.Bio).
 		Scan(&author.ID, &author.UUID, &author.CreatedAt)
 
 	if err != nil {
-		log.Printf("Error creating author: %v\n", err)
-		return fmt.Errorf("failed to create author: %w", err)
+		logging.FromContext(ctx).Error("error creating author", "error", err)
+		return translateError("failed to create author", err, nil)
 	}
 
-	log.Printf("Successfully created author with ID: %d\n", author.ID)
+	logging.FromContext(ctx).Debug("author created", "author_id", author.ID)
 	return nil
 }
 
 // Get All
-func (r *AuthorRepository) GetAuthors(ctx context.Context) ([]domain.Author, error) {
-	log.Println("Attempting to query authors from database")
+func (r *AuthorRepository) GetAuthors(ctx context.Context, filters domain.AuthorFilters) ([]domain.Author, int, error) {
+	logging.FromContext(ctx).Debug("querying authors")
+
+	authors, err := r.listAuthors(ctx, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := r.countAuthors(ctx, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	logging.FromContext(ctx).Debug("authors retrieved", "count", len(authors))
+	return authors, total, nil
+}
+
+// listAuthors busca uma página de autores ordenada por nome
+func (r *AuthorRepository) listAuthors(ctx context.Context, filters domain.AuthorFilters) ([]domain.Author, error) {
+	var value any
+	if filters.Keyset != nil {
+		value = filters.Keyset.Value
+	}
+	cond, order, keyArgs, backward := keyset("name", "id", false, filters.Keyset, value, 3)
 
 	query := `
-        SELECT id, uuid, name, created_at, updated_at 
+        SELECT id, uuid, name, COALESCE(bio, ''), created_at, updated_at 
         FROM authors
-        ORDER BY name`
+        WHERE ` + cond + `
+        ORDER BY ` + order + `
+        LIMIT $1 OFFSET $2`
 
-	rows, err := r.DB.Query(ctx, query)
+	args := append([]any{filters.Limit, offsetOf(filters.Keyset, filters.Offset)}, keyArgs...)
+	rows, err := r.DB.Query(ctx, query, args...)
 	if err != nil {
-		log.Printf("Database query error: %v\n", err)
+		logging.FromContext(ctx).Error("database query error", "error", err)
 		return nil, fmt.Errorf("database query error: %w", err)
 	}
 	defer rows.Close()
 
-	var authors []domain.Author
+	authors := []domain.Author{}
 
 	for rows.Next() {
 		var a domain.Author
-		err := rows.Scan(&a.ID, &a.UUID, &a.Name, &a.CreatedAt, &a.UpdatedAt)
+		err := rows.Scan(&a.ID, &a.UUID, &a.Name, &a.Bio, &a.CreatedAt, &a.UpdatedAt)
 		if err != nil {
-			log.Printf("Row scan error: %v\n", err)
+			logging.FromContext(ctx).Error("row scan error", "error", err)
 			return nil, fmt.Errorf("row scan error: %w", err)
 		}
 		authors = append(authors, a)
 	}
 
 	if err := rows.Err(); err != nil {
-		log.Printf("Rows error: %v\n", err)
+		logging.FromContext(ctx).Error("rows error", "error", err)
 		return nil, fmt.Errorf("rows error: %w", err)
 	}
-
-	log.Printf("Successfully retrieved %d authors\n", len(authors))
+	if backward {
+		slices.Reverse(authors)
+	}
 	return authors, nil
 }
 
-func (r *AuthorRepository) GetAuthorsWithBooks(ctx context.Context) ([]domain.Author, error) {
-	log.Println("Attempting to query authors from database")
+// countAuthors devolve o total de autores no modo offset e -1 no modo cursor
+func (r *AuthorRepository) countAuthors(ctx context.Context, filters domain.AuthorFilters) (int, error) {
+	if filters.Keyset != nil {
+		return -1, nil
+	}
+	var total int
+	if err := r.DB.QueryRow(ctx, `SELECT COUNT(*) FROM authors`).Scan(&total); err != nil {
+		return 0, fmt.Errorf("count failed: %w", err)
+	}
+	return total, nil
+}
 
-	// Primeiro: buscar todos os autores
-	authorsQuery := `
-        SELECT id, uuid, name, created_at, updated_at 
-        FROM authors
-        ORDER BY name`
+func (r *AuthorRepository) GetAuthorsWithBooks(ctx context.Context, filters domain.AuthorFilters) ([]domain.Author, int, error) {
+	logging.FromContext(ctx).Debug("querying authors")
 
-	authorRows, err := r.DB.Query(ctx, authorsQuery)
+	// Primeiro: buscar a página de autores
+	authors, err := r.listAuthors(ctx, filters)
 	if err != nil {
-		log.Printf("Database query error for authors: %v\n", err)
-		return nil, fmt.Errorf("database query error: %w", err)
+		return nil, 0, err
 	}
-	defer authorRows.Close()
-
-	var authors []domain.Author
-	authorIDs := []int{} // Para coletar IDs dos autores encontrados
-
-	for authorRows.Next() {
-		var a domain.Author
-		err := authorRows.Scan(&a.ID, &a.UUID, &a.Name, &a.CreatedAt, &a.UpdatedAt)
-		if err != nil {
-			log.Printf("Row scan error for authors: %v\n", err)
-			return nil, fmt.Errorf("row scan error: %w", err)
-		}
-		authors = append(authors, a)
-		authorIDs = append(authorIDs, a.ID)
+	total, err := r.countAuthors(ctx, filters)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if err := authorRows.Err(); err != nil {
-		log.Printf("Rows error for authors: %v\n", err)
-		return nil, fmt.Errorf("rows error: %w", err)
+	authorIDs := make([]int, 0, len(authors)) // Para coletar IDs dos autores encontrados
+	for _, a := range authors {
+		authorIDs = append(authorIDs, a.ID)
 	}
 
 	// Se não encontrou autores, retorna vazio
 	if len(authors) == 0 {
-		return []domain.Author{}, nil
+		return []domain.Author{}, total, nil
 	}
 
 	// Segundo: buscar todos os livros para os autores encontrados
 	booksQuery := `
         SELECT b.id, b.uuid, b.title, b.description, b.created_at, ba.author_id
         FROM books b
-        JOIN book_authors ba ON b.id = ba.book_id
+        JOIN books_authors ba ON b.id = ba.book_id
         WHERE ba.author_id = ANY($1)
         ORDER BY ba.author_id, b.title`
 
 	bookRows, err := r.DB.Query(ctx, booksQuery, authorIDs)
 	if err != nil {
-		log.Printf("Database query error for books: %v\n", err)
-		return nil, fmt.Errorf("database books query error: %w", err)
+		logging.FromContext(ctx).Error("database query error for books", "error", err)
+		return nil, 0, fmt.Errorf("database books query error: %w", err)
 	}
 	defer bookRows.Close()
 
@@ -142,8 +164,8 @@ func (r *AuthorRepository) GetAuthorsWithBooks(ctx context.Context) ([]domain.Au
 		var authorID int
 		err := bookRows.Scan(&b.ID, &b.UUID, &b.Title, &b.Description, &b.CreatedAt, &authorID)
 		if err != nil {
-			log.Printf("Row scan error for books: %v\n", err)
-			return nil, fmt.Errorf("book row scan error: %w", err)
+			logging.FromContext(ctx).Error("row scan error for books", "error", err)
+			return nil, 0, fmt.Errorf("book row scan error: %w", err)
 		}
 
 		if author, exists := authorMap[authorID]; exists {
@@ -152,40 +174,52 @@ func (r *AuthorRepository) GetAuthorsWithBooks(ctx context.Context) ([]domain.Au
 	}
 
 	if err := bookRows.Err(); err != nil {
-		log.Printf("Rows error for books: %v\n", err)
-		return nil, fmt.Errorf("book rows error: %w", err)
+		logging.FromContext(ctx).Error("rows error for books", "error", err)
+		return nil, 0, fmt.Errorf("book rows error: %w", err)
 	}
 
-	log.Printf("Successfully retrieved %d authors with their books\n", len(authors))
-	return authors, nil
+	logging.FromContext(ctx).Debug("authors with books retrieved", "count", len(authors))
+	return authors, total, nil
 }
 
 func (r *AuthorRepository) GetAuthorByID(ctx context.Context, id int) (*domain.Author, error) {
-	log.Printf("Attempting to query author with ID: %d\n", id)
+	logging.FromContext(ctx).Debug("querying author", "author_id", id)
+	return r.getAuthor(ctx, "id", id)
+}
+
+// GetAuthorByUUID busca um autor, com seus livros, pelo UUID público
+func (r *AuthorRepository) GetAuthorByUUID(ctx context.Context, uuid string) (*domain.Author, error) {
+	logging.FromContext(ctx).Debug("querying author", "author_uuid", uuid)
+	return r.getAuthor(ctx, "uuid", uuid)
+}
 
+// getAuthor carrega o autor pela coluna informada ("id" ou "uuid") junto com seus livros
+func (r *AuthorRepository) getAuthor(ctx context.Context, column string, value any) (*domain.Author, error) {
 	// Busca o autor
 	author := &domain.Author{}
 	err := r.DB.QueryRow(ctx, `
-        SELECT id, uuid, name, created_at, updated_at 
+        SELECT id, uuid, name, COALESCE(bio, ''), created_at, updated_at 
         FROM authors 
-        WHERE id = $1`, id).
-		Scan(&author.ID, &author.UUID, &author.Name, &author.CreatedAt, &author.UpdatedAt)
+        WHERE `+column+` = $1`, value).
+		Scan(&author.ID, &author.UUID, &author.Name, &author.Bio, &author.CreatedAt, &author.UpdatedAt)
 
 	if err != nil {
-		log.Printf("Error fetching author: %v\n", err)
-		return nil, fmt.Errorf("error fetching author: %w", err)
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logging.FromContext(ctx).Error("error fetching author", "error", err)
+		}
+		return nil, translateError("error fetching author", err, domain.ErrAuthorNotFound)
 	}
 
 	// Busca os livros do autor
 	rows, err := r.DB.Query(ctx, `
         SELECT b.id, b.uuid, b.title, b.description, b.created_at
         FROM books b
-        JOIN book_authors ba ON b.id = ba.book_id
+        JOIN books_authors ba ON b.id = ba.book_id
         WHERE ba.author_id = $1
-        ORDER BY b.title`, id)
+        ORDER BY b.title`, author.ID)
 
 	if err != nil {
-		log.Printf("Error fetching author's books: %v\n", err)
+		logging.FromContext(ctx).Error("error fetching author's books", "error", err)
 		return nil, fmt.Errorf("error fetching author's books: %w", err)
 	}
 	defer rows.Close()
@@ -195,12 +229,102 @@ func (r *AuthorRepository) GetAuthorByID(ctx context.Context, id int) (*domain.A
 		var b domain.Book
 		err := rows.Scan(&b.ID, &b.UUID, &b.Title, &b.Description, &b.CreatedAt)
 		if err != nil {
-			log.Printf("Error scanning book: %v\n", err)
-			continue // Ou retornar erro se preferir
+			logging.FromContext(ctx).Error("error scanning book", "error", err)
+			return nil, fmt.Errorf("error scanning book: %w", err)
 		}
 		author.Books = append(author.Books, &b)
 	}
+	if err := rows.Err(); err != nil {
+		logging.FromContext(ctx).Error("rows error for books", "error", err)
+		return nil, fmt.Errorf("book rows error: %w", err)
+	}
 
-	log.Printf("Successfully retrieved author with %d books\n", len(author.Books))
+	logging.FromContext(ctx).Debug("author retrieved", "books", len(author.Books))
 	return author, nil
 }
+
+// UpdateAuthor altera nome e/ou biografia; campos nil são mantidos
+func (r *AuthorRepository) UpdateAuthor(ctx context.Context, uuid string, patch domain.AuthorPatchRequest) (*domain.Author, error) {
+	// Uma troca de nome altera o registro dos livros do autor, então eles
+	// ganham updated_at novo e voltam a ser colhidos via OAI-PMH. As
+	// subconsultas do WITH veem o nome anterior à atualização.
+	_, err := r.DB.Exec(ctx, `
+        WITH old AS (
+            SELECT id, name FROM authors WHERE uuid = $3
+        ), updated AS (
+            UPDATE authors
+            SET name = COALESCE($1, name),
+                bio = CASE WHEN $2::text IS NULL THEN bio ELSE NULLIF($2, '') END,
+                updated_at = NOW()
+            WHERE uuid = $3
+        )
+        UPDATE books SET updated_at = NOW()
+        WHERE id IN (
+            SELECT ba.book_id FROM books_authors ba
+            JOIN old ON old.id = ba.author_id
+            WHERE old.name <> COALESCE($1::text, old.name))`,
+		patch.Name, patch.Bio, uuid)
+	if err != nil {
+		logging.FromContext(ctx).Error("error updating author", "error", err)
+		return nil, translateError("failed to update author", err, nil)
+	}
+
+	return r.GetAuthorByUUID(ctx, uuid)
+}
+
+// DeleteAuthor remove um autor. Se ele ainda estiver vinculado a livros a
+// remoção é recusada com domain.ErrAuthorHasBooks, a menos que unlink seja
+// true, caso em que os vínculos em books_authors são apagados na mesma
+// transação.
+func (r *AuthorRepository) DeleteAuthor(ctx context.Context, uuid string, unlink bool) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var authorID int
+	err = tx.QueryRow(ctx, `SELECT id FROM authors WHERE uuid = $1 FOR UPDATE`, uuid).Scan(&authorID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logging.FromContext(ctx).Error("error fetching author", "error", err)
+		}
+		return translateError("error fetching author", err, domain.ErrAuthorNotFound)
+	}
+
+	var links int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM books_authors WHERE author_id = $1`, authorID).Scan(&links); err != nil {
+		logging.FromContext(ctx).Error("error counting author's books", "error", err)
+		return fmt.Errorf("error counting author's books: %w", err)
+	}
+	if links > 0 {
+		if !unlink {
+			return domain.ErrAuthorHasBooks
+		}
+		_, err := tx.Exec(ctx, `
+            UPDATE books SET updated_at = NOW()
+            WHERE id IN (SELECT book_id FROM books_authors WHERE author_id = $1)`, authorID)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to touch author's books", "error", err)
+			return fmt.Errorf("failed to unlink author")
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM books_authors WHERE author_id = $1`, authorID); err != nil {
+			logging.FromContext(ctx).Error("failed to unlink author from books", "error", err)
+			return fmt.Errorf("failed to unlink author")
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM authors WHERE id = $1`, authorID); err != nil {
+		logging.FromContext(ctx).Error("failed to delete author", "error", err)
+		return fmt.Errorf("failed to delete author")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return fmt.Errorf("failed to save data")
+	}
+
+	logging.FromContext(ctx).Info("author deleted", "author_uuid", uuid, "unlinked_books", links)
+	return nil
+}
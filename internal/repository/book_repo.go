@@ -2,97 +2,118 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
 
 	"github.com/jackc/pgx/v5"
 )
 
 type BookRepository struct {
-	DB *pgx.Conn
+	DB DB
 }
 
-func NewBookRepository(db *pgx.Conn) *BookRepository {
+func NewBookRepository(db DB) *BookRepository {
 	return &BookRepository{DB: db}
 }
 
 // Create
-func (r *BookRepository) CreateBook(ctx context.Context, req domain.BookCreateRequest) error {
+func (r *BookRepository) CreateBook(ctx context.Context, req domain.BookCreateRequest) (*domain.Book, error) {
 	// Verificar se todos os autores existem antes de começar a transação
 	if len(req.AuthorIDs) == 0 {
-		return fmt.Errorf("at least one author ID is required")
+		return nil, domain.ValidationError("at least one author ID is required",
+			domain.FieldError{Field: "authorIds", Message: "must contain at least one author"})
 	}
 
 	// Verificar existência dos autores
-	for _, authorID := range req.AuthorIDs {
-		var exists bool
-		err := r.DB.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM authors WHERE id = $1)`, authorID).Scan(&exists)
-		if err != nil {
-			log.Printf("Failed to check author existence: %v", err)
-			return fmt.Errorf("failed to verify author")
-		}
-		if !exists {
-			return fmt.Errorf("author with ID %d not found", authorID)
-		}
+	missing, err := missingAuthors(ctx, r.DB, req.AuthorIDs)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to check author existence", "error", err)
+		return nil, fmt.Errorf("failed to verify authors: %w", err)
+	}
+	if len(missing) > 0 {
+		return nil, domain.UnknownAuthorsError(missing...)
 	}
 
 	tx, err := r.DB.Begin(ctx)
 	if err != nil {
-		log.Printf("Failed to begin transaction: %v", err)
-		return fmt.Errorf("failed to begin transaction")
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction")
 	}
 	defer tx.Rollback(ctx)
 
 	// Inserir livro
 	var book domain.Book
 	err = tx.QueryRow(ctx, `
-        INSERT INTO books (title, description) 
-        VALUES ($1, $2) 
+        INSERT INTO books (title, description, isbn, publisher, publication_year, language, pages, edition, format)
+        VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6, ''), $7, NULLIF($8, ''), NULLIF($9, ''))
         RETURNING id, uuid, created_at`,
-		req.Title, req.Description,
+		req.Title, req.Description, req.ISBN, req.Publisher, req.PublicationYear,
+		req.Language, req.Pages, req.Edition, req.Format,
 	).Scan(&book.ID, &book.UUID, &book.CreatedAt)
 
 	if err != nil {
-		log.Printf("Failed to insert book: %v", err)
-		return fmt.Errorf("failed to insert book")
+		if !isUniqueViolation(err, isbnConstraint) {
+			logging.FromContext(ctx).Error("failed to insert book", "error", err)
+		}
+		return nil, translateError("failed to insert book", err, nil)
 	}
 
 	// Processar autores (todos já verificados)
-	for _, authorID := range req.AuthorIDs {
-		// Criar relação livro-autor
+	for i, authorID := range uniqueInts(req.AuthorIDs) {
+		// Criar relação livro-autor, na ordem informada
 		_, err = tx.Exec(ctx, `
-            INSERT INTO books_authors (book_id, author_id)
-            VALUES ($1, $2)`,
-			book.ID, authorID,
+            INSERT INTO books_authors (book_id, author_id, position)
+            VALUES ($1, $2, $3)`,
+			book.ID, authorID, i,
 		)
 		if err != nil {
-			log.Printf("Failed to create books_author relation: %v", err)
-			return fmt.Errorf("failed to create books-author relation")
+			logging.FromContext(ctx).Error("failed to create books_author relation", "error", err)
+			return nil, fmt.Errorf("failed to create books-author relation")
 		}
 	}
 
+	if err := saveSubjects(ctx, tx, book.ID, req.Subjects); err != nil {
+		logging.FromContext(ctx).Error("failed to save book subjects", "error", err)
+		return nil, translateError("failed to save book subjects", err, nil)
+	}
+
 	if err := tx.Commit(ctx); err != nil {
-		log.Printf("Failed to commit transaction: %v", err)
-		return fmt.Errorf("failed to save data")
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to save data")
 	}
 
-	return nil
+	return r.GetBookByUUID(ctx, book.UUID)
 }
 
 // GetBooksBasic retrieves books without author information (optimized)
 func (r *BookRepository) GetBooksBasic(ctx context.Context, filters domain.BookFilters) ([]domain.Book, int, error) {
+	col, desc, value, err := bookSort(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	cond, order, keyArgs, backward := keyset(col, "id", desc, filters.Keyset, value, 4)
+	catalogCond, catalogArgs := catalogFilter("books", filters, 4+len(keyArgs))
+
 	// Build query
 	query := `
-        SELECT id, uuid, title, description, created_at
+        SELECT ` + bookColumns("books") + `
         FROM books
         WHERE ($1 = '' OR title ILIKE '%' || $1 || '%')
-        ORDER BY ` + filters.Sort + ` ` + filters.SortDirection + `
+        AND ` + catalogCond + `
+        AND ` + cond + `
+        ORDER BY ` + order + `
         LIMIT $2 OFFSET $3`
 
 	// Execute query
-	rows, err := r.DB.Query(ctx, query, filters.Title, filters.Limit, filters.Offset)
+	args := append([]any{filters.Title, filters.Limit, offsetOf(filters.Keyset, filters.Offset)}, keyArgs...)
+	args = append(args, catalogArgs...)
+	rows, err := r.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("query failed: %w", err)
 	}
@@ -102,32 +123,62 @@ func (r *BookRepository) GetBooksBasic(ctx context.Context, filters domain.BookF
 	var books []domain.Book
 	for rows.Next() {
 		var b domain.Book
-		if err := rows.Scan(&b.ID, &b.UUID, &b.Title, &b.Description, &b.CreatedAt); err != nil {
+		if err := rows.Scan(bookFields(&b)...); err != nil {
 			return nil, 0, fmt.Errorf("scan failed: %w", err)
 		}
 		books = append(books, b)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	if backward {
+		slices.Reverse(books)
+	}
+
+	// No modo cursor não há COUNT: é justamente o custo que ele evita
+	if filters.Keyset != nil {
+		return books, -1, nil
+	}
 
 	// Get total count (optimized count query)
 	var total int
-	countQuery := `SELECT COUNT(*) FROM books WHERE ($1 = '' OR title ILIKE '%' || $1 || '%')`
-	if err := r.DB.QueryRow(ctx, countQuery, filters.Title).Scan(&total); err != nil {
+	catalogCond, catalogArgs = catalogFilter("books", filters, 2)
+	countQuery := `SELECT COUNT(*) FROM books WHERE ($1 = '' OR title ILIKE '%' || $1 || '%') AND ` + catalogCond
+	if err := r.DB.QueryRow(ctx, countQuery, append([]any{filters.Title}, catalogArgs...)...).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("count failed: %w", err)
 	}
 
 	return books, total, nil
-} // GetBooksWithAuthors retrieves books with author information (optimized join)
+}
+
+// GetBooksWithAuthors retrieves books with author information (optimized join).
+// O filtro por autor é aplicado antes da paginação, para que as páginas
+// venham cheias e o total bata com elas; dentro de cada livro só aparecem os
+// autores que casam com o filtro.
 func (r *BookRepository) GetBooksWithAuthors(ctx context.Context, filters domain.BookFilters) ([]domain.Book, int, error) {
+	col, desc, value, err := bookSort(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	cond, pageOrder, keyArgs, _ := keyset(col, "id", desc, filters.Keyset, value, 5)
+	catalogCond, catalogArgs := catalogFilter("books", filters, 5+len(keyArgs))
+
 	// Build query
 	query := `
         WITH paginated_books AS (
             SELECT id FROM books
             WHERE ($1 = '' OR title ILIKE '%' || $1 || '%')
-            ORDER BY ` + filters.Sort + ` ` + filters.SortDirection + `
+            AND ($4 = '' OR EXISTS (
+                SELECT 1 FROM books_authors fba
+                JOIN authors fa ON fa.id = fba.author_id
+                WHERE fba.book_id = books.id AND fa.name ILIKE '%' || $4 || '%'))
+            AND ` + catalogCond + `
+            AND ` + cond + `
+            ORDER BY ` + pageOrder + `
             LIMIT $2 OFFSET $3
         )
         SELECT 
-            b.id, b.uuid, b.title, b.description, b.created_at,
+            ` + bookColumns("b") + `,
             a.id as "author_id", a.uuid as "author_uuid", 
             a.name as "author_name", a.created_at as "author_created_at"
         FROM paginated_books pb
@@ -135,30 +186,37 @@ func (r *BookRepository) GetBooksWithAuthors(ctx context.Context, filters domain
         LEFT JOIN books_authors ba ON b.id = ba.book_id
         LEFT JOIN authors a ON a.id = ba.author_id
         WHERE ($4 = '' OR a.name ILIKE '%' || $4 || '%')
-        ORDER BY b.` + filters.Sort + ` ` + filters.SortDirection + `, a.name`
+        ORDER BY ` + orderBy("b."+col, "b.id", desc) + `, ba.position, a.name`
 
 	// Execute query
-	rows, err := r.DB.Query(ctx, query,
+	args := append([]any{
 		filters.Title,
 		filters.Limit,
-		filters.Offset,
+		offsetOf(filters.Keyset, filters.Offset),
 		filters.AuthorName,
-	)
+	}, keyArgs...)
+	args = append(args, catalogArgs...)
+	rows, err := r.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("query failed: %w", err)
 	}
 	defer rows.Close()
 
-	// Process results with authors
+	// Process results with authors (keeping the query order)
 	booksMap := make(map[int]*domain.Book)
+	var order []int
 	for rows.Next() {
 		var b domain.Book
-		var a domain.Author
-
-		err := rows.Scan(
-			&b.ID, &b.UUID, &b.Title, &b.Description, &b.CreatedAt,
-			&a.ID, &a.UUID, &a.Name, &a.CreatedAt,
+		var (
+			authorID        *int
+			authorUUID      *string
+			authorName      *string
+			authorCreatedAt *time.Time
 		)
+
+		err := rows.Scan(append(bookFields(&b),
+			&authorID, &authorUUID, &authorName, &authorCreatedAt,
+		)...)
 		if err != nil {
 			return nil, 0, fmt.Errorf("scan failed: %w", err)
 		}
@@ -166,32 +224,264 @@ func (r *BookRepository) GetBooksWithAuthors(ctx context.Context, filters domain
 		if _, exists := booksMap[b.ID]; !exists {
 			booksMap[b.ID] = &b
 			booksMap[b.ID].Authors = []*domain.Author{}
+			order = append(order, b.ID)
 		}
 
-		if a.ID != 0 { // Only add if author exists
-			booksMap[b.ID].Authors = append(booksMap[b.ID].Authors, &a)
+		if authorID != nil { // Only add if author exists
+			booksMap[b.ID].Authors = append(booksMap[b.ID].Authors, &domain.Author{
+				ID:        *authorID,
+				UUID:      *authorUUID,
+				Name:      *authorName,
+				CreatedAt: *authorCreatedAt,
+			})
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
 
 	// Convert map to slice
 	books := make([]domain.Book, 0, len(booksMap))
-	for _, book := range booksMap {
-		books = append(books, *book)
+	for _, id := range order {
+		books = append(books, *booksMap[id])
+	}
+
+	if filters.Keyset != nil {
+		return books, -1, nil
 	}
 
 	// Get total count (with same filters)
+	catalogCond, catalogArgs = catalogFilter("b", filters, 3)
 	countQuery := `
-        SELECT COUNT(DISTINCT b.id)
+        SELECT COUNT(*)
         FROM books b
-        LEFT JOIN books_authors ba ON b.id = ba.book_id
-        LEFT JOIN authors a ON a.id = ba.author_id
         WHERE ($1 = '' OR b.title ILIKE '%' || $1 || '%')
-        AND ($2 = '' OR a.name ILIKE '%' || $2 || '%')`
+        AND ($2 = '' OR EXISTS (
+            SELECT 1 FROM books_authors ba
+            JOIN authors a ON a.id = ba.author_id
+            WHERE ba.book_id = b.id AND a.name ILIKE '%' || $2 || '%'))
+        AND ` + catalogCond
 
 	var total int
-	if err := r.DB.QueryRow(ctx, countQuery, filters.Title, filters.AuthorName).Scan(&total); err != nil {
+	countArgs := append([]any{filters.Title, filters.AuthorName}, catalogArgs...)
+	if err := r.DB.QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("count failed: %w", err)
 	}
 
 	return books, total, nil
 }
+
+// GetBookByUUID retrieves a single book, with its authors, by its public UUID
+func (r *BookRepository) GetBookByUUID(ctx context.Context, uuid string) (*domain.Book, error) {
+	book := &domain.Book{}
+	err := r.DB.QueryRow(ctx, `
+        SELECT `+bookColumns("books")+`
+        FROM books
+        WHERE uuid = $1`, uuid).
+		Scan(bookFields(book)...)
+	if err != nil {
+		return nil, translateError("failed to get book", err, domain.ErrBookNotFound)
+	}
+
+	rows, err := r.DB.Query(ctx, `
+        SELECT a.id, a.uuid, a.name, a.created_at
+        FROM authors a
+        JOIN books_authors ba ON a.id = ba.author_id
+        WHERE ba.book_id = $1
+        ORDER BY ba.position, a.name`, book.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get book authors: %w", err)
+	}
+	defer rows.Close()
+
+	book.Authors = []*domain.Author{}
+	for rows.Next() {
+		var a domain.Author
+		if err := rows.Scan(&a.ID, &a.UUID, &a.Name, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		book.Authors = append(book.Authors, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return book, nil
+}
+
+// UpdateBook replaces title, description and the author set of a book in a single transaction
+func (r *BookRepository) UpdateBook(ctx context.Context, uuid string, req domain.BookUpdateRequest) (*domain.Book, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	missing, err := missingAuthors(ctx, tx, req.AuthorIDs)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to check author existence", "error", err)
+		return nil, fmt.Errorf("failed to verify authors: %w", err)
+	}
+	if len(missing) > 0 {
+		return nil, domain.UnknownAuthorsError(missing...)
+	}
+
+	var bookID int
+	err = tx.QueryRow(ctx, `
+        UPDATE books
+        SET title = $1, description = $2, isbn = NULLIF($3, ''), publisher = NULLIF($4, ''),
+            publication_year = $5, language = NULLIF($6, ''), pages = $7,
+            edition = NULLIF($8, ''), format = NULLIF($9, ''), updated_at = NOW()
+        WHERE uuid = $10
+        RETURNING id`,
+		req.Title, req.Description, req.ISBN, req.Publisher, req.PublicationYear,
+		req.Language, req.Pages, req.Edition, req.Format, uuid,
+	).Scan(&bookID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) && !isUniqueViolation(err, isbnConstraint) {
+			logging.FromContext(ctx).Error("failed to update book", "error", err)
+		}
+		return nil, translateError("failed to update book", err, domain.ErrBookNotFound)
+	}
+
+	// Substitui o conjunto de autores
+	if _, err := tx.Exec(ctx, `DELETE FROM books_authors WHERE book_id = $1`, bookID); err != nil {
+		logging.FromContext(ctx).Error("failed to clear books_authors relation", "error", err)
+		return nil, fmt.Errorf("failed to update book authors")
+	}
+	for i, authorID := range uniqueInts(req.AuthorIDs) {
+		_, err = tx.Exec(ctx, `
+            INSERT INTO books_authors (book_id, author_id, position)
+            VALUES ($1, $2, $3)`,
+			bookID, authorID, i,
+		)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to create books_author relation", "error", err)
+			return nil, fmt.Errorf("failed to update book authors")
+		}
+	}
+
+	if err := saveSubjects(ctx, tx, bookID, req.Subjects); err != nil {
+		logging.FromContext(ctx).Error("failed to save book subjects", "error", err)
+		return nil, translateError("failed to save book subjects", err, nil)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to save data")
+	}
+
+	return r.GetBookByUUID(ctx, uuid)
+}
+
+// DeleteBook removes a book and its author relations, recording the deletion
+// in book_deletions
+func (r *BookRepository) DeleteBook(ctx context.Context, uuid string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	// Os exemplares precisam ser removidos antes do livro
+	var hasItems bool
+	err = tx.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM items i JOIN books b ON b.id = i.book_id
+            WHERE b.uuid = $1)`, uuid).Scan(&hasItems)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to check book items", "error", err)
+		return fmt.Errorf("failed to delete book")
+	}
+	if hasItems {
+		return domain.ErrBookHasItems
+	}
+
+	_, err = tx.Exec(ctx, `
+        DELETE FROM books_authors
+        WHERE book_id = (SELECT id FROM books WHERE uuid = $1)`, uuid)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to delete books_authors relation", "error", err)
+		return fmt.Errorf("failed to delete book")
+	}
+
+	// Registra a exclusão para a colheita OAI-PMH, com os assuntos do livro
+	_, err = tx.Exec(ctx, `
+        INSERT INTO book_deletions (book_id, uuid, subjects)
+        SELECT id, uuid, `+subjectsColumn("books")+` FROM books WHERE uuid = $1`, uuid)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to record book deletion", "error", err)
+		return fmt.Errorf("failed to delete book")
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM books WHERE uuid = $1`, uuid)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to delete book", "error", err)
+		return fmt.Errorf("failed to delete book")
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrBookNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return fmt.Errorf("failed to save data")
+	}
+
+	return nil
+}
+
+// bookColumns lists the books columns read by bookFields, qualified by alias
+func bookColumns(alias string) string {
+	return strings.ReplaceAll(`t.id, t.uuid, t.title, t.description,
+            COALESCE(t.isbn, ''), COALESCE(t.publisher, ''), t.publication_year,
+            COALESCE(t.language, ''), t.pages, COALESCE(t.edition, ''), COALESCE(t.format, ''),
+            `+subjectsColumn("t")+`, t.created_at, t.updated_at`, "t.", alias+".")
+}
+
+// bookFields returns the scan destinations matching bookColumns
+func bookFields(b *domain.Book) []any {
+	return []any{&b.ID, &b.UUID, &b.Title, &b.Description,
+		&b.ISBN, &b.Publisher, &b.PublicationYear,
+		&b.Language, &b.Pages, &b.Edition, &b.Format,
+		&b.Subjects, &b.CreatedAt, &b.UpdatedAt}
+}
+
+// missingAuthors returns the IDs, among ids, that do not exist in authors
+func missingAuthors(ctx context.Context, db DB, ids []int) ([]int, error) {
+	rows, err := db.Query(ctx, `SELECT id FROM authors WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[int]bool, len(existing))
+	for _, id := range existing {
+		found[id] = true
+	}
+	var missing []int
+	for _, id := range uniqueInts(ids) {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// uniqueInts removes duplicated IDs keeping the original order
+func uniqueInts(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	out := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
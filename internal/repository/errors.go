@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// Códigos SQLSTATE do Postgres tratados em translateError
+const (
+	pgUniqueViolation           = "23505"
+	pgForeignKeyViolation       = "23503"
+	pgNotNullViolation          = "23502"
+	pgCheckViolation            = "23514"
+	pgStringDataTruncation      = "22001"
+	pgInvalidTextRepresentation = "22P02"
+	pgNumericValueOutOfRange    = "22003"
+)
+
+// Índices únicos com erro de conflito próprio
+const (
+	isbnConstraint       = "books_isbn_key"
+	barcodeConstraint    = "items_barcode_key"
+	activeLoanConstraint = "loans_item_active_key"
+	openHoldConstraint   = "holds_patron_open_key"
+	policyRuleConstraint = "policy_rules_scope_key"
+)
+
+// uniqueConstraints associa índices únicos a erros de conflito específicos
+var uniqueConstraints = map[string]*domain.Error{
+	isbnConstraint:       domain.ErrISBNExists,
+	barcodeConstraint:    domain.ErrBarcodeExists,
+	activeLoanConstraint: domain.ErrItemOnLoan,
+	openHoldConstraint:   domain.ErrHoldExists,
+	policyRuleConstraint: domain.ErrPolicyRuleExists,
+}
+
+// translateError converte erros do pgx em erros de domínio. notFound é
+// retornado para pgx.ErrNoRows; erros não reconhecidos são embrulhados com
+// op e tratados como falhas internas pelo middleware de erros.
+func translateError(op string, err error, notFound *domain.Error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) && notFound != nil {
+		return notFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			if e, ok := uniqueConstraints[pgErr.ConstraintName]; ok {
+				return e
+			}
+			return domain.ConflictError("a record with the same unique value already exists").Wrap(err)
+		case pgForeignKeyViolation:
+			return domain.ConflictError("the operation conflicts with related records").Wrap(err)
+		case pgNotNullViolation, pgCheckViolation:
+			return fieldError(pgErr.ColumnName, "value violates a constraint", err)
+		case pgStringDataTruncation:
+			return fieldError(pgErr.ColumnName, "value is too long", err)
+		case pgInvalidTextRepresentation, pgNumericValueOutOfRange:
+			return domain.ValidationError("invalid value").Wrap(err)
+		}
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func fieldError(column, msg string, cause error) error {
+	if column == "" {
+		return domain.ValidationError(msg).Wrap(cause)
+	}
+	return domain.ValidationError(msg, domain.FieldError{Field: column, Message: msg}).Wrap(cause)
+}
+
+// isUniqueViolation indica se err viola o índice único informado
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == constraint
+}
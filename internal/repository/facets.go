@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+)
+
+// subjectsColumn devolve os assuntos do livro (alias.id) em ordem alfabética
+func subjectsColumn(alias string) string {
+	return `COALESCE((SELECT array_agg(bs.subject ORDER BY bs.subject) FROM book_subjects bs WHERE bs.book_id = ` + alias + `.id), '{}')`
+}
+
+// catalogFilter monta a condição dos filtros de faceta e bibliográficos
+// (tudo menos título e nome de autor) sobre a tabela books
+// (com o alias informado), usando placeholders a partir de $next. Sem
+// filtros, a condição é TRUE.
+func catalogFilter(alias string, filters domain.BookFilters, next int) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	add := func(cond string, value any) {
+		conds = append(conds, strings.ReplaceAll(cond, "$?", fmt.Sprintf("$%d", next+len(args))))
+		args = append(args, value)
+	}
+
+	if len(filters.AuthorUUIDs) > 0 {
+		add(`EXISTS (
+                SELECT 1 FROM books_authors xba
+                JOIN authors xa ON xa.id = xba.author_id
+                WHERE xba.book_id = `+alias+`.id AND xa.uuid = ANY($?::uuid[]))`, filters.AuthorUUIDs)
+	}
+	if len(filters.Subjects) > 0 {
+		add(`EXISTS (
+                SELECT 1 FROM book_subjects xbs
+                WHERE xbs.book_id = `+alias+`.id AND xbs.subject = ANY($?::text[]))`, filters.Subjects)
+	}
+	if len(filters.Languages) > 0 {
+		add(alias+`.language = ANY($?::text[])`, filters.Languages)
+	}
+	if len(filters.Decades) > 0 {
+		add(`(`+alias+`.publication_year / 10) * 10 = ANY($?::int[])`, filters.Decades)
+	}
+	if filters.ISBN != "" {
+		add(alias+`.isbn = $?`, filters.ISBN)
+	}
+	if filters.Publisher != "" {
+		add(alias+`.publisher ILIKE '%' || $? || '%'`, filters.Publisher)
+	}
+	if len(filters.Formats) > 0 {
+		add(alias+`.format = ANY($?::text[])`, filters.Formats)
+	}
+	if filters.YearFrom != nil {
+		add(alias+`.publication_year >= $?`, *filters.YearFrom)
+	}
+	if filters.YearTo != nil {
+		add(alias+`.publication_year <= $?`, *filters.YearTo)
+	}
+	if len(filters.Availability) > 0 {
+		add(`EXISTS (
+                SELECT 1 FROM items xi
+                WHERE xi.book_id = `+alias+`.id AND xi.status = ANY($?::text[]))`, filters.Availability)
+	}
+
+	if len(conds) == 0 {
+		return "TRUE", nil
+	}
+	return strings.Join(conds, "\n        AND "), args
+}
+
+// GetBookFacets conta, por faceta, os livros que passam pelos filtros
+// (título, autor e facetas), devolvendo até limit valores por faceta
+func (r *BookRepository) GetBookFacets(ctx context.Context, filters domain.BookFilters, limit int) (*domain.BookFacets, error) {
+	catalogCond, catalogArgs := catalogFilter("b", filters, 4)
+
+	query := `
+        WITH filtered AS (
+            SELECT b.id, b.language, b.publication_year
+            FROM books b
+            WHERE ($1 = '' OR b.title ILIKE '%' || $1 || '%')
+            AND ($2 = '' OR EXISTS (
+                SELECT 1 FROM books_authors ba
+                JOIN authors a ON a.id = ba.author_id
+                WHERE ba.book_id = b.id AND a.name ILIKE '%' || $2 || '%'))
+            AND ` + catalogCond + `
+        ), counts AS (
+            SELECT 'author' AS facet, a.uuid::text AS value, a.name AS label, COUNT(*) AS n
+            FROM filtered f
+            JOIN books_authors ba ON ba.book_id = f.id
+            JOIN authors a ON a.id = ba.author_id
+            GROUP BY a.uuid, a.name
+            UNION ALL
+            SELECT 'subject', bs.subject, '', COUNT(*)
+            FROM filtered f
+            JOIN book_subjects bs ON bs.book_id = f.id
+            GROUP BY bs.subject
+            UNION ALL
+            SELECT 'language', f.language, '', COUNT(*)
+            FROM filtered f
+            WHERE f.language IS NOT NULL
+            GROUP BY f.language
+            UNION ALL
+            SELECT 'decade', ((f.publication_year / 10) * 10)::text, '', COUNT(*)
+            FROM filtered f
+            WHERE f.publication_year IS NOT NULL
+            GROUP BY (f.publication_year / 10) * 10
+            UNION ALL
+            SELECT 'availability', i.status, '', COUNT(DISTINCT f.id)
+            FROM filtered f
+            JOIN items i ON i.book_id = f.id
+            GROUP BY i.status
+        )
+        SELECT facet, value, label, n
+        FROM (
+            SELECT *, ROW_NUMBER() OVER (PARTITION BY facet ORDER BY n DESC, value) AS rn
+            FROM counts
+        ) ranked
+        WHERE rn <= $3`
+
+	args := append([]any{filters.Title, filters.AuthorName, limit}, catalogArgs...)
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error("facet query failed", "error", err)
+		return nil, fmt.Errorf("facet query failed: %w", err)
+	}
+	defer rows.Close()
+
+	facets := domain.NewBookFacets()
+	for rows.Next() {
+		var (
+			facet string
+			v     domain.FacetValue
+		)
+		if err := rows.Scan(&facet, &v.Value, &v.Label, &v.Count); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		facets.Add(facet, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	// Reordena por faceta; o SQL já limitou
+	facets.Truncate(limit)
+	return facets, nil
+}
+
+// saveSubjects substitui os assuntos do livro
+func saveSubjects(ctx context.Context, db DB, bookID int, subjects []string) error {
+	if _, err := db.Exec(ctx, `DELETE FROM book_subjects WHERE book_id = $1`, bookID); err != nil {
+		return err
+	}
+	if len(subjects) == 0 {
+		return nil
+	}
+	_, err := db.Exec(ctx, `
+        INSERT INTO book_subjects (book_id, subject)
+        SELECT $1, unnest($2::text[])
+        ON CONFLICT DO NOTHING`, bookID, subjects)
+	return err
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// bookDatestamp é a última alteração de um livro (alias b), como em
+// books_datestamp_idx
+const bookDatestamp = `COALESCE(b.updated_at, b.created_at)`
+
+// HarvestRepository atende a colheita OAI-PMH sobre books e book_deletions
+type HarvestRepository struct {
+	DB DB
+}
+
+func NewHarvestRepository(db DB) *HarvestRepository {
+	return &HarvestRepository{DB: db}
+}
+
+// HarvestRecords junta livros e exclusões numa única ordem (datestamp, id).
+// Os livros da página são lidos no mesmo snapshot, para que uma exclusão
+// concorrente não deixe um registro sem livro.
+func (r *HarvestRepository) HarvestRecords(ctx context.Context, q domain.HarvestQuery) ([]domain.HarvestRecord, error) {
+	args := []any{q.Limit}
+	param := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	bookConds := []string{"TRUE"}
+	deletionConds := []string{"TRUE"}
+	if q.From != nil {
+		p := param(*q.From)
+		bookConds = append(bookConds, bookDatestamp+" >= "+p)
+		deletionConds = append(deletionConds, "d.deleted_at >= "+p)
+	}
+	if q.Until != nil {
+		p := param(*q.Until)
+		bookConds = append(bookConds, bookDatestamp+" < "+p)
+		deletionConds = append(deletionConds, "d.deleted_at < "+p)
+	}
+	if len(q.Subjects) > 0 {
+		p := param(q.Subjects)
+		bookConds = append(bookConds, `EXISTS (
+                SELECT 1 FROM book_subjects xbs
+                WHERE xbs.book_id = b.id AND xbs.subject = ANY(`+p+`::text[]))`)
+		deletionConds = append(deletionConds, "d.subjects && "+p+"::text[]")
+	}
+	if q.After != nil {
+		after, err := q.After.TimeValue()
+		if err != nil {
+			return nil, err
+		}
+		pt, pid := param(after), param(q.After.ID)
+		bookConds = append(bookConds, fmt.Sprintf("(%s, b.id) > (%s, %s)", bookDatestamp, pt, pid))
+		deletionConds = append(deletionConds, fmt.Sprintf("(d.deleted_at, d.book_id) > (%s, %s)", pt, pid))
+	}
+
+	tx, err := r.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+        SELECT id, uuid, datestamp, deleted, subjects FROM (
+            (SELECT b.id, b.uuid, `+bookDatestamp+` AS datestamp, FALSE AS deleted,
+                    `+subjectsColumn("b")+` AS subjects
+             FROM books b
+             WHERE `+strings.Join(bookConds, " AND ")+`
+             ORDER BY `+bookDatestamp+`, b.id
+             LIMIT $1)
+            UNION ALL
+            (SELECT d.book_id, d.uuid, d.deleted_at, TRUE, d.subjects
+             FROM book_deletions d
+             WHERE `+strings.Join(deletionConds, " AND ")+`
+             ORDER BY d.deleted_at, d.book_id
+             LIMIT $1)
+        ) h
+        ORDER BY datestamp, id
+        LIMIT $1`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	records, err := pgx.CollectRows(rows, scanHarvestRecord)
+	if err != nil {
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+
+	if q.WithBooks {
+		if err := attachBooks(ctx, tx, records); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func (r *HarvestRepository) GetHarvestRecord(ctx context.Context, uuid string) (*domain.HarvestRecord, error) {
+	tx, err := r.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+        SELECT b.id, b.uuid, `+bookDatestamp+`, FALSE, `+subjectsColumn("b")+`
+        FROM books b WHERE b.uuid = $1
+        UNION ALL
+        SELECT d.book_id, d.uuid, d.deleted_at, TRUE, d.subjects
+        FROM book_deletions d WHERE d.uuid = $1`, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	rec, err := pgx.CollectExactlyOneRow(rows, scanHarvestRecord)
+	if err != nil {
+		return nil, translateError("failed to get harvest record", err, domain.ErrBookNotFound)
+	}
+	records := []domain.HarvestRecord{rec}
+	if err := attachBooks(ctx, tx, records); err != nil {
+		return nil, err
+	}
+	return &records[0], nil
+}
+
+func (r *HarvestRepository) EarliestDatestamp(ctx context.Context) (*time.Time, error) {
+	var earliest *time.Time
+	err := r.DB.QueryRow(ctx, `
+        SELECT LEAST(
+            (SELECT MIN(`+bookDatestamp+`) FROM books b),
+            (SELECT MIN(deleted_at) FROM book_deletions))`).Scan(&earliest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earliest datestamp: %w", err)
+	}
+	return earliest, nil
+}
+
+func (r *HarvestRepository) HarvestSubjects(ctx context.Context) ([]string, error) {
+	rows, err := r.DB.Query(ctx, `
+        SELECT subject FROM book_subjects
+        UNION
+        SELECT unnest(subjects) FROM book_deletions
+        ORDER BY 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subjects: %w", err)
+	}
+	subjects, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subjects: %w", err)
+	}
+	return subjects, nil
+}
+
+// snapshot abre uma transação de leitura em que as consultas veem o mesmo
+// estado do banco
+func (r *HarvestRepository) snapshot(ctx context.Context) (pgx.Tx, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY`); err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to set isolation level: %w", err)
+	}
+	return tx, nil
+}
+
+func scanHarvestRecord(row pgx.CollectableRow) (domain.HarvestRecord, error) {
+	var rec domain.HarvestRecord
+	err := row.Scan(&rec.ID, &rec.UUID, &rec.Datestamp, &rec.Deleted, &rec.Subjects)
+	return rec, err
+}
+
+// attachBooks preenche Book nos registros de livros não excluídos
+func attachBooks(ctx context.Context, db DB, records []domain.HarvestRecord) error {
+	var ids []int
+	for _, rec := range records {
+		if !rec.Deleted {
+			ids = append(ids, rec.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := db.Query(ctx, `SELECT `+bookColumns("b")+` FROM books b WHERE b.id = ANY($1)`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get books: %w", err)
+	}
+	books, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Book, error) {
+		b := &domain.Book{Authors: []*domain.Author{}}
+		return b, row.Scan(bookFields(b)...)
+	})
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	byID := make(map[int]*domain.Book, len(books))
+	for _, b := range books {
+		byID[b.ID] = b
+	}
+
+	rows, err = db.Query(ctx, `
+        SELECT ba.book_id, a.id, a.uuid, a.name, a.created_at
+        FROM books_authors ba
+        JOIN authors a ON a.id = ba.author_id
+        WHERE ba.book_id = ANY($1)
+        ORDER BY ba.position, a.name`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get book authors: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			bookID int
+			a      domain.Author
+		)
+		if err := rows.Scan(&bookID, &a.ID, &a.UUID, &a.Name, &a.CreatedAt); err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		if b := byID[bookID]; b != nil {
+			b.Authors = append(b.Authors, &a)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	for i := range records {
+		if !records[i].Deleted {
+			records[i].Book = byID[records[i].ID]
+		}
+	}
+	return nil
+}
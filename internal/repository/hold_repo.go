@@ -0,0 +1,405 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type HoldRepository struct {
+	DB DB
+}
+
+func NewHoldRepository(db DB) *HoldRepository {
+	return &HoldRepository{DB: db}
+}
+
+// holdColumns lista as colunas lidas por holdFields, sobre holdJoins. A
+// posição conta as reservas na fila do livro até a própria, inclusive.
+const holdColumns = `h.id, h.uuid, h.book_id, b.uuid, b.title, h.patron_id, p.email,
+            h.pickup_branch, h.status,
+            CASE WHEN h.status IN ('waiting', 'suspended') THEN (
+                SELECT COUNT(*)::int FROM holds q
+                WHERE q.book_id = h.book_id AND q.status IN ('waiting', 'suspended')
+                AND (q.created_at, q.id) <= (h.created_at, h.id)) END,
+            COALESCE(h.expires_on::text, ''), COALESCE(h.suspended_until::text, ''),
+            COALESCE(h.item_id, 0), COALESCE(i.uuid::text, ''), COALESCE(i.barcode, ''),
+            h.ready_at, COALESCE(h.pickup_by::text, ''), h.created_at, h.updated_at, h.closed_at`
+
+const holdJoins = `holds h
+        JOIN books b ON b.id = h.book_id
+        JOIN users p ON p.id = h.patron_id
+        LEFT JOIN items i ON i.id = h.item_id`
+
+// holdFields devolve os destinos do Scan na ordem de holdColumns
+func holdFields(h *domain.Hold) []any {
+	return []any{&h.ID, &h.UUID, &h.BookID, &h.BookUUID, &h.Title, &h.PatronID, &h.Patron,
+		&h.PickupBranch, &h.Status, &h.Position,
+		&h.ExpiresOn, &h.SuspendedUntil,
+		&h.ItemID, &h.ItemUUID, &h.Barcode,
+		&h.ReadyAt, &h.PickupBy, &h.CreatedAt, &h.UpdatedAt, &h.ClosedAt}
+}
+
+// eligibleHold é a condição das reservas que podem receber um exemplar;
+// $2 é a data de hoje
+const eligibleHold = `(h.status = 'waiting' OR (h.status = 'suspended' AND h.suspended_until <= $2::date))
+            AND (h.expires_on IS NULL OR h.expires_on >= $2::date)`
+
+// freeItem é a condição dos exemplares disponíveis e não separados (alias i)
+const freeItem = `i.status = 'available'
+            AND NOT EXISTS (SELECT 1 FROM holds r WHERE r.item_id = i.id AND r.status = 'ready')`
+
+// fillHolds separa os exemplares livres do livro para as primeiras
+// reservas elegíveis da fila e devolve quantos separou. A linha do livro
+// fica bloqueada até o fim da transação, o que serializa as filas.
+func fillHolds(ctx context.Context, tx pgx.Tx, bookID int, dates domain.HoldDates) (int, error) {
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM books WHERE id = $1 FOR NO KEY UPDATE`, bookID); err != nil {
+		return 0, fmt.Errorf("failed to lock book queue: %w", err)
+	}
+
+	allocated := 0
+	for {
+		var (
+			holdID int
+			branch string
+		)
+		err := tx.QueryRow(ctx, `
+            SELECT h.id, h.pickup_branch
+            FROM holds h
+            WHERE h.book_id = $1 AND `+eligibleHold+`
+            ORDER BY h.created_at, h.id
+            LIMIT 1`, bookID, dates.Today).Scan(&holdID, &branch)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return allocated, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get next hold: %w", err)
+		}
+
+		// Um exemplar da unidade de retirada vem primeiro; os bloqueados por
+		// um empréstimo em curso ficam de fora
+		var itemID int
+		err = tx.QueryRow(ctx, `
+            SELECT i.id
+            FROM items i
+            WHERE i.book_id = $1 AND `+freeItem+`
+            ORDER BY i.branch = $2 DESC, i.id
+            LIMIT 1
+            FOR UPDATE OF i SKIP LOCKED`, bookID, branch).Scan(&itemID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return allocated, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get free item: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+            UPDATE holds
+            SET status = 'ready', item_id = $2, ready_at = NOW(), pickup_by = $3::date,
+                suspended_until = NULL, updated_at = NOW()
+            WHERE id = $1`,
+			holdID, itemID, dates.PickupBy)
+		if err != nil {
+			return 0, translateError("failed to set hold ready", err, nil)
+		}
+		logging.FromContext(ctx).Info("hold ready for pickup", "hold_id", holdID, "item_id", itemID)
+		allocated++
+	}
+}
+
+func (r *HoldRepository) CreateHold(ctx context.Context, p domain.HoldPlacement) (*domain.Hold, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var bookID int
+	err = tx.QueryRow(ctx, `SELECT id FROM books WHERE uuid = $1`, p.BookUUID).Scan(&bookID)
+	if err != nil {
+		return nil, translateError("failed to get book", err, domain.ErrBookNotFound)
+	}
+
+	var patronID int
+	err = tx.QueryRow(ctx, `
+        SELECT id FROM users
+        WHERE ($1 <> '' AND email = $1) OR ($1 = '' AND id = $2)`,
+		p.PatronEmail, p.PatronID).Scan(&patronID)
+	if err != nil {
+		return nil, translateError("failed to get patron", err, domain.ErrPatronNotFound)
+	}
+
+	var (
+		copies int
+		onLoan bool
+	)
+	err = tx.QueryRow(ctx, `
+        SELECT
+            (SELECT COUNT(*)::int FROM items WHERE book_id = $1 AND status NOT IN ('lost', 'withdrawn')),
+            EXISTS (SELECT 1 FROM loans l JOIN items i ON i.id = l.item_id
+                    WHERE i.book_id = $1 AND l.patron_id = $2 AND l.returned_at IS NULL)`,
+		bookID, patronID).Scan(&copies, &onLoan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check book copies: %w", err)
+	}
+	if copies == 0 {
+		return nil, domain.ErrNoCopies
+	}
+	if onLoan {
+		return nil, domain.ErrHoldOnLoan
+	}
+
+	var uuid string
+	err = tx.QueryRow(ctx, `
+        INSERT INTO holds (book_id, patron_id, pickup_branch, status, expires_on)
+        VALUES ($1, $2, $3, 'waiting', NULLIF($4, '')::date)
+        RETURNING uuid`,
+		bookID, patronID, p.PickupBranch, p.ExpiresOn).Scan(&uuid)
+	if err != nil {
+		if !isUniqueViolation(err, openHoldConstraint) {
+			logging.FromContext(ctx).Error("error creating hold", "error", err)
+		}
+		return nil, translateError("failed to create hold", err, nil)
+	}
+	if _, err := fillHolds(ctx, tx, bookID, p.Dates); err != nil {
+		logging.FromContext(ctx).Error("failed to fill holds", "error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to save data")
+	}
+
+	logging.FromContext(ctx).Info("hold placed", "hold_uuid", uuid, "book_uuid", p.BookUUID)
+	return r.GetHoldByUUID(ctx, uuid)
+}
+
+func (r *HoldRepository) GetHoldByUUID(ctx context.Context, uuid string) (*domain.Hold, error) {
+	h := &domain.Hold{}
+	err := r.DB.QueryRow(ctx, `
+        SELECT `+holdColumns+`
+        FROM `+holdJoins+`
+        WHERE h.uuid = $1`, uuid).Scan(holdFields(h)...)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logging.FromContext(ctx).Error("failed to get hold", "error", err)
+		}
+		return nil, translateError("failed to get hold", err, domain.ErrHoldNotFound)
+	}
+	return h, nil
+}
+
+// GetHolds lista as reservas na ordem da fila
+func (r *HoldRepository) GetHolds(ctx context.Context, filters domain.HoldFilters) ([]domain.Hold, int, error) {
+	if filters.BookUUID != "" {
+		var exists bool
+		err := r.DB.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM books WHERE uuid = $1)`, filters.BookUUID).Scan(&exists)
+		if err != nil {
+			return nil, 0, translateError("failed to get book", err, nil)
+		}
+		if !exists {
+			return nil, 0, domain.ErrBookNotFound
+		}
+	}
+
+	var value any
+	if filters.Keyset != nil {
+		t, err := filters.Keyset.TimeValue()
+		if err != nil {
+			return nil, 0, err
+		}
+		value = t
+	}
+	where := `($1 = '' OR b.uuid::text = lower($1))
+            AND ($2 = 0 OR h.patron_id = $2)
+            AND ($3 = '' OR p.email = $3)
+            AND (cardinality($4::text[]) = 0 OR h.status = ANY($4))`
+	filterArgs := []any{filters.BookUUID, filters.PatronID, filters.PatronEmail, filters.Statuses}
+	if filters.Statuses == nil {
+		filterArgs[3] = []string{}
+	}
+	cond, order, keyArgs, backward := keyset("h.created_at", "h.id", false, filters.Keyset, value, 7)
+
+	args := append(append(filterArgs, filters.Limit, offsetOf(filters.Keyset, filters.Offset)), keyArgs...)
+	rows, err := r.DB.Query(ctx, `
+        SELECT `+holdColumns+`
+        FROM `+holdJoins+`
+        WHERE `+where+`
+        AND `+cond+`
+        ORDER BY `+order+`
+        LIMIT $5 OFFSET $6`, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error("database query error", "error", err)
+		return nil, 0, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	holds := []domain.Hold{}
+	for rows.Next() {
+		var h domain.Hold
+		if err := rows.Scan(holdFields(&h)...); err != nil {
+			return nil, 0, fmt.Errorf("row scan error: %w", err)
+		}
+		holds = append(holds, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	if backward {
+		slices.Reverse(holds)
+	}
+
+	total := -1
+	if filters.Keyset == nil {
+		err := r.DB.QueryRow(ctx, `
+            SELECT COUNT(*)
+            FROM `+holdJoins+`
+            WHERE `+where, filterArgs...).Scan(&total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("count failed: %w", err)
+		}
+	}
+	return holds, total, nil
+}
+
+// changeHold bloqueia a reserva e aplica change dentro de uma transação
+func (r *HoldRepository) changeHold(ctx context.Context, uuid string, change func(tx pgx.Tx, id, bookID int, status string) error) (*domain.Hold, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		id, bookID int
+		status     string
+	)
+	err = tx.QueryRow(ctx, `SELECT id, book_id, status FROM holds WHERE uuid = $1 FOR UPDATE`, uuid).
+		Scan(&id, &bookID, &status)
+	if err != nil {
+		return nil, translateError("failed to get hold", err, domain.ErrHoldNotFound)
+	}
+	if err := change(tx, id, bookID, status); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to save data")
+	}
+	return r.GetHoldByUUID(ctx, uuid)
+}
+
+// CancelHold encerra a reserva; o exemplar de uma reserva pronta vai para
+// a próxima da fila
+func (r *HoldRepository) CancelHold(ctx context.Context, uuid string, dates domain.HoldDates) (*domain.Hold, error) {
+	return r.changeHold(ctx, uuid, func(tx pgx.Tx, id, bookID int, status string) error {
+		if status != domain.HoldWaiting && status != domain.HoldSuspended && status != domain.HoldReady {
+			return domain.HoldStatusError("cancel", status)
+		}
+		_, err := tx.Exec(ctx, `
+            UPDATE holds SET status = 'cancelled', closed_at = NOW(), updated_at = NOW()
+            WHERE id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("failed to cancel hold: %w", err)
+		}
+		if status == domain.HoldReady {
+			_, err = fillHolds(ctx, tx, bookID, dates)
+		}
+		return err
+	})
+}
+
+func (r *HoldRepository) SuspendHold(ctx context.Context, uuid, until string) (*domain.Hold, error) {
+	return r.changeHold(ctx, uuid, func(tx pgx.Tx, id, _ int, status string) error {
+		if status != domain.HoldWaiting && status != domain.HoldSuspended {
+			return domain.HoldStatusError("suspend", status)
+		}
+		_, err := tx.Exec(ctx, `
+            UPDATE holds SET status = 'suspended', suspended_until = NULLIF($2, '')::date, updated_at = NOW()
+            WHERE id = $1`, id, until)
+		if err != nil {
+			return fmt.Errorf("failed to suspend hold: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *HoldRepository) ResumeHold(ctx context.Context, uuid string, dates domain.HoldDates) (*domain.Hold, error) {
+	return r.changeHold(ctx, uuid, func(tx pgx.Tx, id, bookID int, status string) error {
+		if status != domain.HoldSuspended {
+			return domain.HoldStatusError("resume", status)
+		}
+		_, err := tx.Exec(ctx, `
+            UPDATE holds SET status = 'waiting', suspended_until = NULL, updated_at = NOW()
+            WHERE id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("failed to resume hold: %w", err)
+		}
+		_, err = fillHolds(ctx, tx, bookID, dates)
+		return err
+	})
+}
+
+func (r *HoldRepository) SweepHolds(ctx context.Context, dates domain.HoldDates) (domain.HoldSweep, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return domain.HoldSweep{}, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var sweep domain.HoldSweep
+	tag, err := tx.Exec(ctx, `
+        UPDATE holds SET status = 'expired', closed_at = NOW(), updated_at = NOW()
+        WHERE (status IN ('waiting', 'suspended') AND expires_on < $1::date)
+        OR (status = 'ready' AND pickup_by < $1::date)`, dates.Today)
+	if err != nil {
+		return domain.HoldSweep{}, fmt.Errorf("failed to expire holds: %w", err)
+	}
+	sweep.Expired = int(tag.RowsAffected())
+
+	tag, err = tx.Exec(ctx, `
+        UPDATE holds SET status = 'waiting', suspended_until = NULL, updated_at = NOW()
+        WHERE status = 'suspended' AND suspended_until <= $1::date`, dates.Today)
+	if err != nil {
+		return domain.HoldSweep{}, fmt.Errorf("failed to resume holds: %w", err)
+	}
+	sweep.Resumed = int(tag.RowsAffected())
+
+	// Livros com fila e exemplar livre
+	rows, err := tx.Query(ctx, `
+        SELECT DISTINCT h.book_id
+        FROM holds h
+        WHERE h.status = 'waiting'
+        AND EXISTS (SELECT 1 FROM items i WHERE i.book_id = h.book_id AND `+freeItem+`)`)
+	if err != nil {
+		return domain.HoldSweep{}, fmt.Errorf("failed to list queues: %w", err)
+	}
+	bookIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return domain.HoldSweep{}, fmt.Errorf("failed to list queues: %w", err)
+	}
+	for _, bookID := range bookIDs {
+		n, err := fillHolds(ctx, tx, bookID, dates)
+		if err != nil {
+			return domain.HoldSweep{}, err
+		}
+		sweep.Allocated += n
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return domain.HoldSweep{}, fmt.Errorf("failed to save data")
+	}
+	return sweep, nil
+}
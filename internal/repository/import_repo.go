@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// importLockID serializa os lotes de importações simultâneas, para que duas
+// delas não criem o mesmo autor
+const importLockID = 7_235_002
+
+// authorNameKeySQL é domain.AuthorNameKey em SQL (coberto pelo índice
+// authors_name_key_idx)
+const authorNameKeySQL = `lower(regexp_replace(btrim(name), '\s+', ' ', 'g'))`
+
+// importColumns são as colunas da tabela temporária import_rows
+var importColumns = []string{
+	"pos", "title", "description", "isbn", "publisher", "publication_year",
+	"language", "pages", "edition", "format", "subjects", "author_ids",
+}
+
+type ImportRepository struct {
+	DB DB
+}
+
+func NewImportRepository(db DB) *ImportRepository {
+	return &ImportRepository{DB: db}
+}
+
+// ImportBooks grava o lote numa transação. As linhas são copiadas com COPY
+// para uma tabela temporária e aplicadas com alguns comandos em conjunto, em
+// vez de um INSERT por livro.
+func (r *ImportRepository) ImportBooks(ctx context.Context, rows []domain.ImportRow, dryRun bool) (domain.ImportBatchResult, error) {
+	var res domain.ImportBatchResult
+	if len(rows) == 0 {
+		return res, nil
+	}
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return res, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, importLockID); err != nil {
+		return res, fmt.Errorf("failed to lock import: %w", err)
+	}
+
+	authorIDs, created, err := resolveAuthors(ctx, tx, rows)
+	if err != nil {
+		return res, err
+	}
+	res.AuthorsCreated = created
+
+	if _, err := tx.Exec(ctx, `
+        CREATE TEMP TABLE import_rows (
+            pos              INTEGER NOT NULL,
+            title            TEXT NOT NULL,
+            description      TEXT NOT NULL,
+            isbn             TEXT,
+            publisher        TEXT,
+            publication_year INTEGER,
+            language         TEXT,
+            pages            INTEGER,
+            edition          TEXT,
+            format           TEXT,
+            subjects         TEXT[],
+            author_ids       BIGINT[] NOT NULL,
+            book_id          BIGINT,
+            is_new           BOOLEAN NOT NULL DEFAULT FALSE
+        ) ON COMMIT DROP`); err != nil {
+		return res, fmt.Errorf("failed to create staging table: %w", err)
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"import_rows"}, importColumns,
+		pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+			row := rows[i]
+			ids := make([]int64, len(row.Authors))
+			for j, name := range row.Authors {
+				ids[j] = authorIDs[domain.AuthorNameKey(name)]
+			}
+			return []any{
+				i, row.Title, row.Description, nullText(row.ISBN), nullText(row.Publisher),
+				row.PublicationYear, nullText(row.Language), row.Pages, nullText(row.Edition),
+				nullText(row.Format), row.Subjects, ids,
+			}, nil
+		}))
+	if err != nil {
+		return res, fmt.Errorf("failed to copy import rows: %w", err)
+	}
+
+	// Livros com ISBN já cadastrado são atualizados; os demais recebem um
+	// id novo da sequência para que autores e assuntos possam ser ligados
+	// sem voltar ao banco linha a linha
+	steps := []struct{ name, sql string }{
+		{"match books by isbn", `
+            UPDATE import_rows s SET book_id = b.id
+            FROM books b
+            WHERE s.isbn IS NOT NULL AND b.isbn = s.isbn`},
+		{"assign book ids", `
+            UPDATE import_rows
+            SET book_id = nextval(pg_get_serial_sequence('books', 'id')), is_new = TRUE
+            WHERE book_id IS NULL`},
+		{"insert books", `
+            INSERT INTO books (id, title, description, isbn, publisher, publication_year, language, pages, edition, format)
+            SELECT book_id, title, description, isbn, publisher, publication_year, language, pages, edition, format
+            FROM import_rows WHERE is_new
+            ORDER BY pos`},
+		{"update books", `
+            UPDATE books b SET
+                title = s.title,
+                description = COALESCE(NULLIF(s.description, ''), b.description),
+                publisher = COALESCE(s.publisher, b.publisher),
+                publication_year = COALESCE(s.publication_year, b.publication_year),
+                language = COALESCE(s.language, b.language),
+                pages = COALESCE(s.pages, b.pages),
+                edition = COALESCE(s.edition, b.edition),
+                format = COALESCE(s.format, b.format),
+                updated_at = NOW()
+            FROM import_rows s
+            WHERE s.book_id = b.id AND NOT s.is_new`},
+		{"replace book authors", `
+            DELETE FROM books_authors
+            WHERE book_id IN (SELECT book_id FROM import_rows WHERE NOT is_new)`},
+		{"link book authors", `
+            INSERT INTO books_authors (book_id, author_id, position)
+            SELECT s.book_id, a.id, MIN(a.position) - 1
+            FROM import_rows s, unnest(s.author_ids) WITH ORDINALITY AS a(id, position)
+            GROUP BY s.book_id, a.id`},
+		{"replace book subjects", `
+            DELETE FROM book_subjects
+            WHERE book_id IN (SELECT book_id FROM import_rows WHERE NOT is_new AND subjects IS NOT NULL)`},
+		{"save book subjects", `
+            INSERT INTO book_subjects (book_id, subject)
+            SELECT s.book_id, subj
+            FROM import_rows s, unnest(s.subjects) AS subj
+            ON CONFLICT DO NOTHING`},
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(ctx, step.sql); err != nil {
+			logging.FromContext(ctx).Error("import step failed", "step", step.name, "error", err)
+			return res, translateError("failed to "+step.name, err, nil)
+		}
+	}
+
+	if err := tx.QueryRow(ctx, `
+        SELECT COUNT(*) FILTER (WHERE is_new), COUNT(*) FILTER (WHERE NOT is_new)
+        FROM import_rows`).Scan(&res.Created, &res.Updated); err != nil {
+		return res, fmt.Errorf("failed to count imported books: %w", err)
+	}
+
+	if dryRun {
+		return res, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return res, fmt.Errorf("failed to commit import batch: %w", err)
+	}
+	return res, nil
+}
+
+// resolveAuthors devolve o id de cada autor do lote, indexado por
+// AuthorNameKey, criando os que não existem. Se houver mais de um autor com
+// o mesmo nome, usa o mais antigo.
+func resolveAuthors(ctx context.Context, tx pgx.Tx, rows []domain.ImportRow) (map[string]int64, []string, error) {
+	names := map[string]string{} // chave → nome como aparece na primeira linha
+	var keys []string
+	for _, row := range rows {
+		for _, name := range row.Authors {
+			key := domain.AuthorNameKey(name)
+			if _, ok := names[key]; !ok {
+				names[key] = name
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	ids := make(map[string]int64, len(keys))
+	found, err := tx.Query(ctx, `
+        SELECT DISTINCT ON (key) `+authorNameKeySQL+` AS key, id
+        FROM authors
+        WHERE `+authorNameKeySQL+` = ANY($1)
+        ORDER BY key, id`, keys)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up authors: %w", err)
+	}
+	for found.Next() {
+		var key string
+		var id int64
+		if err := found.Scan(&key, &id); err != nil {
+			found.Close()
+			return nil, nil, fmt.Errorf("failed to scan author: %w", err)
+		}
+		ids[key] = id
+	}
+	found.Close()
+	if err := found.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to look up authors: %w", err)
+	}
+
+	var missing []string
+	for _, key := range keys {
+		if _, ok := ids[key]; !ok {
+			missing = append(missing, names[key])
+		}
+	}
+	if len(missing) == 0 {
+		return ids, nil, nil
+	}
+
+	inserted, err := tx.Query(ctx, `
+        INSERT INTO authors (name)
+        SELECT unnest($1::text[])
+        RETURNING id, name`, missing)
+	if err != nil {
+		return nil, nil, translateError("failed to create authors", err, nil)
+	}
+	defer inserted.Close()
+	created := make([]string, 0, len(missing))
+	for inserted.Next() {
+		var id int64
+		var name string
+		if err := inserted.Scan(&id, &name); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan author: %w", err)
+		}
+		key := domain.AuthorNameKey(name)
+		ids[key] = id
+		created = append(created, key)
+	}
+	if err := inserted.Err(); err != nil {
+		return nil, nil, translateError("failed to create authors", err, nil)
+	}
+	return ids, created, nil
+}
+
+// nullText grava strings vazias como NULL
+func nullText(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// SaveImportJob insere o job na primeira chamada (preenchendo ID e UUID) e
+// o atualiza nas seguintes
+func (r *ImportRepository) SaveImportJob(ctx context.Context, job *domain.ImportJob) error {
+	if job.ID == 0 {
+		err := r.DB.QueryRow(ctx, `
+            INSERT INTO import_jobs (status, format, source, dry_run, started_at)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id, uuid`,
+			job.Status, job.Format, job.Source, job.DryRun, job.StartedAt,
+		).Scan(&job.ID, &job.UUID)
+		return translateError("failed to create import job", err, nil)
+	}
+
+	_, err := r.DB.Exec(ctx, `
+        UPDATE import_jobs SET
+            status = $2, rows_read = $3, created = $4, updated = $5, failed = $6,
+            authors_created = $7, errors = $8, errors_truncated = $9, message = $10,
+            finished_at = $11, partial = $12, warnings = $13, warnings_truncated = $14
+        WHERE id = $1`,
+		job.ID, job.Status, job.Rows, job.Created, job.Updated, job.Failed,
+		job.AuthorsCreated, job.Errors, job.ErrorsTruncated, job.Message, job.FinishedAt,
+		job.Partial, job.Warnings, job.WarningsTruncated,
+	)
+	return translateError("failed to update import job", err, nil)
+}
+
+const importJobColumns = `id, uuid, status, format, source, dry_run, rows_read, created, updated, failed,
+        authors_created, errors, errors_truncated, message, started_at, finished_at,
+        partial, warnings, warnings_truncated`
+
+func importJobFields(j *domain.ImportJob) []any {
+	return []any{&j.ID, &j.UUID, &j.Status, &j.Format, &j.Source, &j.DryRun, &j.Rows,
+		&j.Created, &j.Updated, &j.Failed, &j.AuthorsCreated, &j.Errors,
+		&j.ErrorsTruncated, &j.Message, &j.StartedAt, &j.FinishedAt,
+		&j.Partial, &j.Warnings, &j.WarningsTruncated}
+}
+
+func (r *ImportRepository) GetImportJob(ctx context.Context, uuid string) (*domain.ImportJob, error) {
+	var job domain.ImportJob
+	err := r.DB.QueryRow(ctx, `SELECT `+importJobColumns+` FROM import_jobs WHERE uuid = $1`, uuid).
+		Scan(importJobFields(&job)...)
+	if err != nil {
+		return nil, translateError("failed to get import job", err, domain.ErrImportJobNotFound)
+	}
+	return &job, nil
+}
+
+// ListImportJobs devolve os jobs mais recentes primeiro
+func (r *ImportRepository) ListImportJobs(ctx context.Context, limit int) ([]domain.ImportJob, error) {
+	rows, err := r.DB.Query(ctx, `
+        SELECT `+importJobColumns+`
+        FROM import_jobs
+        ORDER BY started_at DESC, id DESC
+        LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list import jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []domain.ImportJob{}
+	for rows.Next() {
+		var job domain.ImportJob
+		if err := rows.Scan(importJobFields(&job)...); err != nil {
+			return nil, fmt.Errorf("failed to scan import job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
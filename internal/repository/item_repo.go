@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type ItemRepository struct {
+	DB DB
+}
+
+func NewItemRepository(db DB) *ItemRepository {
+	return &ItemRepository{DB: db}
+}
+
+// itemColumns lista as colunas lidas por itemFields; i é items e b, books
+const itemColumns = `i.id, i.uuid, i.book_id, b.uuid, i.barcode, COALESCE(i.call_number, ''),
+            i.branch, COALESCE(i.location, ''), i.status, i.material_type, COALESCE(i.acquired_on::text, ''),
+            i.price_cents, i.created_at, i.updated_at`
+
+// itemFields devolve os destinos do Scan na ordem de itemColumns
+func itemFields(it *domain.Item) []any {
+	return []any{&it.ID, &it.UUID, &it.BookID, &it.BookUUID, &it.Barcode, &it.CallNumber,
+		&it.Branch, &it.Location, &it.Status, &it.Material, &it.AcquiredOn,
+		&it.PriceCents, &it.CreatedAt, &it.UpdatedAt}
+}
+
+func (r *ItemRepository) CreateItem(ctx context.Context, bookUUID string, req domain.ItemRequest) (*domain.Item, error) {
+	var uuid string
+	err := r.DB.QueryRow(ctx, `
+        INSERT INTO items (book_id, barcode, call_number, branch, location, status, acquired_on, price_cents, material_type)
+        SELECT id, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6, NULLIF($7, '')::date, $8, $9
+        FROM books WHERE uuid = $1
+        RETURNING uuid`,
+		bookUUID, req.Barcode, req.CallNumber, req.Branch, req.Location, req.Status, req.AcquiredOn, req.PriceCents, req.Material,
+	).Scan(&uuid)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logging.FromContext(ctx).Error("error creating item", "error", err)
+		}
+		return nil, translateError("failed to create item", err, domain.ErrBookNotFound)
+	}
+
+	logging.FromContext(ctx).Debug("item created", "item_uuid", uuid)
+	return r.GetItemByUUID(ctx, uuid)
+}
+
+// GetItems lista os exemplares ordenados por código de barras
+func (r *ItemRepository) GetItems(ctx context.Context, filters domain.ItemFilters) ([]domain.Item, int, error) {
+	if filters.BookUUID != "" {
+		var exists bool
+		err := r.DB.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM books WHERE uuid = $1)`, filters.BookUUID).Scan(&exists)
+		if err != nil {
+			return nil, 0, translateError("failed to get book", err, nil)
+		}
+		if !exists {
+			return nil, 0, domain.ErrBookNotFound
+		}
+	}
+
+	var value any
+	if filters.Keyset != nil {
+		value = filters.Keyset.Value
+	}
+	where := `($1 = '' OR b.uuid::text = lower($1))
+            AND ($2 = '' OR i.barcode = $2)
+            AND ($3 = '' OR i.branch = $3)
+            AND (cardinality($4::text[]) = 0 OR i.status = ANY($4))`
+	filterArgs := []any{filters.BookUUID, filters.Barcode, filters.Branch, filters.Statuses}
+	if filters.Statuses == nil {
+		filterArgs[3] = []string{}
+	}
+	cond, order, keyArgs, backward := keyset("i.barcode", "i.id", false, filters.Keyset, value, 7)
+
+	args := append(append(filterArgs, filters.Limit, offsetOf(filters.Keyset, filters.Offset)), keyArgs...)
+	rows, err := r.DB.Query(ctx, `
+        SELECT `+itemColumns+`
+        FROM items i
+        JOIN books b ON b.id = i.book_id
+        WHERE `+where+`
+        AND `+cond+`
+        ORDER BY `+order+`
+        LIMIT $5 OFFSET $6`, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error("database query error", "error", err)
+		return nil, 0, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	items := []domain.Item{}
+	for rows.Next() {
+		var it domain.Item
+		if err := rows.Scan(itemFields(&it)...); err != nil {
+			return nil, 0, fmt.Errorf("row scan error: %w", err)
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+
+	total := -1
+	if filters.Keyset == nil {
+		err := r.DB.QueryRow(ctx, `
+            SELECT COUNT(*)
+            FROM items i
+            JOIN books b ON b.id = i.book_id
+            WHERE `+where, filterArgs...).Scan(&total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("count failed: %w", err)
+		}
+	}
+	return items, total, nil
+}
+
+func (r *ItemRepository) GetItemByUUID(ctx context.Context, uuid string) (*domain.Item, error) {
+	it := &domain.Item{}
+	err := r.DB.QueryRow(ctx, `
+        SELECT `+itemColumns+`
+        FROM items i
+        JOIN books b ON b.id = i.book_id
+        WHERE i.uuid = $1`, uuid).Scan(itemFields(it)...)
+	if err != nil {
+		return nil, translateError("failed to get item", err, domain.ErrItemNotFound)
+	}
+	return it, nil
+}
+
+// UpdateItem substitui os campos editáveis do exemplar. A linha fica
+// bloqueada (FOR UPDATE) para que a situação on_loan continue acompanhando
+// o empréstimo em aberto.
+func (r *ItemRepository) UpdateItem(ctx context.Context, uuid string, req domain.ItemRequest) (*domain.Item, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var onLoan bool
+	err = tx.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM loans l WHERE l.item_id = i.id AND l.returned_at IS NULL)
+        FROM items i
+        WHERE i.uuid = $1
+        FOR UPDATE`, uuid).Scan(&onLoan)
+	if err != nil {
+		return nil, translateError("failed to get item", err, domain.ErrItemNotFound)
+	}
+	if onLoan != (req.Status == domain.ItemOnLoan) {
+		return nil, domain.ErrOnLoanStatus
+	}
+
+	// Um exemplar separado que deixa de estar disponível devolve a reserva
+	// à fila, no mesmo lugar
+	if req.Status != domain.ItemAvailable {
+		_, err = tx.Exec(ctx, `
+            UPDATE holds
+            SET status = 'waiting', item_id = NULL, ready_at = NULL, pickup_by = NULL, updated_at = NOW()
+            WHERE item_id = (SELECT id FROM items WHERE uuid = $1) AND status = 'ready'`, uuid)
+		if err != nil {
+			logging.FromContext(ctx).Error("error releasing item hold", "error", err)
+			return nil, fmt.Errorf("failed to release item hold: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+        UPDATE items
+        SET barcode = $2, call_number = NULLIF($3, ''), branch = $4, location = NULLIF($5, ''),
+            status = $6, acquired_on = NULLIF($7, '')::date, price_cents = $8, material_type = $9, updated_at = NOW()
+        WHERE uuid = $1`,
+		uuid, req.Barcode, req.CallNumber, req.Branch, req.Location, req.Status, req.AcquiredOn, req.PriceCents, req.Material)
+	if err != nil {
+		logging.FromContext(ctx).Error("error updating item", "error", err)
+		return nil, translateError("failed to update item", err, nil)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to save data")
+	}
+	return r.GetItemByUUID(ctx, uuid)
+}
+
+// DeleteItem recusa exemplares com empréstimos, que guardam o histórico, e
+// exemplares separados para uma reserva
+func (r *ItemRepository) DeleteItem(ctx context.Context, uuid string) error {
+	var hasLoans, reserved bool
+	err := r.DB.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM loans l WHERE l.item_id = i.id),
+               EXISTS (SELECT 1 FROM holds h WHERE h.item_id = i.id AND h.status = 'ready')
+        FROM items i
+        WHERE i.uuid = $1`, uuid).Scan(&hasLoans, &reserved)
+	if err != nil {
+		return translateError("failed to get item", err, domain.ErrItemNotFound)
+	}
+	if hasLoans {
+		return domain.ErrItemHasLoans
+	}
+	if reserved {
+		return domain.ErrItemReserved
+	}
+
+	tag, err := r.DB.Exec(ctx, `DELETE FROM items WHERE uuid = $1`, uuid)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to delete item", "error", err)
+		return translateError("failed to delete item", err, nil)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrItemNotFound
+	}
+	logging.FromContext(ctx).Info("item deleted", "item_uuid", uuid)
+	return nil
+}
+
+func (r *ItemRepository) GetAvailability(ctx context.Context, bookIDs []int) (map[int]domain.Availability, error) {
+	out := make(map[int]domain.Availability, len(bookIDs))
+	for _, id := range bookIDs {
+		out[id] = domain.Availability{}
+	}
+	if len(bookIDs) == 0 {
+		return out, nil
+	}
+
+	rows, err := r.DB.Query(ctx, `
+        SELECT i.book_id,
+               CASE WHEN i.status = 'available' AND EXISTS (
+                   SELECT 1 FROM holds r WHERE r.item_id = i.id AND r.status = 'ready')
+               THEN 'on_hold' ELSE i.status END,
+               COUNT(*)
+        FROM items i
+        WHERE i.book_id = ANY($1)
+        GROUP BY 1, 2`, bookIDs)
+	if err != nil {
+		logging.FromContext(ctx).Error("availability query failed", "error", err)
+		return nil, fmt.Errorf("availability query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			bookID, n int
+			status    string
+		)
+		if err := rows.Scan(&bookID, &status, &n); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		a := out[bookID]
+		a.Count(status, n)
+		out[bookID] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return out, nil
+}
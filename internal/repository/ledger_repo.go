@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type LedgerRepository struct {
+	DB DB
+}
+
+func NewLedgerRepository(db DB) *LedgerRepository {
+	return &LedgerRepository{DB: db}
+}
+
+// entryColumns lista as colunas lidas por entryFields, sobre entryJoins
+const entryColumns = `e.id, e.uuid, e.patron_id, p.email, e.type, e.amount_cents, e.balance_cents,
+            COALESCE(l.uuid::text, ''), e.note, COALESCE(c.email, ''), e.created_at`
+
+// entryJoins liga o lançamento (e) ao leitor (p), ao empréstimo cobrado
+// (l) e ao funcionário que o registrou (c)
+const entryJoins = `ledger_entries e
+        JOIN users p ON p.id = e.patron_id
+        LEFT JOIN loans l ON l.id = e.loan_id
+        LEFT JOIN users c ON c.id = e.created_by`
+
+// entryFields devolve os destinos do Scan na ordem de entryColumns
+func entryFields(e *domain.LedgerEntry) []any {
+	return []any{&e.ID, &e.UUID, &e.PatronID, &e.Patron, &e.Type, &e.AmountCents, &e.BalanceCents,
+		&e.LoanUUID, &e.Note, &e.CreatedBy, &e.CreatedAt}
+}
+
+// PostLedgerEntry bloqueia a linha do leitor antes de ler o saldo, de modo
+// que lançamentos simultâneos se serializam e o saldo corrente fica certo
+func (r *LedgerRepository) PostLedgerEntry(ctx context.Context, p domain.LedgerPosting) (*domain.LedgerEntry, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var patronID int
+	err = tx.QueryRow(ctx, `SELECT id FROM users WHERE email = $1 FOR UPDATE`, p.PatronEmail).Scan(&patronID)
+	if err != nil {
+		return nil, translateError("failed to get patron", err, domain.ErrPatronNotFound)
+	}
+
+	var loanID *int
+	if p.LoanUUID != "" {
+		var id, loanPatron int
+		err := tx.QueryRow(ctx, `SELECT id, patron_id FROM loans WHERE uuid = $1`, p.LoanUUID).Scan(&id, &loanPatron)
+		if err != nil {
+			return nil, translateError("failed to get loan", err, domain.ErrLoanNotFound)
+		}
+		if loanPatron != patronID {
+			return nil, domain.ErrLoanOfOtherPatron
+		}
+		loanID = &id
+	}
+
+	balance, err := patronBalance(ctx, tx, patronID)
+	if err != nil {
+		return nil, err
+	}
+	if !domain.IsCharge(p.Type) && p.AmountCents > balance {
+		return nil, domain.ExceedsBalanceError(balance)
+	}
+	uuid, err := insertEntry(ctx, tx, patronID, p.Type, p.AmountCents, balance, loanID, p.Note, p.StaffID)
+	if err != nil {
+		logging.FromContext(ctx).Error("error creating ledger entry", "error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to save data")
+	}
+
+	logging.FromContext(ctx).Info("ledger entry posted", "entry_uuid", uuid, "type", p.Type, "amount_cents", p.AmountCents)
+	e := &domain.LedgerEntry{}
+	err = r.DB.QueryRow(ctx, `SELECT `+entryColumns+` FROM `+entryJoins+` WHERE e.uuid = $1`, uuid).
+		Scan(entryFields(e)...)
+	if err != nil {
+		return nil, translateError("failed to get ledger entry", err, nil)
+	}
+	return e, nil
+}
+
+// insertEntry grava um lançamento sobre o saldo anterior balance; staffID
+// 0 é a rotina de multas. A linha do leitor precisa estar bloqueada.
+func insertEntry(ctx context.Context, tx pgx.Tx, patronID int, entryType string, amount, balance int64,
+	loanID *int, note string, staffID int) (string, error) {
+	if domain.IsCharge(entryType) {
+		balance += amount
+	} else {
+		balance -= amount
+	}
+	var createdBy *int
+	if staffID != 0 {
+		createdBy = &staffID
+	}
+
+	var uuid string
+	err := tx.QueryRow(ctx, `
+        INSERT INTO ledger_entries (patron_id, type, amount_cents, balance_cents, loan_id, note, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING uuid`,
+		patronID, entryType, amount, balance, loanID, note, createdBy).Scan(&uuid)
+	if err != nil {
+		return "", translateError("failed to create ledger entry", err, nil)
+	}
+	return uuid, nil
+}
+
+// patronBalance lê o saldo devedor do leitor, gravado no último lançamento
+func patronBalance(ctx context.Context, db DB, patronID int) (int64, error) {
+	var balance int64
+	err := db.QueryRow(ctx, `
+        SELECT COALESCE((SELECT balance_cents FROM ledger_entries WHERE patron_id = $1 ORDER BY id DESC LIMIT 1), 0)`,
+		patronID).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get patron balance: %w", err)
+	}
+	return balance, nil
+}
+
+// GetLedger lista os lançamentos do mais recente para o mais antigo
+func (r *LedgerRepository) GetLedger(ctx context.Context, filters domain.LedgerFilters) ([]domain.LedgerEntry, int, int64, error) {
+	patronID := filters.PatronID
+	if filters.PatronEmail != "" {
+		err := r.DB.QueryRow(ctx, `SELECT id FROM users WHERE email = $1`, filters.PatronEmail).Scan(&patronID)
+		if err != nil {
+			return nil, 0, 0, translateError("failed to get patron", err, domain.ErrPatronNotFound)
+		}
+	}
+	balance, err := patronBalance(ctx, r.DB, patronID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var value any
+	if filters.Keyset != nil {
+		t, err := filters.Keyset.TimeValue()
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		value = t
+	}
+	where := `e.patron_id = $1 AND ($2 = '' OR e.type = $2)`
+	filterArgs := []any{patronID, filters.Type}
+	cond, order, keyArgs, backward := keyset("e.created_at", "e.id", true, filters.Keyset, value, 5)
+
+	args := append(append(filterArgs, filters.Limit, offsetOf(filters.Keyset, filters.Offset)), keyArgs...)
+	rows, err := r.DB.Query(ctx, `
+        SELECT `+entryColumns+`
+        FROM `+entryJoins+`
+        WHERE `+where+`
+        AND `+cond+`
+        ORDER BY `+order+`
+        LIMIT $3 OFFSET $4`, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error("database query error", "error", err)
+		return nil, 0, 0, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []domain.LedgerEntry{}
+	for rows.Next() {
+		var e domain.LedgerEntry
+		if err := rows.Scan(entryFields(&e)...); err != nil {
+			return nil, 0, 0, fmt.Errorf("row scan error: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, 0, fmt.Errorf("rows error: %w", err)
+	}
+	if backward {
+		slices.Reverse(entries)
+	}
+
+	total := -1
+	if filters.Keyset == nil {
+		err := r.DB.QueryRow(ctx, `SELECT COUNT(*) FROM ledger_entries e WHERE `+where, filterArgs...).Scan(&total)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("count failed: %w", err)
+		}
+	}
+	return entries, total, balance, nil
+}
+
+// AccrueFines bloqueia de uma vez os empréstimos da passada (FOR UPDATE),
+// o que serializa passadas simultâneas de várias instâncias; o total já
+// lançado para cada empréstimo é lido depois do bloqueio.
+func (r *LedgerRepository) AccrueFines(ctx context.Context, run domain.FineRun) (domain.FineSweep, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return domain.FineSweep{}, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	rules, err := policyRules(ctx, tx)
+	if err != nil {
+		return domain.FineSweep{}, err
+	}
+
+	type overdue struct {
+		loanID, patronID   int
+		category, material string
+		dueDate            string
+		returnedAt         *time.Time
+		settled            int64 // acertado nas renovações, fora da multa do prazo atual
+	}
+	rows, err := tx.Query(ctx, `
+        SELECT l.id, l.patron_id, p.category, i.material_type, l.due_date::text, l.returned_at,
+               l.fines_settled_cents
+        FROM loans l
+        JOIN items i ON i.id = l.item_id
+        JOIN users p ON p.id = l.patron_id
+        WHERE NOT l.fines_closed AND l.due_date < $1::date
+        ORDER BY l.id
+        FOR UPDATE OF l`, run.Today)
+	if err != nil {
+		return domain.FineSweep{}, fmt.Errorf("failed to list overdue loans: %w", err)
+	}
+	var loans []overdue
+	for rows.Next() {
+		var o overdue
+		if err := rows.Scan(&o.loanID, &o.patronID, &o.category, &o.material, &o.dueDate, &o.returnedAt, &o.settled); err != nil {
+			rows.Close()
+			return domain.FineSweep{}, fmt.Errorf("scan failed: %w", err)
+		}
+		loans = append(loans, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return domain.FineSweep{}, fmt.Errorf("rows error: %w", err)
+	}
+
+	var sweep domain.FineSweep
+	for _, o := range loans {
+		end := run.Today
+		if o.returnedAt != nil {
+			end = o.returnedAt.In(run.Loc).Format(time.DateOnly)
+		}
+		rule := domain.ResolvePolicy(rules, o.category, o.material, run.Default).Rule
+		fine, err := domain.OverdueFine(rule, o.dueDate, end, o.settled)
+		if err != nil {
+			return domain.FineSweep{}, err
+		}
+
+		var charged int64
+		err = tx.QueryRow(ctx, `
+            SELECT COALESCE(SUM(amount_cents), 0)::bigint FROM ledger_entries WHERE loan_id = $1 AND type = 'overdue'`,
+			o.loanID).Scan(&charged)
+		if err != nil {
+			return domain.FineSweep{}, fmt.Errorf("failed to sum loan fines: %w", err)
+		}
+		charged -= o.settled
+		if fine > charged {
+			if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, o.patronID); err != nil {
+				return domain.FineSweep{}, fmt.Errorf("failed to lock patron: %w", err)
+			}
+			balance, err := patronBalance(ctx, tx, o.patronID)
+			if err != nil {
+				return domain.FineSweep{}, err
+			}
+			loanID := o.loanID
+			_, err = insertEntry(ctx, tx, o.patronID, domain.EntryOverdue, fine-charged, balance, &loanID,
+				"overdue fine through "+end, 0)
+			if err != nil {
+				return domain.FineSweep{}, err
+			}
+			sweep.Charged++
+			sweep.AmountCents += fine - charged
+		}
+		if o.returnedAt != nil {
+			if _, err := tx.Exec(ctx, `UPDATE loans SET fines_closed = TRUE WHERE id = $1`, o.loanID); err != nil {
+				return domain.FineSweep{}, fmt.Errorf("failed to close loan fines: %w", err)
+			}
+			sweep.Closed++
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return domain.FineSweep{}, fmt.Errorf("failed to save data")
+	}
+	return sweep, nil
+}
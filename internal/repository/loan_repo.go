@@ -0,0 +1,454 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type LoanRepository struct {
+	DB DB
+}
+
+func NewLoanRepository(db DB) *LoanRepository {
+	return &LoanRepository{DB: db}
+}
+
+// loanColumns lista as colunas lidas por loanFields, sobre loanJoins
+const loanColumns = `l.id, l.uuid, l.item_id, i.uuid, i.barcode, b.uuid, b.title,
+            l.patron_id, p.email, st.email, l.checked_out_at, l.due_date::text,
+            l.renewals, l.returned_at, COALESCE(r.email, '')`
+
+// loanJoins liga o empréstimo (l) ao exemplar, ao livro, ao leitor (p) e
+// aos funcionários do empréstimo (st) e da devolução (r)
+const loanJoins = `loans l
+        JOIN items i ON i.id = l.item_id
+        JOIN books b ON b.id = i.book_id
+        JOIN users p ON p.id = l.patron_id
+        JOIN users st ON st.id = l.checked_out_by
+        LEFT JOIN users r ON r.id = l.returned_by`
+
+// loanFields devolve os destinos do Scan na ordem de loanColumns
+func loanFields(l *domain.Loan) []any {
+	return []any{&l.ID, &l.UUID, &l.ItemID, &l.ItemUUID, &l.Barcode, &l.BookUUID, &l.Title,
+		&l.PatronID, &l.Patron, &l.CheckedOutBy, &l.CheckedOutAt, &l.DueDate,
+		&l.Renewals, &l.ReturnedAt, &l.ReturnedBy}
+}
+
+// CreateLoan bloqueia o exemplar (FOR UPDATE) antes de conferir a situação,
+// de modo que dois empréstimos simultâneos do mesmo exemplar se
+// serializam; o índice parcial loans_item_active_key garante o mesmo no
+// banco.
+func (r *LoanRepository) CreateLoan(ctx context.Context, checkout domain.Checkout) (*domain.Loan, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		itemID, bookID   int
+		status, material string
+	)
+	err = tx.QueryRow(ctx, `SELECT id, book_id, status, material_type FROM items WHERE barcode = $1 FOR UPDATE`, checkout.Barcode).
+		Scan(&itemID, &bookID, &status, &material)
+	if err != nil {
+		return nil, translateError("failed to get item", err, domain.ErrItemNotFound)
+	}
+	switch status {
+	case domain.ItemAvailable:
+	case domain.ItemOnLoan:
+		return nil, domain.ErrItemOnLoan
+	default:
+		return nil, domain.ItemUnavailableError(status)
+	}
+
+	// A linha do leitor fica bloqueada para que dois empréstimos
+	// simultâneos não passem juntos pelo limite da regra
+	var (
+		patronID int
+		category string
+	)
+	err = tx.QueryRow(ctx, `SELECT id, category FROM users WHERE email = $1 FOR UPDATE`, checkout.PatronEmail).
+		Scan(&patronID, &category)
+	if err != nil {
+		return nil, translateError("failed to get patron", err, domain.ErrPatronNotFound)
+	}
+
+	// Leitor com saldo devedor acima do limite
+	balance, err := patronBalance(ctx, tx, patronID)
+	if err != nil {
+		return nil, err
+	}
+	if balance > checkout.MaxBalance {
+		return nil, domain.BalanceBlockedError(balance, checkout.MaxBalance)
+	}
+
+	// Exemplar separado para a reserva de outro leitor
+	var reserved bool
+	err = tx.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM holds WHERE item_id = $1 AND status = 'ready' AND patron_id <> $2)`,
+		itemID, patronID).Scan(&reserved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check item holds: %w", err)
+	}
+	if reserved {
+		return nil, domain.ErrItemReserved
+	}
+
+	// Prazo e limite da regra de circulação do leitor e do material
+	rules, err := policyRules(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	rule := domain.ResolvePolicy(rules, category, material, checkout.Default).Rule
+	var open int
+	err = tx.QueryRow(ctx, `
+        SELECT COUNT(*)
+        FROM loans l JOIN items i ON i.id = l.item_id
+        WHERE l.patron_id = $1 AND l.returned_at IS NULL AND ($2 = '' OR i.material_type = $2)`,
+		patronID, rule.MaterialType).Scan(&open)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count patron loans: %w", err)
+	}
+	if open >= rule.MaxItems {
+		return nil, domain.LoanLimitError(rule)
+	}
+	dueDate, err := checkout.Calendar.DueDate(checkout.Dates.Today, rule.LoanDays)
+	if err != nil {
+		return nil, err
+	}
+
+	var uuid string
+	err = tx.QueryRow(ctx, `
+        INSERT INTO loans (item_id, patron_id, checked_out_by, due_date)
+        VALUES ($1, $2, $3, $4::date)
+        RETURNING uuid`,
+		itemID, patronID, checkout.StaffID, dueDate).Scan(&uuid)
+	if err != nil {
+		logging.FromContext(ctx).Error("error creating loan", "error", err)
+		return nil, translateError("failed to create loan", err, nil)
+	}
+	_, err = tx.Exec(ctx, `UPDATE items SET status = $2, updated_at = NOW() WHERE id = $1`, itemID, domain.ItemOnLoan)
+	if err != nil {
+		logging.FromContext(ctx).Error("error updating item status", "error", err)
+		return nil, fmt.Errorf("failed to update item status: %w", err)
+	}
+
+	// O empréstimo atende a reserva do leitor; se ela tinha outro exemplar
+	// separado, ele fica livre para a fila
+	tag, err := tx.Exec(ctx, `
+        UPDATE holds SET status = 'fulfilled', item_id = $3, closed_at = NOW(), updated_at = NOW()
+        WHERE book_id = $1 AND patron_id = $2 AND status IN ('waiting', 'suspended', 'ready')`,
+		bookID, patronID, itemID)
+	if err != nil {
+		logging.FromContext(ctx).Error("error fulfilling holds", "error", err)
+		return nil, fmt.Errorf("failed to fulfill holds: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		if _, err := fillHolds(ctx, tx, bookID, checkout.Dates); err != nil {
+			logging.FromContext(ctx).Error("failed to fill holds", "error", err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to save data")
+	}
+
+	logging.FromContext(ctx).Info("item checked out", "loan_uuid", uuid, "barcode", checkout.Barcode)
+	return r.GetLoanByUUID(ctx, uuid)
+}
+
+// ReturnLoan encerra o empréstimo e passa o exemplar para a fila do livro
+func (r *LoanRepository) ReturnLoan(ctx context.Context, uuid string, staffID int, dates domain.HoldDates) (*domain.Loan, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var itemID int
+	err = tx.QueryRow(ctx, `
+        UPDATE loans
+        SET returned_at = NOW(), returned_by = $2
+        WHERE uuid = $1 AND returned_at IS NULL
+        RETURNING item_id`,
+		uuid, staffID).Scan(&itemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+		err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM loans WHERE uuid = $1)`, uuid).Scan(&exists)
+		if err != nil {
+			return nil, translateError("failed to get loan", err, nil)
+		}
+		if exists {
+			return nil, domain.ErrLoanReturned
+		}
+		return nil, domain.ErrLoanNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("error returning loan", "error", err)
+		return nil, translateError("failed to return loan", err, nil)
+	}
+
+	var bookID int
+	err = tx.QueryRow(ctx, `
+        UPDATE items SET status = CASE WHEN status = $3 THEN $2 ELSE status END, updated_at = NOW()
+        WHERE id = $1
+        RETURNING book_id`,
+		itemID, domain.ItemAvailable, domain.ItemOnLoan).Scan(&bookID)
+	if err != nil {
+		logging.FromContext(ctx).Error("error updating item status", "error", err)
+		return nil, fmt.Errorf("failed to update item status: %w", err)
+	}
+	if _, err := fillHolds(ctx, tx, bookID, dates); err != nil {
+		logging.FromContext(ctx).Error("failed to fill holds", "error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to save data")
+	}
+
+	logging.FromContext(ctx).Info("item returned", "loan_uuid", uuid)
+	return r.GetLoanByUUID(ctx, uuid)
+}
+
+// RenewLoan bloqueia o empréstimo e depois a linha do leitor, na mesma
+// ordem da rotina de multas, antes de ler o saldo e lançar a multa do
+// prazo vencido
+func (r *LoanRepository) RenewLoan(ctx context.Context, renewal domain.Renewal) (*domain.Loan, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		loanID, patronID, bookID int
+		category, material       string
+		dueDate                  string
+		returned                 bool
+		renewals                 int
+		settled                  int64
+	)
+	err = tx.QueryRow(ctx, `
+        SELECT l.id, l.patron_id, i.book_id, p.category, i.material_type, l.due_date::text,
+               l.returned_at IS NOT NULL, l.renewals, l.fines_settled_cents
+        FROM loans l
+        JOIN items i ON i.id = l.item_id
+        JOIN users p ON p.id = l.patron_id
+        WHERE l.uuid = $1
+        FOR UPDATE OF l`, renewal.LoanUUID).
+		Scan(&loanID, &patronID, &bookID, &category, &material, &dueDate, &returned, &renewals, &settled)
+	if err != nil {
+		return nil, translateError("failed to get loan", err, domain.ErrLoanNotFound)
+	}
+	if returned {
+		return nil, domain.ErrLoanReturned
+	}
+
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, patronID); err != nil {
+		return nil, fmt.Errorf("failed to lock patron: %w", err)
+	}
+	balance, err := patronBalance(ctx, tx, patronID)
+	if err != nil {
+		return nil, err
+	}
+	if balance > renewal.MaxBalance {
+		return nil, domain.BalanceBlockedError(balance, renewal.MaxBalance)
+	}
+
+	// Outro leitor na fila do livro
+	var held bool
+	err = tx.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM holds WHERE book_id = $1 AND status = 'waiting' AND patron_id <> $2)`,
+		bookID, patronID).Scan(&held)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check book holds: %w", err)
+	}
+	if held {
+		return nil, domain.ErrLoanHeld
+	}
+
+	rules, err := policyRules(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	rule := domain.ResolvePolicy(rules, category, material, renewal.Default).Rule
+	if renewals >= rule.MaxRenewals {
+		return nil, domain.RenewalLimitError(rule)
+	}
+	newDue, err := renewal.Calendar.DueDate(max(renewal.Today, dueDate), rule.LoanDays)
+	if err != nil {
+		return nil, err
+	}
+
+	// A multa do prazo vencido é lançada agora e fica em
+	// fines_settled_cents, fora da multa do novo prazo
+	var charged int64
+	err = tx.QueryRow(ctx, `
+        SELECT COALESCE(SUM(amount_cents), 0)::bigint FROM ledger_entries WHERE loan_id = $1 AND type = 'overdue'`,
+		loanID).Scan(&charged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum loan fines: %w", err)
+	}
+	fine, err := domain.OverdueFine(rule, dueDate, renewal.Today, settled)
+	if err != nil {
+		return nil, err
+	}
+	if owed := fine - (charged - settled); owed > 0 {
+		_, err := insertEntry(ctx, tx, patronID, domain.EntryOverdue, owed, balance, &loanID,
+			"overdue fine through "+renewal.Today, 0)
+		if err != nil {
+			logging.FromContext(ctx).Error("error creating ledger entry", "error", err)
+			return nil, err
+		}
+		charged += owed
+	}
+
+	_, err = tx.Exec(ctx, `
+        UPDATE loans SET due_date = $2::date, renewals = renewals + 1, fines_settled_cents = $3 WHERE id = $1`,
+		loanID, newDue, charged)
+	if err != nil {
+		logging.FromContext(ctx).Error("error renewing loan", "error", err)
+		return nil, fmt.Errorf("failed to renew loan: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+        INSERT INTO loan_renewals (loan_id, renewed_by, previous_due_date, due_date)
+        VALUES ($1, $2, $3::date, $4::date)`,
+		loanID, renewal.StaffID, dueDate, newDue)
+	if err != nil {
+		logging.FromContext(ctx).Error("error recording renewal", "error", err)
+		return nil, translateError("failed to record renewal", err, nil)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		return nil, fmt.Errorf("failed to save data")
+	}
+
+	logging.FromContext(ctx).Info("loan renewed", "loan_uuid", renewal.LoanUUID, "due_date", newDue)
+	return r.GetLoanByUUID(ctx, renewal.LoanUUID)
+}
+
+func (r *LoanRepository) GetLoanRenewals(ctx context.Context, uuid string) ([]domain.LoanRenewal, error) {
+	var loanID int
+	err := r.DB.QueryRow(ctx, `SELECT id FROM loans WHERE uuid = $1`, uuid).Scan(&loanID)
+	if err != nil {
+		return nil, translateError("failed to get loan", err, domain.ErrLoanNotFound)
+	}
+
+	rows, err := r.DB.Query(ctx, `
+        SELECT r.id, u.email, r.renewed_at, r.previous_due_date::text, r.due_date::text
+        FROM loan_renewals r
+        JOIN users u ON u.id = r.renewed_by
+        WHERE r.loan_id = $1
+        ORDER BY r.id`, loanID)
+	if err != nil {
+		logging.FromContext(ctx).Error("database query error", "error", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	renewals := []domain.LoanRenewal{}
+	for rows.Next() {
+		var rn domain.LoanRenewal
+		if err := rows.Scan(&rn.ID, &rn.RenewedBy, &rn.RenewedAt, &rn.PreviousDueDate, &rn.DueDate); err != nil {
+			return nil, fmt.Errorf("row scan error: %w", err)
+		}
+		renewals = append(renewals, rn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return renewals, nil
+}
+
+func (r *LoanRepository) GetLoanByUUID(ctx context.Context, uuid string) (*domain.Loan, error) {
+	l := &domain.Loan{}
+	err := r.DB.QueryRow(ctx, `
+        SELECT `+loanColumns+`
+        FROM `+loanJoins+`
+        WHERE l.uuid = $1`, uuid).Scan(loanFields(l)...)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logging.FromContext(ctx).Error("failed to get loan", "error", err)
+		}
+		return nil, translateError("failed to get loan", err, domain.ErrLoanNotFound)
+	}
+	return l, nil
+}
+
+// GetLoans lista os empréstimos do mais recente para o mais antigo
+func (r *LoanRepository) GetLoans(ctx context.Context, filters domain.LoanFilters) ([]domain.Loan, int, error) {
+	var value any
+	if filters.Keyset != nil {
+		t, err := filters.Keyset.TimeValue()
+		if err != nil {
+			return nil, 0, err
+		}
+		value = t
+	}
+	where := `($1 = 0 OR l.patron_id = $1)
+            AND ($2 = '' OR p.email = $2)
+            AND ($3 = '' OR i.barcode = $3)
+            AND ($4 = ''
+                OR ($4 = 'active' AND l.returned_at IS NULL)
+                OR ($4 = 'returned' AND l.returned_at IS NOT NULL)
+                OR ($4 = 'overdue' AND l.returned_at IS NULL AND l.due_date < NULLIF($5, '')::date))`
+	filterArgs := []any{filters.PatronID, filters.PatronEmail, filters.Barcode, filters.Status, filters.Today}
+	cond, order, keyArgs, backward := keyset("l.checked_out_at", "l.id", true, filters.Keyset, value, 8)
+
+	args := append(append(filterArgs, filters.Limit, offsetOf(filters.Keyset, filters.Offset)), keyArgs...)
+	rows, err := r.DB.Query(ctx, `
+        SELECT `+loanColumns+`
+        FROM `+loanJoins+`
+        WHERE `+where+`
+        AND `+cond+`
+        ORDER BY `+order+`
+        LIMIT $6 OFFSET $7`, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error("database query error", "error", err)
+		return nil, 0, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	loans := []domain.Loan{}
+	for rows.Next() {
+		var l domain.Loan
+		if err := rows.Scan(loanFields(&l)...); err != nil {
+			return nil, 0, fmt.Errorf("row scan error: %w", err)
+		}
+		loans = append(loans, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	if backward {
+		slices.Reverse(loans)
+	}
+
+	total := -1
+	if filters.Keyset == nil {
+		err := r.DB.QueryRow(ctx, `
+            SELECT COUNT(*)
+            FROM `+loanJoins+`
+            WHERE `+where, filterArgs...).Scan(&total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("count failed: %w", err)
+		}
+	}
+	return loans, total, nil
+}
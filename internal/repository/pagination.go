@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// keyset monta a condição e a ordenação de uma listagem. col e idCol são as
+// colunas de ordenação e desempate (nunca vindas do cliente), desc é a
+// direção pedida e next o número do próximo placeholder ($n).
+//
+// Sem keyset, cond é TRUE e a ordem é a de exibição. Com keyset, cond
+// compara a tupla (col, id) com a do item de referência; ao voltar uma
+// página (Backward) a busca anda na direção inversa e backward indica que o
+// resultado precisa ser invertido para voltar à ordem de exibição.
+func keyset(col, idCol string, desc bool, ks *domain.Keyset, value any, next int) (cond, order string, args []any, backward bool) {
+	if ks == nil {
+		return "TRUE", orderBy(col, idCol, desc), nil, false
+	}
+
+	walkDesc := desc != ks.Backward
+	op := ">"
+	if walkDesc {
+		op = "<"
+	}
+	cond = fmt.Sprintf("(%s, %s) %s ($%d, $%d)", col, idCol, op, next, next+1)
+	return cond, orderBy(col, idCol, walkDesc), []any{value, ks.ID}, ks.Backward
+}
+
+func orderBy(col, idCol string, desc bool) string {
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	return col + " " + dir + ", " + idCol + " " + dir
+}
+
+// bookSort valida a ordenação de livros e converte o valor do keyset para o
+// tipo da coluna
+func bookSort(filters domain.BookFilters) (col string, desc bool, value any, err error) {
+	desc = filters.SortDirection == "DESC" || filters.SortDirection == "desc"
+	switch filters.Sort {
+	case "title", "":
+		col = "title"
+		if filters.Keyset != nil {
+			value = filters.Keyset.Value
+		}
+	case "created_at":
+		col = "created_at"
+		if filters.Keyset != nil {
+			value, err = filters.Keyset.TimeValue()
+		}
+	default:
+		err = domain.ValidationError("invalid sort field",
+			domain.FieldError{Field: "sort", Message: fmt.Sprintf("unknown sort field %q", filters.Sort)})
+	}
+	return col, desc, value, err
+}
+
+// offsetOf ignora o offset no modo cursor
+func offsetOf(ks *domain.Keyset, offset int) int {
+	if ks != nil {
+		return 0
+	}
+	return offset
+}
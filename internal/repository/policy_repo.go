@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type PolicyRepository struct {
+	DB DB
+}
+
+func NewPolicyRepository(db DB) *PolicyRepository {
+	return &PolicyRepository{DB: db}
+}
+
+// ruleColumns lista as colunas lidas por ruleFields
+const ruleColumns = `id, uuid, patron_category, material_type, loan_days, max_renewals, max_items,
+            fine_daily_cents, fine_grace_days, fine_cap_cents, created_at, updated_at`
+
+// ruleFields devolve os destinos do Scan na ordem de ruleColumns
+func ruleFields(r *domain.PolicyRule) []any {
+	return []any{&r.ID, &r.UUID, &r.PatronCategory, &r.MaterialType, &r.LoanDays, &r.MaxRenewals, &r.MaxItems,
+		&r.FineDailyCents, &r.FineGraceDays, &r.FineCapCents, &r.CreatedAt, &r.UpdatedAt}
+}
+
+func (r *PolicyRepository) CreatePolicyRule(ctx context.Context, rule domain.PolicyRule) (*domain.PolicyRule, error) {
+	var uuid string
+	err := r.DB.QueryRow(ctx, `
+        INSERT INTO policy_rules (patron_category, material_type, loan_days, max_renewals, max_items,
+                                  fine_daily_cents, fine_grace_days, fine_cap_cents)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING uuid`,
+		rule.PatronCategory, rule.MaterialType, rule.LoanDays, rule.MaxRenewals, rule.MaxItems,
+		rule.FineDailyCents, rule.FineGraceDays, rule.FineCapCents).Scan(&uuid)
+	if err != nil {
+		if !isUniqueViolation(err, policyRuleConstraint) {
+			logging.FromContext(ctx).Error("error creating policy rule", "error", err)
+		}
+		return nil, translateError("failed to create policy rule", err, nil)
+	}
+
+	logging.FromContext(ctx).Info("policy rule created", "rule_uuid", uuid)
+	return r.GetPolicyRuleByUUID(ctx, uuid)
+}
+
+func (r *PolicyRepository) GetPolicyRules(ctx context.Context) ([]domain.PolicyRule, error) {
+	return policyRules(ctx, r.DB)
+}
+
+// policyRules lê a tabela inteira, na ordem de resolução; ela tem no
+// máximo uma linha por combinação de critérios
+func policyRules(ctx context.Context, db DB) ([]domain.PolicyRule, error) {
+	rows, err := db.Query(ctx, `SELECT `+ruleColumns+` FROM policy_rules`)
+	if err != nil {
+		logging.FromContext(ctx).Error("database query error", "error", err)
+		return nil, fmt.Errorf("failed to get policy rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []domain.PolicyRule{}
+	for rows.Next() {
+		var rule domain.PolicyRule
+		if err := rows.Scan(ruleFields(&rule)...); err != nil {
+			return nil, fmt.Errorf("row scan error: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	domain.SortPolicyRules(rules)
+	return rules, nil
+}
+
+func (r *PolicyRepository) GetPolicyRuleByUUID(ctx context.Context, uuid string) (*domain.PolicyRule, error) {
+	rule := &domain.PolicyRule{}
+	err := r.DB.QueryRow(ctx, `SELECT `+ruleColumns+` FROM policy_rules WHERE uuid = $1`, uuid).Scan(ruleFields(rule)...)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logging.FromContext(ctx).Error("failed to get policy rule", "error", err)
+		}
+		return nil, translateError("failed to get policy rule", err, domain.ErrPolicyRuleNotFound)
+	}
+	return rule, nil
+}
+
+func (r *PolicyRepository) UpdatePolicyRule(ctx context.Context, uuid string, rule domain.PolicyRule) (*domain.PolicyRule, error) {
+	tag, err := r.DB.Exec(ctx, `
+        UPDATE policy_rules
+        SET patron_category = $2, material_type = $3, loan_days = $4, max_renewals = $5, max_items = $6,
+            fine_daily_cents = $7, fine_grace_days = $8, fine_cap_cents = $9, updated_at = NOW()
+        WHERE uuid = $1`,
+		uuid, rule.PatronCategory, rule.MaterialType, rule.LoanDays, rule.MaxRenewals, rule.MaxItems,
+		rule.FineDailyCents, rule.FineGraceDays, rule.FineCapCents)
+	if err != nil {
+		if !isUniqueViolation(err, policyRuleConstraint) {
+			logging.FromContext(ctx).Error("error updating policy rule", "error", err)
+		}
+		return nil, translateError("failed to update policy rule", err, nil)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, domain.ErrPolicyRuleNotFound
+	}
+	return r.GetPolicyRuleByUUID(ctx, uuid)
+}
+
+func (r *PolicyRepository) DeletePolicyRule(ctx context.Context, uuid string) error {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM policy_rules WHERE uuid = $1`, uuid)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to delete policy rule", "error", err)
+		return translateError("failed to delete policy rule", err, nil)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrPolicyRuleNotFound
+	}
+	logging.FromContext(ctx).Info("policy rule deleted", "rule_uuid", uuid)
+	return nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/logging"
+)
+
+// searchConfigs mapeia o idioma da consulta para a configuração de texto e a
+// coluna tsvector criadas na migração 0002
+var searchConfigs = map[string]struct{ config, column string }{
+	"pt": {"pt_unaccent", "search_pt"},
+	"en": {"en_unaccent", "search_en"},
+}
+
+// Opções do ts_headline: trechos curtos com os termos entre dois caracteres
+// de uso privado. O ts_headline não escapa o texto, então o resultado é
+// escapado como HTML em Go e só depois os marcadores viram <mark>.
+const headlineOptions = "StartSel=\ue000, StopSel=\ue001, MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" … \""
+
+var headlineMarks = strings.NewReplacer("\ue000", "<mark>", "\ue001", "</mark>")
+
+type SearchRepository struct {
+	DB DB
+}
+
+func NewSearchRepository(db DB) *SearchRepository {
+	return &SearchRepository{DB: db}
+}
+
+// SearchBooks usa os tsvector de books (índices GIN), ordenando por ts_rank
+func (r *SearchRepository) SearchBooks(ctx context.Context, q domain.SearchQuery) ([]domain.SearchHit, int, error) {
+	cfg, ok := searchConfigs[q.Language]
+	if !ok {
+		return nil, 0, domain.ValidationError("unsupported search language",
+			domain.FieldError{Field: "lang", Message: "must be one of: pt, en"})
+	}
+
+	tsquery, args := buildTSQuery(cfg.config, q.Terms, 3)
+	query := `
+        WITH q AS (SELECT ` + tsquery + ` AS query)
+        SELECT b.id, b.uuid, b.title, b.description, b.created_at,
+               ts_rank(b.` + cfg.column + `, q.query) AS rank,
+               ts_headline('` + cfg.config + `', b.title || '. ' || b.description, q.query, '` + headlineOptions + `'),
+               COUNT(*) OVER () AS total
+        FROM books b, q
+        WHERE b.` + cfg.column + ` @@ q.query
+        ORDER BY rank DESC, b.id
+        LIMIT $1 OFFSET $2`
+
+	rows, err := r.DB.Query(ctx, query, append([]any{q.Limit, q.Offset}, args...)...)
+	if err != nil {
+		logging.FromContext(ctx).Error("search query failed", "error", err)
+		return nil, 0, fmt.Errorf("search query failed: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []domain.SearchHit{}
+	total := 0
+	for rows.Next() {
+		var h domain.SearchHit
+		var rank float32
+		b := &h.Book
+		if err := rows.Scan(&b.ID, &b.UUID, &b.Title, &b.Description, &b.CreatedAt, &rank, &h.Headline, &total); err != nil {
+			return nil, 0, fmt.Errorf("scan failed: %w", err)
+		}
+		h.Rank = float64(rank)
+		h.Headline = headlineMarks.Replace(html.EscapeString(h.Headline))
+		b.Authors = []*domain.Author{}
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+
+	if err := r.loadAuthors(ctx, hits); err != nil {
+		return nil, 0, err
+	}
+	return hits, total, nil
+}
+
+// loadAuthors preenche os autores dos livros encontrados numa única consulta
+func (r *SearchRepository) loadAuthors(ctx context.Context, hits []domain.SearchHit) error {
+	if len(hits) == 0 {
+		return nil
+	}
+	byID := make(map[int]*domain.Book, len(hits))
+	ids := make([]int, 0, len(hits))
+	for i := range hits {
+		byID[hits[i].Book.ID] = &hits[i].Book
+		ids = append(ids, hits[i].Book.ID)
+	}
+
+	rows, err := r.DB.Query(ctx, `
+        SELECT ba.book_id, a.id, a.uuid, a.name, a.created_at
+        FROM books_authors ba
+        JOIN authors a ON a.id = ba.author_id
+        WHERE ba.book_id = ANY($1)
+        ORDER BY ba.position, a.name`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load authors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var a domain.Author
+		if err := rows.Scan(&bookID, &a.ID, &a.UUID, &a.Name, &a.CreatedAt); err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		if b, ok := byID[bookID]; ok {
+			b.Authors = append(b.Authors, &a)
+		}
+	}
+	return rows.Err()
+}
+
+// buildTSQuery monta o tsquery combinando um *_tsquery por termo com &&. Os
+// textos vão como parâmetros a partir de $next; só o nome da configuração,
+// que vem de searchConfigs, é interpolado.
+func buildTSQuery(config string, terms []domain.SearchTerm, next int) (string, []any) {
+	parts := make([]string, 0, len(terms))
+	args := make([]any, 0, len(terms))
+	for _, t := range terms {
+		param := "$" + strconv.Itoa(next+len(args))
+		var part string
+		switch t.Kind {
+		case domain.SearchPhrase:
+			part = "phraseto_tsquery('" + config + "', " + param + ")"
+		case domain.SearchPrefix:
+			// t.Text só tem letras e dígitos, então não há operadores a escapar
+			part = "to_tsquery('" + config + "', " + param + " || ':*')"
+		default:
+			part = "plainto_tsquery('" + config + "', " + param + ")"
+		}
+		if t.Negated {
+			part = "!!" + part
+		}
+		parts = append(parts, part)
+		args = append(args, t.Text)
+	}
+	return "(" + strings.Join(parts, " && ") + ")", args
+}
@@ -2,18 +2,17 @@ package repository
 
 import (
 	"context"
-	"fmt"
-	"strings"
+	"errors"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/patrick-tondorf/lib_api/internal/domain"
 )
 
 type UserRepository struct {
-	db *pgx.Conn
+	db DB
 }
 
-func NewUserRepository(db *pgx.Conn) *UserRepository {
+func NewUserRepository(db DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
@@ -29,10 +28,11 @@ func (repo *UserRepository) CreateUser(ctx context.Context, user domain.User) er
 
 	if err != nil {
 		// Tratamento mais específico de erros
-		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-			return fmt.Errorf("user with this email already exists")
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return domain.ErrUserExists
 		}
-		return fmt.Errorf("failed to create user: %w", err)
+		return translateError("failed to create user", err, nil)
 	}
 
 	return nil
@@ -41,7 +41,7 @@ func (repo *UserRepository) CreateUser(ctx context.Context, user domain.User) er
 func (repo *UserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
 	row := repo.db.QueryRow(
 		ctx,
-		`SELECT id,uuid, email, password_hash, created_at, updated_at 
+		`SELECT id,uuid, email, password_hash, category, role, created_at, updated_at 
          FROM users 
          WHERE email = $1`,
 		email,
@@ -53,16 +53,37 @@ func (repo *UserRepository) GetUserByEmail(ctx context.Context, email string) (*
 		&user.UUID,
 		&user.Email,
 		&user.PasswordHash,
+		&user.Category,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, fmt.Errorf("failed to get user by email: %w", err)
+		return nil, translateError("failed to get user by email", err, domain.ErrUserNotFound)
 	}
 
 	return &user, nil
 }
+
+func (repo *UserRepository) SetUserCategory(ctx context.Context, email, category string) (*domain.User, error) {
+	tag, err := repo.db.Exec(ctx, `UPDATE users SET category = $2, updated_at = NOW() WHERE email = $1`, email, category)
+	if err != nil {
+		return nil, translateError("failed to update user category", err, nil)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, domain.ErrUserNotFound
+	}
+	return repo.GetUserByEmail(ctx, email)
+}
+
+func (repo *UserRepository) SetUserRole(ctx context.Context, email, role string) (*domain.User, error) {
+	tag, err := repo.db.Exec(ctx, `UPDATE users SET role = $2, updated_at = NOW() WHERE email = $1`, email, role)
+	if err != nil {
+		return nil, translateError("failed to update user role", err, nil)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, domain.ErrUserNotFound
+	}
+	return repo.GetUserByEmail(ctx, email)
+}
@@ -1,26 +1,36 @@
 package router
 
 import (
+	"log/slog"
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
 	"github.com/patrick-tondorf/lib_api/docs"
+	"github.com/patrick-tondorf/lib_api/internal/circulation"
 	"github.com/patrick-tondorf/lib_api/internal/config"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
 	"github.com/patrick-tondorf/lib_api/internal/handler"
-	auth "github.com/patrick-tondorf/lib_api/internal/middleware"
-	"github.com/patrick-tondorf/lib_api/internal/repository"
+	"github.com/patrick-tondorf/lib_api/internal/middleware"
+	"github.com/patrick-tondorf/lib_api/internal/oai"
+	"github.com/patrick-tondorf/lib_api/internal/pagination"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter(db *pgx.Conn) *gin.Engine {
+func SetupRouter(cfg *config.Config, stores storage.Stores, logger *slog.Logger) *gin.Engine {
 	r := gin.New()
 
-	// Middlewares básicos
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
+	// Middlewares básicos. ErrorHandler precisa vir antes dos handlers para
+	// renderizar, como problem+json, os erros que eles registram com c.Error
+	r.Use(middleware.RequestID(logger))
+	r.Use(middleware.Logger())
+	r.Use(middleware.Recovery())
+	r.Use(middleware.ErrorHandler())
+
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(middleware.NoRoute)
+	r.NoMethod(middleware.NoMethod)
 
 	// Configuração do Swagger
 	docs.SwaggerInfo.Title = "Library API"
@@ -32,22 +42,23 @@ func SetupRouter(db *pgx.Conn) *gin.Engine {
 	// Rotas do Swagger UI
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	// Inicializa repositórios e handlers
-	bookRepo := repository.NewBookRepository(db)
-	bookHandler := handler.NewBookHandler(bookRepo)
-	authorRepo := repository.NewAuthorRepository(db)
-	authorHandler := handler.NewAuthorHandler(authorRepo)
-	userRepo := repository.NewUserRepository(db)
-	userHandler := handler.NewUserHandler(userRepo)
-
-	// Secret key for JWT - agora com fallback para config.GetSecretKey()
-	secret := os.Getenv("SECRET_KEY")
-	if secret == "" {
-		secret = config.GetSecretKey() // Adicione esta função no seu pacote config
-		if secret == "" {
-			panic("SECRET_KEY not configured in environment variables or config")
-		}
-	}
+	// Inicializa handlers
+	cursors := pagination.NewCodec(cfg.Auth.SecretKey)
+	bookHandler := handler.NewBookHandler(stores.Books, stores.Items, cursors)
+	itemHandler := handler.NewItemHandler(stores.Items, cursors)
+	circ := cfg.Circulation
+	defaults := circulation.DefaultRule(circ)
+	calendar := circ.Calendar()
+	loanHandler := handler.NewLoanHandler(stores.Loans, cursors, defaults, circ.MaxBalanceCents, circ.HoldPickupDays, circ.Location(), calendar)
+	holdHandler := handler.NewHoldHandler(stores.Holds, stores.Items, cursors, circ.LoanDays, circ.HoldPickupDays, circ.Location())
+	policyHandler := handler.NewPolicyHandler(stores.Policies, stores.Users, stores.Items, defaults, circ.Location(), calendar)
+	ledgerHandler := handler.NewLedgerHandler(stores.Ledger, cursors)
+	authorHandler := handler.NewAuthorHandler(stores.Authors, cursors)
+	searchHandler := handler.NewSearchHandler(stores.Search)
+	importHandler := handler.NewImportHandler(stores.Imports)
+	exportHandler := handler.NewExportHandler(stores.Books)
+	citationHandler := handler.NewCitationHandler(stores.Books)
+	userHandler := handler.NewUserHandler(stores.Users, cfg.Auth.SecretKey, cfg.Auth.TokenTTL, cfg.Auth.Admins())
 
 	// Rotas públicas
 	public := r.Group("/api")
@@ -59,28 +70,101 @@ func SetupRouter(db *pgx.Conn) *gin.Engine {
 		public.GET("/health", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"status": "ok"})
 		})
+
+		// OAI-PMH: colheita pública do catálogo
+		if cfg.OAI.Enabled() {
+			provider := oai.New(stores.Harvest, cursors, oai.Config{
+				RepositoryName:       cfg.OAI.RepositoryName,
+				AdminEmail:           cfg.OAI.AdminEmail,
+				RepositoryIdentifier: cfg.OAI.RepositoryIdentifier,
+				PageSize:             cfg.OAI.PageSize,
+			})
+			oaiHandler := handler.NewOAIHandler(provider, cfg.OAI.BaseURL)
+			public.GET("/oai", oaiHandler.Harvest)
+			public.POST("/oai", oaiHandler.Harvest)
+		} else {
+			logger.Info("oai-pmh endpoint disabled", "reason", "oai.admin_email is not set")
+		}
 	}
 
 	// Rotas protegidas
 	protected := r.Group("/api")
-	protected.Use(auth.AuthMiddleware(secret))
+	protected.Use(middleware.AuthMiddleware(cfg.Auth.SecretKey))
+	// Papéis exigidos além do login; leitores usam as rotas /users/me
+	staff := middleware.RequireRole(domain.RoleStaff)
+	admin := middleware.RequireRole(domain.RoleAdmin)
 	{
 
 		//user routes
 		protected.GET("/users/:email", userHandler.GetUserByEmail)
+		protected.PUT("/users/:email/category", admin, userHandler.SetUserCategory)
+		protected.PUT("/users/:email/role", admin, userHandler.SetUserRole)
+		protected.GET("/users/me/loans", loanHandler.GetMyLoans)
+		protected.POST("/users/me/loans/renew", loanHandler.RenewMyLoans)
+		protected.GET("/users/me/holds", holdHandler.GetMyHolds)
+		protected.GET("/users/me/ledger", ledgerHandler.GetMyLedger)
+		protected.GET("/users/:email/ledger", staff, ledgerHandler.GetLedger)
+		protected.POST("/users/:email/ledger", staff, ledgerHandler.PostLedgerEntry)
 		// Book routes
 		protected.POST("/books", bookHandler.CreateBook)
 		protected.GET("/books", bookHandler.GetBooks)
-		//protected.GET("/books/:id", bookHandler.GetBookByID)
-		protected.PUT("/books/:id", bookHandler.UpdateBook)
-		//protected.DELETE("/books/:id", bookHandler.DeleteBook)
+		protected.GET("/books/:uuid", bookHandler.GetBook)
+		protected.PUT("/books/:uuid", bookHandler.UpdateBook)
+		protected.DELETE("/books/:uuid", bookHandler.DeleteBook)
+		protected.GET("/books/:uuid/cite", citationHandler.CiteBook)
+		protected.POST("/books/cite", citationHandler.CiteBooks)
+		protected.POST("/books/:uuid/items", staff, itemHandler.CreateItem)
+		protected.GET("/books/:uuid/items", itemHandler.GetBookItems)
+		protected.GET("/books/:uuid/holds", staff, holdHandler.GetBookHolds)
+
+		// Item routes
+		protected.GET("/items", itemHandler.GetItems)
+		protected.GET("/items/:uuid", itemHandler.GetItem)
+		protected.PUT("/items/:uuid", staff, itemHandler.UpdateItem)
+		protected.DELETE("/items/:uuid", staff, itemHandler.DeleteItem)
+
+		// Loan routes
+		protected.POST("/loans", staff, loanHandler.CreateLoan)
+		protected.GET("/loans", staff, loanHandler.GetLoans)
+		protected.GET("/loans/:uuid", loanHandler.GetLoan)
+		protected.POST("/loans/:uuid/return", staff, loanHandler.ReturnLoan)
+		protected.POST("/loans/:uuid/renew", loanHandler.RenewLoan)
+		protected.GET("/loans/:uuid/renewals", loanHandler.GetLoanRenewals)
+
+		// Hold routes
+		protected.POST("/holds", holdHandler.CreateHold)
+		protected.GET("/holds", staff, holdHandler.GetHolds)
+		protected.GET("/holds/:uuid", holdHandler.GetHold)
+		protected.POST("/holds/:uuid/cancel", holdHandler.CancelHold)
+		protected.POST("/holds/:uuid/suspend", holdHandler.SuspendHold)
+		protected.POST("/holds/:uuid/resume", holdHandler.ResumeHold)
+
+		// Policy routes
+		protected.POST("/policies/rules", admin, policyHandler.CreatePolicyRule)
+		protected.GET("/policies/rules", staff, policyHandler.GetPolicyRules)
+		protected.GET("/policies/rules/:uuid", staff, policyHandler.GetPolicyRule)
+		protected.PUT("/policies/rules/:uuid", admin, policyHandler.UpdatePolicyRule)
+		protected.DELETE("/policies/rules/:uuid", admin, policyHandler.DeletePolicyRule)
+		protected.POST("/policies/simulate", staff, policyHandler.SimulatePolicy)
 
 		// Author routes
 		protected.POST("/authors", authorHandler.CreateAuthor)
 		protected.GET("/authors", authorHandler.GetAuthors)
-		protected.GET("/authors/:id", authorHandler.GetAuthorByID)
-		//protected.PUT("/authors/:id", authorHandler.UpdateAuthor)
-		//protected.DELETE("/authors/:id", authorHandler.DeleteAuthor)
+		protected.GET("/authors/:uuid", authorHandler.GetAuthorByID)
+		protected.PUT("/authors/:uuid", authorHandler.UpdateAuthor)
+		protected.PATCH("/authors/:uuid", authorHandler.PatchAuthor)
+		protected.DELETE("/authors/:uuid", authorHandler.DeleteAuthor)
+
+		// Search routes
+		protected.GET("/search", searchHandler.Search)
+
+		// Import routes
+		protected.POST("/imports", staff, importHandler.CreateImport)
+		protected.GET("/imports", staff, importHandler.GetImports)
+		protected.GET("/imports/:uuid", staff, importHandler.GetImport)
+
+		// Export routes
+		protected.GET("/exports/books", exportHandler.ExportBooks)
 
 		// Rotas protegidas adicionais do usuário
 		//protected.GET("/users/me", userHandler.GetCurrentUser)
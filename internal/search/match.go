@@ -0,0 +1,177 @@
+// Package search avalia consultas de busca textual em Go, para os backends
+// sem Postgres (memória e SQLite). Aproxima o comportamento do tsvector:
+// ignora maiúsculas e acentos e pondera título > autores > descrição, mas
+// não faz stemming.
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// Pesos de ts_rank para A (título), B (autores) e C (descrição)
+const (
+	weightTitle       = 1.0
+	weightAuthors     = 0.4
+	weightDescription = 0.2
+)
+
+// Document é o texto pesquisável de um livro
+type Document struct {
+	Title       string
+	Authors     []string
+	Description string
+}
+
+// Match avalia a consulta sobre o documento. Devolve false se algum termo
+// positivo não aparece ou algum termo negado aparece; senão, a relevância.
+func Match(terms []domain.SearchTerm, doc Document) (float64, bool) {
+	fields := []struct {
+		tokens []string
+		weight float64
+	}{
+		{tokenize(doc.Title), weightTitle},
+		{tokenize(strings.Join(doc.Authors, " ")), weightAuthors},
+		{tokenize(doc.Description), weightDescription},
+	}
+
+	var score float64
+	for _, t := range terms {
+		needle := tokenize(t.Text)
+		found := 0.0
+		for _, f := range fields {
+			found += float64(count(f.tokens, needle, t.Kind == domain.SearchPrefix)) * f.weight
+		}
+		if t.Negated {
+			if found > 0 {
+				return 0, false
+			}
+			continue
+		}
+		if found == 0 {
+			return 0, false
+		}
+		score += found
+	}
+	// Normaliza para (0, 1), como o ts_rank
+	return score / (1 + score), true
+}
+
+// Headline destaca os termos positivos em "título. descrição" com
+// <mark>...</mark>, recortando uma janela em torno do primeiro destaque. O
+// resultado é HTML: as palavras saem escapadas.
+func Headline(terms []domain.SearchTerm, doc Document) string {
+	text := doc.Title
+	if doc.Description != "" {
+		text += ". " + doc.Description
+	}
+
+	words := strings.Fields(text)
+	folded := make([][]string, len(words))
+	for i, w := range words {
+		folded[i] = tokenize(w)
+	}
+	// Uma palavra como "sci-fi" pode render mais de um token; para destacar
+	// basta comparar o primeiro token de cada palavra
+	flat := make([]string, len(words))
+	for i, f := range folded {
+		if len(f) > 0 {
+			flat[i] = f[0]
+		}
+	}
+
+	marked := make([]bool, len(words))
+	first := -1
+	for _, t := range terms {
+		if t.Negated {
+			continue
+		}
+		needle := tokenize(t.Text)
+		for i := range words {
+			if matchAt(flat, i, needle, t.Kind == domain.SearchPrefix) {
+				for j := i; j < i+len(needle); j++ {
+					marked[j] = true
+				}
+				if first < 0 || i < first {
+					first = i
+				}
+			}
+		}
+	}
+
+	const window = 30
+	start := 0
+	if first > window/3 {
+		start = first - window/3
+	}
+	end := min(len(words), start+window)
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteByte(' ')
+		}
+		word := html.EscapeString(words[i])
+		if marked[i] {
+			b.WriteString("<mark>" + word + "</mark>")
+		} else {
+			b.WriteString(word)
+		}
+	}
+	if end < len(words) {
+		b.WriteString(" …")
+	}
+	return b.String()
+}
+
+func count(tokens, needle []string, prefix bool) int {
+	n := 0
+	for i := range tokens {
+		if matchAt(tokens, i, needle, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// matchAt compara a sequência needle a partir de tokens[i]; com prefix a
+// última palavra só precisa ser prefixo
+func matchAt(tokens []string, i int, needle []string, prefix bool) bool {
+	if len(needle) == 0 || i+len(needle) > len(tokens) {
+		return false
+	}
+	for j, w := range needle {
+		tok := tokens[i+j]
+		if prefix && j == len(needle)-1 {
+			if !strings.HasPrefix(tok, w) {
+				return false
+			}
+		} else if tok != w {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenize separa palavras em minúsculas e sem acentos. O transformer tem
+// estado, então é criado a cada chamada.
+func tokenize(s string) []string {
+	unaccent := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(unaccent, strings.ToLower(s))
+	if err != nil {
+		folded = strings.ToLower(s)
+	}
+	return strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
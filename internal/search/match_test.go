@@ -0,0 +1,38 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+func TestHeadline(t *testing.T) {
+	word := func(text string) domain.SearchTerm { return domain.SearchTerm{Kind: domain.SearchWord, Text: text} }
+	tests := []struct {
+		name  string
+		terms []domain.SearchTerm
+		doc   Document
+		want  string
+	}{
+		{"title and description", []domain.SearchTerm{word("dune")},
+			Document{Title: "Dune", Description: "Spice and dune worms"},
+			"<mark>Dune.</mark> Spice and <mark>dune</mark> worms"},
+		{"accents ignored", []domain.SearchTerm{word("acao")},
+			Document{Title: "Ação direta"},
+			"<mark>Ação</mark> direta"},
+		{"negated terms not marked", []domain.SearchTerm{word("dune"), {Kind: domain.SearchWord, Text: "spice", Negated: true}},
+			Document{Title: "Dune", Description: "spice"},
+			"<mark>Dune.</mark> spice"},
+		{"html escaped", []domain.SearchTerm{word("dune")},
+			Document{Title: "Dune <b>", Description: `<script>alert("x")</script> & dune`},
+			"<mark>Dune</mark> &lt;b&gt;. &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; <mark>dune</mark>"},
+		{"marked word escaped", []domain.SearchTerm{word("x")},
+			Document{Title: "<x>"},
+			"<mark>&lt;x&gt;</mark>"},
+	}
+	for _, tt := range tests {
+		if got := Headline(tt.terms, tt.doc); got != tt.want {
+			t.Errorf("%s: Headline() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
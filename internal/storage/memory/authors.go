@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+type authorRecord struct {
+	id        int
+	uuid      string
+	name      string
+	bio       string
+	createdAt time.Time
+	updatedAt *time.Time
+}
+
+func (a *authorRecord) toDomain() domain.Author {
+	return domain.Author{
+		ID:        a.id,
+		UUID:      a.uuid,
+		Name:      a.name,
+		Bio:       a.bio,
+		CreatedAt: a.createdAt,
+		UpdatedAt: copyTime(a.updatedAt),
+	}
+}
+
+func (s *Store) CreateAuthor(ctx context.Context, author *domain.Author) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextAuthorID++
+	rec := &authorRecord{
+		id:        s.nextAuthorID,
+		uuid:      newUUID(),
+		name:      author.Name,
+		bio:       author.Bio,
+		createdAt: s.now(),
+	}
+	s.authors[rec.id] = rec
+
+	author.ID, author.UUID, author.CreatedAt = rec.id, rec.uuid, rec.createdAt
+	return nil
+}
+
+func (s *Store) GetAuthors(ctx context.Context, filters domain.AuthorFilters) ([]domain.Author, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all, page := s.pageAuthors(filters)
+	authors := make([]domain.Author, 0, len(page))
+	for _, rec := range page {
+		authors = append(authors, rec.toDomain())
+	}
+	return authors, totalOf(filters.Keyset, len(all)), nil
+}
+
+func (s *Store) GetAuthorsWithBooks(ctx context.Context, filters domain.AuthorFilters) ([]domain.Author, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all, page := s.pageAuthors(filters)
+	authors := make([]domain.Author, 0, len(page))
+	for _, rec := range page {
+		a := rec.toDomain()
+		a.Books = s.booksOfAuthor(rec.id)
+		authors = append(authors, a)
+	}
+	return authors, totalOf(filters.Keyset, len(all)), nil
+}
+
+// pageAuthors devolve todos os autores ordenados e a página pedida.
+// Deve ser chamado com o lock adquirido.
+func (s *Store) pageAuthors(filters domain.AuthorFilters) (all, page []*authorRecord) {
+	all = s.sortedAuthors()
+	var ref authorRecord
+	if filters.Keyset != nil {
+		ref = authorRecord{id: filters.Keyset.ID, name: filters.Keyset.Value}
+	}
+	page = window(all, filters.Limit, filters.Offset, filters.Keyset, func(rec *authorRecord) int {
+		if c := strings.Compare(rec.name, ref.name); c != 0 {
+			return c
+		}
+		return rec.id - ref.id
+	})
+	return all, page
+}
+
+func (s *Store) GetAuthorByID(ctx context.Context, id int) (*domain.Author, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec, ok := s.authors[id]
+	if !ok {
+		return nil, domain.ErrAuthorNotFound
+	}
+	a := rec.toDomain()
+	a.Books = s.booksOfAuthor(rec.id)
+	return &a, nil
+}
+
+func (s *Store) GetAuthorByUUID(ctx context.Context, uuid string) (*domain.Author, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec := s.authorByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrAuthorNotFound
+	}
+	a := rec.toDomain()
+	a.Books = s.booksOfAuthor(rec.id)
+	return &a, nil
+}
+
+func (s *Store) UpdateAuthor(ctx context.Context, uuid string, patch domain.AuthorPatchRequest) (*domain.Author, error) {
+	s.mu.Lock()
+	rec := s.authorByUUID(uuid)
+	if rec == nil {
+		s.mu.Unlock()
+		return nil, domain.ErrAuthorNotFound
+	}
+	now := s.now()
+	if patch.Name != nil && *patch.Name != rec.name {
+		rec.name = *patch.Name
+		// o nome faz parte do registro dos livros colhidos via OAI-PMH
+		for _, b := range s.books {
+			if b.hasAuthor(rec.id) {
+				b.updatedAt = &now
+			}
+		}
+	}
+	if patch.Bio != nil {
+		rec.bio = *patch.Bio
+	}
+	rec.updatedAt = &now
+	s.mu.Unlock()
+
+	return s.GetAuthorByUUID(ctx, uuid)
+}
+
+func (s *Store) DeleteAuthor(ctx context.Context, uuid string, unlink bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.authorByUUID(uuid)
+	if rec == nil {
+		return domain.ErrAuthorNotFound
+	}
+
+	var linked []*bookRecord
+	for _, b := range s.books {
+		if b.hasAuthor(rec.id) {
+			linked = append(linked, b)
+		}
+	}
+	if len(linked) > 0 && !unlink {
+		return domain.ErrAuthorHasBooks
+	}
+	now := s.now()
+	for _, b := range linked {
+		b.removeAuthor(rec.id)
+		b.updatedAt = &now
+	}
+
+	delete(s.authors, rec.id)
+	return nil
+}
+
+// authorByUUID deve ser chamado com o lock adquirido
+func (s *Store) authorByUUID(uuid string) *authorRecord {
+	for _, rec := range s.authors {
+		if strings.EqualFold(rec.uuid, uuid) {
+			return rec
+		}
+	}
+	return nil
+}
+
+// sortedAuthors retorna os autores ordenados por nome, como o ORDER BY name
+// do repositório Postgres. Deve ser chamado com o lock adquirido.
+func (s *Store) sortedAuthors() []*authorRecord {
+	out := make([]*authorRecord, 0, len(s.authors))
+	for _, rec := range s.authors {
+		out = append(out, rec)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].name != out[j].name {
+			return out[i].name < out[j].name
+		}
+		return out[i].id < out[j].id
+	})
+	return out
+}
+
+// booksOfAuthor retorna os livros do autor ordenados por título.
+// Deve ser chamado com o lock adquirido.
+func (s *Store) booksOfAuthor(authorID int) []*domain.Book {
+	var recs []*bookRecord
+	for _, b := range s.books {
+		if b.hasAuthor(authorID) {
+			recs = append(recs, b)
+		}
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].title != recs[j].title {
+			return recs[i].title < recs[j].title
+		}
+		return recs[i].id < recs[j].id
+	})
+
+	books := make([]*domain.Book, 0, len(recs))
+	for _, b := range recs {
+		book := b.toDomain()
+		books = append(books, &book)
+	}
+	return books
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
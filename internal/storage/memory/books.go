@@ -0,0 +1,396 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+type bookRecord struct {
+	id          int
+	uuid        string
+	title       string
+	description string
+	meta        domain.BookMetadata
+	authorIDs   []int
+	createdAt   time.Time
+	updatedAt   *time.Time
+}
+
+func (b *bookRecord) toDomain() domain.Book {
+	createdAt := b.createdAt
+	return domain.Book{
+		ID:              b.id,
+		UUID:            b.uuid,
+		Title:           b.title,
+		Description:     b.description,
+		ISBN:            b.meta.ISBN,
+		Publisher:       b.meta.Publisher,
+		PublicationYear: copyInt(b.meta.PublicationYear),
+		Language:        b.meta.Language,
+		Pages:           copyInt(b.meta.Pages),
+		Edition:         b.meta.Edition,
+		Format:          b.meta.Format,
+		Subjects:        slices.Sorted(slices.Values(b.meta.Subjects)),
+		CreatedAt:       &createdAt,
+		UpdatedAt:       copyTime(b.updatedAt),
+	}
+}
+
+func (b *bookRecord) hasAuthor(authorID int) bool {
+	for _, id := range b.authorIDs {
+		if id == authorID {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *bookRecord) removeAuthor(authorID int) {
+	kept := b.authorIDs[:0]
+	for _, id := range b.authorIDs {
+		if id != authorID {
+			kept = append(kept, id)
+		}
+	}
+	b.authorIDs = kept
+}
+
+func (s *Store) CreateBook(ctx context.Context, req domain.BookCreateRequest) (*domain.Book, error) {
+	if len(req.AuthorIDs) == 0 {
+		return nil, domain.ValidationError("at least one author ID is required",
+			domain.FieldError{Field: "authorIds", Message: "must contain at least one author"})
+	}
+
+	s.mu.Lock()
+	if missing := s.missingAuthors(req.AuthorIDs); len(missing) > 0 {
+		s.mu.Unlock()
+		return nil, domain.UnknownAuthorsError(missing...)
+	}
+	if s.isbnTaken(req.ISBN, 0) {
+		s.mu.Unlock()
+		return nil, domain.ErrISBNExists
+	}
+
+	s.nextBookID++
+	rec := &bookRecord{
+		id:          s.nextBookID,
+		uuid:        newUUID(),
+		title:       req.Title,
+		description: req.Description,
+		meta:        cloneMetadata(req.BookMetadata),
+		authorIDs:   uniqueInts(req.AuthorIDs),
+		createdAt:   s.now(),
+	}
+	s.books[rec.id] = rec
+	s.mu.Unlock()
+
+	return s.GetBookByUUID(ctx, rec.uuid)
+}
+
+// GetBooksBasic segue a semântica do repositório Postgres: filtro de título
+// sem diferenciar maiúsculas, ordenação por title ou created_at (desempate
+// por id) e LIMIT/OFFSET ou keyset, com o total calculado sobre o conjunto
+// filtrado apenas no modo offset.
+func (s *Store) GetBooksBasic(ctx context.Context, filters domain.BookFilters) ([]domain.Book, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	filters.AuthorName = "" // só vale para a listagem com autores
+	matched, page, err := s.pageBooks(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var books []domain.Book
+	for _, b := range page {
+		books = append(books, b.toDomain())
+	}
+	return books, totalOf(filters.Keyset, len(matched)), nil
+}
+
+// GetBooksWithAuthors filtra os livros que têm algum autor que casa com
+// AuthorName antes de paginar e, dentro da página, mantém apenas esses
+// autores, como a consulta Postgres.
+func (s *Store) GetBooksWithAuthors(ctx context.Context, filters domain.BookFilters) ([]domain.Book, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched, page, err := s.pageBooks(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	books := []domain.Book{}
+	for _, rec := range page {
+		b := rec.toDomain()
+		b.Authors = s.authorsOf(rec, filters.AuthorName)
+		books = append(books, b)
+	}
+	return books, totalOf(filters.Keyset, len(matched)), nil
+}
+
+func (s *Store) GetBookByUUID(ctx context.Context, uuid string) (*domain.Book, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec := s.bookByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrBookNotFound
+	}
+	b := rec.toDomain()
+	b.Authors = s.authorsOf(rec, "")
+	return &b, nil
+}
+
+func (s *Store) UpdateBook(ctx context.Context, uuid string, req domain.BookUpdateRequest) (*domain.Book, error) {
+	s.mu.Lock()
+	if missing := s.missingAuthors(req.AuthorIDs); len(missing) > 0 {
+		s.mu.Unlock()
+		return nil, domain.UnknownAuthorsError(missing...)
+	}
+
+	rec := s.bookByUUID(uuid)
+	if rec == nil {
+		s.mu.Unlock()
+		return nil, domain.ErrBookNotFound
+	}
+	rec.title = req.Title
+	rec.description = req.Description
+	if s.isbnTaken(req.ISBN, rec.id) {
+		s.mu.Unlock()
+		return nil, domain.ErrISBNExists
+	}
+	rec.meta = cloneMetadata(req.BookMetadata)
+	rec.authorIDs = uniqueInts(req.AuthorIDs)
+	now := s.now()
+	rec.updatedAt = &now
+	s.mu.Unlock()
+
+	return s.GetBookByUUID(ctx, uuid)
+}
+
+func (s *Store) DeleteBook(ctx context.Context, uuid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.bookByUUID(uuid)
+	if rec == nil {
+		return domain.ErrBookNotFound
+	}
+	for _, it := range s.items {
+		if it.bookID == rec.id {
+			return domain.ErrBookHasItems
+		}
+	}
+	delete(s.books, rec.id)
+	for id, h := range s.holds {
+		if h.bookID == rec.id {
+			delete(s.holds, id)
+		}
+	}
+	s.deletions[rec.id] = &deletionRecord{
+		id:        rec.id,
+		uuid:      rec.uuid,
+		subjects:  slices.Clone(rec.meta.Subjects),
+		deletedAt: s.now(),
+	}
+	return nil
+}
+
+// bookByUUID deve ser chamado com o lock adquirido
+func (s *Store) bookByUUID(uuid string) *bookRecord {
+	for _, rec := range s.books {
+		if strings.EqualFold(rec.uuid, uuid) {
+			return rec
+		}
+	}
+	return nil
+}
+
+// pageBooks aplica os filtros de título e autor, a ordenação e a paginação.
+// Devolve todos os livros filtrados e a página pedida.
+// Deve ser chamado com o lock adquirido.
+func (s *Store) pageBooks(filters domain.BookFilters) (matched, page []*bookRecord, err error) {
+	var cmp func(a, b *bookRecord) int
+	ref := &bookRecord{}
+	switch filters.Sort {
+	case "title", "":
+		cmp = func(a, b *bookRecord) int { return strings.Compare(strings.ToLower(a.title), strings.ToLower(b.title)) }
+		if filters.Keyset != nil {
+			ref.title = filters.Keyset.Value
+		}
+	case "created_at":
+		cmp = func(a, b *bookRecord) int { return a.createdAt.Compare(b.createdAt) }
+		if filters.Keyset != nil {
+			if ref.createdAt, err = filters.Keyset.TimeValue(); err != nil {
+				return nil, nil, err
+			}
+		}
+	default:
+		return nil, nil, domain.ValidationError("invalid sort field",
+			domain.FieldError{Field: "sort", Message: fmt.Sprintf("unknown sort field %q", filters.Sort)})
+	}
+	desc := strings.EqualFold(filters.SortDirection, "DESC")
+
+	// Ordem de exibição: chave de ordenação e depois id, na mesma direção
+	display := func(a, b *bookRecord) int {
+		c := cmp(a, b)
+		if c == 0 {
+			c = a.id - b.id
+		}
+		if desc {
+			return -c
+		}
+		return c
+	}
+
+	matched = s.filterBooks(filters)
+	slices.SortFunc(matched, display)
+
+	if filters.Keyset != nil {
+		ref.id = filters.Keyset.ID
+	}
+	page = window(matched, filters.Limit, filters.Offset, filters.Keyset, func(rec *bookRecord) int { return display(rec, ref) })
+	return matched, page, nil
+}
+
+// filterBooks aplica os filtros de título, autor e facetas, sem ordenar.
+// Deve ser chamado com o lock adquirido.
+func (s *Store) filterBooks(filters domain.BookFilters) []*bookRecord {
+	title := strings.ToLower(filters.Title)
+	var matched []*bookRecord
+	for _, rec := range s.books {
+		if title != "" && !strings.Contains(strings.ToLower(rec.title), title) {
+			continue
+		}
+		if filters.AuthorName != "" && len(s.authorsOf(rec, filters.AuthorName)) == 0 {
+			continue
+		}
+		if !s.matchesFacets(rec, filters) {
+			continue
+		}
+		matched = append(matched, rec)
+	}
+	return matched
+}
+
+// matchesFacets aplica os filtros de faceta e bibliográficos: OU entre
+// valores da mesma faceta, E entre facetas.
+// Deve ser chamado com o lock adquirido.
+func (s *Store) matchesFacets(rec *bookRecord, filters domain.BookFilters) bool {
+	if len(filters.AuthorUUIDs) > 0 && !slices.ContainsFunc(rec.authorIDs, func(id int) bool {
+		a, ok := s.authors[id]
+		return ok && slices.ContainsFunc(filters.AuthorUUIDs, func(u string) bool { return strings.EqualFold(u, a.uuid) })
+	}) {
+		return false
+	}
+	if len(filters.Subjects) > 0 && !slices.ContainsFunc(rec.meta.Subjects, func(subject string) bool {
+		return slices.Contains(filters.Subjects, subject)
+	}) {
+		return false
+	}
+	if len(filters.Languages) > 0 && !slices.Contains(filters.Languages, rec.meta.Language) {
+		return false
+	}
+	year := rec.meta.PublicationYear
+	if len(filters.Decades) > 0 && (year == nil || !slices.Contains(filters.Decades, domain.Decade(*year))) {
+		return false
+	}
+	if filters.ISBN != "" && rec.meta.ISBN != filters.ISBN {
+		return false
+	}
+	if filters.Publisher != "" && !strings.Contains(strings.ToLower(rec.meta.Publisher), strings.ToLower(filters.Publisher)) {
+		return false
+	}
+	if len(filters.Formats) > 0 && !slices.Contains(filters.Formats, rec.meta.Format) {
+		return false
+	}
+	if filters.YearFrom != nil && (year == nil || *year < *filters.YearFrom) {
+		return false
+	}
+	if filters.YearTo != nil && (year == nil || *year > *filters.YearTo) {
+		return false
+	}
+	if len(filters.Availability) > 0 && !slices.ContainsFunc(s.itemStatuses(rec.id), func(status string) bool {
+		return slices.Contains(filters.Availability, status)
+	}) {
+		return false
+	}
+	return true
+}
+
+// isbnTaken indica se outro livro (id diferente de except) já usa o ISBN.
+// Deve ser chamado com o lock adquirido.
+func (s *Store) isbnTaken(isbn string, except int) bool {
+	if isbn == "" {
+		return false
+	}
+	for _, rec := range s.books {
+		if rec.id != except && rec.meta.ISBN == isbn {
+			return true
+		}
+	}
+	return false
+}
+
+// authorsOf retorna os autores do livro na ordem de autoria, opcionalmente
+// filtrados por nome. Deve ser chamado com o lock adquirido.
+func (s *Store) authorsOf(rec *bookRecord, nameFilter string) []*domain.Author {
+	nameFilter = strings.ToLower(nameFilter)
+	authors := []*domain.Author{}
+	for _, id := range rec.authorIDs {
+		a, ok := s.authors[id]
+		if !ok {
+			continue
+		}
+		if nameFilter != "" && !strings.Contains(strings.ToLower(a.name), nameFilter) {
+			continue
+		}
+		author := a.toDomain()
+		authors = append(authors, &author)
+	}
+	return authors
+}
+
+// missingAuthors deve ser chamado com o lock adquirido
+func (s *Store) missingAuthors(ids []int) []int {
+	var missing []int
+	for _, id := range uniqueInts(ids) {
+		if _, ok := s.authors[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+func uniqueInts(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	out := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func copyInt(n *int) *int {
+	if n == nil {
+		return nil
+	}
+	v := *n
+	return &v
+}
+
+func cloneMetadata(m domain.BookMetadata) domain.BookMetadata {
+	m.PublicationYear = copyInt(m.PublicationYear)
+	m.Pages = copyInt(m.Pages)
+	m.Subjects = slices.Clone(m.Subjects)
+	return m
+}
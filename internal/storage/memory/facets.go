@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// GetBookFacets conta, por faceta, os livros que passam pelos filtros,
+// como a consulta Postgres
+func (s *Store) GetBookFacets(ctx context.Context, filters domain.BookFilters, limit int) (*domain.BookFacets, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[string]map[string]int{}
+	count := func(facet, value string) {
+		if counts[facet] == nil {
+			counts[facet] = map[string]int{}
+		}
+		counts[facet][value]++
+	}
+	labels := map[string]string{}
+
+	for _, rec := range s.filterBooks(filters) {
+		for _, id := range uniqueInts(rec.authorIDs) {
+			if a, ok := s.authors[id]; ok {
+				count(domain.FacetAuthor, a.uuid)
+				labels[a.uuid] = a.name
+			}
+		}
+		for _, subject := range rec.meta.Subjects {
+			count(domain.FacetSubject, subject)
+		}
+		if rec.meta.Language != "" {
+			count(domain.FacetLanguage, rec.meta.Language)
+		}
+		if rec.meta.PublicationYear != nil {
+			count(domain.FacetDecade, domain.DecadeValue(*rec.meta.PublicationYear))
+		}
+		for _, status := range s.itemStatuses(rec.id) {
+			count(domain.FacetAvailability, status)
+		}
+	}
+
+	facets := domain.NewBookFacets()
+	for facet, values := range counts {
+		for value, n := range values {
+			v := domain.FacetValue{Value: value, Count: n}
+			if facet == domain.FacetAuthor {
+				v.Label = labels[value]
+			}
+			facets.Add(facet, v)
+		}
+	}
+	facets.Truncate(limit)
+	return facets, nil
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// deletionRecord marca um livro excluído, para a colheita OAI-PMH
+type deletionRecord struct {
+	id        int
+	uuid      string
+	subjects  []string
+	deletedAt time.Time
+}
+
+func (b *bookRecord) datestamp() time.Time {
+	if b.updatedAt != nil {
+		return *b.updatedAt
+	}
+	return b.createdAt
+}
+
+// HarvestRecords segue a ordem do Postgres: datestamp e, no empate, id
+func (s *Store) HarvestRecords(ctx context.Context, q domain.HarvestQuery) ([]domain.HarvestRecord, error) {
+	var after time.Time
+	if q.After != nil {
+		var err error
+		if after, err = q.After.TimeValue(); err != nil {
+			return nil, err
+		}
+	}
+	matches := func(rec domain.HarvestRecord) bool {
+		ds := rec.Datestamp
+		switch {
+		case q.From != nil && ds.Before(*q.From):
+			return false
+		case q.Until != nil && !ds.Before(*q.Until):
+			return false
+		case q.After != nil && (ds.Before(after) || ds.Equal(after) && rec.ID <= q.After.ID):
+			return false
+		case len(q.Subjects) > 0 && !slices.ContainsFunc(rec.Subjects, func(subject string) bool {
+			return slices.Contains(q.Subjects, subject)
+		}):
+			return false
+		}
+		return true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []domain.HarvestRecord
+	for _, b := range s.books {
+		if rec := harvestRecordOf(b); matches(rec) {
+			records = append(records, rec)
+		}
+	}
+	for _, d := range s.deletions {
+		if rec := d.toHarvestRecord(); matches(rec) {
+			records = append(records, rec)
+		}
+	}
+	slices.SortFunc(records, func(a, b domain.HarvestRecord) int {
+		if c := a.Datestamp.Compare(b.Datestamp); c != 0 {
+			return c
+		}
+		return a.ID - b.ID
+	})
+	if q.Limit > 0 && len(records) > q.Limit {
+		records = records[:q.Limit]
+	}
+
+	if q.WithBooks {
+		for i, rec := range records {
+			if !rec.Deleted {
+				records[i].Book = s.harvestBook(s.books[rec.ID])
+			}
+		}
+	}
+	return records, nil
+}
+
+func (s *Store) GetHarvestRecord(ctx context.Context, uuid string) (*domain.HarvestRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if b := s.bookByUUID(uuid); b != nil {
+		rec := harvestRecordOf(b)
+		rec.Book = s.harvestBook(b)
+		return &rec, nil
+	}
+	for _, d := range s.deletions {
+		if strings.EqualFold(d.uuid, uuid) {
+			rec := d.toHarvestRecord()
+			return &rec, nil
+		}
+	}
+	return nil, domain.ErrBookNotFound
+}
+
+func (s *Store) EarliestDatestamp(ctx context.Context) (*time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var earliest *time.Time
+	consider := func(t time.Time) {
+		if earliest == nil || t.Before(*earliest) {
+			earliest = &t
+		}
+	}
+	for _, b := range s.books {
+		consider(b.datestamp())
+	}
+	for _, d := range s.deletions {
+		consider(d.deletedAt)
+	}
+	return earliest, nil
+}
+
+func (s *Store) HarvestSubjects(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var subjects []string
+	for _, b := range s.books {
+		subjects = append(subjects, b.meta.Subjects...)
+	}
+	for _, d := range s.deletions {
+		subjects = append(subjects, d.subjects...)
+	}
+	slices.Sort(subjects)
+	return slices.Compact(subjects), nil
+}
+
+func harvestRecordOf(b *bookRecord) domain.HarvestRecord {
+	return domain.HarvestRecord{
+		ID:        b.id,
+		UUID:      b.uuid,
+		Datestamp: b.datestamp(),
+		Subjects:  slices.Sorted(slices.Values(b.meta.Subjects)),
+	}
+}
+
+func (d *deletionRecord) toHarvestRecord() domain.HarvestRecord {
+	return domain.HarvestRecord{
+		ID:        d.id,
+		UUID:      d.uuid,
+		Datestamp: d.deletedAt,
+		Deleted:   true,
+		Subjects:  slices.Sorted(slices.Values(d.subjects)),
+	}
+}
+
+// harvestBook deve ser chamado com o lock adquirido
+func (s *Store) harvestBook(b *bookRecord) *domain.Book {
+	book := b.toDomain()
+	book.Authors = s.authorsOf(b, "")
+	return &book
+}
@@ -0,0 +1,380 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+type holdRecord struct {
+	id             int
+	uuid           string
+	bookID         int
+	patronID       int
+	pickupBranch   string
+	status         string
+	expiresOn      string
+	suspendedUntil string
+	itemID         int
+	readyAt        *time.Time
+	pickupBy       string
+	createdAt      time.Time
+	updatedAt      *time.Time
+	closedAt       *time.Time
+}
+
+// queued indica se a reserva ocupa um lugar na fila
+func (h *holdRecord) queued() bool {
+	return h.status == domain.HoldWaiting || h.status == domain.HoldSuspended
+}
+
+// open indica se a reserva ainda não foi encerrada
+func (h *holdRecord) open() bool {
+	return h.queued() || h.status == domain.HoldReady
+}
+
+// eligible indica se a reserva pode receber um exemplar hoje
+func (h *holdRecord) eligible(today string) bool {
+	waiting := h.status == domain.HoldWaiting ||
+		(h.status == domain.HoldSuspended && h.suspendedUntil != "" && h.suspendedUntil <= today)
+	return waiting && (h.expiresOn == "" || h.expiresOn >= today)
+}
+
+// before compara a ordem de chegada (created_at, id)
+func (h *holdRecord) before(o *holdRecord) int {
+	if c := h.createdAt.Compare(o.createdAt); c != 0 {
+		return c
+	}
+	return h.id - o.id
+}
+
+// prefers indica se o exemplar a vem antes de b para a reserva: primeiro
+// os da unidade de retirada, depois pelo id
+func (h *holdRecord) prefers(a, b *itemRecord) bool {
+	if atA, atB := a.branch == h.pickupBranch, b.branch == h.pickupBranch; atA != atB {
+		return atA
+	}
+	return a.id < b.id
+}
+
+func (h *holdRecord) close(status string, now time.Time) {
+	h.status = status
+	h.closedAt = &now
+	h.updatedAt = &now
+}
+
+// holdToDomain deve ser chamado com o lock adquirido
+func (s *Store) holdToDomain(h *holdRecord) domain.Hold {
+	out := domain.Hold{
+		ID:             h.id,
+		UUID:           h.uuid,
+		BookID:         h.bookID,
+		PatronID:       h.patronID,
+		Patron:         s.userEmail(h.patronID),
+		PickupBranch:   h.pickupBranch,
+		Status:         h.status,
+		ExpiresOn:      h.expiresOn,
+		SuspendedUntil: h.suspendedUntil,
+		ItemID:         h.itemID,
+		ReadyAt:        copyTime(h.readyAt),
+		PickupBy:       h.pickupBy,
+		CreatedAt:      h.createdAt,
+		UpdatedAt:      copyTime(h.updatedAt),
+		ClosedAt:       copyTime(h.closedAt),
+	}
+	if b, ok := s.books[h.bookID]; ok {
+		out.BookUUID = b.uuid
+		out.Title = b.title
+	}
+	if it, ok := s.items[h.itemID]; ok {
+		out.ItemUUID = it.uuid
+		out.Barcode = it.barcode
+	}
+	if h.queued() {
+		position := 0
+		for _, q := range s.holds {
+			if q.bookID == h.bookID && q.queued() && q.before(h) <= 0 {
+				position++
+			}
+		}
+		out.Position = &position
+	}
+	return out
+}
+
+// reservedBy devolve a reserva pronta que separou o exemplar, se houver.
+// Deve ser chamado com o lock adquirido.
+func (s *Store) reservedBy(itemID int) *holdRecord {
+	for _, h := range s.holds {
+		if h.itemID == itemID && h.status == domain.HoldReady {
+			return h
+		}
+	}
+	return nil
+}
+
+// fillHolds separa os exemplares livres do livro para as primeiras
+// reservas elegíveis da fila, preferindo a unidade de retirada, e devolve
+// quantos separou. Deve ser chamado com o lock de escrita.
+func (s *Store) fillHolds(bookID int, dates domain.HoldDates) int {
+	var queue []*holdRecord
+	for _, h := range s.holds {
+		if h.bookID == bookID && h.eligible(dates.Today) {
+			queue = append(queue, h)
+		}
+	}
+	slices.SortFunc(queue, (*holdRecord).before)
+
+	allocated := 0
+	for _, h := range queue {
+		var pick *itemRecord
+		for _, it := range s.items {
+			if it.bookID != bookID || it.status != domain.ItemAvailable || s.reservedBy(it.id) != nil {
+				continue
+			}
+			if pick == nil || h.prefers(it, pick) {
+				pick = it
+			}
+		}
+		if pick == nil {
+			break
+		}
+
+		now := s.now()
+		h.status = domain.HoldReady
+		h.itemID = pick.id
+		h.readyAt = &now
+		h.pickupBy = dates.PickupBy
+		h.suspendedUntil = ""
+		h.updatedAt = &now
+		allocated++
+	}
+	return allocated
+}
+
+func (s *Store) CreateHold(ctx context.Context, p domain.HoldPlacement) (*domain.Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	book := s.bookByUUID(p.BookUUID)
+	if book == nil {
+		return nil, domain.ErrBookNotFound
+	}
+
+	patronID := 0
+	if p.PatronEmail != "" {
+		if u, ok := s.users[p.PatronEmail]; ok {
+			patronID, _ = strconv.Atoi(u.ID)
+		}
+	} else if s.userEmail(p.PatronID) != "" {
+		patronID = p.PatronID
+	}
+	if patronID == 0 {
+		return nil, domain.ErrPatronNotFound
+	}
+
+	copies := 0
+	for _, it := range s.items {
+		if it.bookID == book.id && it.status != domain.ItemLost && it.status != domain.ItemWithdrawn {
+			copies++
+		}
+	}
+	if copies == 0 {
+		return nil, domain.ErrNoCopies
+	}
+	for _, l := range s.loans {
+		if l.patronID == patronID && l.returnedAt == nil && s.items[l.itemID].bookID == book.id {
+			return nil, domain.ErrHoldOnLoan
+		}
+	}
+	for _, h := range s.holds {
+		if h.bookID == book.id && h.patronID == patronID && h.open() {
+			return nil, domain.ErrHoldExists
+		}
+	}
+
+	s.nextHoldID++
+	rec := &holdRecord{
+		id:           s.nextHoldID,
+		uuid:         newUUID(),
+		bookID:       book.id,
+		patronID:     patronID,
+		pickupBranch: p.PickupBranch,
+		status:       domain.HoldWaiting,
+		expiresOn:    p.ExpiresOn,
+		createdAt:    s.now(),
+	}
+	s.holds[rec.id] = rec
+	s.fillHolds(book.id, p.Dates)
+
+	h := s.holdToDomain(rec)
+	return &h, nil
+}
+
+func (s *Store) GetHoldByUUID(ctx context.Context, uuid string) (*domain.Hold, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec := s.holdByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrHoldNotFound
+	}
+	h := s.holdToDomain(rec)
+	return &h, nil
+}
+
+// GetHolds lista as reservas na ordem da fila, como a consulta Postgres
+func (s *Store) GetHolds(ctx context.Context, filters domain.HoldFilters) ([]domain.Hold, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bookID := 0
+	if filters.BookUUID != "" {
+		book := s.bookByUUID(filters.BookUUID)
+		if book == nil {
+			return nil, 0, domain.ErrBookNotFound
+		}
+		bookID = book.id
+	}
+
+	ref := &holdRecord{}
+	if filters.Keyset != nil {
+		t, err := filters.Keyset.TimeValue()
+		if err != nil {
+			return nil, 0, err
+		}
+		ref.createdAt, ref.id = t, filters.Keyset.ID
+	}
+
+	var matched []*holdRecord
+	for _, h := range s.holds {
+		if bookID != 0 && h.bookID != bookID {
+			continue
+		}
+		if filters.PatronID != 0 && h.patronID != filters.PatronID {
+			continue
+		}
+		if filters.PatronEmail != "" && s.userEmail(h.patronID) != filters.PatronEmail {
+			continue
+		}
+		if len(filters.Statuses) > 0 && !slices.Contains(filters.Statuses, h.status) {
+			continue
+		}
+		matched = append(matched, h)
+	}
+	slices.SortFunc(matched, (*holdRecord).before)
+
+	page := window(matched, filters.Limit, filters.Offset, filters.Keyset, func(h *holdRecord) int { return h.before(ref) })
+
+	holds := make([]domain.Hold, 0, len(page))
+	for _, h := range page {
+		holds = append(holds, s.holdToDomain(h))
+	}
+	return holds, totalOf(filters.Keyset, len(matched)), nil
+}
+
+func (s *Store) CancelHold(ctx context.Context, uuid string, dates domain.HoldDates) (*domain.Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.holdByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrHoldNotFound
+	}
+	if !rec.open() {
+		return nil, domain.HoldStatusError("cancel", rec.status)
+	}
+
+	wasReady := rec.status == domain.HoldReady
+	rec.close(domain.HoldCancelled, s.now())
+	if wasReady {
+		s.fillHolds(rec.bookID, dates)
+	}
+
+	h := s.holdToDomain(rec)
+	return &h, nil
+}
+
+func (s *Store) SuspendHold(ctx context.Context, uuid, until string) (*domain.Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.holdByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrHoldNotFound
+	}
+	if !rec.queued() {
+		return nil, domain.HoldStatusError("suspend", rec.status)
+	}
+
+	now := s.now()
+	rec.status = domain.HoldSuspended
+	rec.suspendedUntil = until
+	rec.updatedAt = &now
+
+	h := s.holdToDomain(rec)
+	return &h, nil
+}
+
+func (s *Store) ResumeHold(ctx context.Context, uuid string, dates domain.HoldDates) (*domain.Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.holdByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrHoldNotFound
+	}
+	if rec.status != domain.HoldSuspended {
+		return nil, domain.HoldStatusError("resume", rec.status)
+	}
+
+	now := s.now()
+	rec.status = domain.HoldWaiting
+	rec.suspendedUntil = ""
+	rec.updatedAt = &now
+	s.fillHolds(rec.bookID, dates)
+
+	h := s.holdToDomain(rec)
+	return &h, nil
+}
+
+func (s *Store) SweepHolds(ctx context.Context, dates domain.HoldDates) (domain.HoldSweep, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sweep domain.HoldSweep
+	now := s.now()
+	books := map[int]bool{}
+	for _, h := range s.holds {
+		switch {
+		case h.queued() && h.expiresOn != "" && h.expiresOn < dates.Today,
+			h.status == domain.HoldReady && h.pickupBy < dates.Today:
+			h.close(domain.HoldExpired, now)
+			sweep.Expired++
+		case h.status == domain.HoldSuspended && h.suspendedUntil != "" && h.suspendedUntil <= dates.Today:
+			h.status = domain.HoldWaiting
+			h.suspendedUntil = ""
+			h.updatedAt = &now
+			sweep.Resumed++
+		}
+		books[h.bookID] = true
+	}
+	for bookID := range books {
+		sweep.Allocated += s.fillHolds(bookID, dates)
+	}
+	return sweep, nil
+}
+
+// holdByUUID deve ser chamado com o lock adquirido
+func (s *Store) holdByUUID(uuid string) *holdRecord {
+	for _, h := range s.holds {
+		if strings.EqualFold(h.uuid, uuid) {
+			return h
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// ImportBooks aplica o lote sob o lock de escrita, o que o torna atômico
+// como a transação do Postgres. Em dry-run apenas calcula as contagens.
+func (s *Store) ImportBooks(ctx context.Context, rows []domain.ImportRow, dryRun bool) (domain.ImportBatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res domain.ImportBatchResult
+
+	// Autores existentes por nome normalizado; com nomes repetidos vale o
+	// mais antigo (menor id), como no Postgres
+	authorIDs := map[string]int{}
+	for _, a := range s.authors {
+		key := domain.AuthorNameKey(a.name)
+		if id, ok := authorIDs[key]; !ok || a.id < id {
+			authorIDs[key] = a.id
+		}
+	}
+	booksByISBN := map[string]*bookRecord{}
+	for _, b := range s.books {
+		if b.meta.ISBN != "" {
+			booksByISBN[b.meta.ISBN] = b
+		}
+	}
+
+	for _, row := range rows {
+		ids := make([]int, 0, len(row.Authors))
+		for _, name := range row.Authors {
+			key := domain.AuthorNameKey(name)
+			id, ok := authorIDs[key]
+			if !ok {
+				res.AuthorsCreated = append(res.AuthorsCreated, key)
+				if !dryRun {
+					s.nextAuthorID++
+					id = s.nextAuthorID
+					s.authors[id] = &authorRecord{id: id, uuid: newUUID(), name: name, createdAt: s.now()}
+				}
+				authorIDs[key] = id
+			}
+			ids = append(ids, id)
+		}
+
+		rec, exists := booksByISBN[row.ISBN]
+		if !exists || row.ISBN == "" {
+			res.Created++
+			if dryRun {
+				continue
+			}
+			s.nextBookID++
+			s.books[s.nextBookID] = &bookRecord{
+				id:          s.nextBookID,
+				uuid:        newUUID(),
+				title:       row.Title,
+				description: row.Description,
+				meta:        cloneMetadata(row.BookMetadata),
+				authorIDs:   uniqueInts(ids),
+				createdAt:   s.now(),
+			}
+			if row.ISBN != "" {
+				booksByISBN[row.ISBN] = s.books[s.nextBookID]
+			}
+			continue
+		}
+
+		res.Updated++
+		if dryRun {
+			continue
+		}
+		now := s.now()
+		rec.title = row.Title
+		if row.Description != "" {
+			rec.description = row.Description
+		}
+		mergeMetadata(&rec.meta, row.BookMetadata)
+		rec.authorIDs = uniqueInts(ids)
+		rec.updatedAt = &now
+	}
+	return res, nil
+}
+
+// mergeMetadata copia para dst os campos preenchidos em src
+func mergeMetadata(dst *domain.BookMetadata, src domain.BookMetadata) {
+	src = cloneMetadata(src)
+	dst.Publisher = cmp.Or(src.Publisher, dst.Publisher)
+	dst.Language = cmp.Or(src.Language, dst.Language)
+	dst.Edition = cmp.Or(src.Edition, dst.Edition)
+	dst.Format = cmp.Or(src.Format, dst.Format)
+	if src.PublicationYear != nil {
+		dst.PublicationYear = src.PublicationYear
+	}
+	if src.Pages != nil {
+		dst.Pages = src.Pages
+	}
+	if src.Subjects != nil {
+		dst.Subjects = src.Subjects
+	}
+}
+
+func (s *Store) SaveImportJob(ctx context.Context, job *domain.ImportJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job.ID == 0 {
+		s.nextImportJobID++
+		job.ID = s.nextImportJobID
+		job.UUID = newUUID()
+	}
+	saved := *job
+	saved.Errors = slices.Clone(job.Errors)
+	saved.Warnings = slices.Clone(job.Warnings)
+	s.importJobs[job.ID] = &saved
+	return nil
+}
+
+func (s *Store) GetImportJob(ctx context.Context, uuid string) (*domain.ImportJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, job := range s.importJobs {
+		if job.UUID == uuid {
+			j := *job
+			return &j, nil
+		}
+	}
+	return nil, domain.ErrImportJobNotFound
+}
+
+// ListImportJobs devolve os jobs mais recentes primeiro
+func (s *Store) ListImportJobs(ctx context.Context, limit int) ([]domain.ImportJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := make([]domain.ImportJob, 0, len(s.importJobs))
+	for _, job := range s.importJobs {
+		jobs = append(jobs, *job)
+	}
+	slices.SortFunc(jobs, func(a, b domain.ImportJob) int {
+		if c := b.StartedAt.Compare(a.StartedAt); c != 0 {
+			return c
+		}
+		return b.ID - a.ID
+	})
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
@@ -0,0 +1,269 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+type itemRecord struct {
+	id         int
+	uuid       string
+	bookID     int
+	barcode    string
+	callNumber string
+	branch     string
+	location   string
+	status     string
+	material   string
+	acquiredOn string
+	priceCents *int64
+	createdAt  time.Time
+	updatedAt  *time.Time
+}
+
+// itemToDomain deve ser chamado com o lock adquirido (lê o UUID do livro)
+func (s *Store) itemToDomain(it *itemRecord) domain.Item {
+	out := domain.Item{
+		ID:         it.id,
+		UUID:       it.uuid,
+		BookID:     it.bookID,
+		Barcode:    it.barcode,
+		CallNumber: it.callNumber,
+		Branch:     it.branch,
+		Location:   it.location,
+		Status:     it.status,
+		Material:   it.material,
+		AcquiredOn: it.acquiredOn,
+		PriceCents: copyInt64(it.priceCents),
+		CreatedAt:  it.createdAt,
+		UpdatedAt:  copyTime(it.updatedAt),
+	}
+	if b, ok := s.books[it.bookID]; ok {
+		out.BookUUID = b.uuid
+	}
+	return out
+}
+
+func (it *itemRecord) apply(req domain.ItemRequest) {
+	it.barcode = req.Barcode
+	it.callNumber = req.CallNumber
+	it.branch = req.Branch
+	it.location = req.Location
+	it.status = req.Status
+	it.material = req.Material
+	it.acquiredOn = req.AcquiredOn
+	it.priceCents = copyInt64(req.PriceCents)
+}
+
+func (s *Store) CreateItem(ctx context.Context, bookUUID string, req domain.ItemRequest) (*domain.Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	book := s.bookByUUID(bookUUID)
+	if book == nil {
+		return nil, domain.ErrBookNotFound
+	}
+	if s.barcodeTaken(req.Barcode, 0) {
+		return nil, domain.ErrBarcodeExists
+	}
+
+	s.nextItemID++
+	rec := &itemRecord{
+		id:        s.nextItemID,
+		uuid:      newUUID(),
+		bookID:    book.id,
+		createdAt: s.now(),
+	}
+	rec.apply(req)
+	s.items[rec.id] = rec
+
+	it := s.itemToDomain(rec)
+	return &it, nil
+}
+
+// GetItems lista os exemplares ordenados por código de barras (desempate
+// por id), como a consulta Postgres
+func (s *Store) GetItems(ctx context.Context, filters domain.ItemFilters) ([]domain.Item, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bookID := 0
+	if filters.BookUUID != "" {
+		book := s.bookByUUID(filters.BookUUID)
+		if book == nil {
+			return nil, 0, domain.ErrBookNotFound
+		}
+		bookID = book.id
+	}
+
+	var matched []*itemRecord
+	for _, it := range s.items {
+		if bookID != 0 && it.bookID != bookID {
+			continue
+		}
+		if filters.Barcode != "" && it.barcode != filters.Barcode {
+			continue
+		}
+		if filters.Branch != "" && it.branch != filters.Branch {
+			continue
+		}
+		if len(filters.Statuses) > 0 && !slices.Contains(filters.Statuses, it.status) {
+			continue
+		}
+		matched = append(matched, it)
+	}
+	display := func(a, b *itemRecord) int {
+		if c := strings.Compare(a.barcode, b.barcode); c != 0 {
+			return c
+		}
+		return a.id - b.id
+	}
+	slices.SortFunc(matched, display)
+
+	ref := &itemRecord{}
+	if filters.Keyset != nil {
+		ref.barcode, ref.id = filters.Keyset.Value, filters.Keyset.ID
+	}
+	page := window(matched, filters.Limit, filters.Offset, filters.Keyset, func(it *itemRecord) int { return display(it, ref) })
+
+	items := make([]domain.Item, 0, len(page))
+	for _, it := range page {
+		items = append(items, s.itemToDomain(it))
+	}
+	return items, totalOf(filters.Keyset, len(matched)), nil
+}
+
+func (s *Store) GetItemByUUID(ctx context.Context, uuid string) (*domain.Item, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec := s.itemByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrItemNotFound
+	}
+	it := s.itemToDomain(rec)
+	return &it, nil
+}
+
+func (s *Store) UpdateItem(ctx context.Context, uuid string, req domain.ItemRequest) (*domain.Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.itemByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrItemNotFound
+	}
+	if (s.activeLoan(rec.id) != nil) != (req.Status == domain.ItemOnLoan) {
+		return nil, domain.ErrOnLoanStatus
+	}
+	if s.barcodeTaken(req.Barcode, rec.id) {
+		return nil, domain.ErrBarcodeExists
+	}
+	now := s.now()
+	// Um exemplar separado que deixa de estar disponível devolve a reserva
+	// à fila, no mesmo lugar
+	if h := s.reservedBy(rec.id); h != nil && req.Status != domain.ItemAvailable {
+		h.status = domain.HoldWaiting
+		h.itemID = 0
+		h.readyAt = nil
+		h.pickupBy = ""
+		h.updatedAt = &now
+	}
+	rec.apply(req)
+	rec.updatedAt = &now
+
+	it := s.itemToDomain(rec)
+	return &it, nil
+}
+
+func (s *Store) DeleteItem(ctx context.Context, uuid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.itemByUUID(uuid)
+	if rec == nil {
+		return domain.ErrItemNotFound
+	}
+	for _, l := range s.loans {
+		if l.itemID == rec.id {
+			return domain.ErrItemHasLoans
+		}
+	}
+	if s.reservedBy(rec.id) != nil {
+		return domain.ErrItemReserved
+	}
+	delete(s.items, rec.id)
+	// Como o ON DELETE SET NULL das reservas encerradas
+	for _, h := range s.holds {
+		if h.itemID == rec.id {
+			h.itemID = 0
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetAvailability(ctx context.Context, bookIDs []int) (map[int]domain.Availability, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make(map[int]domain.Availability, len(bookIDs))
+	for _, id := range bookIDs {
+		out[id] = domain.Availability{}
+	}
+	for _, it := range s.items {
+		if a, ok := out[it.bookID]; ok {
+			status := it.status
+			if status == domain.ItemAvailable && s.reservedBy(it.id) != nil {
+				status = domain.ItemOnHold
+			}
+			a.Count(status, 1)
+			out[it.bookID] = a
+		}
+	}
+	return out, nil
+}
+
+// itemByUUID deve ser chamado com o lock adquirido
+func (s *Store) itemByUUID(uuid string) *itemRecord {
+	for _, it := range s.items {
+		if strings.EqualFold(it.uuid, uuid) {
+			return it
+		}
+	}
+	return nil
+}
+
+// barcodeTaken indica se outro exemplar (id diferente de except) já usa o
+// código de barras. Deve ser chamado com o lock adquirido.
+func (s *Store) barcodeTaken(barcode string, except int) bool {
+	for _, it := range s.items {
+		if it.id != except && it.barcode == barcode {
+			return true
+		}
+	}
+	return false
+}
+
+// itemStatuses devolve as situações distintas dos exemplares do livro.
+// Deve ser chamado com o lock adquirido.
+func (s *Store) itemStatuses(bookID int) []string {
+	var statuses []string
+	for _, it := range s.items {
+		if it.bookID == bookID && !slices.Contains(statuses, it.status) {
+			statuses = append(statuses, it.status)
+		}
+	}
+	return statuses
+}
+
+func copyInt64(n *int64) *int64 {
+	if n == nil {
+		return nil
+	}
+	v := *n
+	return &v
+}
@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+type entryRecord struct {
+	id        int
+	uuid      string
+	patronID  int
+	kind      string
+	amount    int64
+	balance   int64
+	loanID    int // 0 sem empréstimo
+	note      string
+	staffID   int // 0 na rotina de multas
+	createdAt time.Time
+}
+
+// entryToDomain deve ser chamado com o lock adquirido
+func (s *Store) entryToDomain(e *entryRecord) domain.LedgerEntry {
+	out := domain.LedgerEntry{
+		ID:           e.id,
+		UUID:         e.uuid,
+		PatronID:     e.patronID,
+		Patron:       s.userEmail(e.patronID),
+		Type:         e.kind,
+		AmountCents:  e.amount,
+		BalanceCents: e.balance,
+		Note:         e.note,
+		CreatedAt:    e.createdAt,
+	}
+	if l, ok := s.loans[e.loanID]; ok {
+		out.LoanUUID = l.uuid
+	}
+	if e.staffID != 0 {
+		out.CreatedBy = s.userEmail(e.staffID)
+	}
+	return out
+}
+
+func (s *Store) PostLedgerEntry(ctx context.Context, p domain.LedgerPosting) (*domain.LedgerEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	patron, ok := s.users[p.PatronEmail]
+	if !ok {
+		return nil, domain.ErrPatronNotFound
+	}
+	patronID, _ := strconv.Atoi(patron.ID)
+
+	loanID := 0
+	if p.LoanUUID != "" {
+		l := s.loanByUUID(p.LoanUUID)
+		if l == nil {
+			return nil, domain.ErrLoanNotFound
+		}
+		if l.patronID != patronID {
+			return nil, domain.ErrLoanOfOtherPatron
+		}
+		loanID = l.id
+	}
+
+	if balance := s.patronBalance(patronID); !domain.IsCharge(p.Type) && p.AmountCents > balance {
+		return nil, domain.ExceedsBalanceError(balance)
+	}
+	e := s.postEntry(patronID, p.Type, p.AmountCents, loanID, p.Note, p.StaffID)
+	out := s.entryToDomain(e)
+	return &out, nil
+}
+
+// postEntry grava um lançamento sobre o saldo atual do leitor. Deve ser
+// chamado com o lock adquirido.
+func (s *Store) postEntry(patronID int, kind string, amount int64, loanID int, note string, staffID int) *entryRecord {
+	balance := s.patronBalance(patronID)
+	if domain.IsCharge(kind) {
+		balance += amount
+	} else {
+		balance -= amount
+	}
+
+	s.nextEntryID++
+	e := &entryRecord{
+		id:        s.nextEntryID,
+		uuid:      newUUID(),
+		patronID:  patronID,
+		kind:      kind,
+		amount:    amount,
+		balance:   balance,
+		loanID:    loanID,
+		note:      note,
+		staffID:   staffID,
+		createdAt: s.now(),
+	}
+	s.entries[e.id] = e
+	return e
+}
+
+// patronBalance lê o saldo devedor do leitor, gravado no último
+// lançamento. Deve ser chamado com o lock adquirido.
+func (s *Store) patronBalance(patronID int) int64 {
+	var last *entryRecord
+	for _, e := range s.entries {
+		if e.patronID == patronID && (last == nil || e.id > last.id) {
+			last = e
+		}
+	}
+	if last == nil {
+		return 0
+	}
+	return last.balance
+}
+
+// GetLedger lista os lançamentos do mais recente para o mais antigo
+// (desempate por id), como a consulta Postgres
+func (s *Store) GetLedger(ctx context.Context, filters domain.LedgerFilters) ([]domain.LedgerEntry, int, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	patronID := filters.PatronID
+	if filters.PatronEmail != "" {
+		patron, ok := s.users[filters.PatronEmail]
+		if !ok {
+			return nil, 0, 0, domain.ErrPatronNotFound
+		}
+		patronID, _ = strconv.Atoi(patron.ID)
+	}
+
+	ref := &entryRecord{}
+	if filters.Keyset != nil {
+		t, err := filters.Keyset.TimeValue()
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		ref.createdAt, ref.id = t, filters.Keyset.ID
+	}
+
+	var matched []*entryRecord
+	for _, e := range s.entries {
+		if e.patronID == patronID && (filters.Type == "" || e.kind == filters.Type) {
+			matched = append(matched, e)
+		}
+	}
+	display := func(a, b *entryRecord) int {
+		if c := b.createdAt.Compare(a.createdAt); c != 0 {
+			return c
+		}
+		return b.id - a.id
+	}
+	slices.SortFunc(matched, display)
+
+	page := window(matched, filters.Limit, filters.Offset, filters.Keyset, func(e *entryRecord) int { return display(e, ref) })
+
+	entries := make([]domain.LedgerEntry, 0, len(page))
+	for _, e := range page {
+		entries = append(entries, s.entryToDomain(e))
+	}
+	return entries, totalOf(filters.Keyset, len(matched)), s.patronBalance(patronID), nil
+}
+
+func (s *Store) AccrueFines(ctx context.Context, run domain.FineRun) (domain.FineSweep, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var overdue []*loanRecord
+	for _, l := range s.loans {
+		if !l.finesClosed && l.dueDate < run.Today {
+			overdue = append(overdue, l)
+		}
+	}
+	slices.SortFunc(overdue, func(a, b *loanRecord) int { return a.id - b.id })
+
+	var sweep domain.FineSweep
+	rules := s.policyRules()
+	for _, l := range overdue {
+		end := run.Today
+		if l.returnedAt != nil {
+			end = l.returnedAt.In(run.Loc).Format(time.DateOnly)
+		}
+		var category, material string
+		if u, ok := s.users[s.userEmail(l.patronID)]; ok {
+			category = u.Category
+		}
+		if it, ok := s.items[l.itemID]; ok {
+			material = it.material
+		}
+		rule := domain.ResolvePolicy(rules, category, material, run.Default).Rule
+		fine, err := domain.OverdueFine(rule, l.dueDate, end, l.finesSettled)
+		if err != nil {
+			return domain.FineSweep{}, err
+		}
+
+		// Só conta o lançado no prazo atual, sem o acertado nas renovações
+		charged := -l.finesSettled
+		for _, e := range s.entries {
+			if e.loanID == l.id && e.kind == domain.EntryOverdue {
+				charged += e.amount
+			}
+		}
+		if fine > charged {
+			s.postEntry(l.patronID, domain.EntryOverdue, fine-charged, l.id, "overdue fine through "+end, 0)
+			sweep.Charged++
+			sweep.AmountCents += fine - charged
+		}
+		if l.returnedAt != nil {
+			l.finesClosed = true
+			sweep.Closed++
+		}
+	}
+	return sweep, nil
+}
@@ -0,0 +1,389 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+type loanRecord struct {
+	id           int
+	uuid         string
+	itemID       int
+	patronID     int
+	staffID      int
+	checkedOutAt time.Time
+	dueDate      string
+	returnedAt   *time.Time
+	returnedBy   int
+	renewals     int
+	finesSettled int64 // multa já lançada para os prazos anteriores à última renovação
+	finesClosed  bool  // devolvido e com a multa por atraso encerrada
+}
+
+type renewalRecord struct {
+	id              int
+	loanID          int
+	staffID         int
+	renewedAt       time.Time
+	previousDueDate string
+	dueDate         string
+}
+
+// loanToDomain deve ser chamado com o lock adquirido (lê exemplar, livro e
+// usuários)
+func (s *Store) loanToDomain(l *loanRecord) domain.Loan {
+	out := domain.Loan{
+		ID:           l.id,
+		UUID:         l.uuid,
+		ItemID:       l.itemID,
+		PatronID:     l.patronID,
+		Patron:       s.userEmail(l.patronID),
+		CheckedOutBy: s.userEmail(l.staffID),
+		CheckedOutAt: l.checkedOutAt,
+		DueDate:      l.dueDate,
+		Renewals:     l.renewals,
+		ReturnedAt:   copyTime(l.returnedAt),
+	}
+	if l.returnedAt != nil {
+		out.ReturnedBy = s.userEmail(l.returnedBy)
+	}
+	if it, ok := s.items[l.itemID]; ok {
+		out.ItemUUID = it.uuid
+		out.Barcode = it.barcode
+		if b, ok := s.books[it.bookID]; ok {
+			out.BookUUID = b.uuid
+			out.Title = b.title
+		}
+	}
+	return out
+}
+
+// CreateLoan confere e altera o exemplar sob o mesmo lock, o que torna o
+// empréstimo atômico
+func (s *Store) CreateLoan(ctx context.Context, checkout domain.Checkout) (*domain.Loan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var item *itemRecord
+	for _, it := range s.items {
+		if it.barcode == checkout.Barcode {
+			item = it
+			break
+		}
+	}
+	if item == nil {
+		return nil, domain.ErrItemNotFound
+	}
+	switch item.status {
+	case domain.ItemAvailable:
+	case domain.ItemOnLoan:
+		return nil, domain.ErrItemOnLoan
+	default:
+		return nil, domain.ItemUnavailableError(item.status)
+	}
+	if s.activeLoan(item.id) != nil {
+		return nil, domain.ErrItemOnLoan
+	}
+
+	patron, ok := s.users[checkout.PatronEmail]
+	if !ok {
+		return nil, domain.ErrPatronNotFound
+	}
+	patronID, _ := strconv.Atoi(patron.ID)
+	if balance := s.patronBalance(patronID); balance > checkout.MaxBalance {
+		return nil, domain.BalanceBlockedError(balance, checkout.MaxBalance)
+	}
+	if h := s.reservedBy(item.id); h != nil && h.patronID != patronID {
+		return nil, domain.ErrItemReserved
+	}
+
+	// Prazo e limite da regra de circulação do leitor e do material
+	rule := domain.ResolvePolicy(s.policyRules(), patron.Category, item.material, checkout.Default).Rule
+	open := 0
+	for _, l := range s.loans {
+		if l.patronID == patronID && l.returnedAt == nil &&
+			(rule.MaterialType == "" || s.items[l.itemID].material == rule.MaterialType) {
+			open++
+		}
+	}
+	if open >= rule.MaxItems {
+		return nil, domain.LoanLimitError(rule)
+	}
+	dueDate, err := checkout.Calendar.DueDate(checkout.Dates.Today, rule.LoanDays)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	s.nextLoanID++
+	rec := &loanRecord{
+		id:           s.nextLoanID,
+		uuid:         newUUID(),
+		itemID:       item.id,
+		patronID:     patronID,
+		staffID:      checkout.StaffID,
+		checkedOutAt: now,
+		dueDate:      dueDate,
+	}
+	s.loans[rec.id] = rec
+	item.status = domain.ItemOnLoan
+	item.updatedAt = &now
+
+	// O empréstimo atende a reserva do leitor; se ela tinha outro exemplar
+	// separado, ele fica livre para a fila
+	fulfilled := false
+	for _, h := range s.holds {
+		if h.bookID == item.bookID && h.patronID == patronID && h.open() {
+			h.close(domain.HoldFulfilled, now)
+			h.itemID = item.id
+			fulfilled = true
+		}
+	}
+	if fulfilled {
+		s.fillHolds(item.bookID, checkout.Dates)
+	}
+
+	l := s.loanToDomain(rec)
+	return &l, nil
+}
+
+// ReturnLoan encerra o empréstimo e passa o exemplar para a fila do livro
+func (s *Store) ReturnLoan(ctx context.Context, uuid string, staffID int, dates domain.HoldDates) (*domain.Loan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.loanByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrLoanNotFound
+	}
+	if rec.returnedAt != nil {
+		return nil, domain.ErrLoanReturned
+	}
+
+	now := s.now()
+	rec.returnedAt = &now
+	rec.returnedBy = staffID
+	if it, ok := s.items[rec.itemID]; ok {
+		if it.status == domain.ItemOnLoan {
+			it.status = domain.ItemAvailable
+			it.updatedAt = &now
+		}
+		s.fillHolds(it.bookID, dates)
+	}
+
+	l := s.loanToDomain(rec)
+	return &l, nil
+}
+
+// RenewLoan confere e prorroga o empréstimo sob o mesmo lock
+func (s *Store) RenewLoan(ctx context.Context, renewal domain.Renewal) (*domain.Loan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.loanByUUID(renewal.LoanUUID)
+	if rec == nil {
+		return nil, domain.ErrLoanNotFound
+	}
+	if rec.returnedAt != nil {
+		return nil, domain.ErrLoanReturned
+	}
+
+	balance := s.patronBalance(rec.patronID)
+	if balance > renewal.MaxBalance {
+		return nil, domain.BalanceBlockedError(balance, renewal.MaxBalance)
+	}
+
+	// Outro leitor na fila do livro
+	item := s.items[rec.itemID]
+	for _, h := range s.holds {
+		if h.bookID == item.bookID && h.status == domain.HoldWaiting && h.patronID != rec.patronID {
+			return nil, domain.ErrLoanHeld
+		}
+	}
+
+	var category string
+	if u, ok := s.users[s.userEmail(rec.patronID)]; ok {
+		category = u.Category
+	}
+	rule := domain.ResolvePolicy(s.policyRules(), category, item.material, renewal.Default).Rule
+	if rec.renewals >= rule.MaxRenewals {
+		return nil, domain.RenewalLimitError(rule)
+	}
+	newDue, err := renewal.Calendar.DueDate(max(renewal.Today, rec.dueDate), rule.LoanDays)
+	if err != nil {
+		return nil, err
+	}
+
+	// A multa do prazo vencido é lançada agora e fica em finesSettled,
+	// fora da multa do novo prazo
+	var charged int64
+	for _, e := range s.entries {
+		if e.loanID == rec.id && e.kind == domain.EntryOverdue {
+			charged += e.amount
+		}
+	}
+	fine, err := domain.OverdueFine(rule, rec.dueDate, renewal.Today, rec.finesSettled)
+	if err != nil {
+		return nil, err
+	}
+	if owed := fine - (charged - rec.finesSettled); owed > 0 {
+		s.postEntry(rec.patronID, domain.EntryOverdue, owed, rec.id, "overdue fine through "+renewal.Today, 0)
+		charged += owed
+	}
+
+	s.nextRenewalID++
+	s.renewals[s.nextRenewalID] = &renewalRecord{
+		id:              s.nextRenewalID,
+		loanID:          rec.id,
+		staffID:         renewal.StaffID,
+		renewedAt:       s.now(),
+		previousDueDate: rec.dueDate,
+		dueDate:         newDue,
+	}
+	rec.dueDate = newDue
+	rec.renewals++
+	rec.finesSettled = charged
+
+	l := s.loanToDomain(rec)
+	return &l, nil
+}
+
+func (s *Store) GetLoanRenewals(ctx context.Context, uuid string) ([]domain.LoanRenewal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec := s.loanByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrLoanNotFound
+	}
+	var matched []*renewalRecord
+	for _, r := range s.renewals {
+		if r.loanID == rec.id {
+			matched = append(matched, r)
+		}
+	}
+	slices.SortFunc(matched, func(a, b *renewalRecord) int { return a.id - b.id })
+
+	renewals := make([]domain.LoanRenewal, 0, len(matched))
+	for _, r := range matched {
+		renewals = append(renewals, domain.LoanRenewal{
+			ID:              r.id,
+			RenewedBy:       s.userEmail(r.staffID),
+			RenewedAt:       r.renewedAt,
+			PreviousDueDate: r.previousDueDate,
+			DueDate:         r.dueDate,
+		})
+	}
+	return renewals, nil
+}
+
+func (s *Store) GetLoanByUUID(ctx context.Context, uuid string) (*domain.Loan, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec := s.loanByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrLoanNotFound
+	}
+	l := s.loanToDomain(rec)
+	return &l, nil
+}
+
+// GetLoans lista os empréstimos do mais recente para o mais antigo
+// (desempate por id), como a consulta Postgres
+func (s *Store) GetLoans(ctx context.Context, filters domain.LoanFilters) ([]domain.Loan, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ref := &loanRecord{}
+	if filters.Keyset != nil {
+		t, err := filters.Keyset.TimeValue()
+		if err != nil {
+			return nil, 0, err
+		}
+		ref.checkedOutAt, ref.id = t, filters.Keyset.ID
+	}
+
+	var matched []*loanRecord
+	for _, l := range s.loans {
+		if filters.PatronID != 0 && l.patronID != filters.PatronID {
+			continue
+		}
+		if filters.PatronEmail != "" && s.userEmail(l.patronID) != filters.PatronEmail {
+			continue
+		}
+		if filters.Barcode != "" {
+			if it, ok := s.items[l.itemID]; !ok || it.barcode != filters.Barcode {
+				continue
+			}
+		}
+		switch filters.Status {
+		case domain.LoanActive:
+			if l.returnedAt != nil {
+				continue
+			}
+		case domain.LoanReturned:
+			if l.returnedAt == nil {
+				continue
+			}
+		case domain.LoanOverdue:
+			if l.returnedAt != nil || l.dueDate >= filters.Today {
+				continue
+			}
+		}
+		matched = append(matched, l)
+	}
+	display := func(a, b *loanRecord) int {
+		if c := b.checkedOutAt.Compare(a.checkedOutAt); c != 0 {
+			return c
+		}
+		return b.id - a.id
+	}
+	slices.SortFunc(matched, display)
+
+	page := window(matched, filters.Limit, filters.Offset, filters.Keyset, func(l *loanRecord) int { return display(l, ref) })
+
+	loans := make([]domain.Loan, 0, len(page))
+	for _, l := range page {
+		loans = append(loans, s.loanToDomain(l))
+	}
+	return loans, totalOf(filters.Keyset, len(matched)), nil
+}
+
+// loanByUUID deve ser chamado com o lock adquirido
+func (s *Store) loanByUUID(uuid string) *loanRecord {
+	for _, l := range s.loans {
+		if strings.EqualFold(l.uuid, uuid) {
+			return l
+		}
+	}
+	return nil
+}
+
+// activeLoan devolve o empréstimo em aberto do exemplar, se houver. Deve
+// ser chamado com o lock adquirido.
+func (s *Store) activeLoan(itemID int) *loanRecord {
+	for _, l := range s.loans {
+		if l.itemID == itemID && l.returnedAt == nil {
+			return l
+		}
+	}
+	return nil
+}
+
+// userEmail devolve o email do usuário pelo id interno. Deve ser chamado
+// com o lock adquirido.
+func (s *Store) userEmail(id int) string {
+	key := strconv.Itoa(id)
+	for _, u := range s.users {
+		if u.ID == key {
+			return u.Email
+		}
+	}
+	return ""
+}
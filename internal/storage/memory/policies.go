@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+func (s *Store) CreatePolicyRule(ctx context.Context, rule domain.PolicyRule) (*domain.PolicyRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ruleFor(rule.PatronCategory, rule.MaterialType, 0) != nil {
+		return nil, domain.ErrPolicyRuleExists
+	}
+
+	s.nextRuleID++
+	now := s.now()
+	rule.ID = s.nextRuleID
+	rule.UUID = newUUID()
+	rule.CreatedAt = &now
+	rule.UpdatedAt = nil
+	s.rules[rule.ID] = &rule
+	return copyRule(&rule), nil
+}
+
+func (s *Store) GetPolicyRules(ctx context.Context) ([]domain.PolicyRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.policyRules(), nil
+}
+
+func (s *Store) GetPolicyRuleByUUID(ctx context.Context, uuid string) (*domain.PolicyRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec := s.ruleByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrPolicyRuleNotFound
+	}
+	return copyRule(rec), nil
+}
+
+func (s *Store) UpdatePolicyRule(ctx context.Context, uuid string, rule domain.PolicyRule) (*domain.PolicyRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.ruleByUUID(uuid)
+	if rec == nil {
+		return nil, domain.ErrPolicyRuleNotFound
+	}
+	if s.ruleFor(rule.PatronCategory, rule.MaterialType, rec.ID) != nil {
+		return nil, domain.ErrPolicyRuleExists
+	}
+
+	now := s.now()
+	rec.PatronCategory = rule.PatronCategory
+	rec.MaterialType = rule.MaterialType
+	rec.LoanDays = rule.LoanDays
+	rec.MaxRenewals = rule.MaxRenewals
+	rec.MaxItems = rule.MaxItems
+	rec.FineDailyCents = rule.FineDailyCents
+	rec.FineGraceDays = rule.FineGraceDays
+	rec.FineCapCents = rule.FineCapCents
+	rec.UpdatedAt = &now
+	return copyRule(rec), nil
+}
+
+func (s *Store) DeletePolicyRule(ctx context.Context, uuid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.ruleByUUID(uuid)
+	if rec == nil {
+		return domain.ErrPolicyRuleNotFound
+	}
+	delete(s.rules, rec.ID)
+	return nil
+}
+
+// policyRules copia as regras na ordem de resolução. Deve ser chamado com
+// o lock adquirido.
+func (s *Store) policyRules() []domain.PolicyRule {
+	rules := make([]domain.PolicyRule, 0, len(s.rules))
+	for _, r := range s.rules {
+		rules = append(rules, *copyRule(r))
+	}
+	domain.SortPolicyRules(rules)
+	return rules
+}
+
+// ruleFor devolve a regra dos critérios informados, exceto a de id skip.
+// Deve ser chamado com o lock adquirido.
+func (s *Store) ruleFor(category, material string, skip int) *domain.PolicyRule {
+	for _, r := range s.rules {
+		if r.ID != skip && r.PatronCategory == category && r.MaterialType == material {
+			return r
+		}
+	}
+	return nil
+}
+
+// ruleByUUID deve ser chamado com o lock adquirido
+func (s *Store) ruleByUUID(uuid string) *domain.PolicyRule {
+	for _, r := range s.rules {
+		if strings.EqualFold(r.UUID, uuid) {
+			return r
+		}
+	}
+	return nil
+}
+
+func copyRule(r *domain.PolicyRule) *domain.PolicyRule {
+	out := *r
+	out.CreatedAt = copyTime(r.CreatedAt)
+	out.UpdatedAt = copyTime(r.UpdatedAt)
+	return &out
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/search"
+)
+
+// SearchBooks avalia a consulta livro a livro com o pacote search; sem
+// stemming, ao contrário do Postgres
+func (s *Store) SearchBooks(ctx context.Context, q domain.SearchQuery) ([]domain.SearchHit, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var hits []domain.SearchHit
+	for _, rec := range s.books {
+		b := rec.toDomain()
+		b.Authors = s.authorsOf(rec, "")
+		doc := search.Document{Title: b.Title, Description: b.Description}
+		for _, a := range b.Authors {
+			doc.Authors = append(doc.Authors, a.Name)
+		}
+
+		rank, ok := search.Match(q.Terms, doc)
+		if !ok {
+			continue
+		}
+		hits = append(hits, domain.SearchHit{Book: b, Rank: rank, Headline: search.Headline(q.Terms, doc)})
+	}
+
+	slices.SortFunc(hits, func(a, b domain.SearchHit) int {
+		if a.Rank != b.Rank {
+			if a.Rank > b.Rank {
+				return -1
+			}
+			return 1
+		}
+		return a.Book.ID - b.Book.ID
+	})
+	page := window(hits, q.Limit, q.Offset, nil, nil)
+	if page == nil {
+		page = []domain.SearchHit{}
+	}
+	return page, len(hits), nil
+}
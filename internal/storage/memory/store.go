@@ -0,0 +1,121 @@
+// Package memory implementa os stores em memória, sem banco de dados.
+// Útil para desenvolvimento local e para testes dos handlers; os dados são
+// perdidos quando o processo termina.
+package memory
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// Store guarda o acervo, os usuários e a circulação em mapas e implementa
+// todas as interfaces de storage.Stores. É seguro para uso concorrente.
+type Store struct {
+	mu sync.RWMutex
+
+	books     map[int]*bookRecord
+	deletions map[int]*deletionRecord // livros excluídos, pelo id que tinham
+	authors   map[int]*authorRecord
+	users     map[string]*domain.User // indexado por email
+	items     map[int]*itemRecord
+	loans     map[int]*loanRecord
+	holds     map[int]*holdRecord
+	rules     map[int]*domain.PolicyRule
+	entries   map[int]*entryRecord
+	renewals  map[int]*renewalRecord
+
+	importJobs map[int]*domain.ImportJob
+
+	nextBookID      int
+	nextAuthorID    int
+	nextUserID      int
+	nextImportJobID int
+	nextItemID      int
+	nextLoanID      int
+	nextHoldID      int
+	nextRuleID      int
+	nextEntryID     int
+	nextRenewalID   int
+
+	now func() time.Time
+}
+
+func New() *Store {
+	return &Store{
+		books:     make(map[int]*bookRecord),
+		deletions: make(map[int]*deletionRecord),
+		authors:   make(map[int]*authorRecord),
+		users:     make(map[string]*domain.User),
+		items:     make(map[int]*itemRecord),
+		loans:     make(map[int]*loanRecord),
+		holds:     make(map[int]*holdRecord),
+		rules:     make(map[int]*domain.PolicyRule),
+		entries:   make(map[int]*entryRecord),
+		renewals:  make(map[int]*renewalRecord),
+
+		importJobs: make(map[int]*domain.ImportJob),
+
+		now: time.Now,
+	}
+}
+
+// Stores retorna o Store em cada uma das interfaces usadas pelo router
+func (s *Store) Stores() storage.Stores {
+	return storage.Stores{Books: s, Authors: s, Users: s, Search: s, Imports: s, Harvest: s, Items: s, Loans: s, Holds: s, Policies: s, Ledger: s}
+}
+
+// newUUID gera um UUID v4 aleatório
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// window recorta recs, já na ordem de exibição, conforme a paginação pedida:
+// Limit/Offset ou, com keyset, os itens depois (ou antes, se Backward) do de
+// referência. cmpRef compara um item com o de referência na ordem de exibição.
+func window[T any](recs []T, limit, offset int, ks *domain.Keyset, cmpRef func(T) int) []T {
+	if ks == nil {
+		if offset >= len(recs) {
+			return nil
+		}
+		recs = recs[offset:]
+		if limit > 0 && limit < len(recs) {
+			recs = recs[:limit]
+		}
+		return recs
+	}
+
+	if !ks.Backward {
+		i := sort.Search(len(recs), func(i int) bool { return cmpRef(recs[i]) > 0 })
+		recs = recs[i:]
+		if limit > 0 && limit < len(recs) {
+			recs = recs[:limit]
+		}
+		return recs
+	}
+	i := sort.Search(len(recs), func(i int) bool { return cmpRef(recs[i]) >= 0 })
+	recs = recs[:i]
+	if limit > 0 && limit < len(recs) {
+		recs = recs[len(recs)-limit:]
+	}
+	return recs
+}
+
+// totalOf devolve o total filtrado no modo offset e -1 no modo cursor
+func totalOf(ks *domain.Keyset, n int) int {
+	if ks != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+func (s *Store) CreateUser(ctx context.Context, user domain.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[user.Email]; exists {
+		return domain.ErrUserExists
+	}
+
+	s.nextUserID++
+	s.users[user.Email] = &domain.User{
+		ID:           strconv.Itoa(s.nextUserID),
+		UUID:         newUUID(),
+		Email:        user.Email,
+		PasswordHash: user.PasswordHash,
+		Category:     domain.PatronPublic,
+		Role:         domain.RolePatron,
+		CreatedAt:    s.now(),
+	}
+	return nil
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec, ok := s.users[email]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	u := *rec
+	u.UpdatedAt = copyTime(rec.UpdatedAt)
+	return &u, nil
+}
+
+func (s *Store) SetUserCategory(ctx context.Context, email, category string) (*domain.User, error) {
+	s.mu.Lock()
+	rec, ok := s.users[email]
+	if ok {
+		now := s.now()
+		rec.Category = category
+		rec.UpdatedAt = &now
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return s.GetUserByEmail(ctx, email)
+}
+
+func (s *Store) SetUserRole(ctx context.Context, email, role string) (*domain.User, error) {
+	s.mu.Lock()
+	rec, ok := s.users[email]
+	if ok {
+		now := s.now()
+		rec.Role = role
+		rec.UpdatedAt = &now
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return s.GetUserByEmail(ctx, email)
+}
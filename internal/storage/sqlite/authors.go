@@ -0,0 +1,229 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+func (s *Store) CreateAuthor(ctx context.Context, author *domain.Author) error {
+	author.UUID = newUUID()
+	author.CreatedAt = s.now()
+
+	res, err := s.db.ExecContext(ctx, `
+        INSERT INTO authors (uuid, name, bio, created_at)
+        VALUES (?1, ?2, NULLIF(?3, ''), ?4)`,
+		author.UUID, author.Name, author.Bio, author.CreatedAt)
+	if err != nil {
+		return translateError("failed to create author", err, nil)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	author.ID = int(id)
+	return nil
+}
+
+func (s *Store) GetAuthors(ctx context.Context, filters domain.AuthorFilters) ([]domain.Author, int, error) {
+	var value any
+	if filters.Keyset != nil {
+		value = filters.Keyset.Value
+	}
+	cond, order, keyArgs, backward := keyset("name", "id", false, filters.Keyset, value, 3)
+
+	args := append([]any{filters.Limit, offsetOf(filters.Keyset, filters.Offset)}, keyArgs...)
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT id, uuid, name, COALESCE(bio, ''), created_at, updated_at
+        FROM authors
+        WHERE `+cond+`
+        ORDER BY `+order+`
+        LIMIT ?1 OFFSET ?2`,
+		args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	authors := []domain.Author{}
+	for rows.Next() {
+		var a domain.Author
+		if err := rows.Scan(&a.ID, &a.UUID, &a.Name, &a.Bio, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, 0, fmt.Errorf("row scan error: %w", err)
+		}
+		authors = append(authors, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	if backward {
+		slices.Reverse(authors)
+	}
+
+	if filters.Keyset != nil {
+		return authors, -1, nil
+	}
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM authors`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count failed: %w", err)
+	}
+	return authors, total, nil
+}
+
+func (s *Store) GetAuthorsWithBooks(ctx context.Context, filters domain.AuthorFilters) ([]domain.Author, int, error) {
+	authors, total, err := s.GetAuthors(ctx, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(authors) == 0 {
+		return authors, total, nil
+	}
+
+	authorMap := make(map[int]*domain.Author, len(authors))
+	for i := range authors {
+		authors[i].Books = []*domain.Book{}
+		authorMap[authors[i].ID] = &authors[i]
+	}
+
+	// Só os livros dos autores da página
+	ids, _ := json.Marshal(slices.Collect(maps.Keys(authorMap)))
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT b.id, b.uuid, b.title, b.description, b.created_at, ba.author_id
+        FROM books b
+        JOIN books_authors ba ON b.id = ba.book_id
+        WHERE ba.author_id IN (SELECT value FROM json_each(?1))
+        ORDER BY ba.author_id, b.title`, string(ids))
+	if err != nil {
+		return nil, 0, fmt.Errorf("database books query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b domain.Book
+		var authorID int
+		if err := rows.Scan(&b.ID, &b.UUID, &b.Title, &b.Description, &b.CreatedAt, &authorID); err != nil {
+			return nil, 0, fmt.Errorf("book row scan error: %w", err)
+		}
+		if author, ok := authorMap[authorID]; ok {
+			author.Books = append(author.Books, &b)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("book rows error: %w", err)
+	}
+	return authors, total, nil
+}
+
+func (s *Store) GetAuthorByID(ctx context.Context, id int) (*domain.Author, error) {
+	return s.getAuthor(ctx, "id = ?1", id)
+}
+
+func (s *Store) GetAuthorByUUID(ctx context.Context, uuid string) (*domain.Author, error) {
+	return s.getAuthor(ctx, "uuid = lower(?1)", uuid)
+}
+
+func (s *Store) getAuthor(ctx context.Context, where string, value any) (*domain.Author, error) {
+	author := &domain.Author{}
+	err := s.db.QueryRowContext(ctx, `
+        SELECT id, uuid, name, COALESCE(bio, ''), created_at, updated_at
+        FROM authors
+        WHERE `+where, value).
+		Scan(&author.ID, &author.UUID, &author.Name, &author.Bio, &author.CreatedAt, &author.UpdatedAt)
+	if err != nil {
+		return nil, translateError("error fetching author", err, domain.ErrAuthorNotFound)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT b.id, b.uuid, b.title, b.description, b.created_at
+        FROM books b
+        JOIN books_authors ba ON b.id = ba.book_id
+        WHERE ba.author_id = ?1
+        ORDER BY b.title`, author.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching author's books: %w", err)
+	}
+	defer rows.Close()
+
+	author.Books = []*domain.Book{}
+	for rows.Next() {
+		var b domain.Book
+		if err := rows.Scan(&b.ID, &b.UUID, &b.Title, &b.Description, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning book: %w", err)
+		}
+		author.Books = append(author.Books, &b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("book rows error: %w", err)
+	}
+	return author, nil
+}
+
+// UpdateAuthor também renova updated_at dos livros do autor quando o nome
+// muda, para que voltem a ser colhidos via OAI-PMH
+func (s *Store) UpdateAuthor(ctx context.Context, uuid string, patch domain.AuthorPatchRequest) (*domain.Author, error) {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		now := s.now()
+		_, err := tx.ExecContext(ctx, `
+            UPDATE books SET updated_at = ?1
+            WHERE id IN (
+                SELECT ba.book_id FROM books_authors ba
+                JOIN authors a ON a.id = ba.author_id
+                WHERE a.uuid = lower(?2) AND a.name <> COALESCE(?3, a.name))`,
+			now, uuid, patch.Name)
+		if err != nil {
+			return fmt.Errorf("failed to touch author's books: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+            UPDATE authors
+            SET name = COALESCE(?1, name),
+                bio = CASE WHEN ?2 IS NULL THEN bio ELSE NULLIF(?2, '') END,
+                updated_at = ?3
+            WHERE uuid = lower(?4)`,
+			patch.Name, patch.Bio, now, uuid)
+		return translateError("failed to update author", err, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAuthorByUUID(ctx, uuid)
+}
+
+func (s *Store) DeleteAuthor(ctx context.Context, uuid string, unlink bool) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var authorID int
+		err := tx.QueryRowContext(ctx, `SELECT id FROM authors WHERE uuid = lower(?1)`, uuid).Scan(&authorID)
+		if err != nil {
+			return translateError("error fetching author", err, domain.ErrAuthorNotFound)
+		}
+
+		var links int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM books_authors WHERE author_id = ?1`, authorID).Scan(&links); err != nil {
+			return fmt.Errorf("error counting author's books: %w", err)
+		}
+		if links > 0 {
+			if !unlink {
+				return domain.ErrAuthorHasBooks
+			}
+			_, err := tx.ExecContext(ctx, `
+                UPDATE books SET updated_at = ?2
+                WHERE id IN (SELECT book_id FROM books_authors WHERE author_id = ?1)`, authorID, s.now())
+			if err != nil {
+				return fmt.Errorf("failed to touch author's books: %w", err)
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM books_authors WHERE author_id = ?1`, authorID); err != nil {
+				return fmt.Errorf("failed to unlink author: %w", err)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM authors WHERE id = ?1`, authorID); err != nil {
+			return fmt.Errorf("failed to delete author: %w", err)
+		}
+		return nil
+	})
+}
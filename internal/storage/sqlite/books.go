@@ -0,0 +1,387 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// bookSort traduz os filtros de ordenação para a expressão da coluna e o
+// valor do keyset; title usa NOCASE para ordenar como o Postgres, sem
+// diferenciar maiúsculas.
+func bookSort(filters domain.BookFilters) (col string, desc bool, value any, err error) {
+	desc = strings.EqualFold(filters.SortDirection, "DESC")
+	switch filters.Sort {
+	case "title", "":
+		col = "title COLLATE NOCASE"
+		if filters.Keyset != nil {
+			value = filters.Keyset.Value
+		}
+	case "created_at":
+		col = "created_at"
+		if filters.Keyset != nil {
+			value, err = filters.Keyset.TimeValue()
+		}
+	default:
+		err = domain.ValidationError("invalid sort field",
+			domain.FieldError{Field: "sort", Message: fmt.Sprintf("unknown sort field %q", filters.Sort)})
+	}
+	return col, desc, value, err
+}
+
+func (s *Store) CreateBook(ctx context.Context, req domain.BookCreateRequest) (*domain.Book, error) {
+	if len(req.AuthorIDs) == 0 {
+		return nil, domain.ValidationError("at least one author ID is required",
+			domain.FieldError{Field: "authorIds", Message: "must contain at least one author"})
+	}
+
+	uuid := newUUID()
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkAuthors(ctx, tx, req.AuthorIDs); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `
+            INSERT INTO books (uuid, title, description, isbn, publisher, publication_year,
+                               language, pages, edition, format, created_at)
+            VALUES (?1, ?2, ?3, NULLIF(?4, ''), NULLIF(?5, ''), ?6,
+                    NULLIF(?7, ''), ?8, NULLIF(?9, ''), NULLIF(?10, ''), ?11)`,
+			uuid, req.Title, req.Description, req.ISBN, req.Publisher, req.PublicationYear,
+			req.Language, req.Pages, req.Edition, req.Format, s.now())
+		if err != nil {
+			return translateError("failed to insert book", err, nil)
+		}
+		bookID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		if err := linkAuthors(ctx, tx, bookID, req.AuthorIDs); err != nil {
+			return err
+		}
+		return saveSubjects(ctx, tx, bookID, req.Subjects)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBookByUUID(ctx, uuid)
+}
+
+func (s *Store) GetBooksBasic(ctx context.Context, filters domain.BookFilters) ([]domain.Book, int, error) {
+	col, desc, value, err := bookSort(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	cond, order, keyArgs, backward := keyset(col, "id", desc, filters.Keyset, value, 4)
+	catalogCond, catalogArgs := catalogFilter("books", filters, 4+len(keyArgs))
+
+	args := append([]any{filters.Title, filters.Limit, offsetOf(filters.Keyset, filters.Offset)}, keyArgs...)
+	args = append(args, catalogArgs...)
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+bookColumns("books")+`
+        FROM books
+        WHERE (?1 = '' OR ilike(title, ?1))
+        AND `+catalogCond+`
+        AND `+cond+`
+        ORDER BY `+order+`
+        LIMIT ?2 OFFSET ?3`,
+		args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var books []domain.Book
+	for rows.Next() {
+		var b domain.Book
+		if err := rows.Scan(bookFields(&b)...); err != nil {
+			return nil, 0, fmt.Errorf("scan failed: %w", err)
+		}
+		books = append(books, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	if backward {
+		slices.Reverse(books)
+	}
+
+	if filters.Keyset != nil {
+		return books, -1, nil
+	}
+
+	var total int
+	catalogCond, catalogArgs = catalogFilter("books", filters, 2)
+	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM books WHERE (?1 = '' OR ilike(title, ?1)) AND `+catalogCond,
+		append([]any{filters.Title}, catalogArgs...)...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count failed: %w", err)
+	}
+
+	return books, total, nil
+}
+
+// GetBooksWithAuthors filtra por autor antes de paginar, como o repositório
+// Postgres, e mostra em cada livro apenas os autores que casam com o filtro
+func (s *Store) GetBooksWithAuthors(ctx context.Context, filters domain.BookFilters) ([]domain.Book, int, error) {
+	col, desc, value, err := bookSort(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	cond, pageOrder, keyArgs, _ := keyset(col, "id", desc, filters.Keyset, value, 5)
+	catalogCond, catalogArgs := catalogFilter("books", filters, 5+len(keyArgs))
+
+	args := append([]any{filters.Title, filters.Limit, offsetOf(filters.Keyset, filters.Offset), filters.AuthorName}, keyArgs...)
+	args = append(args, catalogArgs...)
+	rows, err := s.db.QueryContext(ctx, `
+        WITH paginated_books AS (
+            SELECT id FROM books
+            WHERE (?1 = '' OR ilike(title, ?1))
+            AND (?4 = '' OR EXISTS (
+                SELECT 1 FROM books_authors fba
+                JOIN authors fa ON fa.id = fba.author_id
+                WHERE fba.book_id = books.id AND ilike(fa.name, ?4)))
+            AND `+catalogCond+`
+            AND `+cond+`
+            ORDER BY `+pageOrder+`
+            LIMIT ?2 OFFSET ?3
+        )
+        SELECT
+            `+bookColumns("b")+`,
+            a.id, a.uuid, a.name, a.created_at
+        FROM paginated_books pb
+        JOIN books b ON pb.id = b.id
+        LEFT JOIN books_authors ba ON b.id = ba.book_id
+        LEFT JOIN authors a ON a.id = ba.author_id
+        WHERE (?4 = '' OR ilike(a.name, ?4))
+        ORDER BY `+orderBy("b."+col, "b.id", desc)+`, ba.position, a.name`,
+		args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	booksMap := make(map[int]*domain.Book)
+	var order []int
+	for rows.Next() {
+		var b domain.Book
+		var (
+			authorID        sql.NullInt64
+			authorUUID      sql.NullString
+			authorName      sql.NullString
+			authorCreatedAt sql.NullTime
+		)
+		err := rows.Scan(append(bookFields(&b),
+			&authorID, &authorUUID, &authorName, &authorCreatedAt,
+		)...)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan failed: %w", err)
+		}
+
+		if _, exists := booksMap[b.ID]; !exists {
+			b.Authors = []*domain.Author{}
+			booksMap[b.ID] = &b
+			order = append(order, b.ID)
+		}
+		if authorID.Valid {
+			booksMap[b.ID].Authors = append(booksMap[b.ID].Authors, &domain.Author{
+				ID:        int(authorID.Int64),
+				UUID:      authorUUID.String,
+				Name:      authorName.String,
+				CreatedAt: authorCreatedAt.Time,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+
+	books := make([]domain.Book, 0, len(booksMap))
+	for _, id := range order {
+		books = append(books, *booksMap[id])
+	}
+
+	if filters.Keyset != nil {
+		return books, -1, nil
+	}
+
+	var total int
+	catalogCond, catalogArgs = catalogFilter("b", filters, 3)
+	err = s.db.QueryRowContext(ctx, `
+        SELECT COUNT(*)
+        FROM books b
+        WHERE (?1 = '' OR ilike(b.title, ?1))
+        AND (?2 = '' OR EXISTS (
+            SELECT 1 FROM books_authors ba
+            JOIN authors a ON a.id = ba.author_id
+            WHERE ba.book_id = b.id AND ilike(a.name, ?2)))
+        AND `+catalogCond,
+		append([]any{filters.Title, filters.AuthorName}, catalogArgs...)...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count failed: %w", err)
+	}
+
+	return books, total, nil
+}
+
+func (s *Store) GetBookByUUID(ctx context.Context, uuid string) (*domain.Book, error) {
+	book := &domain.Book{}
+	err := s.db.QueryRowContext(ctx, `
+        SELECT `+bookColumns("books")+`
+        FROM books
+        WHERE uuid = lower(?1)`, uuid).
+		Scan(bookFields(book)...)
+	if err != nil {
+		return nil, translateError("failed to get book", err, domain.ErrBookNotFound)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT a.id, a.uuid, a.name, a.created_at
+        FROM authors a
+        JOIN books_authors ba ON a.id = ba.author_id
+        WHERE ba.book_id = ?1
+        ORDER BY ba.position, a.name`, book.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get book authors: %w", err)
+	}
+	defer rows.Close()
+
+	book.Authors = []*domain.Author{}
+	for rows.Next() {
+		var a domain.Author
+		if err := rows.Scan(&a.ID, &a.UUID, &a.Name, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		book.Authors = append(book.Authors, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return book, nil
+}
+
+func (s *Store) UpdateBook(ctx context.Context, uuid string, req domain.BookUpdateRequest) (*domain.Book, error) {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkAuthors(ctx, tx, req.AuthorIDs); err != nil {
+			return err
+		}
+
+		var bookID int64
+		err := tx.QueryRowContext(ctx, `
+            UPDATE books
+            SET title = ?1, description = ?2, isbn = NULLIF(?3, ''), publisher = NULLIF(?4, ''),
+                publication_year = ?5, language = NULLIF(?6, ''), pages = ?7,
+                edition = NULLIF(?8, ''), format = NULLIF(?9, ''), updated_at = ?10
+            WHERE uuid = lower(?11)
+            RETURNING id`,
+			req.Title, req.Description, req.ISBN, req.Publisher, req.PublicationYear,
+			req.Language, req.Pages, req.Edition, req.Format, s.now(), uuid).Scan(&bookID)
+		if err != nil {
+			return translateError("failed to update book", err, domain.ErrBookNotFound)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM books_authors WHERE book_id = ?1`, bookID); err != nil {
+			return fmt.Errorf("failed to update book authors: %w", err)
+		}
+		if err := linkAuthors(ctx, tx, bookID, req.AuthorIDs); err != nil {
+			return err
+		}
+		return saveSubjects(ctx, tx, bookID, req.Subjects)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBookByUUID(ctx, uuid)
+}
+
+// DeleteBook remove o livro e registra a exclusão em book_deletions, para a
+// colheita OAI-PMH
+func (s *Store) DeleteBook(ctx context.Context, uuid string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		// Os exemplares precisam ser removidos antes do livro
+		var hasItems bool
+		err := tx.QueryRowContext(ctx, `
+            SELECT EXISTS (
+                SELECT 1 FROM items i JOIN books b ON b.id = i.book_id
+                WHERE b.uuid = lower(?1))`, uuid).Scan(&hasItems)
+		if err != nil {
+			return fmt.Errorf("failed to check book items: %w", err)
+		}
+		if hasItems {
+			return domain.ErrBookHasItems
+		}
+
+		_, err = tx.ExecContext(ctx, `
+            INSERT INTO book_deletions (book_id, uuid, subjects, deleted_at)
+            SELECT id, uuid, `+subjectsColumn("books")+`, ?2 FROM books WHERE uuid = lower(?1)`,
+			uuid, s.now())
+		if err != nil {
+			return fmt.Errorf("failed to record book deletion: %w", err)
+		}
+
+		// books_authors é removida pelo ON DELETE CASCADE
+		res, err := tx.ExecContext(ctx, `DELETE FROM books WHERE uuid = lower(?1)`, uuid)
+		if err != nil {
+			return fmt.Errorf("failed to delete book: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return domain.ErrBookNotFound
+		}
+		return nil
+	})
+}
+
+// bookColumns lista as colunas de books lidas por bookFields, com o alias
+func bookColumns(alias string) string {
+	return strings.ReplaceAll(`t.id, t.uuid, t.title, t.description,
+            COALESCE(t.isbn, ''), COALESCE(t.publisher, ''), t.publication_year,
+            COALESCE(t.language, ''), t.pages, COALESCE(t.edition, ''), COALESCE(t.format, ''),
+            `+subjectsColumn("t")+`, t.created_at, t.updated_at`, "t.", alias+".")
+}
+
+// bookFields devolve os destinos do Scan na ordem de bookColumns
+func bookFields(b *domain.Book) []any {
+	return []any{&b.ID, &b.UUID, &b.Title, &b.Description,
+		&b.ISBN, &b.Publisher, &b.PublicationYear,
+		&b.Language, &b.Pages, &b.Edition, &b.Format,
+		(*subjectsJSON)(&b.Subjects), &b.CreatedAt, &b.UpdatedAt}
+}
+
+// checkAuthors confirma que todos os IDs existem
+func checkAuthors(ctx context.Context, tx *sql.Tx, ids []int) error {
+	var missing []int
+	for _, id := range ids {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM authors WHERE id = ?1)`, id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to verify author: %w", err)
+		}
+		if !exists {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return domain.UnknownAuthorsError(missing...)
+	}
+	return nil
+}
+
+// linkAuthors grava os autores na ordem de authorIDs
+func linkAuthors(ctx context.Context, tx *sql.Tx, bookID int64, authorIDs []int) error {
+	for i, authorID := range authorIDs {
+		_, err := tx.ExecContext(ctx, `
+            INSERT INTO books_authors (book_id, author_id, position)
+            VALUES (?1, ?2, ?3)
+            ON CONFLICT DO NOTHING`,
+			bookID, authorID, i)
+		if err != nil {
+			return fmt.Errorf("failed to create books-author relation: %w", err)
+		}
+	}
+	return nil
+}
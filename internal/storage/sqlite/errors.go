@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// uniqueColumns associa colunas únicas a erros de conflito específicos
+var uniqueColumns = map[string]*domain.Error{
+	"books.isbn":                     domain.ErrISBNExists,
+	"items.barcode":                  domain.ErrBarcodeExists,
+	"loans.item_id":                  domain.ErrItemOnLoan, // índice parcial dos empréstimos em aberto
+	"holds.book_id, holds.patron_id": domain.ErrHoldExists,
+
+	"policy_rules.patron_category, policy_rules.material_type": domain.ErrPolicyRuleExists,
+}
+
+// translateError converte erros do SQLite em erros de domínio, como o
+// translateError do repositório Postgres
+func translateError(op string, err error, notFound *domain.Error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) && notFound != nil {
+		return notFound
+	}
+
+	var se sqlite3.Error
+	if errors.As(err, &se) && se.Code == sqlite3.ErrConstraint {
+		switch se.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			// A mensagem do SQLite traz as colunas: "UNIQUE constraint failed: books.isbn"
+			for column, e := range uniqueColumns {
+				if strings.HasSuffix(se.Error(), "failed: "+column) {
+					return e
+				}
+			}
+			return domain.ConflictError("a record with the same unique value already exists").Wrap(err)
+		case sqlite3.ErrConstraintForeignKey:
+			return domain.ConflictError("the operation conflicts with related records").Wrap(err)
+		case sqlite3.ErrConstraintNotNull, sqlite3.ErrConstraintCheck:
+			return domain.ValidationError("value violates a constraint").Wrap(err)
+		}
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func isUniqueViolation(err error) bool {
+	var se sqlite3.Error
+	if errors.As(err, &se) {
+		return se.ExtendedCode == sqlite3.ErrConstraintUnique || se.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// subjectsColumn devolve os assuntos do livro (alias.id) como um array JSON
+// em ordem alfabética
+func subjectsColumn(alias string) string {
+	return `(SELECT json_group_array(subject) FROM (
+                SELECT bs.subject FROM book_subjects bs WHERE bs.book_id = ` + alias + `.id ORDER BY bs.subject))`
+}
+
+// subjectsJSON converte o array JSON de subjectsColumn; [] vira nil, que
+// some do JSON da resposta
+type subjectsJSON []string
+
+func (s *subjectsJSON) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	case nil:
+		*s = nil
+		return nil
+	default:
+		return fmt.Errorf("unexpected subjects type %T", src)
+	}
+	var subjects []string
+	if err := json.Unmarshal(raw, &subjects); err != nil {
+		return err
+	}
+	if len(subjects) == 0 {
+		subjects = nil
+	}
+	*s = subjects
+	return nil
+}
+
+// catalogFilter monta a condição dos filtros de faceta e bibliográficos
+// (tudo menos título e nome de autor) sobre a tabela books
+// (com o alias informado), usando placeholders a partir de ?next. As listas
+// são passadas como arrays JSON e abertas com json_each.
+func catalogFilter(alias string, filters domain.BookFilters, next int) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	scalar := func(cond string, value any) {
+		conds = append(conds, strings.ReplaceAll(cond, "??", fmt.Sprintf("?%d", next+len(args))))
+		args = append(args, value)
+	}
+	add := func(cond string, values any) {
+		raw, _ := json.Marshal(values)
+		scalar(cond, string(raw))
+	}
+
+	if len(filters.AuthorUUIDs) > 0 {
+		uuids := make([]string, len(filters.AuthorUUIDs))
+		for i, u := range filters.AuthorUUIDs {
+			uuids[i] = strings.ToLower(u)
+		}
+		add(`EXISTS (
+                SELECT 1 FROM books_authors xba
+                JOIN authors xa ON xa.id = xba.author_id
+                WHERE xba.book_id = `+alias+`.id AND xa.uuid IN (SELECT value FROM json_each(??)))`, uuids)
+	}
+	if len(filters.Subjects) > 0 {
+		add(`EXISTS (
+                SELECT 1 FROM book_subjects xbs
+                WHERE xbs.book_id = `+alias+`.id AND xbs.subject IN (SELECT value FROM json_each(??)))`, filters.Subjects)
+	}
+	if len(filters.Languages) > 0 {
+		add(alias+`.language IN (SELECT value FROM json_each(??))`, filters.Languages)
+	}
+	if len(filters.Decades) > 0 {
+		add(`(`+alias+`.publication_year / 10) * 10 IN (SELECT value FROM json_each(??))`, filters.Decades)
+	}
+	if filters.ISBN != "" {
+		scalar(alias+`.isbn = ??`, filters.ISBN)
+	}
+	if filters.Publisher != "" {
+		scalar(`ilike(`+alias+`.publisher, ??)`, filters.Publisher)
+	}
+	if len(filters.Formats) > 0 {
+		add(alias+`.format IN (SELECT value FROM json_each(??))`, filters.Formats)
+	}
+	if filters.YearFrom != nil {
+		scalar(alias+`.publication_year >= ??`, *filters.YearFrom)
+	}
+	if filters.YearTo != nil {
+		scalar(alias+`.publication_year <= ??`, *filters.YearTo)
+	}
+	if len(filters.Availability) > 0 {
+		add(`EXISTS (
+                SELECT 1 FROM items xi
+                WHERE xi.book_id = `+alias+`.id AND xi.status IN (SELECT value FROM json_each(??)))`, filters.Availability)
+	}
+
+	if len(conds) == 0 {
+		return "1", nil
+	}
+	return strings.Join(conds, "\n        AND "), args
+}
+
+// GetBookFacets conta, por faceta, os livros que passam pelos filtros
+// (título, autor e facetas), devolvendo até limit valores por faceta
+func (s *Store) GetBookFacets(ctx context.Context, filters domain.BookFilters, limit int) (*domain.BookFacets, error) {
+	catalogCond, catalogArgs := catalogFilter("b", filters, 4)
+
+	args := append([]any{filters.Title, filters.AuthorName, limit}, catalogArgs...)
+	rows, err := s.db.QueryContext(ctx, `
+        WITH filtered AS (
+            SELECT b.id, b.language, b.publication_year
+            FROM books b
+            WHERE (?1 = '' OR ilike(b.title, ?1))
+            AND (?2 = '' OR EXISTS (
+                SELECT 1 FROM books_authors ba
+                JOIN authors a ON a.id = ba.author_id
+                WHERE ba.book_id = b.id AND ilike(a.name, ?2)))
+            AND `+catalogCond+`
+        ), counts AS (
+            SELECT 'author' AS facet, a.uuid AS value, a.name AS label, COUNT(*) AS n
+            FROM filtered f
+            JOIN books_authors ba ON ba.book_id = f.id
+            JOIN authors a ON a.id = ba.author_id
+            GROUP BY a.uuid, a.name
+            UNION ALL
+            SELECT 'subject', bs.subject, '', COUNT(*)
+            FROM filtered f
+            JOIN book_subjects bs ON bs.book_id = f.id
+            GROUP BY bs.subject
+            UNION ALL
+            SELECT 'language', f.language, '', COUNT(*)
+            FROM filtered f
+            WHERE f.language IS NOT NULL
+            GROUP BY f.language
+            UNION ALL
+            SELECT 'decade', CAST((f.publication_year / 10) * 10 AS TEXT), '', COUNT(*)
+            FROM filtered f
+            WHERE f.publication_year IS NOT NULL
+            GROUP BY (f.publication_year / 10) * 10
+            UNION ALL
+            SELECT 'availability', i.status, '', COUNT(DISTINCT f.id)
+            FROM filtered f
+            JOIN items i ON i.book_id = f.id
+            GROUP BY i.status
+        )
+        SELECT facet, value, label, n
+        FROM (
+            SELECT *, ROW_NUMBER() OVER (PARTITION BY facet ORDER BY n DESC, value) AS rn
+            FROM counts
+        )
+        WHERE rn <= ?3`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("facet query failed: %w", err)
+	}
+	defer rows.Close()
+
+	facets := domain.NewBookFacets()
+	for rows.Next() {
+		var (
+			facet string
+			v     domain.FacetValue
+		)
+		if err := rows.Scan(&facet, &v.Value, &v.Label, &v.Count); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		facets.Add(facet, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	facets.Truncate(limit)
+	return facets, nil
+}
+
+// saveSubjects substitui os assuntos do livro
+func saveSubjects(ctx context.Context, tx *sql.Tx, bookID int64, subjects []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM book_subjects WHERE book_id = ?1`, bookID); err != nil {
+		return fmt.Errorf("failed to update book subjects: %w", err)
+	}
+	for _, subject := range subjects {
+		_, err := tx.ExecContext(ctx, `
+            INSERT INTO book_subjects (book_id, subject)
+            VALUES (?1, ?2)
+            ON CONFLICT DO NOTHING`,
+			bookID, subject)
+		if err != nil {
+			return translateError("failed to save book subject", err, nil)
+		}
+	}
+	return nil
+}
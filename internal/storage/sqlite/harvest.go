@@ -0,0 +1,279 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// bookDatestamp é a última alteração de um livro (alias b), como em
+// books_datestamp_idx
+const bookDatestamp = `COALESCE(b.updated_at, b.created_at)`
+
+// HarvestRecords junta livros e exclusões numa única ordem (datestamp, id),
+// numa transação para que os livros da página sejam lidos no mesmo estado
+func (s *Store) HarvestRecords(ctx context.Context, q domain.HarvestQuery) ([]domain.HarvestRecord, error) {
+	args := []any{q.Limit}
+	param := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("?%d", len(args))
+	}
+	bookConds := []string{"1"}
+	deletionConds := []string{"1"}
+	if q.From != nil {
+		p := param(q.From.UTC())
+		bookConds = append(bookConds, bookDatestamp+" >= "+p)
+		deletionConds = append(deletionConds, "d.deleted_at >= "+p)
+	}
+	if q.Until != nil {
+		p := param(q.Until.UTC())
+		bookConds = append(bookConds, bookDatestamp+" < "+p)
+		deletionConds = append(deletionConds, "d.deleted_at < "+p)
+	}
+	if len(q.Subjects) > 0 {
+		raw, _ := json.Marshal(q.Subjects)
+		p := param(string(raw))
+		bookConds = append(bookConds, `EXISTS (
+                SELECT 1 FROM book_subjects xbs
+                WHERE xbs.book_id = b.id AND xbs.subject IN (SELECT value FROM json_each(`+p+`)))`)
+		deletionConds = append(deletionConds, `EXISTS (
+                SELECT 1 FROM json_each(d.subjects) ds
+                WHERE ds.value IN (SELECT value FROM json_each(`+p+`)))`)
+	}
+	if q.After != nil {
+		after, err := q.After.TimeValue()
+		if err != nil {
+			return nil, err
+		}
+		pt, pid := param(after.UTC()), param(q.After.ID)
+		bookConds = append(bookConds, fmt.Sprintf("(%s, b.id) > (%s, %s)", bookDatestamp, pt, pid))
+		deletionConds = append(deletionConds, fmt.Sprintf("(d.deleted_at, d.book_id) > (%s, %s)", pt, pid))
+	}
+
+	var records []domain.HarvestRecord
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+            SELECT id, uuid, datestamp, deleted, subjects FROM (
+                SELECT * FROM (
+                    SELECT b.id, b.uuid, `+bookDatestamp+` AS datestamp, 0 AS deleted,
+                           `+subjectsColumn("b")+` AS subjects
+                    FROM books b
+                    WHERE `+strings.Join(bookConds, " AND ")+`
+                    ORDER BY `+bookDatestamp+`, b.id
+                    LIMIT ?1)
+                UNION ALL
+                SELECT * FROM (
+                    SELECT d.book_id, d.uuid, d.deleted_at, 1, d.subjects
+                    FROM book_deletions d
+                    WHERE `+strings.Join(deletionConds, " AND ")+`
+                    ORDER BY d.deleted_at, d.book_id
+                    LIMIT ?1)
+            )
+            ORDER BY datestamp, id
+            LIMIT ?1`, args...)
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		if records, err = scanHarvestRecords(rows); err != nil {
+			return err
+		}
+		if q.WithBooks {
+			return s.attachBooks(ctx, tx, records)
+		}
+		return nil
+	})
+	return records, err
+}
+
+func (s *Store) GetHarvestRecord(ctx context.Context, uuid string) (*domain.HarvestRecord, error) {
+	var rec *domain.HarvestRecord
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+            SELECT b.id, b.uuid, `+bookDatestamp+`, 0, `+subjectsColumn("b")+`
+            FROM books b WHERE b.uuid = lower(?1)
+            UNION ALL
+            SELECT d.book_id, d.uuid, d.deleted_at, 1, d.subjects
+            FROM book_deletions d WHERE d.uuid = lower(?1)`, uuid)
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		records, err := scanHarvestRecords(rows)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return domain.ErrBookNotFound
+		}
+		if err := s.attachBooks(ctx, tx, records[:1]); err != nil {
+			return err
+		}
+		rec = &records[0]
+		return nil
+	})
+	return rec, err
+}
+
+func (s *Store) EarliestDatestamp(ctx context.Context) (*time.Time, error) {
+	var earliest timestamp
+	err := s.db.QueryRowContext(ctx, `
+        SELECT MIN(datestamp) FROM (
+            SELECT MIN(`+bookDatestamp+`) AS datestamp FROM books b
+            UNION ALL
+            SELECT MIN(deleted_at) FROM book_deletions)`).Scan(&earliest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earliest datestamp: %w", err)
+	}
+	if earliest.IsZero() {
+		return nil, nil
+	}
+	t := time.Time(earliest)
+	return &t, nil
+}
+
+func (s *Store) HarvestSubjects(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT subject FROM book_subjects
+        UNION
+        SELECT ds.value FROM book_deletions d, json_each(d.subjects) ds
+        ORDER BY 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subjects: %w", err)
+	}
+	defer rows.Close()
+
+	var subjects []string
+	for rows.Next() {
+		var subject string
+		if err := rows.Scan(&subject); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		subjects = append(subjects, subject)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return subjects, nil
+}
+
+func scanHarvestRecords(rows *sql.Rows) ([]domain.HarvestRecord, error) {
+	defer rows.Close()
+
+	var records []domain.HarvestRecord
+	for rows.Next() {
+		var rec domain.HarvestRecord
+		if err := rows.Scan(&rec.ID, &rec.UUID, (*timestamp)(&rec.Datestamp), &rec.Deleted, (*subjectsJSON)(&rec.Subjects)); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return records, nil
+}
+
+// attachBooks preenche Book nos registros de livros não excluídos
+func (s *Store) attachBooks(ctx context.Context, tx *sql.Tx, records []domain.HarvestRecord) error {
+	var ids []int
+	for _, rec := range records {
+		if !rec.Deleted {
+			ids = append(ids, rec.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	rawIDs, _ := json.Marshal(ids)
+
+	rows, err := tx.QueryContext(ctx, `
+        SELECT `+bookColumns("b")+`
+        FROM books b WHERE b.id IN (SELECT value FROM json_each(?1))`, string(rawIDs))
+	if err != nil {
+		return fmt.Errorf("failed to get books: %w", err)
+	}
+	defer rows.Close()
+	byID := make(map[int]*domain.Book, len(ids))
+	for rows.Next() {
+		b := &domain.Book{Authors: []*domain.Author{}}
+		if err := rows.Scan(bookFields(b)...); err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		byID[b.ID] = b
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	authorRows, err := tx.QueryContext(ctx, `
+        SELECT ba.book_id, a.id, a.uuid, a.name, a.created_at
+        FROM books_authors ba
+        JOIN authors a ON a.id = ba.author_id
+        WHERE ba.book_id IN (SELECT value FROM json_each(?1))
+        ORDER BY ba.position, a.name`, string(rawIDs))
+	if err != nil {
+		return fmt.Errorf("failed to get book authors: %w", err)
+	}
+	defer authorRows.Close()
+	for authorRows.Next() {
+		var (
+			bookID int
+			a      domain.Author
+		)
+		if err := authorRows.Scan(&bookID, &a.ID, &a.UUID, &a.Name, &a.CreatedAt); err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		if b := byID[bookID]; b != nil {
+			b.Authors = append(b.Authors, &a)
+		}
+	}
+	if err := authorRows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	for i := range records {
+		if !records[i].Deleted {
+			records[i].Book = byID[records[i].ID]
+		}
+	}
+	return nil
+}
+
+// timestamp lê datas de expressões (COALESCE, MIN, UNION), que o driver
+// devolve como texto por não terem o tipo TIMESTAMP declarado. NULL vira o
+// instante zero.
+type timestamp time.Time
+
+func (t *timestamp) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case time.Time:
+		*t = timestamp(v)
+		return nil
+	case nil:
+		*t = timestamp{}
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("unexpected timestamp type %T", src)
+	}
+	for _, layout := range sqlite3.SQLiteTimestampFormats {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			*t = timestamp(parsed)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid timestamp %q", s)
+}
+
+func (t timestamp) IsZero() bool {
+	return time.Time(t).IsZero()
+}
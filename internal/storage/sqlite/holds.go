@@ -0,0 +1,387 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// holdColumns lista as colunas lidas por holdFields, sobre holdJoins. A
+// posição conta as reservas na fila do livro até a própria, inclusive.
+const holdColumns = `h.id, h.uuid, h.book_id, b.uuid, b.title, h.patron_id, p.email,
+            h.pickup_branch, h.status,
+            CASE WHEN h.status IN ('waiting', 'suspended') THEN (
+                SELECT COUNT(*) FROM holds q
+                WHERE q.book_id = h.book_id AND q.status IN ('waiting', 'suspended')
+                AND (q.created_at, q.id) <= (h.created_at, h.id)) END,
+            COALESCE(h.expires_on, ''), COALESCE(h.suspended_until, ''),
+            COALESCE(h.item_id, 0), COALESCE(i.uuid, ''), COALESCE(i.barcode, ''),
+            h.ready_at, COALESCE(h.pickup_by, ''), h.created_at, h.updated_at, h.closed_at`
+
+const holdJoins = `holds h
+        JOIN books b ON b.id = h.book_id
+        JOIN users p ON p.id = h.patron_id
+        LEFT JOIN items i ON i.id = h.item_id`
+
+// holdFields devolve os destinos do Scan na ordem de holdColumns
+func holdFields(h *domain.Hold) []any {
+	return []any{&h.ID, &h.UUID, &h.BookID, &h.BookUUID, &h.Title, &h.PatronID, &h.Patron,
+		&h.PickupBranch, &h.Status, &h.Position,
+		&h.ExpiresOn, &h.SuspendedUntil,
+		&h.ItemID, &h.ItemUUID, &h.Barcode,
+		&h.ReadyAt, &h.PickupBy, &h.CreatedAt, &h.UpdatedAt, &h.ClosedAt}
+}
+
+// eligibleHold é a condição das reservas que podem receber um exemplar;
+// ?2 é a data de hoje
+const eligibleHold = `(h.status = 'waiting' OR (h.status = 'suspended' AND h.suspended_until <= ?2))
+            AND (h.expires_on IS NULL OR h.expires_on >= ?2)`
+
+// freeItem é a condição dos exemplares disponíveis e não separados (alias i)
+const freeItem = `i.status = 'available'
+            AND NOT EXISTS (SELECT 1 FROM holds r WHERE r.item_id = i.id AND r.status = 'ready')`
+
+// fillHolds separa os exemplares livres do livro para as primeiras
+// reservas elegíveis da fila e devolve quantos separou
+func (s *Store) fillHolds(ctx context.Context, tx *sql.Tx, bookID int64, dates domain.HoldDates) (int, error) {
+	allocated := 0
+	for {
+		var (
+			holdID int64
+			branch string
+		)
+		err := tx.QueryRowContext(ctx, `
+            SELECT h.id, h.pickup_branch
+            FROM holds h
+            WHERE h.book_id = ?1 AND `+eligibleHold+`
+            ORDER BY h.created_at, h.id
+            LIMIT 1`, bookID, dates.Today).Scan(&holdID, &branch)
+		if errors.Is(err, sql.ErrNoRows) {
+			return allocated, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get next hold: %w", err)
+		}
+
+		// Um exemplar da unidade de retirada vem primeiro
+		var itemID int64
+		err = tx.QueryRowContext(ctx, `
+            SELECT i.id
+            FROM items i
+            WHERE i.book_id = ?1 AND `+freeItem+`
+            ORDER BY i.branch = ?2 DESC, i.id
+            LIMIT 1`, bookID, branch).Scan(&itemID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return allocated, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get free item: %w", err)
+		}
+
+		now := s.now()
+		_, err = tx.ExecContext(ctx, `
+            UPDATE holds
+            SET status = 'ready', item_id = ?2, ready_at = ?3, pickup_by = ?4,
+                suspended_until = NULL, updated_at = ?3
+            WHERE id = ?1`,
+			holdID, itemID, now, dates.PickupBy)
+		if err != nil {
+			return 0, translateError("failed to set hold ready", err, nil)
+		}
+		allocated++
+	}
+}
+
+func (s *Store) CreateHold(ctx context.Context, p domain.HoldPlacement) (*domain.Hold, error) {
+	uuid := newUUID()
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var bookID int64
+		err := tx.QueryRowContext(ctx, `SELECT id FROM books WHERE uuid = lower(?1)`, p.BookUUID).Scan(&bookID)
+		if err != nil {
+			return translateError("failed to get book", err, domain.ErrBookNotFound)
+		}
+
+		var patronID int64
+		err = tx.QueryRowContext(ctx, `
+            SELECT id FROM users
+            WHERE (?1 <> '' AND email = ?1) OR (?1 = '' AND id = ?2)`,
+			p.PatronEmail, p.PatronID).Scan(&patronID)
+		if err != nil {
+			return translateError("failed to get patron", err, domain.ErrPatronNotFound)
+		}
+
+		var copies int
+		var onLoan bool
+		err = tx.QueryRowContext(ctx, `
+            SELECT
+                (SELECT COUNT(*) FROM items WHERE book_id = ?1 AND status NOT IN ('lost', 'withdrawn')),
+                EXISTS (SELECT 1 FROM loans l JOIN items i ON i.id = l.item_id
+                        WHERE i.book_id = ?1 AND l.patron_id = ?2 AND l.returned_at IS NULL)`,
+			bookID, patronID).Scan(&copies, &onLoan)
+		if err != nil {
+			return fmt.Errorf("failed to check book copies: %w", err)
+		}
+		if copies == 0 {
+			return domain.ErrNoCopies
+		}
+		if onLoan {
+			return domain.ErrHoldOnLoan
+		}
+
+		_, err = tx.ExecContext(ctx, `
+            INSERT INTO holds (uuid, book_id, patron_id, pickup_branch, status, expires_on, created_at)
+            VALUES (?1, ?2, ?3, ?4, 'waiting', NULLIF(?5, ''), ?6)`,
+			uuid, bookID, patronID, p.PickupBranch, p.ExpiresOn, s.now())
+		if err != nil {
+			return translateError("failed to create hold", err, nil)
+		}
+		_, err = s.fillHolds(ctx, tx, bookID, p.Dates)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetHoldByUUID(ctx, uuid)
+}
+
+func (s *Store) GetHoldByUUID(ctx context.Context, uuid string) (*domain.Hold, error) {
+	h := &domain.Hold{}
+	err := s.db.QueryRowContext(ctx, `
+        SELECT `+holdColumns+`
+        FROM `+holdJoins+`
+        WHERE h.uuid = lower(?1)`, uuid).Scan(holdFields(h)...)
+	if err != nil {
+		return nil, translateError("failed to get hold", err, domain.ErrHoldNotFound)
+	}
+	return h, nil
+}
+
+// GetHolds lista as reservas na ordem da fila
+func (s *Store) GetHolds(ctx context.Context, filters domain.HoldFilters) ([]domain.Hold, int, error) {
+	if filters.BookUUID != "" {
+		var exists bool
+		err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM books WHERE uuid = lower(?1))`, filters.BookUUID).Scan(&exists)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get book: %w", err)
+		}
+		if !exists {
+			return nil, 0, domain.ErrBookNotFound
+		}
+	}
+
+	var value any
+	if filters.Keyset != nil {
+		t, err := filters.Keyset.TimeValue()
+		if err != nil {
+			return nil, 0, err
+		}
+		value = t
+	}
+	statuses := "[]"
+	if len(filters.Statuses) > 0 {
+		raw, _ := json.Marshal(filters.Statuses)
+		statuses = string(raw)
+	}
+	where := `(?1 = '' OR b.uuid = lower(?1))
+            AND (?2 = 0 OR h.patron_id = ?2)
+            AND (?3 = '' OR p.email = ?3)
+            AND (json_array_length(?4) = 0 OR h.status IN (SELECT value FROM json_each(?4)))`
+	filterArgs := []any{filters.BookUUID, filters.PatronID, filters.PatronEmail, statuses}
+	cond, order, keyArgs, backward := keyset("h.created_at", "h.id", false, filters.Keyset, value, 7)
+
+	args := append(append(filterArgs, filters.Limit, offsetOf(filters.Keyset, filters.Offset)), keyArgs...)
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+holdColumns+`
+        FROM `+holdJoins+`
+        WHERE `+where+`
+        AND `+cond+`
+        ORDER BY `+order+`
+        LIMIT ?5 OFFSET ?6`, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	holds := []domain.Hold{}
+	for rows.Next() {
+		var h domain.Hold
+		if err := rows.Scan(holdFields(&h)...); err != nil {
+			return nil, 0, fmt.Errorf("row scan error: %w", err)
+		}
+		holds = append(holds, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	if backward {
+		slices.Reverse(holds)
+	}
+
+	if filters.Keyset != nil {
+		return holds, -1, nil
+	}
+	var total int
+	err = s.db.QueryRowContext(ctx, `
+        SELECT COUNT(*)
+        FROM `+holdJoins+`
+        WHERE `+where, filterArgs...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count failed: %w", err)
+	}
+	return holds, total, nil
+}
+
+// holdState lê o id, o livro e a situação da reserva dentro da transação
+func holdState(ctx context.Context, tx *sql.Tx, uuid string) (id, bookID int64, status string, err error) {
+	err = tx.QueryRowContext(ctx, `SELECT id, book_id, status FROM holds WHERE uuid = lower(?1)`, uuid).
+		Scan(&id, &bookID, &status)
+	if err != nil {
+		return 0, 0, "", translateError("failed to get hold", err, domain.ErrHoldNotFound)
+	}
+	return id, bookID, status, nil
+}
+
+// CancelHold encerra a reserva; o exemplar de uma reserva pronta vai para
+// a próxima da fila
+func (s *Store) CancelHold(ctx context.Context, uuid string, dates domain.HoldDates) (*domain.Hold, error) {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		id, bookID, status, err := holdState(ctx, tx, uuid)
+		if err != nil {
+			return err
+		}
+		if status != domain.HoldWaiting && status != domain.HoldSuspended && status != domain.HoldReady {
+			return domain.HoldStatusError("cancel", status)
+		}
+
+		now := s.now()
+		_, err = tx.ExecContext(ctx, `
+            UPDATE holds SET status = 'cancelled', closed_at = ?2, updated_at = ?2
+            WHERE id = ?1`, id, now)
+		if err != nil {
+			return fmt.Errorf("failed to cancel hold: %w", err)
+		}
+		if status == domain.HoldReady {
+			_, err = s.fillHolds(ctx, tx, bookID, dates)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetHoldByUUID(ctx, uuid)
+}
+
+func (s *Store) SuspendHold(ctx context.Context, uuid, until string) (*domain.Hold, error) {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		id, _, status, err := holdState(ctx, tx, uuid)
+		if err != nil {
+			return err
+		}
+		if status != domain.HoldWaiting && status != domain.HoldSuspended {
+			return domain.HoldStatusError("suspend", status)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+            UPDATE holds SET status = 'suspended', suspended_until = NULLIF(?2, ''), updated_at = ?3
+            WHERE id = ?1`, id, until, s.now())
+		if err != nil {
+			return fmt.Errorf("failed to suspend hold: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetHoldByUUID(ctx, uuid)
+}
+
+func (s *Store) ResumeHold(ctx context.Context, uuid string, dates domain.HoldDates) (*domain.Hold, error) {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		id, bookID, status, err := holdState(ctx, tx, uuid)
+		if err != nil {
+			return err
+		}
+		if status != domain.HoldSuspended {
+			return domain.HoldStatusError("resume", status)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+            UPDATE holds SET status = 'waiting', suspended_until = NULL, updated_at = ?2
+            WHERE id = ?1`, id, s.now())
+		if err != nil {
+			return fmt.Errorf("failed to resume hold: %w", err)
+		}
+		_, err = s.fillHolds(ctx, tx, bookID, dates)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetHoldByUUID(ctx, uuid)
+}
+
+func (s *Store) SweepHolds(ctx context.Context, dates domain.HoldDates) (domain.HoldSweep, error) {
+	var sweep domain.HoldSweep
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		now := s.now()
+		res, err := tx.ExecContext(ctx, `
+            UPDATE holds SET status = 'expired', closed_at = ?2, updated_at = ?2
+            WHERE (status IN ('waiting', 'suspended') AND expires_on < ?1)
+            OR (status = 'ready' AND pickup_by < ?1)`, dates.Today, now)
+		if err != nil {
+			return fmt.Errorf("failed to expire holds: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		sweep.Expired = int(n)
+
+		res, err = tx.ExecContext(ctx, `
+            UPDATE holds SET status = 'waiting', suspended_until = NULL, updated_at = ?2
+            WHERE status = 'suspended' AND suspended_until <= ?1`, dates.Today, now)
+		if err != nil {
+			return fmt.Errorf("failed to resume holds: %w", err)
+		}
+		n, _ = res.RowsAffected()
+		sweep.Resumed = int(n)
+
+		// Livros com fila e exemplar livre
+		rows, err := tx.QueryContext(ctx, `
+            SELECT DISTINCT h.book_id
+            FROM holds h
+            WHERE h.status = 'waiting'
+            AND EXISTS (SELECT 1 FROM items i WHERE i.book_id = h.book_id AND `+freeItem+`)`)
+		if err != nil {
+			return fmt.Errorf("failed to list queues: %w", err)
+		}
+		var bookIDs []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("scan failed: %w", err)
+			}
+			bookIDs = append(bookIDs, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("rows error: %w", err)
+		}
+
+		for _, bookID := range bookIDs {
+			n, err := s.fillHolds(ctx, tx, bookID, dates)
+			if err != nil {
+				return err
+			}
+			sweep.Allocated += n
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.HoldSweep{}, err
+	}
+	return sweep, nil
+}
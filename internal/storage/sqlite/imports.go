@@ -0,0 +1,238 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// ImportBooks grava o lote numa transação, linha a linha (o SQLite não tem
+// COPY, e dentro de uma transação os INSERTs são baratos)
+func (s *Store) ImportBooks(ctx context.Context, rows []domain.ImportRow, dryRun bool) (domain.ImportBatchResult, error) {
+	var res domain.ImportBatchResult
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	authorIDs, err := authorsByNameKey(ctx, tx)
+	if err != nil {
+		return res, err
+	}
+
+	now := s.now()
+	for _, row := range rows {
+		ids := make([]int, 0, len(row.Authors))
+		for _, name := range row.Authors {
+			key := domain.AuthorNameKey(name)
+			id, ok := authorIDs[key]
+			if !ok {
+				r, err := tx.ExecContext(ctx, `
+                    INSERT INTO authors (uuid, name, created_at) VALUES (?1, ?2, ?3)`,
+					newUUID(), name, now)
+				if err != nil {
+					return res, translateError("failed to create author", err, nil)
+				}
+				id64, err := r.LastInsertId()
+				if err != nil {
+					return res, err
+				}
+				id = int(id64)
+				authorIDs[key] = id
+				res.AuthorsCreated = append(res.AuthorsCreated, key)
+			}
+			ids = append(ids, id)
+		}
+
+		var bookID int64
+		if row.ISBN != "" {
+			err := tx.QueryRowContext(ctx, `SELECT id FROM books WHERE isbn = ?1`, row.ISBN).Scan(&bookID)
+			if err != nil && err != sql.ErrNoRows {
+				return res, fmt.Errorf("failed to look up book by isbn: %w", err)
+			}
+		}
+
+		if bookID == 0 {
+			r, err := tx.ExecContext(ctx, `
+                INSERT INTO books (uuid, title, description, isbn, publisher, publication_year,
+                                   language, pages, edition, format, created_at)
+                VALUES (?1, ?2, ?3, NULLIF(?4, ''), NULLIF(?5, ''), ?6,
+                        NULLIF(?7, ''), ?8, NULLIF(?9, ''), NULLIF(?10, ''), ?11)`,
+				newUUID(), row.Title, row.Description, row.ISBN, row.Publisher, row.PublicationYear,
+				row.Language, row.Pages, row.Edition, row.Format, now)
+			if err != nil {
+				return res, translateError("failed to insert book", err, nil)
+			}
+			if bookID, err = r.LastInsertId(); err != nil {
+				return res, err
+			}
+			res.Created++
+		} else {
+			// Campos vazios na linha mantêm o valor gravado
+			_, err := tx.ExecContext(ctx, `
+                UPDATE books SET
+                    title = ?2,
+                    description = COALESCE(NULLIF(?3, ''), description),
+                    publisher = COALESCE(NULLIF(?4, ''), publisher),
+                    publication_year = COALESCE(?5, publication_year),
+                    language = COALESCE(NULLIF(?6, ''), language),
+                    pages = COALESCE(?7, pages),
+                    edition = COALESCE(NULLIF(?8, ''), edition),
+                    format = COALESCE(NULLIF(?9, ''), format),
+                    updated_at = ?10
+                WHERE id = ?1`,
+				bookID, row.Title, row.Description, row.Publisher, row.PublicationYear,
+				row.Language, row.Pages, row.Edition, row.Format, now)
+			if err != nil {
+				return res, translateError("failed to update book", err, nil)
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM books_authors WHERE book_id = ?1`, bookID); err != nil {
+				return res, fmt.Errorf("failed to update book authors: %w", err)
+			}
+			res.Updated++
+		}
+
+		if err := linkAuthors(ctx, tx, bookID, ids); err != nil {
+			return res, err
+		}
+		if row.Subjects != nil {
+			if err := saveSubjects(ctx, tx, bookID, row.Subjects); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	if dryRun {
+		return res, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return res, fmt.Errorf("failed to commit import batch: %w", err)
+	}
+	return res, nil
+}
+
+// authorsByNameKey indexa os autores por domain.AuthorNameKey; com nomes
+// repetidos vale o mais antigo (menor id), como no Postgres
+func authorsByNameKey(ctx context.Context, tx *sql.Tx) (map[string]int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, name FROM authors ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up authors: %w", err)
+	}
+	defer rows.Close()
+
+	ids := map[string]int{}
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan author: %w", err)
+		}
+		ids[domain.AuthorNameKey(name)] = id
+	}
+	return ids, rows.Err()
+}
+
+// importErrorsJSON converte as colunas errors e warnings (arrays JSON)
+type importErrorsJSON []domain.ImportRowError
+
+func (e *importErrorsJSON) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return fmt.Errorf("unexpected import errors type %T", src)
+	}
+	return json.Unmarshal(raw, (*[]domain.ImportRowError)(e))
+}
+
+// SaveImportJob insere o job na primeira chamada (preenchendo ID e UUID) e
+// o atualiza nas seguintes
+func (s *Store) SaveImportJob(ctx context.Context, job *domain.ImportJob) error {
+	if job.ID == 0 {
+		uuid := newUUID()
+		r, err := s.db.ExecContext(ctx, `
+            INSERT INTO import_jobs (uuid, status, format, source, dry_run, started_at)
+            VALUES (?1, ?2, ?3, ?4, ?5, ?6)`,
+			uuid, job.Status, job.Format, job.Source, job.DryRun, job.StartedAt)
+		if err != nil {
+			return translateError("failed to create import job", err, nil)
+		}
+		id, err := r.LastInsertId()
+		if err != nil {
+			return err
+		}
+		job.ID, job.UUID = int(id), uuid
+		return nil
+	}
+
+	errs, err := json.Marshal(job.Errors)
+	if err != nil {
+		return err
+	}
+	warnings, err := json.Marshal(job.Warnings)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+        UPDATE import_jobs SET
+            status = ?2, rows_read = ?3, created = ?4, updated = ?5, failed = ?6,
+            authors_created = ?7, errors = ?8, errors_truncated = ?9, message = ?10,
+            finished_at = ?11, partial = ?12, warnings = ?13, warnings_truncated = ?14
+        WHERE id = ?1`,
+		job.ID, job.Status, job.Rows, job.Created, job.Updated, job.Failed,
+		job.AuthorsCreated, string(errs), job.ErrorsTruncated, job.Message, job.FinishedAt,
+		job.Partial, string(warnings), job.WarningsTruncated)
+	return translateError("failed to update import job", err, nil)
+}
+
+const importJobColumns = `id, uuid, status, format, source, dry_run, rows_read, created, updated, failed,
+        authors_created, errors, errors_truncated, message, started_at, finished_at,
+        partial, warnings, warnings_truncated`
+
+func importJobFields(j *domain.ImportJob) []any {
+	return []any{&j.ID, &j.UUID, &j.Status, &j.Format, &j.Source, &j.DryRun, &j.Rows,
+		&j.Created, &j.Updated, &j.Failed, &j.AuthorsCreated, (*importErrorsJSON)(&j.Errors),
+		&j.ErrorsTruncated, &j.Message, &j.StartedAt, &j.FinishedAt,
+		&j.Partial, (*importErrorsJSON)(&j.Warnings), &j.WarningsTruncated}
+}
+
+func (s *Store) GetImportJob(ctx context.Context, uuid string) (*domain.ImportJob, error) {
+	var job domain.ImportJob
+	err := s.db.QueryRowContext(ctx, `SELECT `+importJobColumns+` FROM import_jobs WHERE uuid = ?1`, uuid).
+		Scan(importJobFields(&job)...)
+	if err != nil {
+		return nil, translateError("failed to get import job", err, domain.ErrImportJobNotFound)
+	}
+	return &job, nil
+}
+
+// ListImportJobs devolve os jobs mais recentes primeiro
+func (s *Store) ListImportJobs(ctx context.Context, limit int) ([]domain.ImportJob, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+importJobColumns+`
+        FROM import_jobs
+        ORDER BY started_at DESC, id DESC
+        LIMIT ?1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list import jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []domain.ImportJob{}
+	for rows.Next() {
+		var job domain.ImportJob
+		if err := rows.Scan(importJobFields(&job)...); err != nil {
+			return nil, fmt.Errorf("failed to scan import job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
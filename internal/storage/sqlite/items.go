@@ -0,0 +1,240 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// itemColumns lista as colunas lidas por itemFields; i é items e b, books
+const itemColumns = `i.id, i.uuid, i.book_id, b.uuid, i.barcode, COALESCE(i.call_number, ''),
+            i.branch, COALESCE(i.location, ''), i.status, i.material_type, COALESCE(i.acquired_on, ''),
+            i.price_cents, i.created_at, i.updated_at`
+
+// itemFields devolve os destinos do Scan na ordem de itemColumns
+func itemFields(it *domain.Item) []any {
+	return []any{&it.ID, &it.UUID, &it.BookID, &it.BookUUID, &it.Barcode, &it.CallNumber,
+		&it.Branch, &it.Location, &it.Status, &it.Material, &it.AcquiredOn,
+		&it.PriceCents, &it.CreatedAt, &it.UpdatedAt}
+}
+
+func (s *Store) CreateItem(ctx context.Context, bookUUID string, req domain.ItemRequest) (*domain.Item, error) {
+	uuid := newUUID()
+	res, err := s.db.ExecContext(ctx, `
+        INSERT INTO items (uuid, book_id, barcode, call_number, branch, location, status, acquired_on, price_cents, created_at, material_type)
+        SELECT ?1, id, ?3, NULLIF(?4, ''), ?5, NULLIF(?6, ''), ?7, NULLIF(?8, ''), ?9, ?10, ?11
+        FROM books WHERE uuid = lower(?2)`,
+		uuid, bookUUID, req.Barcode, req.CallNumber, req.Branch, req.Location, req.Status, req.AcquiredOn, req.PriceCents, s.now(), req.Material)
+	if err != nil {
+		return nil, translateError("failed to create item", err, nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, domain.ErrBookNotFound
+	}
+	return s.GetItemByUUID(ctx, uuid)
+}
+
+// GetItems lista os exemplares ordenados por código de barras
+func (s *Store) GetItems(ctx context.Context, filters domain.ItemFilters) ([]domain.Item, int, error) {
+	if filters.BookUUID != "" {
+		var exists bool
+		err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM books WHERE uuid = lower(?1))`, filters.BookUUID).Scan(&exists)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get book: %w", err)
+		}
+		if !exists {
+			return nil, 0, domain.ErrBookNotFound
+		}
+	}
+
+	var value any
+	if filters.Keyset != nil {
+		value = filters.Keyset.Value
+	}
+	statuses := "[]"
+	if len(filters.Statuses) > 0 {
+		raw, _ := json.Marshal(filters.Statuses)
+		statuses = string(raw)
+	}
+	where := `(?1 = '' OR b.uuid = lower(?1))
+            AND (?2 = '' OR i.barcode = ?2)
+            AND (?3 = '' OR i.branch = ?3)
+            AND (json_array_length(?4) = 0 OR i.status IN (SELECT value FROM json_each(?4)))`
+	filterArgs := []any{filters.BookUUID, filters.Barcode, filters.Branch, statuses}
+	cond, order, keyArgs, backward := keyset("i.barcode", "i.id", false, filters.Keyset, value, 7)
+
+	args := append(append(filterArgs, filters.Limit, offsetOf(filters.Keyset, filters.Offset)), keyArgs...)
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+itemColumns+`
+        FROM items i
+        JOIN books b ON b.id = i.book_id
+        WHERE `+where+`
+        AND `+cond+`
+        ORDER BY `+order+`
+        LIMIT ?5 OFFSET ?6`, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	items := []domain.Item{}
+	for rows.Next() {
+		var it domain.Item
+		if err := rows.Scan(itemFields(&it)...); err != nil {
+			return nil, 0, fmt.Errorf("row scan error: %w", err)
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+
+	if filters.Keyset != nil {
+		return items, -1, nil
+	}
+	var total int
+	err = s.db.QueryRowContext(ctx, `
+        SELECT COUNT(*)
+        FROM items i
+        JOIN books b ON b.id = i.book_id
+        WHERE `+where, filterArgs...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count failed: %w", err)
+	}
+	return items, total, nil
+}
+
+func (s *Store) GetItemByUUID(ctx context.Context, uuid string) (*domain.Item, error) {
+	it := &domain.Item{}
+	err := s.db.QueryRowContext(ctx, `
+        SELECT `+itemColumns+`
+        FROM items i
+        JOIN books b ON b.id = i.book_id
+        WHERE i.uuid = lower(?1)`, uuid).Scan(itemFields(it)...)
+	if err != nil {
+		return nil, translateError("failed to get item", err, domain.ErrItemNotFound)
+	}
+	return it, nil
+}
+
+// UpdateItem substitui os campos editáveis do exemplar; o livro não muda.
+// A situação on_loan acompanha o empréstimo em aberto.
+func (s *Store) UpdateItem(ctx context.Context, uuid string, req domain.ItemRequest) (*domain.Item, error) {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var onLoan bool
+		err := tx.QueryRowContext(ctx, `
+            SELECT EXISTS (SELECT 1 FROM loans l WHERE l.item_id = i.id AND l.returned_at IS NULL)
+            FROM items i
+            WHERE i.uuid = lower(?1)`, uuid).Scan(&onLoan)
+		if err != nil {
+			return translateError("failed to get item", err, domain.ErrItemNotFound)
+		}
+		if onLoan != (req.Status == domain.ItemOnLoan) {
+			return domain.ErrOnLoanStatus
+		}
+
+		// Um exemplar separado que deixa de estar disponível devolve a
+		// reserva à fila, no mesmo lugar
+		if req.Status != domain.ItemAvailable {
+			_, err = tx.ExecContext(ctx, `
+                UPDATE holds
+                SET status = 'waiting', item_id = NULL, ready_at = NULL, pickup_by = NULL, updated_at = ?2
+                WHERE item_id = (SELECT id FROM items WHERE uuid = lower(?1)) AND status = 'ready'`,
+				uuid, s.now())
+			if err != nil {
+				return fmt.Errorf("failed to release item hold: %w", err)
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+            UPDATE items
+            SET barcode = ?2, call_number = NULLIF(?3, ''), branch = ?4, location = NULLIF(?5, ''),
+                status = ?6, acquired_on = NULLIF(?7, ''), price_cents = ?8, updated_at = ?9, material_type = ?10
+            WHERE uuid = lower(?1)`,
+			uuid, req.Barcode, req.CallNumber, req.Branch, req.Location, req.Status, req.AcquiredOn, req.PriceCents, s.now(), req.Material)
+		if err != nil {
+			return translateError("failed to update item", err, nil)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetItemByUUID(ctx, uuid)
+}
+
+// DeleteItem recusa exemplares com empréstimos, que guardam o histórico, e
+// exemplares separados para uma reserva
+func (s *Store) DeleteItem(ctx context.Context, uuid string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var hasLoans, reserved bool
+		err := tx.QueryRowContext(ctx, `
+            SELECT EXISTS (SELECT 1 FROM loans l WHERE l.item_id = i.id),
+                   EXISTS (SELECT 1 FROM holds h WHERE h.item_id = i.id AND h.status = 'ready')
+            FROM items i
+            WHERE i.uuid = lower(?1)`, uuid).Scan(&hasLoans, &reserved)
+		if err != nil {
+			return translateError("failed to get item", err, domain.ErrItemNotFound)
+		}
+		if hasLoans {
+			return domain.ErrItemHasLoans
+		}
+		if reserved {
+			return domain.ErrItemReserved
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM items WHERE uuid = lower(?1)`, uuid); err != nil {
+			return translateError("failed to delete item", err, nil)
+		}
+		return nil
+	})
+}
+
+func (s *Store) GetAvailability(ctx context.Context, bookIDs []int) (map[int]domain.Availability, error) {
+	out := make(map[int]domain.Availability, len(bookIDs))
+	for _, id := range bookIDs {
+		out[id] = domain.Availability{}
+	}
+	if len(bookIDs) == 0 {
+		return out, nil
+	}
+
+	ids, _ := json.Marshal(bookIDs)
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT i.book_id,
+               CASE WHEN i.status = 'available' AND EXISTS (
+                   SELECT 1 FROM holds r WHERE r.item_id = i.id AND r.status = 'ready')
+               THEN 'on_hold' ELSE i.status END,
+               COUNT(*)
+        FROM items i
+        WHERE i.book_id IN (SELECT value FROM json_each(?1))
+        GROUP BY 1, 2`, string(ids))
+	if err != nil {
+		return nil, fmt.Errorf("availability query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			bookID, n int
+			status    string
+		)
+		if err := rows.Scan(&bookID, &status, &n); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		a := out[bookID]
+		a.Count(status, n)
+		out[bookID] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return out, nil
+}
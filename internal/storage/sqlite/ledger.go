@@ -0,0 +1,253 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// entryColumns lista as colunas lidas por entryFields, sobre entryJoins
+const entryColumns = `e.id, e.uuid, e.patron_id, p.email, e.type, e.amount_cents, e.balance_cents,
+            COALESCE(l.uuid, ''), e.note, COALESCE(c.email, ''), e.created_at`
+
+// entryJoins liga o lançamento (e) ao leitor (p), ao empréstimo cobrado
+// (l) e ao funcionário que o registrou (c)
+const entryJoins = `ledger_entries e
+        JOIN users p ON p.id = e.patron_id
+        LEFT JOIN loans l ON l.id = e.loan_id
+        LEFT JOIN users c ON c.id = e.created_by`
+
+// entryFields devolve os destinos do Scan na ordem de entryColumns
+func entryFields(e *domain.LedgerEntry) []any {
+	return []any{&e.ID, &e.UUID, &e.PatronID, &e.Patron, &e.Type, &e.AmountCents, &e.BalanceCents,
+		&e.LoanUUID, &e.Note, &e.CreatedBy, &e.CreatedAt}
+}
+
+// PostLedgerEntry grava o lançamento numa transação imediata, que
+// serializa as escritas e, com elas, o saldo corrente
+func (s *Store) PostLedgerEntry(ctx context.Context, p domain.LedgerPosting) (*domain.LedgerEntry, error) {
+	uuid := newUUID()
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var patronID int64
+		err := tx.QueryRowContext(ctx, `SELECT id FROM users WHERE email = ?1`, p.PatronEmail).Scan(&patronID)
+		if err != nil {
+			return translateError("failed to get patron", err, domain.ErrPatronNotFound)
+		}
+
+		var loanID sql.NullInt64
+		if p.LoanUUID != "" {
+			var loanPatron int64
+			err := tx.QueryRowContext(ctx, `SELECT id, patron_id FROM loans WHERE uuid = lower(?1)`, p.LoanUUID).
+				Scan(&loanID, &loanPatron)
+			if err != nil {
+				return translateError("failed to get loan", err, domain.ErrLoanNotFound)
+			}
+			if loanPatron != patronID {
+				return domain.ErrLoanOfOtherPatron
+			}
+		}
+
+		balance, err := patronBalance(ctx, tx, patronID)
+		if err != nil {
+			return err
+		}
+		if !domain.IsCharge(p.Type) && p.AmountCents > balance {
+			return domain.ExceedsBalanceError(balance)
+		}
+		return s.insertEntry(ctx, tx, uuid, patronID, p.Type, p.AmountCents, balance, loanID, p.Note, p.StaffID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e := &domain.LedgerEntry{}
+	err = s.db.QueryRowContext(ctx, `SELECT `+entryColumns+` FROM `+entryJoins+` WHERE e.uuid = ?1`, uuid).
+		Scan(entryFields(e)...)
+	if err != nil {
+		return nil, translateError("failed to get ledger entry", err, nil)
+	}
+	return e, nil
+}
+
+// insertEntry grava um lançamento sobre o saldo anterior balance; staffID
+// 0 é a rotina de multas
+func (s *Store) insertEntry(ctx context.Context, tx *sql.Tx, uuid string, patronID int64, entryType string,
+	amount, balance int64, loanID sql.NullInt64, note string, staffID int) error {
+	if domain.IsCharge(entryType) {
+		balance += amount
+	} else {
+		balance -= amount
+	}
+	createdBy := sql.NullInt64{Int64: int64(staffID), Valid: staffID != 0}
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO ledger_entries (uuid, patron_id, type, amount_cents, balance_cents, loan_id, note, created_by, created_at)
+        VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9)`,
+		uuid, patronID, entryType, amount, balance, loanID, note, createdBy, s.now())
+	if err != nil {
+		return translateError("failed to create ledger entry", err, nil)
+	}
+	return nil
+}
+
+// patronBalance lê o saldo devedor do leitor, gravado no último lançamento
+func patronBalance(ctx context.Context, q queryer, patronID int64) (int64, error) {
+	var balance int64
+	err := q.QueryRowContext(ctx, `
+        SELECT COALESCE((SELECT balance_cents FROM ledger_entries WHERE patron_id = ?1 ORDER BY id DESC LIMIT 1), 0)`,
+		patronID).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get patron balance: %w", err)
+	}
+	return balance, nil
+}
+
+// GetLedger lista os lançamentos do mais recente para o mais antigo
+func (s *Store) GetLedger(ctx context.Context, filters domain.LedgerFilters) ([]domain.LedgerEntry, int, int64, error) {
+	patronID := int64(filters.PatronID)
+	if filters.PatronEmail != "" {
+		err := s.db.QueryRowContext(ctx, `SELECT id FROM users WHERE email = ?1`, filters.PatronEmail).Scan(&patronID)
+		if err != nil {
+			return nil, 0, 0, translateError("failed to get patron", err, domain.ErrPatronNotFound)
+		}
+	}
+	balance, err := patronBalance(ctx, s.db, patronID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var value any
+	if filters.Keyset != nil {
+		t, err := filters.Keyset.TimeValue()
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		value = t
+	}
+	where := `e.patron_id = ?1 AND (?2 = '' OR e.type = ?2)`
+	filterArgs := []any{patronID, filters.Type}
+	cond, order, keyArgs, backward := keyset("e.created_at", "e.id", true, filters.Keyset, value, 5)
+
+	args := append(append(filterArgs, filters.Limit, offsetOf(filters.Keyset, filters.Offset)), keyArgs...)
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+entryColumns+`
+        FROM `+entryJoins+`
+        WHERE `+where+`
+        AND `+cond+`
+        ORDER BY `+order+`
+        LIMIT ?3 OFFSET ?4`, args...)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []domain.LedgerEntry{}
+	for rows.Next() {
+		var e domain.LedgerEntry
+		if err := rows.Scan(entryFields(&e)...); err != nil {
+			return nil, 0, 0, fmt.Errorf("row scan error: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, 0, fmt.Errorf("rows error: %w", err)
+	}
+	if backward {
+		slices.Reverse(entries)
+	}
+
+	if filters.Keyset != nil {
+		return entries, -1, balance, nil
+	}
+	var total int
+	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM ledger_entries e WHERE `+where, filterArgs...).Scan(&total)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("count failed: %w", err)
+	}
+	return entries, total, balance, nil
+}
+
+// AccrueFines faz a passada inteira numa transação imediata
+func (s *Store) AccrueFines(ctx context.Context, run domain.FineRun) (domain.FineSweep, error) {
+	var sweep domain.FineSweep
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		rules, err := policyRules(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		type overdue struct {
+			loanID, patronID   int64
+			category, material string
+			dueDate            string
+			returnedAt         *time.Time
+			charged            int64 // no prazo atual, sem o que foi acertado nas renovações
+			settled            int64 // acertado nas renovações
+		}
+		rows, err := tx.QueryContext(ctx, `
+            SELECT l.id, l.patron_id, p.category, i.material_type, l.due_date, l.returned_at,
+                   COALESCE((SELECT SUM(amount_cents) FROM ledger_entries
+                             WHERE loan_id = l.id AND type = 'overdue'), 0) - l.fines_settled_cents,
+                   l.fines_settled_cents
+            FROM loans l
+            JOIN items i ON i.id = l.item_id
+            JOIN users p ON p.id = l.patron_id
+            WHERE NOT l.fines_closed AND l.due_date < ?1
+            ORDER BY l.id`, run.Today)
+		if err != nil {
+			return fmt.Errorf("failed to list overdue loans: %w", err)
+		}
+		var loans []overdue
+		for rows.Next() {
+			var o overdue
+			if err := rows.Scan(&o.loanID, &o.patronID, &o.category, &o.material, &o.dueDate, &o.returnedAt, &o.charged, &o.settled); err != nil {
+				rows.Close()
+				return fmt.Errorf("scan failed: %w", err)
+			}
+			loans = append(loans, o)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("rows error: %w", err)
+		}
+
+		for _, o := range loans {
+			end := run.Today
+			if o.returnedAt != nil {
+				end = o.returnedAt.In(run.Loc).Format(time.DateOnly)
+			}
+			rule := domain.ResolvePolicy(rules, o.category, o.material, run.Default).Rule
+			fine, err := domain.OverdueFine(rule, o.dueDate, end, o.settled)
+			if err != nil {
+				return err
+			}
+			if fine > o.charged {
+				balance, err := patronBalance(ctx, tx, o.patronID)
+				if err != nil {
+					return err
+				}
+				err = s.insertEntry(ctx, tx, newUUID(), o.patronID, domain.EntryOverdue, fine-o.charged, balance,
+					sql.NullInt64{Int64: o.loanID, Valid: true}, "overdue fine through "+end, 0)
+				if err != nil {
+					return err
+				}
+				sweep.Charged++
+				sweep.AmountCents += fine - o.charged
+			}
+			if o.returnedAt != nil {
+				if _, err := tx.ExecContext(ctx, `UPDATE loans SET fines_closed = TRUE WHERE id = ?1`, o.loanID); err != nil {
+					return fmt.Errorf("failed to close loan fines: %w", err)
+				}
+				sweep.Closed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.FineSweep{}, err
+	}
+	return sweep, nil
+}
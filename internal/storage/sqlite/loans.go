@@ -0,0 +1,395 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// loanColumns lista as colunas lidas por loanFields, sobre loanJoins
+const loanColumns = `l.id, l.uuid, l.item_id, i.uuid, i.barcode, b.uuid, b.title,
+            l.patron_id, p.email, st.email, l.checked_out_at, l.due_date,
+            l.renewals, l.returned_at, COALESCE(r.email, '')`
+
+// loanJoins liga o empréstimo (l) ao exemplar, ao livro, ao leitor (p) e
+// aos funcionários do empréstimo (st) e da devolução (r)
+const loanJoins = `loans l
+        JOIN items i ON i.id = l.item_id
+        JOIN books b ON b.id = i.book_id
+        JOIN users p ON p.id = l.patron_id
+        JOIN users st ON st.id = l.checked_out_by
+        LEFT JOIN users r ON r.id = l.returned_by`
+
+// loanFields devolve os destinos do Scan na ordem de loanColumns
+func loanFields(l *domain.Loan) []any {
+	return []any{&l.ID, &l.UUID, &l.ItemID, &l.ItemUUID, &l.Barcode, &l.BookUUID, &l.Title,
+		&l.PatronID, &l.Patron, &l.CheckedOutBy, &l.CheckedOutAt, &l.DueDate,
+		&l.Renewals, &l.ReturnedAt, &l.ReturnedBy}
+}
+
+// CreateLoan empresta o exemplar numa transação imediata (_txlock), que
+// serializa as escritas; o índice parcial loans_item_active_key é a última
+// barreira contra dois empréstimos do mesmo exemplar.
+func (s *Store) CreateLoan(ctx context.Context, checkout domain.Checkout) (*domain.Loan, error) {
+	uuid := newUUID()
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var (
+			itemID, bookID   int64
+			status, material string
+		)
+		err := tx.QueryRowContext(ctx, `SELECT id, book_id, status, material_type FROM items WHERE barcode = ?1`, checkout.Barcode).
+			Scan(&itemID, &bookID, &status, &material)
+		if err != nil {
+			return translateError("failed to get item", err, domain.ErrItemNotFound)
+		}
+		switch status {
+		case domain.ItemAvailable:
+		case domain.ItemOnLoan:
+			return domain.ErrItemOnLoan
+		default:
+			return domain.ItemUnavailableError(status)
+		}
+
+		var (
+			patronID int64
+			category string
+		)
+		err = tx.QueryRowContext(ctx, `SELECT id, category FROM users WHERE email = ?1`, checkout.PatronEmail).
+			Scan(&patronID, &category)
+		if err != nil {
+			return translateError("failed to get patron", err, domain.ErrPatronNotFound)
+		}
+
+		// Leitor com saldo devedor acima do limite
+		balance, err := patronBalance(ctx, tx, patronID)
+		if err != nil {
+			return err
+		}
+		if balance > checkout.MaxBalance {
+			return domain.BalanceBlockedError(balance, checkout.MaxBalance)
+		}
+
+		// Exemplar separado para a reserva de outro leitor
+		var reserved bool
+		err = tx.QueryRowContext(ctx, `
+            SELECT EXISTS (SELECT 1 FROM holds WHERE item_id = ?1 AND status = 'ready' AND patron_id <> ?2)`,
+			itemID, patronID).Scan(&reserved)
+		if err != nil {
+			return fmt.Errorf("failed to check item holds: %w", err)
+		}
+		if reserved {
+			return domain.ErrItemReserved
+		}
+
+		// Prazo e limite da regra de circulação do leitor e do material
+		rules, err := policyRules(ctx, tx)
+		if err != nil {
+			return err
+		}
+		rule := domain.ResolvePolicy(rules, category, material, checkout.Default).Rule
+		var open int
+		err = tx.QueryRowContext(ctx, `
+            SELECT COUNT(*)
+            FROM loans l JOIN items i ON i.id = l.item_id
+            WHERE l.patron_id = ?1 AND l.returned_at IS NULL AND (?2 = '' OR i.material_type = ?2)`,
+			patronID, rule.MaterialType).Scan(&open)
+		if err != nil {
+			return fmt.Errorf("failed to count patron loans: %w", err)
+		}
+		if open >= rule.MaxItems {
+			return domain.LoanLimitError(rule)
+		}
+		dueDate, err := checkout.Calendar.DueDate(checkout.Dates.Today, rule.LoanDays)
+		if err != nil {
+			return err
+		}
+
+		now := s.now()
+		_, err = tx.ExecContext(ctx, `
+            INSERT INTO loans (uuid, item_id, patron_id, checked_out_by, checked_out_at, due_date)
+            VALUES (?1, ?2, ?3, ?4, ?5, ?6)`,
+			uuid, itemID, patronID, checkout.StaffID, now, dueDate)
+		if err != nil {
+			return translateError("failed to create loan", err, nil)
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE items SET status = ?2, updated_at = ?3 WHERE id = ?1`,
+			itemID, domain.ItemOnLoan, now)
+		if err != nil {
+			return fmt.Errorf("failed to update item status: %w", err)
+		}
+
+		// O empréstimo atende a reserva do leitor; se ela tinha outro
+		// exemplar separado, ele fica livre para a fila
+		res, err := tx.ExecContext(ctx, `
+            UPDATE holds SET status = 'fulfilled', item_id = ?3, closed_at = ?4, updated_at = ?4
+            WHERE book_id = ?1 AND patron_id = ?2 AND status IN ('waiting', 'suspended', 'ready')`,
+			bookID, patronID, itemID, now)
+		if err != nil {
+			return fmt.Errorf("failed to fulfill holds: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			_, err = s.fillHolds(ctx, tx, bookID, checkout.Dates)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLoanByUUID(ctx, uuid)
+}
+
+// ReturnLoan encerra o empréstimo e passa o exemplar para a fila do livro
+func (s *Store) ReturnLoan(ctx context.Context, uuid string, staffID int, dates domain.HoldDates) (*domain.Loan, error) {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		now := s.now()
+		var itemID int64
+		err := tx.QueryRowContext(ctx, `
+            UPDATE loans
+            SET returned_at = ?2, returned_by = ?3
+            WHERE uuid = lower(?1) AND returned_at IS NULL
+            RETURNING item_id`,
+			uuid, now, staffID).Scan(&itemID)
+		if errors.Is(err, sql.ErrNoRows) {
+			var exists bool
+			err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM loans WHERE uuid = lower(?1))`, uuid).Scan(&exists)
+			if err != nil {
+				return fmt.Errorf("failed to get loan: %w", err)
+			}
+			if exists {
+				return domain.ErrLoanReturned
+			}
+			return domain.ErrLoanNotFound
+		}
+		if err != nil {
+			return translateError("failed to return loan", err, nil)
+		}
+
+		var bookID int64
+		err = tx.QueryRowContext(ctx, `
+            UPDATE items SET status = CASE WHEN status = ?4 THEN ?2 ELSE status END, updated_at = ?3
+            WHERE id = ?1
+            RETURNING book_id`,
+			itemID, domain.ItemAvailable, now, domain.ItemOnLoan).Scan(&bookID)
+		if err != nil {
+			return fmt.Errorf("failed to update item status: %w", err)
+		}
+		_, err = s.fillHolds(ctx, tx, bookID, dates)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLoanByUUID(ctx, uuid)
+}
+
+// RenewLoan prorroga o empréstimo numa transação imediata
+func (s *Store) RenewLoan(ctx context.Context, renewal domain.Renewal) (*domain.Loan, error) {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var (
+			loanID, patronID, bookID int64
+			category, material       string
+			dueDate                  string
+			returned                 bool
+			renewals                 int
+			settled                  int64
+		)
+		err := tx.QueryRowContext(ctx, `
+            SELECT l.id, l.patron_id, i.book_id, p.category, i.material_type, l.due_date,
+                   l.returned_at IS NOT NULL, l.renewals, l.fines_settled_cents
+            FROM loans l
+            JOIN items i ON i.id = l.item_id
+            JOIN users p ON p.id = l.patron_id
+            WHERE l.uuid = lower(?1)`, renewal.LoanUUID).
+			Scan(&loanID, &patronID, &bookID, &category, &material, &dueDate, &returned, &renewals, &settled)
+		if err != nil {
+			return translateError("failed to get loan", err, domain.ErrLoanNotFound)
+		}
+		if returned {
+			return domain.ErrLoanReturned
+		}
+
+		balance, err := patronBalance(ctx, tx, patronID)
+		if err != nil {
+			return err
+		}
+		if balance > renewal.MaxBalance {
+			return domain.BalanceBlockedError(balance, renewal.MaxBalance)
+		}
+
+		// Outro leitor na fila do livro
+		var held bool
+		err = tx.QueryRowContext(ctx, `
+            SELECT EXISTS (SELECT 1 FROM holds WHERE book_id = ?1 AND status = 'waiting' AND patron_id <> ?2)`,
+			bookID, patronID).Scan(&held)
+		if err != nil {
+			return fmt.Errorf("failed to check book holds: %w", err)
+		}
+		if held {
+			return domain.ErrLoanHeld
+		}
+
+		rules, err := policyRules(ctx, tx)
+		if err != nil {
+			return err
+		}
+		rule := domain.ResolvePolicy(rules, category, material, renewal.Default).Rule
+		if renewals >= rule.MaxRenewals {
+			return domain.RenewalLimitError(rule)
+		}
+		newDue, err := renewal.Calendar.DueDate(max(renewal.Today, dueDate), rule.LoanDays)
+		if err != nil {
+			return err
+		}
+
+		// A multa do prazo vencido é lançada agora e fica em
+		// fines_settled_cents, fora da multa do novo prazo
+		var charged int64
+		err = tx.QueryRowContext(ctx, `
+            SELECT COALESCE(SUM(amount_cents), 0) FROM ledger_entries WHERE loan_id = ?1 AND type = 'overdue'`,
+			loanID).Scan(&charged)
+		if err != nil {
+			return fmt.Errorf("failed to sum loan fines: %w", err)
+		}
+		fine, err := domain.OverdueFine(rule, dueDate, renewal.Today, settled)
+		if err != nil {
+			return err
+		}
+		if owed := fine - (charged - settled); owed > 0 {
+			err = s.insertEntry(ctx, tx, newUUID(), patronID, domain.EntryOverdue, owed, balance,
+				sql.NullInt64{Int64: loanID, Valid: true}, "overdue fine through "+renewal.Today, 0)
+			if err != nil {
+				return err
+			}
+			charged += owed
+		}
+
+		_, err = tx.ExecContext(ctx, `
+            UPDATE loans SET due_date = ?2, renewals = renewals + 1, fines_settled_cents = ?3 WHERE id = ?1`,
+			loanID, newDue, charged)
+		if err != nil {
+			return fmt.Errorf("failed to renew loan: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+            INSERT INTO loan_renewals (loan_id, renewed_by, renewed_at, previous_due_date, due_date)
+            VALUES (?1, ?2, ?3, ?4, ?5)`,
+			loanID, renewal.StaffID, s.now(), dueDate, newDue)
+		if err != nil {
+			return translateError("failed to record renewal", err, nil)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLoanByUUID(ctx, renewal.LoanUUID)
+}
+
+func (s *Store) GetLoanRenewals(ctx context.Context, uuid string) ([]domain.LoanRenewal, error) {
+	var loanID int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM loans WHERE uuid = lower(?1)`, uuid).Scan(&loanID)
+	if err != nil {
+		return nil, translateError("failed to get loan", err, domain.ErrLoanNotFound)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT r.id, u.email, r.renewed_at, r.previous_due_date, r.due_date
+        FROM loan_renewals r
+        JOIN users u ON u.id = r.renewed_by
+        WHERE r.loan_id = ?1
+        ORDER BY r.id`, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	renewals := []domain.LoanRenewal{}
+	for rows.Next() {
+		var r domain.LoanRenewal
+		if err := rows.Scan(&r.ID, &r.RenewedBy, &r.RenewedAt, &r.PreviousDueDate, &r.DueDate); err != nil {
+			return nil, fmt.Errorf("row scan error: %w", err)
+		}
+		renewals = append(renewals, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return renewals, nil
+}
+
+func (s *Store) GetLoanByUUID(ctx context.Context, uuid string) (*domain.Loan, error) {
+	l := &domain.Loan{}
+	err := s.db.QueryRowContext(ctx, `
+        SELECT `+loanColumns+`
+        FROM `+loanJoins+`
+        WHERE l.uuid = lower(?1)`, uuid).Scan(loanFields(l)...)
+	if err != nil {
+		return nil, translateError("failed to get loan", err, domain.ErrLoanNotFound)
+	}
+	return l, nil
+}
+
+// GetLoans lista os empréstimos do mais recente para o mais antigo
+func (s *Store) GetLoans(ctx context.Context, filters domain.LoanFilters) ([]domain.Loan, int, error) {
+	var value any
+	if filters.Keyset != nil {
+		t, err := filters.Keyset.TimeValue()
+		if err != nil {
+			return nil, 0, err
+		}
+		value = t
+	}
+	where := `(?1 = 0 OR l.patron_id = ?1)
+            AND (?2 = '' OR p.email = ?2)
+            AND (?3 = '' OR i.barcode = ?3)
+            AND (?4 = ''
+                OR (?4 = 'active' AND l.returned_at IS NULL)
+                OR (?4 = 'returned' AND l.returned_at IS NOT NULL)
+                OR (?4 = 'overdue' AND l.returned_at IS NULL AND l.due_date < ?5))`
+	filterArgs := []any{filters.PatronID, filters.PatronEmail, filters.Barcode, filters.Status, filters.Today}
+	cond, order, keyArgs, backward := keyset("l.checked_out_at", "l.id", true, filters.Keyset, value, 8)
+
+	args := append(append(filterArgs, filters.Limit, offsetOf(filters.Keyset, filters.Offset)), keyArgs...)
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+loanColumns+`
+        FROM `+loanJoins+`
+        WHERE `+where+`
+        AND `+cond+`
+        ORDER BY `+order+`
+        LIMIT ?6 OFFSET ?7`, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	loans := []domain.Loan{}
+	for rows.Next() {
+		var l domain.Loan
+		if err := rows.Scan(loanFields(&l)...); err != nil {
+			return nil, 0, fmt.Errorf("row scan error: %w", err)
+		}
+		loans = append(loans, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	if backward {
+		slices.Reverse(loans)
+	}
+
+	if filters.Keyset != nil {
+		return loans, -1, nil
+	}
+	var total int
+	err = s.db.QueryRowContext(ctx, `
+        SELECT COUNT(*)
+        FROM `+loanJoins+`
+        WHERE `+where, filterArgs...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count failed: %w", err)
+	}
+	return loans, total, nil
+}
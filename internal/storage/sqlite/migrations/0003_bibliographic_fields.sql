@@ -0,0 +1,9 @@
+-- Campos bibliográficos; o ISBN (ISBN-13) identifica a edição e é único
+ALTER TABLE books ADD COLUMN isbn TEXT CHECK (length(isbn) = 13);
+ALTER TABLE books ADD COLUMN publisher TEXT;
+ALTER TABLE books ADD COLUMN pages INTEGER CHECK (pages > 0);
+ALTER TABLE books ADD COLUMN edition TEXT;
+ALTER TABLE books ADD COLUMN format TEXT CHECK (format IN ('hardcover', 'paperback', 'ebook', 'audiobook'));
+
+CREATE UNIQUE INDEX books_isbn_key ON books (isbn);
+CREATE INDEX books_publisher_idx ON books (publisher COLLATE NOCASE);
@@ -0,0 +1,21 @@
+-- Relatórios das importações de catálogo; errors guarda a lista em JSON
+CREATE TABLE import_jobs (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid             TEXT NOT NULL UNIQUE,
+    status           TEXT NOT NULL CHECK (status IN ('running', 'completed', 'failed')),
+    format           TEXT NOT NULL,
+    source           TEXT NOT NULL DEFAULT '',
+    dry_run          BOOLEAN NOT NULL DEFAULT FALSE,
+    rows_read        INTEGER NOT NULL DEFAULT 0,
+    created          INTEGER NOT NULL DEFAULT 0,
+    updated          INTEGER NOT NULL DEFAULT 0,
+    failed           INTEGER NOT NULL DEFAULT 0,
+    authors_created  INTEGER NOT NULL DEFAULT 0,
+    errors           TEXT NOT NULL DEFAULT '[]',
+    errors_truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    message          TEXT NOT NULL DEFAULT '',
+    started_at       TIMESTAMP NOT NULL,
+    finished_at      TIMESTAMP
+);
+
+CREATE INDEX import_jobs_started_at_idx ON import_jobs (started_at);
@@ -0,0 +1,4 @@
+-- Registros importados com perdas (campos MARC não aproveitados)
+ALTER TABLE import_jobs ADD COLUMN partial INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE import_jobs ADD COLUMN warnings TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE import_jobs ADD COLUMN warnings_truncated BOOLEAN NOT NULL DEFAULT FALSE;
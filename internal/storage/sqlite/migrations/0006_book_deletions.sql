@@ -0,0 +1,11 @@
+-- Livros excluídos, para a colheita OAI-PMH. Os assuntos ficam em JSON.
+CREATE TABLE book_deletions (
+    book_id    INTEGER PRIMARY KEY,
+    uuid       TEXT NOT NULL UNIQUE,
+    subjects   TEXT NOT NULL DEFAULT '[]',
+    deleted_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX book_deletions_deleted_at_idx ON book_deletions (deleted_at, book_id);
+
+CREATE INDEX books_datestamp_idx ON books (COALESCE(updated_at, created_at), id);
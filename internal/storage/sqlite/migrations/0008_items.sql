@@ -0,0 +1,19 @@
+-- Exemplares físicos dos livros; um livro com exemplares não pode ser excluído
+CREATE TABLE items (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid        TEXT NOT NULL UNIQUE,
+    book_id     INTEGER NOT NULL REFERENCES books (id) ON DELETE RESTRICT,
+    barcode     TEXT NOT NULL UNIQUE,
+    call_number TEXT,
+    branch      TEXT NOT NULL,
+    location    TEXT,
+    status      TEXT NOT NULL DEFAULT 'available'
+                CHECK (status IN ('available', 'on_loan', 'in_transit', 'lost', 'withdrawn')),
+    acquired_on TEXT,
+    price_cents INTEGER CHECK (price_cents >= 0),
+    created_at  TIMESTAMP NOT NULL,
+    updated_at  TIMESTAMP
+);
+
+CREATE INDEX items_book_id_idx ON items (book_id, status);
+CREATE INDEX items_branch_idx ON items (branch);
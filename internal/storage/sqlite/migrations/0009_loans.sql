@@ -0,0 +1,19 @@
+-- Empréstimos de exemplares; a data de devolução é um dia (AAAA-MM-DD)
+CREATE TABLE loans (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid           TEXT NOT NULL UNIQUE,
+    item_id        INTEGER NOT NULL REFERENCES items (id) ON DELETE RESTRICT,
+    patron_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    checked_out_by INTEGER NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    checked_out_at TIMESTAMP NOT NULL,
+    due_date       TEXT NOT NULL,
+    returned_at    TIMESTAMP,
+    returned_by    INTEGER REFERENCES users (id) ON DELETE RESTRICT,
+    CHECK ((returned_at IS NULL) = (returned_by IS NULL))
+);
+
+-- Um exemplar tem no máximo um empréstimo em aberto
+CREATE UNIQUE INDEX loans_item_active_key ON loans (item_id) WHERE returned_at IS NULL;
+CREATE INDEX loans_patron_id_idx ON loans (patron_id, checked_out_at, id);
+CREATE INDEX loans_checked_out_at_idx ON loans (checked_out_at, id);
+CREATE INDEX loans_due_date_idx ON loans (due_date) WHERE returned_at IS NULL;
@@ -0,0 +1,27 @@
+-- Reservas de títulos, em fila FIFO por created_at; uma reserva pronta
+-- (ready) tem um exemplar separado em item_id
+CREATE TABLE holds (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid            TEXT NOT NULL UNIQUE,
+    book_id         INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    patron_id       INTEGER NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    pickup_branch   TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'waiting'
+                    CHECK (status IN ('waiting', 'suspended', 'ready', 'fulfilled', 'cancelled', 'expired')),
+    expires_on      TEXT,
+    suspended_until TEXT,
+    item_id         INTEGER REFERENCES items (id) ON DELETE SET NULL,
+    ready_at        TIMESTAMP,
+    pickup_by       TEXT,
+    created_at      TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP,
+    closed_at       TIMESTAMP,
+    CHECK (status <> 'ready' OR item_id IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX holds_patron_open_key ON holds (book_id, patron_id)
+    WHERE status IN ('waiting', 'suspended', 'ready');
+CREATE UNIQUE INDEX holds_item_ready_key ON holds (item_id) WHERE status = 'ready';
+CREATE INDEX holds_queue_idx ON holds (book_id, created_at, id) WHERE status IN ('waiting', 'suspended');
+CREATE INDEX holds_patron_id_idx ON holds (patron_id, created_at, id);
+CREATE INDEX holds_created_at_idx ON holds (created_at, id);
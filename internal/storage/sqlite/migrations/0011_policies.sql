@@ -0,0 +1,22 @@
+-- Critérios das regras de circulação: categoria do leitor e tipo de
+-- material do exemplar
+ALTER TABLE users ADD COLUMN category TEXT NOT NULL DEFAULT 'public'
+    CHECK (category IN ('student', 'staff', 'public'));
+ALTER TABLE items ADD COLUMN material_type TEXT NOT NULL DEFAULT 'book'
+    CHECK (material_type IN ('book', 'reference', 'dvd', 'ebook'));
+
+-- Regras de circulação; critério vazio ('') vale para qualquer valor
+CREATE TABLE policy_rules (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid            TEXT NOT NULL UNIQUE,
+    patron_category TEXT NOT NULL DEFAULT ''
+                    CHECK (patron_category IN ('', 'student', 'staff', 'public')),
+    material_type   TEXT NOT NULL DEFAULT ''
+                    CHECK (material_type IN ('', 'book', 'reference', 'dvd', 'ebook')),
+    loan_days       INTEGER NOT NULL CHECK (loan_days BETWEEN 1 AND 365),
+    max_renewals    INTEGER NOT NULL CHECK (max_renewals >= 0),
+    max_items       INTEGER NOT NULL CHECK (max_items >= 0),
+    created_at      TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP,
+    UNIQUE (patron_category, material_type)
+);
@@ -0,0 +1,28 @@
+-- Multa por atraso de cada regra de circulação, em centavos
+ALTER TABLE policy_rules ADD COLUMN fine_daily_cents INTEGER NOT NULL DEFAULT 0 CHECK (fine_daily_cents >= 0);
+ALTER TABLE policy_rules ADD COLUMN fine_grace_days INTEGER NOT NULL DEFAULT 0 CHECK (fine_grace_days >= 0);
+ALTER TABLE policy_rules ADD COLUMN fine_cap_cents INTEGER NOT NULL DEFAULT 0 CHECK (fine_cap_cents >= 0);
+
+-- Conta dos leitores; balance_cents é o saldo devedor depois de cada
+-- lançamento
+CREATE TABLE ledger_entries (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid          TEXT NOT NULL UNIQUE,
+    patron_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    type          TEXT NOT NULL CHECK (type IN ('overdue', 'lost', 'damage', 'payment', 'waiver')),
+    amount_cents  INTEGER NOT NULL CHECK (amount_cents > 0),
+    balance_cents INTEGER NOT NULL,
+    loan_id       INTEGER REFERENCES loans (id) ON DELETE RESTRICT,
+    note          TEXT NOT NULL DEFAULT '',
+    created_by    INTEGER REFERENCES users (id) ON DELETE RESTRICT,
+    created_at    TIMESTAMP NOT NULL
+);
+
+CREATE INDEX ledger_entries_patron_id_idx ON ledger_entries (patron_id, created_at, id);
+CREATE INDEX ledger_entries_loan_id_idx ON ledger_entries (loan_id) WHERE loan_id IS NOT NULL;
+
+-- Devolvidos com a multa já encerrada; os devolvidos antes desta migração
+-- não são multados
+ALTER TABLE loans ADD COLUMN fines_closed BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE loans SET fines_closed = TRUE WHERE returned_at IS NOT NULL;
+CREATE INDEX loans_fines_open_idx ON loans (due_date) WHERE NOT fines_closed;
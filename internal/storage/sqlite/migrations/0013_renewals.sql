@@ -0,0 +1,16 @@
+-- Renovações de empréstimos; fines_settled_cents é a multa já lançada para
+-- os prazos anteriores à última renovação
+ALTER TABLE loans ADD COLUMN renewals INTEGER NOT NULL DEFAULT 0 CHECK (renewals >= 0);
+ALTER TABLE loans ADD COLUMN fines_settled_cents INTEGER NOT NULL DEFAULT 0 CHECK (fines_settled_cents >= 0);
+
+-- Histórico das renovações, com o prazo anterior e o novo
+CREATE TABLE loan_renewals (
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    loan_id           INTEGER NOT NULL REFERENCES loans (id) ON DELETE CASCADE,
+    renewed_by        INTEGER NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+    renewed_at        TIMESTAMP NOT NULL,
+    previous_due_date TEXT NOT NULL,
+    due_date          TEXT NOT NULL
+);
+
+CREATE INDEX loan_renewals_loan_id_idx ON loan_renewals (loan_id, id);
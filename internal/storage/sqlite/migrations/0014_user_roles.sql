@@ -0,0 +1,3 @@
+-- Papel do usuário na API (leitor, funcionário ou administrador)
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'patron'
+    CHECK (role IN ('patron', 'staff', 'admin'));
@@ -0,0 +1,41 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// keyset é o equivalente SQLite do helper do repositório Postgres: devolve
+// a condição e a ordenação da página, com placeholders ?n a partir de next.
+// Com Backward a busca anda na direção inversa e backward indica que o
+// resultado precisa ser invertido.
+func keyset(col, idCol string, desc bool, ks *domain.Keyset, value any, next int) (cond, order string, args []any, backward bool) {
+	if ks == nil {
+		return "1", orderBy(col, idCol, desc), nil, false
+	}
+
+	walkDesc := desc != ks.Backward
+	op := ">"
+	if walkDesc {
+		op = "<"
+	}
+	cond = fmt.Sprintf("(%s, %s) %s (?%d, ?%d)", col, idCol, op, next, next+1)
+	return cond, orderBy(col, idCol, walkDesc), []any{value, ks.ID}, ks.Backward
+}
+
+func orderBy(col, idCol string, desc bool) string {
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	return col + " " + dir + ", " + idCol + " " + dir
+}
+
+// offsetOf ignora o offset no modo cursor
+func offsetOf(ks *domain.Keyset, offset int) int {
+	if ks != nil {
+		return 0
+	}
+	return offset
+}
@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+// ruleColumns lista as colunas lidas por ruleFields
+const ruleColumns = `id, uuid, patron_category, material_type, loan_days, max_renewals, max_items,
+            fine_daily_cents, fine_grace_days, fine_cap_cents, created_at, updated_at`
+
+// ruleFields devolve os destinos do Scan na ordem de ruleColumns
+func ruleFields(r *domain.PolicyRule) []any {
+	return []any{&r.ID, &r.UUID, &r.PatronCategory, &r.MaterialType, &r.LoanDays, &r.MaxRenewals, &r.MaxItems,
+		&r.FineDailyCents, &r.FineGraceDays, &r.FineCapCents, &r.CreatedAt, &r.UpdatedAt}
+}
+
+// queryer é o que as consultas usadas dentro e fora de transações
+// precisam de *sql.DB e de *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (s *Store) CreatePolicyRule(ctx context.Context, rule domain.PolicyRule) (*domain.PolicyRule, error) {
+	uuid := newUUID()
+	_, err := s.db.ExecContext(ctx, `
+        INSERT INTO policy_rules (uuid, patron_category, material_type, loan_days, max_renewals, max_items,
+                                  fine_daily_cents, fine_grace_days, fine_cap_cents, created_at)
+        VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10)`,
+		uuid, rule.PatronCategory, rule.MaterialType, rule.LoanDays, rule.MaxRenewals, rule.MaxItems,
+		rule.FineDailyCents, rule.FineGraceDays, rule.FineCapCents, s.now())
+	if err != nil {
+		return nil, translateError("failed to create policy rule", err, nil)
+	}
+	return s.GetPolicyRuleByUUID(ctx, uuid)
+}
+
+func (s *Store) GetPolicyRules(ctx context.Context) ([]domain.PolicyRule, error) {
+	return policyRules(ctx, s.db)
+}
+
+// policyRules lê a tabela inteira, na ordem de resolução; ela tem no
+// máximo uma linha por combinação de critérios
+func policyRules(ctx context.Context, q queryer) ([]domain.PolicyRule, error) {
+	rows, err := q.QueryContext(ctx, `SELECT `+ruleColumns+` FROM policy_rules`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []domain.PolicyRule{}
+	for rows.Next() {
+		var r domain.PolicyRule
+		if err := rows.Scan(ruleFields(&r)...); err != nil {
+			return nil, fmt.Errorf("row scan error: %w", err)
+		}
+		rules = append(rules, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	domain.SortPolicyRules(rules)
+	return rules, nil
+}
+
+func (s *Store) GetPolicyRuleByUUID(ctx context.Context, uuid string) (*domain.PolicyRule, error) {
+	r := &domain.PolicyRule{}
+	err := s.db.QueryRowContext(ctx, `SELECT `+ruleColumns+` FROM policy_rules WHERE uuid = lower(?1)`, uuid).
+		Scan(ruleFields(r)...)
+	if err != nil {
+		return nil, translateError("failed to get policy rule", err, domain.ErrPolicyRuleNotFound)
+	}
+	return r, nil
+}
+
+func (s *Store) UpdatePolicyRule(ctx context.Context, uuid string, rule domain.PolicyRule) (*domain.PolicyRule, error) {
+	res, err := s.db.ExecContext(ctx, `
+        UPDATE policy_rules
+        SET patron_category = ?2, material_type = ?3, loan_days = ?4, max_renewals = ?5, max_items = ?6,
+            fine_daily_cents = ?7, fine_grace_days = ?8, fine_cap_cents = ?9, updated_at = ?10
+        WHERE uuid = lower(?1)`,
+		uuid, rule.PatronCategory, rule.MaterialType, rule.LoanDays, rule.MaxRenewals, rule.MaxItems,
+		rule.FineDailyCents, rule.FineGraceDays, rule.FineCapCents, s.now())
+	if err != nil {
+		return nil, translateError("failed to update policy rule", err, nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, domain.ErrPolicyRuleNotFound
+	}
+	return s.GetPolicyRuleByUUID(ctx, uuid)
+}
+
+func (s *Store) DeletePolicyRule(ctx context.Context, uuid string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM policy_rules WHERE uuid = lower(?1)`, uuid)
+	if err != nil {
+		return translateError("failed to delete policy rule", err, nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrPolicyRuleNotFound
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/search"
+)
+
+// SearchBooks avalia a consulta em Go com o pacote search. O build padrão do
+// go-sqlite3 não inclui FTS5, e o volume de uma instalação SQLite é pequeno.
+func (s *Store) SearchBooks(ctx context.Context, q domain.SearchQuery) ([]domain.SearchHit, int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT b.id, b.uuid, b.title, b.description, b.created_at,
+               COALESCE(json_group_array(json_object('id', a.id, 'uuid', a.uuid, 'name', a.name)
+                   ORDER BY ba.position, a.name) FILTER (WHERE a.id IS NOT NULL), '[]')
+        FROM books b
+        LEFT JOIN books_authors ba ON ba.book_id = b.id
+        LEFT JOIN authors a ON a.id = ba.author_id
+        GROUP BY b.id`)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []domain.SearchHit
+	for rows.Next() {
+		var b domain.Book
+		var authorsJSON string
+		if err := rows.Scan(&b.ID, &b.UUID, &b.Title, &b.Description, &b.CreatedAt, &authorsJSON); err != nil {
+			return nil, 0, fmt.Errorf("scan failed: %w", err)
+		}
+		if b.Authors, err = decodeAuthors(authorsJSON); err != nil {
+			return nil, 0, err
+		}
+
+		doc := search.Document{Title: b.Title, Description: b.Description}
+		for _, a := range b.Authors {
+			doc.Authors = append(doc.Authors, a.Name)
+		}
+		rank, ok := search.Match(q.Terms, doc)
+		if !ok {
+			continue
+		}
+		hits = append(hits, domain.SearchHit{Book: b, Rank: rank, Headline: search.Headline(q.Terms, doc)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+
+	slices.SortFunc(hits, func(a, b domain.SearchHit) int {
+		if a.Rank != b.Rank {
+			if a.Rank > b.Rank {
+				return -1
+			}
+			return 1
+		}
+		return a.Book.ID - b.Book.ID
+	})
+
+	total := len(hits)
+	if q.Offset >= total {
+		return []domain.SearchHit{}, total, nil
+	}
+	hits = hits[q.Offset:]
+	if q.Limit < len(hits) {
+		hits = hits[:q.Limit]
+	}
+	return hits, total, nil
+}
+
+func decodeAuthors(raw string) ([]*domain.Author, error) {
+	var authors []*domain.Author
+	if err := json.NewDecoder(strings.NewReader(raw)).Decode(&authors); err != nil {
+		return nil, fmt.Errorf("failed to decode authors: %w", err)
+	}
+	return authors, nil
+}
@@ -0,0 +1,161 @@
+// Package sqlite implementa os stores sobre um arquivo SQLite, para
+// instalações de um único nó sem servidor Postgres. O esquema é mantido por
+// migrações embutidas, aplicadas automaticamente na abertura.
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+)
+
+// driverName registra o driver com a função ilike(texto, padrão), que
+// reproduz o ILIKE '%padrão%' do Postgres também para letras acentuadas
+// (o LIKE do SQLite só ignora maiúsculas em ASCII).
+const driverName = "sqlite3_libapi"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("ilike", ilike, true)
+		},
+	})
+}
+
+func ilike(s, pattern string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(pattern))
+}
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Store implementa todas as interfaces de storage.Stores sobre um único
+// arquivo SQLite
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// Open abre (ou cria) o arquivo e aplica as migrações pendentes
+func Open(ctx context.Context, path string) (*Store, error) {
+	params := url.Values{}
+	params.Set("_foreign_keys", "on")
+	params.Set("_journal_mode", "WAL")
+	params.Set("_busy_timeout", "5000")
+	params.Set("_txlock", "immediate")
+
+	db, err := sql.Open(driverName, "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", path, err)
+	}
+
+	s := &Store{db: db, now: func() time.Time { return time.Now().UTC() }}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Stores retorna o Store em cada uma das interfaces usadas pelo router
+func (s *Store) Stores() storage.Stores {
+	return storage.Stores{Books: s, Authors: s, Users: s, Search: s, Imports: s, Harvest: s, Items: s, Loans: s, Holds: s, Policies: s, Ledger: s}
+}
+
+// migrate aplica, em ordem e cada um em sua transação, os arquivos
+// migrations/NNNN_nome.sql ainda não registrados em schema_migrations.
+func (s *Store) migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version    INTEGER PRIMARY KEY,
+            name       TEXT NOT NULL,
+            applied_at TIMESTAMP NOT NULL
+        )`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, e := range entries {
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(e.Name(), ".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return fmt.Errorf("migration file %q must be named NNNN_name.sql", e.Name())
+		}
+
+		var applied bool
+		err = s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = ?1)`, version).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		if applied {
+			continue
+		}
+
+		body, err := migrationFiles.ReadFile("migrations/" + e.Name())
+		if err != nil {
+			return err
+		}
+		err = s.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?1, ?2, ?3)`,
+				version, name, s.now())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s failed: %w", e.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// newUUID gera um UUID v4 aleatório (o SQLite não tem gen_random_uuid)
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
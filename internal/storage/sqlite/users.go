@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+func (s *Store) CreateUser(ctx context.Context, user domain.User) error {
+	_, err := s.db.ExecContext(ctx, `
+        INSERT INTO users (uuid, email, password_hash, created_at)
+        VALUES (?1, ?2, ?3, ?4)`,
+		newUUID(), user.Email, user.PasswordHash, s.now())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrUserExists
+		}
+		return translateError("failed to create user", err, nil)
+	}
+	return nil
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	var user domain.User
+	err := s.db.QueryRowContext(ctx, `
+        SELECT id, uuid, email, password_hash, category, role, created_at, updated_at
+        FROM users
+        WHERE email = ?1`, email).
+		Scan(&user.ID, &user.UUID, &user.Email, &user.PasswordHash, &user.Category, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, translateError("failed to get user by email", err, domain.ErrUserNotFound)
+	}
+	return &user, nil
+}
+
+func (s *Store) SetUserCategory(ctx context.Context, email, category string) (*domain.User, error) {
+	res, err := s.db.ExecContext(ctx, `UPDATE users SET category = ?2, updated_at = ?3 WHERE email = ?1`,
+		email, category, s.now())
+	if err != nil {
+		return nil, translateError("failed to update user category", err, nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, domain.ErrUserNotFound
+	}
+	return s.GetUserByEmail(ctx, email)
+}
+
+func (s *Store) SetUserRole(ctx context.Context, email, role string) (*domain.User, error) {
+	res, err := s.db.ExecContext(ctx, `UPDATE users SET role = ?2, updated_at = ?3 WHERE email = ?1`,
+		email, role, s.now())
+	if err != nil {
+		return nil, translateError("failed to update user role", err, nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, domain.ErrUserNotFound
+	}
+	return s.GetUserByEmail(ctx, email)
+}
@@ -0,0 +1,200 @@
+// Package storage define os contratos de persistência usados pelos handlers.
+// As implementações retornam os erros de domain (domain.ErrBookNotFound...).
+// Há uma implementação Postgres (pacote repository), uma SQLite
+// (storage/sqlite) e uma em memória (storage/memory), escolhidas por
+// storage.backend.
+//
+// Listagens aceitam dois modos de paginação. No modo offset (Keyset nil) os
+// stores aplicam Limit/Offset e devolvem o total filtrado. No modo cursor
+// (Keyset definido) devolvem até Limit itens depois (ou, com Backward, antes)
+// do item de referência, na ordem de exibição, e não calculam o total (-1).
+// A ordem sempre desempata pelo id, para que o keyset seja estável.
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+type BookStore interface {
+	CreateBook(ctx context.Context, req domain.BookCreateRequest) (*domain.Book, error)
+	GetBooksBasic(ctx context.Context, filters domain.BookFilters) ([]domain.Book, int, error)
+	GetBooksWithAuthors(ctx context.Context, filters domain.BookFilters) ([]domain.Book, int, error)
+	// GetBookFacets conta os livros filtrados por faceta, com até limit
+	// valores por faceta (os mais frequentes). Ignora a paginação.
+	GetBookFacets(ctx context.Context, filters domain.BookFilters, limit int) (*domain.BookFacets, error)
+	GetBookByUUID(ctx context.Context, uuid string) (*domain.Book, error)
+	UpdateBook(ctx context.Context, uuid string, req domain.BookUpdateRequest) (*domain.Book, error)
+	DeleteBook(ctx context.Context, uuid string) error
+}
+
+type AuthorStore interface {
+	CreateAuthor(ctx context.Context, author *domain.Author) error
+	GetAuthors(ctx context.Context, filters domain.AuthorFilters) ([]domain.Author, int, error)
+	GetAuthorsWithBooks(ctx context.Context, filters domain.AuthorFilters) ([]domain.Author, int, error)
+	GetAuthorByID(ctx context.Context, id int) (*domain.Author, error)
+	GetAuthorByUUID(ctx context.Context, uuid string) (*domain.Author, error)
+	UpdateAuthor(ctx context.Context, uuid string, patch domain.AuthorPatchRequest) (*domain.Author, error)
+	DeleteAuthor(ctx context.Context, uuid string, unlink bool) error
+}
+
+// SearchStore faz a busca textual por livros (título, descrição e autores),
+// ordenada por relevância
+type SearchStore interface {
+	SearchBooks(ctx context.Context, q domain.SearchQuery) ([]domain.SearchHit, int, error)
+}
+
+// ImportStore grava os lotes da importação de catálogo e os relatórios
+// (jobs). ImportBooks grava um lote numa única transação: resolve cada
+// autor pelo nome (domain.AuthorNameKey), criando os que faltam, atualiza os
+// livros cujo ISBN já existe e insere os demais. Campos vazios numa linha
+// não apagam o valor já gravado; autores, e assuntos quando informados, são
+// substituídos. Com dryRun a transação é desfeita no fim.
+type ImportStore interface {
+	ImportBooks(ctx context.Context, rows []domain.ImportRow, dryRun bool) (domain.ImportBatchResult, error)
+	SaveImportJob(ctx context.Context, job *domain.ImportJob) error
+	GetImportJob(ctx context.Context, uuid string) (*domain.ImportJob, error)
+	ListImportJobs(ctx context.Context, limit int) ([]domain.ImportJob, error)
+}
+
+// HarvestStore atende a colheita OAI-PMH. Além dos livros, lista as
+// exclusões, que DeleteBook registra com os assuntos do livro para que a
+// remoção também chegue a quem colhe por set.
+type HarvestStore interface {
+	HarvestRecords(ctx context.Context, q domain.HarvestQuery) ([]domain.HarvestRecord, error)
+	// GetHarvestRecord devolve o livro (com autores) ou a sua exclusão;
+	// domain.ErrBookNotFound se o UUID nunca existiu
+	GetHarvestRecord(ctx context.Context, uuid string) (*domain.HarvestRecord, error)
+	// EarliestDatestamp devolve o menor datestamp, ou nil num catálogo vazio
+	EarliestDatestamp(ctx context.Context) (*time.Time, error)
+	// HarvestSubjects lista os assuntos distintos de livros e exclusões
+	HarvestSubjects(ctx context.Context) ([]string, error)
+}
+
+// ItemStore guarda os exemplares físicos dos livros. Um livro com
+// exemplares não pode ser excluído (domain.ErrBookHasItems), nem um
+// exemplar com empréstimos (domain.ErrItemHasLoans) ou separado para uma
+// reserva (domain.ErrItemReserved). A situação on_loan é mantida pelos
+// empréstimos: UpdateItem não a atribui nem a retira. Se UpdateItem tira
+// de available um exemplar separado, a reserva volta para a fila.
+type ItemStore interface {
+	CreateItem(ctx context.Context, bookUUID string, req domain.ItemRequest) (*domain.Item, error)
+	GetItems(ctx context.Context, filters domain.ItemFilters) ([]domain.Item, int, error)
+	GetItemByUUID(ctx context.Context, uuid string) (*domain.Item, error)
+	UpdateItem(ctx context.Context, uuid string, req domain.ItemRequest) (*domain.Item, error)
+	DeleteItem(ctx context.Context, uuid string) error
+	// GetAvailability resume os exemplares de cada livro (pelo id interno),
+	// contando como on_hold os separados para reservas prontas;
+	// livros sem exemplares vêm com contagens zeradas
+	GetAvailability(ctx context.Context, bookIDs []int) (map[int]domain.Availability, error)
+}
+
+// LoanStore registra os empréstimos. CreateLoan é atômico: o exemplar só é
+// emprestado se estiver disponível e sem outro empréstimo em aberto; do
+// contrário devolve domain.ErrItemOnLoan ou domain.ItemUnavailableError.
+// Um exemplar separado para uma reserva só sai para o dono da reserva
+// (domain.ErrItemReserved), e o empréstimo atende as reservas abertas do
+// leitor para o livro. O prazo e o limite de empréstimos vêm da regra de
+// circulação resolvida (domain.ResolvePolicy) para a categoria do leitor e
+// o material do exemplar; acima do limite, domain.LoanLimitError. Um
+// leitor com saldo devedor acima de Checkout.MaxBalance não empresta
+// (domain.BalanceBlockedError). Empréstimo e devolução atualizam a
+// situação do exemplar na mesma transação; na devolução o exemplar vai
+// para a próxima reserva da fila.
+type LoanStore interface {
+	CreateLoan(ctx context.Context, checkout domain.Checkout) (*domain.Loan, error)
+	// ReturnLoan encerra o empréstimo em nome do funcionário staffID;
+	// domain.ErrLoanReturned se ele já tinha sido devolvido
+	ReturnLoan(ctx context.Context, uuid string, staffID int, dates domain.HoldDates) (*domain.Loan, error)
+	// RenewLoan prorroga um empréstimo em aberto e registra a renovação no
+	// histórico. Recusa com domain.ErrLoanReturned, domain.ErrLoanHeld
+	// (outro leitor espera na fila do livro), domain.RenewalLimitError
+	// ou domain.BalanceBlockedError. A multa do prazo vencido é lançada
+	// na renovação, de modo que o novo prazo começa sem atraso.
+	RenewLoan(ctx context.Context, renewal domain.Renewal) (*domain.Loan, error)
+	// GetLoanRenewals lista as renovações do empréstimo, da mais antiga
+	// para a mais recente
+	GetLoanRenewals(ctx context.Context, uuid string) ([]domain.LoanRenewal, error)
+	GetLoanByUUID(ctx context.Context, uuid string) (*domain.Loan, error)
+	GetLoans(ctx context.Context, filters domain.LoanFilters) ([]domain.Loan, int, error)
+}
+
+// HoldStore guarda as reservas e as filas dos livros. Sempre que um
+// exemplar fica livre (devolução, cancelamento, fim de suspensão), ele é
+// separado para a primeira reserva elegível da fila, preferindo um
+// exemplar da unidade de retirada. São elegíveis as reservas em espera e
+// as suspensas cujo prazo já terminou, desde que não vencidas.
+type HoldStore interface {
+	// CreateHold põe o leitor no fim da fila; havendo exemplar livre, a
+	// reserva já sai pronta para retirada
+	CreateHold(ctx context.Context, p domain.HoldPlacement) (*domain.Hold, error)
+	GetHoldByUUID(ctx context.Context, uuid string) (*domain.Hold, error)
+	GetHolds(ctx context.Context, filters domain.HoldFilters) ([]domain.Hold, int, error)
+	CancelHold(ctx context.Context, uuid string, dates domain.HoldDates) (*domain.Hold, error)
+	// SuspendHold congela uma reserva em espera (ou muda o prazo de uma já
+	// suspensa); until vazio suspende por tempo indeterminado
+	SuspendHold(ctx context.Context, uuid, until string) (*domain.Hold, error)
+	ResumeHold(ctx context.Context, uuid string, dates domain.HoldDates) (*domain.Hold, error)
+	// SweepHolds expira as reservas vencidas e as prontas não retiradas até
+	// PickupBy, reativa as suspensões encerradas e separa os exemplares
+	// livres para as filas
+	SweepHolds(ctx context.Context, dates domain.HoldDates) (domain.HoldSweep, error)
+}
+
+// PolicyStore guarda a tabela de regras de circulação. Há no máximo uma
+// regra por combinação de critérios (domain.ErrPolicyRuleExists).
+type PolicyStore interface {
+	CreatePolicyRule(ctx context.Context, rule domain.PolicyRule) (*domain.PolicyRule, error)
+	// GetPolicyRules lista todas as regras, na ordem de domain.SortPolicyRules
+	GetPolicyRules(ctx context.Context) ([]domain.PolicyRule, error)
+	GetPolicyRuleByUUID(ctx context.Context, uuid string) (*domain.PolicyRule, error)
+	UpdatePolicyRule(ctx context.Context, uuid string, rule domain.PolicyRule) (*domain.PolicyRule, error)
+	DeletePolicyRule(ctx context.Context, uuid string) error
+}
+
+// LedgerStore guarda a conta dos leitores. Os lançamentos nunca mudam e
+// cada um grava o saldo devedor resultante; pagamentos e abonos não passam
+// do saldo (domain.ExceedsBalanceError).
+type LedgerStore interface {
+	// PostLedgerEntry lança uma cobrança manual, um pagamento ou um abono;
+	// o empréstimo de uma cobrança precisa ser do leitor
+	// (domain.ErrLoanOfOtherPatron)
+	PostLedgerEntry(ctx context.Context, p domain.LedgerPosting) (*domain.LedgerEntry, error)
+	// GetLedger lista os lançamentos e devolve também o saldo atual do
+	// leitor; domain.ErrPatronNotFound se o email não existir
+	GetLedger(ctx context.Context, filters domain.LedgerFilters) ([]domain.LedgerEntry, int, int64, error)
+	// AccrueFines lança a multa por atraso acumulada de cada empréstimo
+	// vencido, pela regra de circulação resolvida na hora, descontando o
+	// que já foi lançado para ele; pode rodar quantas vezes for preciso no
+	// mesmo dia. Um empréstimo devolvido recebe o último lançamento, até a
+	// data da devolução, e sai da rotina.
+	AccrueFines(ctx context.Context, run domain.FineRun) (domain.FineSweep, error)
+}
+
+type UserStore interface {
+	CreateUser(ctx context.Context, user domain.User) error
+	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	// SetUserCategory muda a categoria de leitor usada pelas regras de
+	// circulação
+	SetUserCategory(ctx context.Context, email, category string) (*domain.User, error)
+	// SetUserRole muda o papel do usuário na API, que vale a partir do
+	// próximo login
+	SetUserRole(ctx context.Context, email, role string) (*domain.User, error)
+}
+
+// Stores agrupa as implementações de um backend
+type Stores struct {
+	Books    BookStore
+	Authors  AuthorStore
+	Users    UserStore
+	Search   SearchStore
+	Imports  ImportStore
+	Harvest  HarvestStore
+	Items    ItemStore
+	Loans    LoanStore
+	Holds    HoldStore
+	Policies PolicyStore
+	Ledger   LedgerStore
+}
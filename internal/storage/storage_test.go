@@ -0,0 +1,458 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+	"github.com/patrick-tondorf/lib_api/internal/storage"
+	"github.com/patrick-tondorf/lib_api/internal/storage/memory"
+	"github.com/patrick-tondorf/lib_api/internal/storage/sqlite"
+)
+
+// Os mesmos cenários rodam contra o store em memória e o SQLite, para que
+// os dois sigam o contrato de storage da mesma forma
+
+// defaultRule é a regra padrão dos cenários: 14 dias, 25 centavos por dia
+// de atraso, teto de 10.00
+var defaultRule = domain.PolicyRule{LoanDays: 14, MaxRenewals: 2, MaxItems: 5, FineDailyCents: 25, FineCapCents: 1000}
+
+// eachBackend roda fn com um store vazio de cada implementação
+func eachBackend(t *testing.T, fn func(t *testing.T, s storage.Stores)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, memory.New().Stores())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "lib.db"))
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		fn(t, db.Stores())
+	})
+}
+
+func mustUser(t *testing.T, s storage.Stores, email string) int {
+	t.Helper()
+	ctx := context.Background()
+	if err := s.Users.CreateUser(ctx, domain.User{Email: email, PasswordHash: "x"}); err != nil {
+		t.Fatalf("create user %s: %v", email, err)
+	}
+	u, err := s.Users.GetUserByEmail(ctx, email)
+	if err != nil {
+		t.Fatalf("get user %s: %v", email, err)
+	}
+	id, _ := strconv.Atoi(u.ID)
+	return id
+}
+
+// mustBook cria um livro com um autor e os exemplares dos códigos de
+// barras informados, e devolve o UUID do livro
+func mustBook(t *testing.T, s storage.Stores, title, isbn string, barcodes ...string) string {
+	t.Helper()
+	ctx := context.Background()
+	author := &domain.Author{Name: "Author of " + title}
+	if err := s.Authors.CreateAuthor(ctx, author); err != nil {
+		t.Fatalf("create author: %v", err)
+	}
+	req := domain.BookCreateRequest{Title: title, AuthorIDs: []int{author.ID}}
+	req.ISBN = isbn
+	book, err := s.Books.CreateBook(ctx, req)
+	if err != nil {
+		t.Fatalf("create book %q: %v", title, err)
+	}
+	for _, barcode := range barcodes {
+		req := domain.ItemRequest{Barcode: barcode, Branch: "Central"}
+		if err := req.Normalize(); err != nil {
+			t.Fatalf("item %s: %v", barcode, err)
+		}
+		if _, err := s.Items.CreateItem(ctx, book.UUID, req); err != nil {
+			t.Fatalf("create item %s: %v", barcode, err)
+		}
+	}
+	return book.UUID
+}
+
+func checkout(s storage.Stores, barcode, patron string, staffID int, today string) (*domain.Loan, error) {
+	return s.Loans.CreateLoan(context.Background(), domain.Checkout{
+		Barcode:     barcode,
+		PatronEmail: patron,
+		StaffID:     staffID,
+		Default:     defaultRule,
+		MaxBalance:  100000,
+		Dates:       domain.HoldDates{Today: today, PickupBy: today},
+	})
+}
+
+func TestUsers(t *testing.T) {
+	eachBackend(t, func(t *testing.T, s storage.Stores) {
+		ctx := context.Background()
+		mustUser(t, s, "reader@lib.com")
+
+		if err := s.Users.CreateUser(ctx, domain.User{Email: "reader@lib.com", PasswordHash: "x"}); !errors.Is(err, domain.ErrUserExists) {
+			t.Errorf("duplicate user: got %v, want ErrUserExists", err)
+		}
+		if _, err := s.Users.GetUserByEmail(ctx, "nobody@lib.com"); !errors.Is(err, domain.ErrUserNotFound) {
+			t.Errorf("missing user: got %v, want ErrUserNotFound", err)
+		}
+
+		u, err := s.Users.GetUserByEmail(ctx, "reader@lib.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Category != domain.PatronPublic || u.Role != domain.RolePatron {
+			t.Errorf("new user: category %q role %q, want public patron", u.Category, u.Role)
+		}
+
+		if u, err = s.Users.SetUserCategory(ctx, "reader@lib.com", domain.PatronStudent); err != nil || u.Category != domain.PatronStudent {
+			t.Errorf("set category: %+v, %v", u, err)
+		}
+		if u, err = s.Users.SetUserRole(ctx, "reader@lib.com", domain.RoleStaff); err != nil || u.Role != domain.RoleStaff {
+			t.Errorf("set role: %+v, %v", u, err)
+		}
+		if _, err := s.Users.SetUserRole(ctx, "nobody@lib.com", domain.RoleStaff); !errors.Is(err, domain.ErrUserNotFound) {
+			t.Errorf("set role of missing user: got %v, want ErrUserNotFound", err)
+		}
+	})
+}
+
+func TestBooks(t *testing.T) {
+	eachBackend(t, func(t *testing.T, s storage.Stores) {
+		ctx := context.Background()
+		mustBook(t, s, "Brave New World", "9780060850524")
+		mustBook(t, s, "Animal Farm", "")
+		withItem := mustBook(t, s, "Carrie", "", "C001")
+
+		tests := []struct {
+			name    string
+			filters domain.BookFilters
+			want    []string
+			total   int
+		}{
+			{"first page", domain.BookFilters{Sort: "title", SortDirection: "ASC", Limit: 2}, []string{"Animal Farm", "Brave New World"}, 3},
+			{"second page", domain.BookFilters{Sort: "title", SortDirection: "ASC", Limit: 2, Offset: 2}, []string{"Carrie"}, 3},
+			{"descending", domain.BookFilters{Sort: "title", SortDirection: "DESC", Limit: 1}, []string{"Carrie"}, 3},
+			{"title filter", domain.BookFilters{Title: "farm", Limit: 10}, []string{"Animal Farm"}, 1},
+		}
+		for _, tt := range tests {
+			books, total, err := s.Books.GetBooksBasic(ctx, tt.filters)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			var titles []string
+			for _, b := range books {
+				titles = append(titles, b.Title)
+			}
+			if total != tt.total || len(titles) != len(tt.want) {
+				t.Errorf("%s: got %v (total %d), want %v (total %d)", tt.name, titles, total, tt.want, tt.total)
+				continue
+			}
+			for i := range titles {
+				if titles[i] != tt.want[i] {
+					t.Errorf("%s: got %v, want %v", tt.name, titles, tt.want)
+					break
+				}
+			}
+		}
+
+		author := &domain.Author{Name: "Someone"}
+		if err := s.Authors.CreateAuthor(ctx, author); err != nil {
+			t.Fatal(err)
+		}
+		dup := domain.BookCreateRequest{Title: "Other", AuthorIDs: []int{author.ID}}
+		dup.ISBN = "9780060850524"
+		if _, err := s.Books.CreateBook(ctx, dup); !errors.Is(err, domain.ErrISBNExists) {
+			t.Errorf("duplicate ISBN: got %v, want ErrISBNExists", err)
+		}
+		// Autor repetido conta uma vez só
+		created, err := s.Books.CreateBook(ctx, domain.BookCreateRequest{Title: "Twice", AuthorIDs: []int{author.ID, author.ID}})
+		if err != nil {
+			t.Fatalf("repeated author: %v", err)
+		}
+		if created.UUID == "" || len(created.Authors) != 1 || created.Authors[0].Name != "Someone" {
+			t.Errorf("repeated author: got uuid %q and authors %+v", created.UUID, created.Authors)
+		}
+		if _, err := s.Books.GetBookByUUID(ctx, "00000000-0000-4000-8000-000000000000"); !errors.Is(err, domain.ErrBookNotFound) {
+			t.Errorf("missing book: got %v, want ErrBookNotFound", err)
+		}
+		if err := s.Books.DeleteBook(ctx, withItem); !errors.Is(err, domain.ErrBookHasItems) {
+			t.Errorf("delete book with items: got %v, want ErrBookHasItems", err)
+		}
+	})
+}
+
+func TestBookFacets(t *testing.T) {
+	eachBackend(t, func(t *testing.T, s storage.Stores) {
+		ctx := context.Background()
+		staff := mustUser(t, s, "staff@lib.com")
+		mustUser(t, s, "reader@lib.com")
+
+		var authors []*domain.Author
+		for _, name := range []string{"George Orwell", "Aldous Huxley"} {
+			a := &domain.Author{Name: name}
+			if err := s.Authors.CreateAuthor(ctx, a); err != nil {
+				t.Fatal(err)
+			}
+			authors = append(authors, a)
+		}
+		orwell, huxley := authors[0], authors[1]
+		book := func(title, language string, year int, authorIDs []int, subjects []string, barcodes ...string) {
+			t.Helper()
+			req := domain.BookCreateRequest{Title: title, AuthorIDs: authorIDs}
+			req.Language, req.Subjects = language, subjects
+			if year > 0 {
+				req.PublicationYear = &year
+			}
+			if err := req.Normalize(); err != nil {
+				t.Fatalf("%s: %v", title, err)
+			}
+			b, err := s.Books.CreateBook(ctx, req)
+			if err != nil {
+				t.Fatalf("create book %q: %v", title, err)
+			}
+			for _, barcode := range barcodes {
+				item := domain.ItemRequest{Barcode: barcode, Branch: "Central"}
+				if err := item.Normalize(); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := s.Items.CreateItem(ctx, b.UUID, item); err != nil {
+					t.Fatalf("create item %s: %v", barcode, err)
+				}
+			}
+		}
+		book("Nineteen Eighty-Four", "en", 1949, []int{orwell.ID}, []string{"Dystopia", "Politics"}, "F001", "F002")
+		book("Animal Farm", "en", 1945, []int{orwell.ID, orwell.ID}, []string{"Politics", "Satire"}, "F003")
+		book("Brave New World", "en", 1932, []int{huxley.ID}, []string{"Dystopia"})
+		book("Admirável mundo novo", "pt-BR", 1932, []int{huxley.ID}, []string{"Distopia"})
+		book("Essays", "", 0, []int{orwell.ID}, nil)
+		if _, err := checkout(s, "F002", "reader@lib.com", staff, "2024-03-01"); err != nil {
+			t.Fatalf("checkout: %v", err)
+		}
+
+		value := func(v string, n int) domain.FacetValue { return domain.FacetValue{Value: v, Count: n} }
+		labeled := func(v, label string, n int) domain.FacetValue {
+			return domain.FacetValue{Value: v, Label: label, Count: n}
+		}
+		tests := []struct {
+			name    string
+			filters domain.BookFilters
+			limit   int
+			want    domain.BookFacets
+		}{
+			{name: "everything", limit: 10, want: domain.BookFacets{
+				Author:       []domain.FacetValue{labeled(orwell.UUID, "George Orwell", 3), labeled(huxley.UUID, "Aldous Huxley", 2)},
+				Subject:      []domain.FacetValue{value("Dystopia", 2), value("Politics", 2), value("Distopia", 1), value("Satire", 1)},
+				Language:     []domain.FacetValue{value("en", 3), value("pt-BR", 1)},
+				Decade:       []domain.FacetValue{labeled("1930", "1930s", 2), labeled("1940", "1940s", 2)},
+				Availability: []domain.FacetValue{labeled("available", "Available", 2), labeled("on_loan", "On loan", 1)},
+			}},
+			{name: "limit", limit: 1, want: domain.BookFacets{
+				Author:       []domain.FacetValue{labeled(orwell.UUID, "George Orwell", 3)},
+				Subject:      []domain.FacetValue{value("Dystopia", 2)},
+				Language:     []domain.FacetValue{value("en", 3)},
+				Decade:       []domain.FacetValue{labeled("1930", "1930s", 2)},
+				Availability: []domain.FacetValue{labeled("available", "Available", 2)},
+			}},
+			// Valores da mesma faceta somam, facetas diferentes restringem
+			{name: "subject or subject, and decade", limit: 10,
+				filters: domain.BookFilters{Subjects: []string{"Dystopia", "Satire"}, Decades: []int{1940}},
+				want: domain.BookFacets{
+					Author:       []domain.FacetValue{labeled(orwell.UUID, "George Orwell", 2)},
+					Subject:      []domain.FacetValue{value("Politics", 2), value("Dystopia", 1), value("Satire", 1)},
+					Language:     []domain.FacetValue{value("en", 2)},
+					Decade:       []domain.FacetValue{labeled("1940", "1940s", 2)},
+					Availability: []domain.FacetValue{labeled("available", "Available", 2), labeled("on_loan", "On loan", 1)},
+				}},
+			{name: "author and language", limit: 10,
+				filters: domain.BookFilters{AuthorUUIDs: []string{huxley.UUID}, Languages: []string{"pt-BR", "fr"}},
+				want: domain.BookFacets{
+					Author:       []domain.FacetValue{labeled(huxley.UUID, "Aldous Huxley", 1)},
+					Subject:      []domain.FacetValue{value("Distopia", 1)},
+					Language:     []domain.FacetValue{value("pt-BR", 1)},
+					Decade:       []domain.FacetValue{labeled("1930", "1930s", 1)},
+					Availability: []domain.FacetValue{},
+				}},
+			{name: "availability", limit: 10,
+				filters: domain.BookFilters{Availability: []string{domain.ItemOnLoan}},
+				want: domain.BookFacets{
+					Author:       []domain.FacetValue{labeled(orwell.UUID, "George Orwell", 1)},
+					Subject:      []domain.FacetValue{value("Dystopia", 1), value("Politics", 1)},
+					Language:     []domain.FacetValue{value("en", 1)},
+					Decade:       []domain.FacetValue{labeled("1940", "1940s", 1)},
+					Availability: []domain.FacetValue{labeled("available", "Available", 1), labeled("on_loan", "On loan", 1)},
+				}},
+			{name: "no match", limit: 10,
+				filters: domain.BookFilters{Subjects: []string{"Satire"}, Decades: []int{1930}},
+				want:    *domain.NewBookFacets()},
+		}
+		for _, tt := range tests {
+			got, err := s.Books.GetBookFacets(ctx, tt.filters, tt.limit)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("%s:\n got %+v\nwant %+v", tt.name, *got, tt.want)
+			}
+		}
+
+		// Os filtros de faceta também valem para a listagem
+		books, total, err := s.Books.GetBooksWithAuthors(ctx, domain.BookFilters{
+			Subjects: []string{"Dystopia", "Satire"}, Decades: []int{1940}, Sort: "title", SortDirection: "ASC", Limit: 10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 2 || len(books) != 2 || books[0].Title != "Animal Farm" || books[1].Title != "Nineteen Eighty-Four" {
+			t.Errorf("filtered list: %d books, total %d", len(books), total)
+		}
+	})
+}
+
+func TestCirculation(t *testing.T) {
+	eachBackend(t, func(t *testing.T, s storage.Stores) {
+		ctx := context.Background()
+		staff := mustUser(t, s, "staff@lib.com")
+		mustUser(t, s, "reader@lib.com")
+		mustUser(t, s, "other@lib.com")
+		bookUUID := mustBook(t, s, "Nineteen Eighty-Four", "", "B001", "B002")
+		dates := domain.HoldDates{Today: "2024-03-01", PickupBy: "2024-03-08"}
+
+		loan, err := checkout(s, "B001", "reader@lib.com", staff, dates.Today)
+		if err != nil {
+			t.Fatalf("checkout: %v", err)
+		}
+		if loan.DueDate != "2024-03-15" {
+			t.Errorf("due date: got %s, want 2024-03-15", loan.DueDate)
+		}
+		if _, err := checkout(s, "B001", "other@lib.com", staff, dates.Today); !errors.Is(err, domain.ErrItemOnLoan) {
+			t.Errorf("double checkout: got %v, want ErrItemOnLoan", err)
+		}
+		if _, err := checkout(s, "B002", "reader@lib.com", staff, dates.Today); err != nil {
+			t.Fatalf("second checkout: %v", err)
+		}
+
+		// Sem exemplar livre a reserva espera; a devolução separa o
+		// exemplar para ela
+		hold, err := s.Holds.CreateHold(ctx, domain.HoldPlacement{BookUUID: bookUUID, PatronEmail: "other@lib.com", PickupBranch: "Central", Dates: dates})
+		if err != nil {
+			t.Fatalf("hold: %v", err)
+		}
+		if hold.Status != domain.HoldWaiting {
+			t.Errorf("hold status: got %s, want waiting", hold.Status)
+		}
+		if _, err := s.Loans.ReturnLoan(ctx, loan.UUID, staff, dates); err != nil {
+			t.Fatalf("return: %v", err)
+		}
+		if _, err := s.Loans.ReturnLoan(ctx, loan.UUID, staff, dates); !errors.Is(err, domain.ErrLoanReturned) {
+			t.Errorf("second return: got %v, want ErrLoanReturned", err)
+		}
+		if hold, err = s.Holds.GetHoldByUUID(ctx, hold.UUID); err != nil || hold.Status != domain.HoldReady {
+			t.Errorf("hold after return: %+v, %v", hold, err)
+		}
+		if _, err := checkout(s, "B001", "reader@lib.com", staff, dates.Today); !errors.Is(err, domain.ErrItemReserved) {
+			t.Errorf("checkout of a reserved copy: got %v, want ErrItemReserved", err)
+		}
+
+		book, err := s.Books.GetBookByUUID(ctx, bookUUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts, err := s.Items.GetAvailability(ctx, []int{book.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := domain.Availability{Total: 2, OnHold: 1, OnLoan: 1}
+		if got := counts[book.ID]; got != want {
+			t.Errorf("availability: got %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestFines(t *testing.T) {
+	eachBackend(t, func(t *testing.T, s storage.Stores) {
+		ctx := context.Background()
+		staff := mustUser(t, s, "staff@lib.com")
+		mustUser(t, s, "reader@lib.com")
+		mustUser(t, s, "other@lib.com")
+		mustBook(t, s, "Dune", "", "D001", "D002")
+
+		loan, err := checkout(s, "D001", "reader@lib.com", staff, "2024-03-01")
+		if err != nil {
+			t.Fatalf("checkout: %v", err)
+		}
+		if _, err := checkout(s, "D002", "other@lib.com", staff, "2024-03-01"); err != nil {
+			t.Fatalf("checkout: %v", err)
+		}
+
+		balance := func(email string) int64 {
+			t.Helper()
+			_, _, b, err := s.Ledger.GetLedger(ctx, domain.LedgerFilters{PatronEmail: email, Limit: 10})
+			if err != nil {
+				t.Fatalf("ledger of %s: %v", email, err)
+			}
+			return b
+		}
+		accrue := func(today string) {
+			t.Helper()
+			if _, err := s.Ledger.AccrueFines(ctx, domain.FineRun{Today: today, Loc: time.UTC, Default: defaultRule}); err != nil {
+				t.Fatalf("accrue %s: %v", today, err)
+			}
+		}
+
+		// Vence em 2024-03-15: 10 dias de atraso, e a passada repetida no
+		// mesmo dia não cobra de novo
+		accrue("2024-03-25")
+		accrue("2024-03-25")
+		if got := balance("reader@lib.com"); got != 250 {
+			t.Errorf("balance after 10 days: got %d, want 250", got)
+		}
+
+		// Renovação em atraso: cobra a multa até hoje (20 dias) e o novo
+		// prazo parte de hoje
+		renewed, err := s.Loans.RenewLoan(ctx, domain.Renewal{LoanUUID: loan.UUID, StaffID: staff, Default: defaultRule, MaxBalance: 100000, Today: "2024-04-04"})
+		if err != nil {
+			t.Fatalf("renew: %v", err)
+		}
+		if renewed.DueDate != "2024-04-18" || renewed.Renewals != 1 {
+			t.Errorf("renewed loan: due %s renewals %d, want 2024-04-18 and 1", renewed.DueDate, renewed.Renewals)
+		}
+		if got := balance("reader@lib.com"); got != 500 {
+			t.Errorf("balance after overdue renewal: got %d, want 500", got)
+		}
+
+		// O teto vale para a soma das multas do empréstimo, não para cada
+		// prazo
+		accrue("2024-12-31")
+		if got := balance("reader@lib.com"); got != 1000 {
+			t.Errorf("balance after the cap: got %d, want 1000", got)
+		}
+		if got := balance("other@lib.com"); got != 1000 {
+			t.Errorf("balance of the other patron: got %d, want 1000", got)
+		}
+
+		tests := []struct {
+			name    string
+			posting domain.LedgerPosting
+			want    error
+		}{
+			{"payment above the balance", domain.LedgerPosting{PatronEmail: "reader@lib.com", Type: domain.EntryPayment, AmountCents: 1001, StaffID: staff}, domain.ErrConflict},
+			{"charge on a loan of another patron", domain.LedgerPosting{PatronEmail: "other@lib.com", Type: domain.EntryDamage, AmountCents: 100, LoanUUID: loan.UUID, StaffID: staff}, domain.ErrLoanOfOtherPatron},
+			{"missing patron", domain.LedgerPosting{PatronEmail: "nobody@lib.com", Type: domain.EntryPayment, AmountCents: 1, StaffID: staff}, domain.ErrPatronNotFound},
+			{"payment", domain.LedgerPosting{PatronEmail: "reader@lib.com", Type: domain.EntryPayment, AmountCents: 400, StaffID: staff}, nil},
+		}
+		for _, tt := range tests {
+			_, err := s.Ledger.PostLedgerEntry(ctx, tt.posting)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+			}
+		}
+		if got := balance("reader@lib.com"); got != 600 {
+			t.Errorf("balance after payment: got %d, want 600", got)
+		}
+	})
+}
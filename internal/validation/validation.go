@@ -0,0 +1,79 @@
+// Package validation configura o validador das tags binding (o mesmo que o
+// gin usa no ShouldBindJSON) e traduz suas falhas em domain.FieldError.
+// Também é usado fora dos handlers, como na importação de catálogo.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/patrick-tondorf/lib_api/internal/domain"
+)
+
+func init() {
+	// Usa o nome JSON do campo nas mensagens de validação ("authorIds" em
+	// vez de "AuthorIDs"), que é o que o cliente enviou
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+	}
+}
+
+// Struct valida s com as tags binding, como o ShouldBindJSON
+func Struct(s any) error {
+	return binding.Validator.ValidateStruct(s)
+}
+
+// FieldErrors converte os erros do validador em uma lista de campos
+// inválidos; ok é false se err não veio do validador
+func FieldErrors(err error) (fields []domain.FieldError, ok bool) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+	fields = make([]domain.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, domain.FieldError{Field: fe.Field(), Message: Message(fe)})
+	}
+	return fields, true
+}
+
+// Message descreve a regra violada para o cliente
+func Message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at least %s item(s)", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s characters long", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at most %s item(s)", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s characters long", fe.Param())
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "lte":
+		return "must be less than or equal to " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "email":
+		return "must be a valid email address"
+	default:
+		return "failed on the '" + fe.Tag() + "' rule"
+	}
+}